@@ -2,98 +2,211 @@ package crypto
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 )
 
+// Algorithm identifiers found in the encryption header.
+const (
+	AlgIDRC4      = 0x6801 // CALG_RC4
+	AlgIDAES128   = 0x660E // CALG_AES_128
+	AlgIDAES192   = 0x660F // CALG_AES_192
+	AlgIDAES256   = 0x6610 // CALG_AES_256
+	AlgHashIDMD5  = 0x8003 // CALG_MD5
+	AlgHashIDSHA1 = 0x8004 // CALG_SHA1
+)
+
+// ErrUnsupportedEncryption is returned when a document is encrypted with an
+// algorithm this package cannot decrypt. Use errors.As with
+// *UnsupportedEncryptionError to find out which algorithm was detected.
+var ErrUnsupportedEncryption = errors.New("unsupported encryption algorithm")
+
+// UnsupportedEncryptionError reports the algorithm of a document whose
+// encryption is not supported. It matches ErrUnsupportedEncryption.
+type UnsupportedEncryptionError struct {
+	AlgID uint32 // Algorithm identifier from the encryption header
+}
+
+// Error implements the error interface.
+func (e *UnsupportedEncryptionError) Error() string {
+	if e.IsAES() {
+		return fmt.Sprintf("%s: AES (0x%04X) encrypted documents are not supported", ErrUnsupportedEncryption, e.AlgID)
+	}
+	return fmt.Sprintf("%s: 0x%04X", ErrUnsupportedEncryption, e.AlgID)
+}
+
+// Is reports whether target is ErrUnsupportedEncryption.
+func (e *UnsupportedEncryptionError) Is(target error) bool {
+	return target == ErrUnsupportedEncryption
+}
+
+// IsAES returns true if the unsupported algorithm is one of the AES variants.
+func (e *UnsupportedEncryptionError) IsAES() bool {
+	return e.AlgID == AlgIDAES128 || e.AlgID == AlgIDAES192 || e.AlgID == AlgIDAES256
+}
+
 // EncryptionHeader represents the encryption information stored in the table stream
 // for encrypted Word documents.
+//
+// RC4 encryption (version 1.1) stores only the salt and verifier, and is
+// reported with AlgID AlgIDRC4 and AlgHashID AlgHashIDMD5. RC4 CryptoAPI
+// encryption (versions 2.2 to 4.2) stores an EncryptionHeader and an
+// EncryptionVerifier as described in MS-OFFCRYPTO.
 type EncryptionHeader struct {
-	Version           uint16 // Encryption version
+	Version           uint16 // Major version: 1 for RC4, 2 to 4 for CryptoAPI
+	MinorVersion      uint16 // Minor version: 1 for RC4, 2 for CryptoAPI
 	EncryptionFlags   uint32 // Encryption flags
-	HeaderSize        uint32 // Size of encryption header
+	HeaderSize        uint32 // Size of the CryptoAPI EncryptionHeader
 	ProviderType      uint32 // Cryptographic provider type
 	AlgID             uint32 // Algorithm identifier
 	AlgHashID         uint32 // Hash algorithm identifier
-	KeySize           uint32 // Key size in bits
+	KeySize           uint32 // Key size in bits; zero means 40 for CryptoAPI and RC4 does not use it
 	ProviderName      string // Cryptographic provider name
 	Salt              []byte // Random salt for key derivation
 	EncryptedVerifier []byte // Encrypted verifier for password validation
 	VerifierHash      []byte // Hash of the verifier
+
+	size int // Number of bytes read by ParseEncryptionHeader
 }
 
 // ParseEncryptionHeader parses the encryption header from table stream data.
 func ParseEncryptionHeader(data []byte) (*EncryptionHeader, error) {
-	if len(data) < 32 {
+	if len(data) < 4 {
 		return nil, errors.New("encryption header too small")
 	}
 
 	reader := bytes.NewReader(data)
 	header := &EncryptionHeader{}
+	binary.Read(reader, binary.LittleEndian, &header.Version)
+	binary.Read(reader, binary.LittleEndian, &header.MinorVersion)
 
-	// Read basic header fields
-	if err := binary.Read(reader, binary.LittleEndian, &header.Version); err != nil {
-		return nil, fmt.Errorf("failed to read version: %w", err)
-	}
+	switch {
+	case header.Version == 1 && header.MinorVersion == 1:
+		header.AlgID = AlgIDRC4
+		header.AlgHashID = AlgHashIDMD5
+		header.Salt = make([]byte, 16)
+		header.EncryptedVerifier = make([]byte, 16)
+		header.VerifierHash = make([]byte, md5.Size)
+		for _, field := range [][]byte{header.Salt, header.EncryptedVerifier, header.VerifierHash} {
+			if _, err := io.ReadFull(reader, field); err != nil {
+				return nil, fmt.Errorf("encryption header too small: %w", err)
+			}
+		}
 
-	if err := binary.Read(reader, binary.LittleEndian, &header.EncryptionFlags); err != nil {
-		return nil, fmt.Errorf("failed to read flags: %w", err)
-	}
+	case header.Version >= 2 && header.Version <= 4 && header.MinorVersion == 2:
+		if err := header.readCryptoAPI(reader); err != nil {
+			return nil, err
+		}
 
-	if err := binary.Read(reader, binary.LittleEndian, &header.HeaderSize); err != nil {
-		return nil, fmt.Errorf("failed to read header size: %w", err)
+	default:
+		return nil, fmt.Errorf("unsupported encryption version %d.%d", header.Version, header.MinorVersion)
 	}
 
-	if err := binary.Read(reader, binary.LittleEndian, &header.ProviderType); err != nil {
-		return nil, fmt.Errorf("failed to read provider type: %w", err)
-	}
+	header.size = len(data) - reader.Len()
+	return header, nil
+}
 
-	if err := binary.Read(reader, binary.LittleEndian, &header.AlgID); err != nil {
-		return nil, fmt.Errorf("failed to read algorithm ID: %w", err)
+// readCryptoAPI reads the fields of an RC4 CryptoAPI header that follow the
+// version: the flags, the EncryptionHeader and the EncryptionVerifier.
+func (h *EncryptionHeader) readCryptoAPI(reader *bytes.Reader) error {
+	var fields struct {
+		Flags        uint32
+		HeaderSize   uint32
+		HeaderFlags  uint32
+		SizeExtra    uint32
+		AlgID        uint32
+		AlgHashID    uint32
+		KeySize      uint32
+		ProviderType uint32
+		Reserved1    uint32
+		Reserved2    uint32
 	}
-
-	if err := binary.Read(reader, binary.LittleEndian, &header.AlgHashID); err != nil {
-		return nil, fmt.Errorf("failed to read hash algorithm ID: %w", err)
+	if err := binary.Read(reader, binary.LittleEndian, &fields); err != nil {
+		return fmt.Errorf("failed to read encryption header: %w", err)
 	}
-
-	if err := binary.Read(reader, binary.LittleEndian, &header.KeySize); err != nil {
-		return nil, fmt.Errorf("failed to read key size: %w", err)
+	if fields.HeaderSize < 32 || int64(fields.HeaderSize-32) > int64(reader.Len()) {
+		return fmt.Errorf("invalid encryption header size %d", fields.HeaderSize)
 	}
+	h.EncryptionFlags = fields.Flags
+	h.HeaderSize = fields.HeaderSize
+	h.AlgID = fields.AlgID
+	h.AlgHashID = fields.AlgHashID
+	if h.AlgHashID == 0 {
+		h.AlgHashID = AlgHashIDSHA1 // Zero leaves the hash to the flags, which means SHA-1
+	}
+	h.KeySize = fields.KeySize
+	h.ProviderType = fields.ProviderType
 
-	// Skip reserved fields and read provider name
-	reader.Seek(8, 1) // Skip 8 bytes of reserved fields
-
-	// Read provider name (null-terminated Unicode string)
-	providerNameBytes := make([]byte, 64) // Maximum provider name length
-	reader.Read(providerNameBytes)
-	header.ProviderName = parseUnicodeString(providerNameBytes)
+	// The provider name fills the rest of the EncryptionHeader
+	providerName := make([]byte, fields.HeaderSize-32)
+	reader.Read(providerName)
+	h.ProviderName = parseUnicodeString(providerName)
 
-	// Read salt (16 bytes)
-	header.Salt = make([]byte, 16)
-	if _, err := reader.Read(header.Salt); err != nil {
-		return nil, fmt.Errorf("failed to read salt: %w", err)
+	// EncryptionVerifier: the salt, the verifier and its hash, each salt and
+	// hash preceded by its size
+	var saltSize uint32
+	if err := binary.Read(reader, binary.LittleEndian, &saltSize); err != nil {
+		return fmt.Errorf("failed to read salt size: %w", err)
 	}
-
-	// Read encrypted verifier (16 bytes)
-	header.EncryptedVerifier = make([]byte, 16)
-	if _, err := reader.Read(header.EncryptedVerifier); err != nil {
-		return nil, fmt.Errorf("failed to read encrypted verifier: %w", err)
+	if saltSize != 16 {
+		return fmt.Errorf("invalid salt size %d", saltSize)
 	}
-
-	// Read verifier hash (16 bytes for MD5)
-	header.VerifierHash = make([]byte, 16)
-	if _, err := reader.Read(header.VerifierHash); err != nil {
-		return nil, fmt.Errorf("failed to read verifier hash: %w", err)
+	h.Salt = make([]byte, saltSize)
+	if _, err := io.ReadFull(reader, h.Salt); err != nil {
+		return fmt.Errorf("failed to read salt: %w", err)
+	}
+	h.EncryptedVerifier = make([]byte, 16)
+	if _, err := io.ReadFull(reader, h.EncryptedVerifier); err != nil {
+		return fmt.Errorf("failed to read encrypted verifier: %w", err)
+	}
+	var hashSize uint32
+	if err := binary.Read(reader, binary.LittleEndian, &hashSize); err != nil {
+		return fmt.Errorf("failed to read verifier hash size: %w", err)
 	}
+	if hashSize > 32 {
+		return fmt.Errorf("invalid verifier hash size %d", hashSize)
+	}
+	// RC4 stores exactly hashSize bytes, AES pads the hash to 32 bytes
+	if h.AlgID == 0 || h.IsRC4Encryption() {
+		h.VerifierHash = make([]byte, hashSize)
+	} else {
+		h.VerifierHash = make([]byte, 32)
+	}
+	if _, err := io.ReadFull(reader, h.VerifierHash); err != nil {
+		return fmt.Errorf("failed to read verifier hash: %w", err)
+	}
+	return nil
+}
 
-	return header, nil
+// Size returns the number of bytes the header occupies at the start of the
+// table stream. The encrypted table stream data follows it.
+func (h *EncryptionHeader) Size() int {
+	return h.size
 }
 
 // IsRC4Encryption returns true if the encryption uses RC4 algorithm.
 func (h *EncryptionHeader) IsRC4Encryption() bool {
 	// RC4 algorithm ID
-	return h.AlgID == 0x6801 // CALG_RC4
+	return h.AlgID == AlgIDRC4
+}
+
+// UsesSHA1 returns true if keys are derived with the SHA-1 based CryptoAPI scheme.
+// Other hash algorithms fall back to the legacy MD5 derivation.
+func (h *EncryptionHeader) UsesSHA1() bool {
+	return h.AlgHashID == AlgHashIDSHA1
+}
+
+// deriveKey generates the RC4 key for the given block using the derivation
+// selected by the header's hash algorithm.
+func (h *EncryptionHeader) deriveKey(password string, blockNo uint32) ([]byte, error) {
+	if h.UsesSHA1() {
+		return GenerateCryptoAPIKey(password, h.Salt, blockNo, h.KeySize)
+	}
+	return GenerateRC4Key(password, h.Salt, blockNo)
 }
 
 // IsPasswordProtected returns true if the document is password protected.
@@ -108,7 +221,7 @@ func (h *EncryptionHeader) ValidatePassword(password string) (bool, error) {
 	}
 
 	// Generate decryption key from password and salt
-	key, err := GenerateDecryptionKey(password, h.Salt)
+	key, err := h.deriveKey(password, 0)
 	if err != nil {
 		return false, fmt.Errorf("failed to generate key: %w", err)
 	}
@@ -122,25 +235,34 @@ func (h *EncryptionHeader) ValidatePassword(password string) (bool, error) {
 	// Decrypt the verifier
 	decryptedVerifier := rc4.Decrypt(h.EncryptedVerifier)
 
-	// Hash the decrypted verifier
-	verifierHash := GeneratePasswordHash(string(decryptedVerifier))
-
-	// Compare with stored hash
-	for i := 0; i < len(verifierHash) && i < len(h.VerifierHash); i++ {
-		if verifierHash[i] != h.VerifierHash[i] {
-			return false, nil // Password is incorrect
-		}
+	if h.UsesSHA1() {
+		// The verifier hash is encrypted with the same RC4 stream, directly
+		// following the verifier, and holds the SHA-1 of the verifier
+		decryptedHash := rc4.Decrypt(h.VerifierHash)
+		verifierHash := sha1.Sum(decryptedVerifier)
+		return bytes.Equal(verifierHash[:], decryptedHash), nil
 	}
 
-	return true, nil
+	// RC4 encryption stores the MD5 of the verifier the same way
+	decryptedHash := rc4.Decrypt(h.VerifierHash)
+	verifierHash := md5.Sum(decryptedVerifier)
+	return bytes.Equal(verifierHash[:], decryptedHash), nil
 }
 
-// CreateDecryptionCipher creates an RC4 cipher for decrypting document content.
+// CreateDecryptionCipher creates an RC4 cipher for the first block of an
+// encrypted stream, after checking the password. Use NewStreamDecryptor to
+// decrypt whole streams. It returns an *UnsupportedEncryptionError if the
+// document is not RC4 encrypted.
 func (h *EncryptionHeader) CreateDecryptionCipher(password string) (*RC4, error) {
 	if !h.IsPasswordProtected() {
 		return nil, errors.New("document is not password protected")
 	}
 
+	// An AlgID of zero leaves the algorithm to the flags, which for Word means RC4
+	if h.AlgID != 0 && !h.IsRC4Encryption() {
+		return nil, &UnsupportedEncryptionError{AlgID: h.AlgID}
+	}
+
 	// Validate password first
 	valid, err := h.ValidatePassword(password)
 	if err != nil {
@@ -151,7 +273,7 @@ func (h *EncryptionHeader) CreateDecryptionCipher(password string) (*RC4, error)
 	}
 
 	// Generate decryption key
-	key, err := GenerateDecryptionKey(password, h.Salt)
+	key, err := h.deriveKey(password, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate key: %w", err)
 	}
@@ -160,6 +282,52 @@ func (h *EncryptionHeader) CreateDecryptionCipher(password string) (*RC4, error)
 	return NewRC4(key)
 }
 
+// BlockSize is the size of the blocks encrypted streams are split into. Each
+// block is encrypted with a fresh RC4 keystream whose key is derived from the
+// block number, so that any block can be decrypted on its own.
+const BlockSize = 512
+
+// StreamDecryptor decrypts data read from the streams of an RC4 encrypted
+// document, deriving the key of each block it touches.
+type StreamDecryptor struct {
+	header   *EncryptionHeader
+	password string
+}
+
+// NewStreamDecryptor checks the password and returns a decryptor for the
+// streams of the document. It returns an *UnsupportedEncryptionError if the
+// document is not RC4 encrypted.
+func (h *EncryptionHeader) NewStreamDecryptor(password string) (*StreamDecryptor, error) {
+	if _, err := h.CreateDecryptionCipher(password); err != nil {
+		return nil, err
+	}
+	return &StreamDecryptor{header: h, password: password}, nil
+}
+
+// DecryptAt decrypts data read from an encrypted stream at offset. The data
+// is split at block boundaries and each part is decrypted with the keystream
+// of its block, block offset/512, advanced to its position in the block.
+// RC4 is symmetric, so this also encrypts.
+func (s *StreamDecryptor) DecryptAt(data []byte, offset uint32) ([]byte, error) {
+	output := make([]byte, 0, len(data))
+	for len(output) < len(data) {
+		position := offset + uint32(len(output))
+		key, err := s.header.deriveKey(s.password, position/BlockSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		rc4, err := NewRC4(key)
+		if err != nil {
+			return nil, err
+		}
+		rc4.Skip(int(position % BlockSize))
+
+		n := min(len(data)-len(output), BlockSize-int(position%BlockSize))
+		output = append(output, rc4.Decrypt(data[len(output):len(output)+n])...)
+	}
+	return output, nil
+}
+
 // parseUnicodeString extracts a null-terminated Unicode string from byte data.
 func parseUnicodeString(data []byte) string {
 	var result []rune
@@ -6,8 +6,11 @@ package crypto
 
 import (
 	"crypto/md5"
+	"crypto/sha1"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"unicode/utf16"
 )
 
 // RC4 represents an RC4 cipher context.
@@ -56,6 +59,16 @@ func (rc4 *RC4) Decrypt(data []byte) []byte {
 	return output
 }
 
+// Skip advances the keystream by n bytes, as if n bytes had been decrypted,
+// so that decryption continues at offset n within the block being decrypted.
+func (rc4 *RC4) Skip(n int) {
+	for k := 0; k < n; k++ {
+		rc4.i++
+		rc4.j += rc4.s[rc4.i]
+		rc4.s[rc4.i], rc4.s[rc4.j] = rc4.s[rc4.j], rc4.s[rc4.i]
+	}
+}
+
 // GeneratePasswordHash creates a password hash compatible with Word documents.
 // This implements the Word 97-2003 password hashing algorithm.
 func GeneratePasswordHash(password string) []byte {
@@ -96,6 +109,85 @@ func GenerateDecryptionKey(password string, salt []byte) ([]byte, error) {
 	return finalHash[:], nil
 }
 
+// GenerateRC4Key derives the key for the given block number using the RC4
+// encryption scheme (version 1.1) from MS-OFFCRYPTO:
+//
+//	H0     = MD5(UTF16LE(password))
+//	H1     = MD5(16 repetitions of H0[:5] + salt)
+//	Hfinal = MD5(H1[:5] + blockNo)
+//
+// The key is the 128 bits of Hfinal.
+func GenerateRC4Key(password string, salt []byte, blockNo uint32) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, errors.New("password cannot be empty")
+	}
+
+	if len(salt) < 16 {
+		return nil, fmt.Errorf("salt must be at least 16 bytes, got %d", len(salt))
+	}
+
+	h0 := GeneratePasswordHash(password)
+	var intermediate []byte
+	for i := 0; i < 16; i++ {
+		intermediate = append(intermediate, h0[:5]...)
+		intermediate = append(intermediate, salt[:16]...)
+	}
+	h1 := md5.Sum(intermediate)
+
+	var block [4]byte
+	binary.LittleEndian.PutUint32(block[:], blockNo)
+	hFinal := md5.Sum(append(h1[:5], block[:]...))
+	return hFinal[:], nil
+}
+
+// GenerateCryptoAPIKey derives the RC4 key for the given block number using the
+// RC4 CryptoAPI scheme from MS-OFFCRYPTO:
+//
+//	H0     = SHA1(salt + UTF16LE(password))
+//	Hfinal = SHA1(H0 + blockNo)
+//
+// The key is the first keySize bits of Hfinal. A 40-bit key is zero-padded to
+// 128 bits as Word does. A keySize of 0 is treated as 40 bits.
+func GenerateCryptoAPIKey(password string, salt []byte, blockNo uint32, keySize uint32) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, errors.New("password cannot be empty")
+	}
+
+	if len(salt) < 16 {
+		return nil, fmt.Errorf("salt must be at least 16 bytes, got %d", len(salt))
+	}
+
+	if keySize == 0 {
+		keySize = 40
+	}
+	if keySize%8 != 0 || keySize < 40 || keySize > 128 {
+		return nil, fmt.Errorf("unsupported key size %d bits", keySize)
+	}
+
+	// Hash the salt followed by the UTF-16LE password
+	h0 := sha1.New()
+	h0.Write(salt[:16])
+	for _, unit := range utf16.Encode([]rune(password)) {
+		h0.Write([]byte{byte(unit), byte(unit >> 8)})
+	}
+
+	// Combine with the block number
+	var block [4]byte
+	binary.LittleEndian.PutUint32(block[:], blockNo)
+	hFinal := sha1.New()
+	hFinal.Write(h0.Sum(nil))
+	hFinal.Write(block[:])
+	digest := hFinal.Sum(nil)
+
+	keyLen := int(keySize / 8)
+	if keySize == 40 {
+		key := make([]byte, 16)
+		copy(key, digest[:keyLen])
+		return key, nil
+	}
+	return digest[:keyLen], nil
+}
+
 // VerifyPassword checks if the given password matches the document's password hash.
 func VerifyPassword(password string, expectedHash []byte, salt []byte) (bool, error) {
 	if len(expectedHash) != 16 {
@@ -5,27 +5,18 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 )
 
 // ParseFIB reads a byte slice (from the WordDocument stream)
 // and parses it into a FileInformationBlock struct.
 func ParseFIB(data []byte) (*FileInformationBlock, error) {
-	if len(data) < 32 { // Minimum size for FibBase
-		return nil, errors.New("fib: data too short for FibBase")
+	fib, err := ParseFIBBase(data)
+	if err != nil {
+		return nil, err
 	}
-
 	r := bytes.NewReader(data)
-	fib := &FileInformationBlock{}
-
-	// Read the fixed-size FibBase
-	if err := binary.Read(r, binary.LittleEndian, &fib.Base); err != nil {
-		return nil, fmt.Errorf("fib: could not read FibBase: %w", err)
-	}
-
-	// Validate Word document identifier
-	if fib.Base.WIdent != 0xA5EC {
-		return nil, errors.New("fib: invalid wIdent, not a Word document")
-	}
+	r.Seek(int64(binary.Size(fib.Base)), io.SeekStart)
 
 	// Read remaining FIB sections
 	currentOffset, _ := r.Seek(0, 1) // Get current position
@@ -44,17 +35,30 @@ func ParseFIB(data []byte) (*FileInformationBlock, error) {
 		return nil, fmt.Errorf("fib: failed to read Cslw at offset %d: %w", currentOffset, err)
 	}
 
-	// Skip FibRgLw
-	fibRgLwBytes := make([]byte, 76) // Known size for FibRgLw97
-	if _, err := r.Read(fibRgLwBytes); err != nil {
+	// Read FibRgLw, cslw 32-bit values. Values beyond FibRgLw97 are
+	// skipped, and missing ones are left zero.
+	fibRgLwBytes := make([]byte, int(fib.Cslw)*4)
+	if _, err := io.ReadFull(r, fibRgLwBytes); err != nil {
 		return nil, fmt.Errorf("fib: failed to read FibRgLw: %w", err)
 	}
+	padded := make([]byte, binary.Size(fib.FibRgLw))
+	copy(padded, fibRgLwBytes)
+	if err := binary.Read(bytes.NewReader(padded), binary.LittleEndian, &fib.FibRgLw); err != nil {
+		return nil, fmt.Errorf("fib: could not decode FibRgLw: %w", err)
+	}
 
 	currentOffset, _ = r.Seek(0, 1)
 	if err := binary.Read(r, binary.LittleEndian, &fib.CbRgFcLcb); err != nil {
 		return nil, fmt.Errorf("fib: failed to read CbRgFcLcb at offset %d: %w", currentOffset, err)
 	}
 
+	// Reject counts larger than any FibRgFcLcb defined, so a corrupt value is
+	// reported as such rather than as a short stream. The count is checked
+	// against the version once FibRgCswNew has been read
+	if maxCount := maxCbRgFcLcb(nFibNewest); fib.CbRgFcLcb > maxCount {
+		return nil, fmt.Errorf("fib: invalid cbRgFcLcb 0x%X, expected at most 0x%X", fib.CbRgFcLcb, maxCount)
+	}
+
 	// Read the variable-length FibRgFcLcb
 	// CbRgFcLcb is a count of 64-bit values (8 bytes each).
 	blobSize := int(fib.CbRgFcLcb) * 8
@@ -66,6 +70,25 @@ func ParseFIB(data []byte) (*FileInformationBlock, error) {
 		return nil, fmt.Errorf("fib: could not read RgFcLcbBlob: %w", err)
 	}
 
+	// Word 2000 and later record their version in FibRgCswNew, keeping
+	// nFib at 0x00C1 for readers that only know Word 97. Both are optional
+	// for Word 97 files, so a missing value is left zero
+	if binary.Read(r, binary.LittleEndian, &fib.CswNew) == nil && fib.CswNew > 0 {
+		if binary.Read(r, binary.LittleEndian, &fib.NFibNew) != nil {
+			fib.CswNew = 0
+		}
+	}
+
+	// A FIB without FibRgCswNew is held to the FibRgFcLcb of its nFib. One
+	// with FibRgCswNew comes from Word 2000 or later, and Word for Mac writes
+	// the Word 2007 FibRgFcLcb with the nFibNew of Word 2003, so those are
+	// only held to the largest size
+	if fib.CswNew == 0 {
+		if maxCount := maxCbRgFcLcb(fib.Base.NFib); fib.CbRgFcLcb > maxCount {
+			return nil, fmt.Errorf("fib: invalid cbRgFcLcb 0x%X for nFib 0x%04X, expected at most 0x%X", fib.CbRgFcLcb, fib.Base.NFib, maxCount)
+		}
+	}
+
 	// Parse the FibRgFcLcb structure based on nFib version
 	if err := parseFibRgFcLcb(fib); err != nil {
 		return nil, fmt.Errorf("fib: failed to parse FibRgFcLcb: %w", err)
@@ -74,6 +97,55 @@ func ParseFIB(data []byte) (*FileInformationBlock, error) {
 	return fib, nil
 }
 
+// NFib returns the version of the file format: nFibNew from FibRgCswNew if
+// the FIB has one, otherwise nFib from FibBase.
+func (fib *FileInformationBlock) NFib() uint16 {
+	if fib.CswNew > 0 {
+		return fib.NFibNew
+	}
+	return fib.Base.NFib
+}
+
+// nFibNewest is the nFib of Word 2007, the newest version of the format.
+const nFibNewest = 0x0112
+
+// ParseFIBBase parses only the FibBase at the start of data, leaving the
+// rest of the FIB zero. The FibBase of an encrypted document is stored
+// unencrypted, while the rest of its FIB can only be parsed once the stream
+// has been decrypted.
+func ParseFIBBase(data []byte) (*FileInformationBlock, error) {
+	if len(data) < 32 { // Minimum size for FibBase
+		return nil, errors.New("fib: data too short for FibBase")
+	}
+
+	fib := &FileInformationBlock{}
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &fib.Base); err != nil {
+		return nil, fmt.Errorf("fib: could not read FibBase: %w", err)
+	}
+
+	// Validate Word document identifier
+	if fib.Base.WIdent != 0xA5EC {
+		return nil, errors.New("fib: invalid wIdent, not a Word document")
+	}
+	return fib, nil
+}
+
+// maxCbRgFcLcb returns the largest valid cbRgFcLcb for the given nFib.
+func maxCbRgFcLcb(nFib uint16) uint16 {
+	switch nFib {
+	case 0x00C1: // Word 97
+		return 0x005D
+	case 0x00D9: // Word 2000
+		return 0x006C
+	case 0x0101: // Word 2002
+		return 0x0088
+	case 0x010C: // Word 2003
+		return 0x00A4
+	default: // Word 2007 and unknown versions
+		return 0x00B7
+	}
+}
+
 // parseFibRgFcLcb parses the variable FibRgFcLcb section based on the nFib version.
 func parseFibRgFcLcb(fib *FileInformationBlock) error {
 	if len(fib.RgFcLcbBlob) == 0 {
@@ -131,13 +203,127 @@ func parseFibRgFcLcb97(fib *FileInformationBlock, r *bytes.Reader) error {
 	}
 
 	// Parse other important fields
+	if len(fields) >= 8 {
+		fib.RgFcLcb.FcPlcffndRef = fields[4]
+		fib.RgFcLcb.LcbPlcffndRef = fields[5]
+		fib.RgFcLcb.FcPlcffndTxt = fields[6]
+		fib.RgFcLcb.LcbPlcffndTxt = fields[7]
+	}
+	if len(fields) >= 12 {
+		fib.RgFcLcb.FcPlcfandRef = fields[8]
+		fib.RgFcLcb.LcbPlcfandRef = fields[9]
+		fib.RgFcLcb.FcPlcfandTxt = fields[10]
+		fib.RgFcLcb.LcbPlcfandTxt = fields[11]
+	}
 	if len(fields) >= 20 {
 		fib.RgFcLcb.FcPlcfsed = fields[12]
 		fib.RgFcLcb.LcbPlcfsed = fields[13]
 	}
 	if len(fields) >= 24 {
-		fib.RgFcLcb.FcPlcfhdd = fields[16]
-		fib.RgFcLcb.LcbPlcfhdd = fields[17]
+		fib.RgFcLcb.FcPlcfhdd = fields[22]
+		fib.RgFcLcb.LcbPlcfhdd = fields[23]
+	}
+	if len(fields) >= 26 {
+		fib.RgFcLcb.FcPlcfbteChpx = fields[24]
+		fib.RgFcLcb.LcbPlcfbteChpx = fields[25]
+	}
+	if len(fields) >= 28 {
+		fib.RgFcLcb.FcPlcfbtePapx = fields[26]
+		fib.RgFcLcb.LcbPlcfbtePapx = fields[27]
+	}
+	if len(fields) >= 32 {
+		fib.RgFcLcb.FcSttbfffn = fields[30]
+		fib.RgFcLcb.LcbSttbfffn = fields[31]
+	}
+	if len(fields) >= 40 {
+		fib.RgFcLcb.FcPlcffldMom = fields[32]
+		fib.RgFcLcb.LcbPlcffldMom = fields[33]
+		fib.RgFcLcb.FcPlcffldHdr = fields[34]
+		fib.RgFcLcb.LcbPlcffldHdr = fields[35]
+		fib.RgFcLcb.FcPlcffldFtn = fields[36]
+		fib.RgFcLcb.LcbPlcffldFtn = fields[37]
+		fib.RgFcLcb.FcPlcffldAtn = fields[38]
+		fib.RgFcLcb.LcbPlcffldAtn = fields[39]
+	}
+	if len(fields) >= 48 {
+		fib.RgFcLcb.FcSttbfbkmk = fields[42]
+		fib.RgFcLcb.LcbSttbfbkmk = fields[43]
+		fib.RgFcLcb.FcPlcfbkf = fields[44]
+		fib.RgFcLcb.LcbPlcfbkf = fields[45]
+		fib.RgFcLcb.FcPlcfbkl = fields[46]
+		fib.RgFcLcb.LcbPlcfbkl = fields[47]
+	}
+	if len(fields) >= 64 {
+		fib.RgFcLcb.FcDop = fields[62]
+		fib.RgFcLcb.LcbDop = fields[63]
+	}
+	if len(fields) >= 74 {
+		fib.RgFcLcb.FcGrpXstAtnOwners = fields[72]
+		fib.RgFcLcb.LcbGrpXstAtnOwners = fields[73]
+	}
+	if len(fields) >= 82 {
+		fib.RgFcLcb.FcPlcSpaMom = fields[80]
+		fib.RgFcLcb.LcbPlcSpaMom = fields[81]
+	}
+	if len(fields) >= 96 {
+		fib.RgFcLcb.FcPlcfendRef = fields[92]
+		fib.RgFcLcb.LcbPlcfendRef = fields[93]
+		fib.RgFcLcb.FcPlcfendTxt = fields[94]
+		fib.RgFcLcb.LcbPlcfendTxt = fields[95]
+	}
+	if len(fields) >= 98 {
+		fib.RgFcLcb.FcPlcffldEdn = fields[96]
+		fib.RgFcLcb.LcbPlcffldEdn = fields[97]
+	}
+	if len(fields) >= 102 {
+		fib.RgFcLcb.FcDggInfo = fields[100]
+		fib.RgFcLcb.LcbDggInfo = fields[101]
+	}
+	if len(fields) >= 104 {
+		fib.RgFcLcb.FcSttbfRMark = fields[102]
+		fib.RgFcLcb.LcbSttbfRMark = fields[103]
+	}
+	if len(fields) >= 112 {
+		fib.RgFcLcb.FcPlcfspl = fields[110]
+		fib.RgFcLcb.LcbPlcfspl = fields[111]
+	}
+	if len(fields) >= 120 {
+		fib.RgFcLcb.FcPlcffldTxbx = fields[114]
+		fib.RgFcLcb.LcbPlcffldTxbx = fields[115]
+		fib.RgFcLcb.FcPlcffldHdrTxbx = fields[118]
+		fib.RgFcLcb.LcbPlcffldHdrTxbx = fields[119]
+	}
+	if len(fields) >= 122 {
+		fib.RgFcLcb.FcStwUser = fields[120]
+		fib.RgFcLcb.LcbStwUser = fields[121]
+	}
+	if len(fields) >= 124 {
+		fib.RgFcLcb.FcSttbttmbd = fields[122]
+		fib.RgFcLcb.LcbSttbttmbd = fields[123]
+	}
+	if len(fields) >= 150 {
+		fib.RgFcLcb.FcPlfLst = fields[146]
+		fib.RgFcLcb.LcbPlfLst = fields[147]
+		fib.RgFcLcb.FcPlfLfo = fields[148]
+		fib.RgFcLcb.LcbPlfLfo = fields[149]
+	}
+	if len(fields) >= 156 {
+		fib.RgFcLcb.FcDocUndoWord9 = fields[154]
+		fib.RgFcLcb.LcbDocUndoWord9 = fields[155]
+	}
+	if len(fields) >= 182 {
+		fib.RgFcLcb.FcPlcfgram = fields[180]
+		fib.RgFcLcb.LcbPlcfgram = fields[181]
+	}
+
+	// Smart tags are stored in FibRgFcLcb2002, after the FibRgFcLcb2000 fields
+	if len(fields) >= 238 {
+		fib.RgFcLcb.FcPlcfBkfFactoid = fields[230]
+		fib.RgFcLcb.LcbPlcfBkfFactoid = fields[231]
+		fib.RgFcLcb.FcPlcfBklFactoid = fields[234]
+		fib.RgFcLcb.LcbPlcfBklFactoid = fields[235]
+		fib.RgFcLcb.FcFactoidData = fields[236]
+		fib.RgFcLcb.LcbFactoidData = fields[237]
 	}
 
 	return nil
@@ -162,6 +348,11 @@ func (fib *FileInformationBlock) IsEncrypted() bool {
 	return (fib.Base.Flags1 & 0x0100) != 0 // fEncrypted flag
 }
 
+// IsReadOnlyRecommended returns true if Word should suggest opening the document read-only.
+func (fib *FileInformationBlock) IsReadOnlyRecommended() bool {
+	return (fib.Base.Flags1 & 0x0400) != 0 // fReadOnlyRecommended flag
+}
+
 // IsObfuscated returns true if the document uses XOR obfuscation.
 func (fib *FileInformationBlock) IsObfuscated() bool {
 	return (fib.Base.Flags1 & 0x8000) != 0 // fObfuscated flag
@@ -174,3 +365,45 @@ func (fib *FileInformationBlock) GetTableStreamName() string {
 	}
 	return "0Table"
 }
+
+// ANSICodePage returns the Windows code page of the document's 8-bit text.
+//
+// Word 97 and later store compressed text as CP-1252. Older formats use the
+// ANSI code page of the language the document was created with.
+func (fib *FileInformationBlock) ANSICodePage() int {
+	if fib.Base.NFib >= 0x00C1 {
+		return 1252
+	}
+
+	switch fib.Base.Lid & 0x03FF { // Primary language
+	case 0x11: // Japanese
+		return 932
+	case 0x04: // Chinese
+		if fib.Base.Lid == 0x0804 || fib.Base.Lid == 0x1004 { // PRC, Singapore
+			return 936
+		}
+		return 950
+	case 0x12: // Korean
+		return 949
+	case 0x1E: // Thai
+		return 874
+	case 0x05, 0x0E, 0x15, 0x18, 0x1A, 0x1B, 0x1C, 0x24: // Czech, Hungarian, Polish, Romanian, Croatian, Slovak, Albanian, Slovenian
+		return 1250
+	case 0x02, 0x19, 0x22, 0x23: // Bulgarian, Russian, Ukrainian, Belarusian
+		return 1251
+	case 0x08: // Greek
+		return 1253
+	case 0x1F: // Turkish
+		return 1254
+	case 0x0D: // Hebrew
+		return 1255
+	case 0x01: // Arabic
+		return 1256
+	case 0x25, 0x26, 0x27: // Estonian, Latvian, Lithuanian
+		return 1257
+	case 0x2A: // Vietnamese
+		return 1258
+	default:
+		return 1252
+	}
+}
@@ -12,7 +12,7 @@ type FileInformationBlock struct {
 	// Parsed version for convenience
 	RgFcLcb FibRgFcLcb97
 	CswNew  uint16
-	// FibRgCswNew would follow here if present
+	NFibNew uint16 // Version from FibRgCswNew, valid if CswNew is not zero
 }
 
 // FibBase is the fixed-size (32 byte) header of the FIB.
@@ -41,6 +41,7 @@ type FibRgW97 struct {
 type FibRgLw97 struct {
 	CbMac      uint32   // Size of main document text stream in bytes
 	_          uint32   // reserved
+	_          uint32   // reserved
 	CcpText    uint32   // Count of characters in main document
 	CcpFtn     uint32   // Count of characters in footnotes
 	CcpHdd     uint32   // Count of characters in headers/footers
@@ -91,6 +92,8 @@ type FibRgFcLcb97 struct {
 	LcbPlcffldAtn       uint32 // Length of field PLC for annotation document
 	FcPlcffldMcr        uint32 // File position of field PLC for macro document
 	LcbPlcffldMcr       uint32 // Length of field PLC for macro document
+	FcPlcffldEdn        uint32 // File position of field PLC for endnote document
+	LcbPlcffldEdn       uint32 // Length of field PLC for endnote document
 	FcSttbfbkmk         uint32 // File position of bookmark names STTB
 	LcbSttbfbkmk        uint32 // Length of bookmark names STTB
 	FcPlcfbkf           uint32 // File position of bookmark start PLC
@@ -117,8 +120,16 @@ type FibRgFcLcb97 struct {
 	LcbSttbfAssoc       uint32 // Length of associated strings STTB
 	FcClx               uint32 // File position of character and paragraph formatting PLC
 	LcbClx              uint32 // Length of character and paragraph formatting PLC
+	FcGrpXstAtnOwners   uint32 // File position of comment author names
+	LcbGrpXstAtnOwners  uint32 // Length of comment author names
+	FcPlcSpaMom         uint32 // File position of main document shape anchor PLC
+	LcbPlcSpaMom        uint32 // Length of main document shape anchor PLC
 	FcPlcfpgdFtn2       uint32 // File position of page descriptor PLC for footnotes
 	LcbPlcfpgdFtn2      uint32 // Length of page descriptor PLC for footnotes
+	FcPlcfendRef        uint32 // File position of endnote reference PLC
+	LcbPlcfendRef       uint32 // Length of endnote reference PLC
+	FcPlcfendTxt        uint32 // File position of endnote text PLC
+	LcbPlcfendTxt       uint32 // Length of endnote text PLC
 	FcPlcfpgdEdn        uint32 // File position of page descriptor PLC for endnotes
 	LcbPlcfpgdEdn       uint32 // Length of page descriptor PLC for endnotes
 	FcPlcfpgdEdn2       uint32 // File position of page descriptor PLC for endnotes
@@ -147,5 +158,19 @@ type FibRgFcLcb97 struct {
 	LcbStwUser          uint32 // Length of user-defined table
 	FcSttbttmbd         uint32 // File position of embedded TrueType font data
 	LcbSttbttmbd        uint32 // Length of embedded TrueType font data
+	FcPlfLst            uint32 // File position of list definitions
+	LcbPlfLst           uint32 // Length of list definitions, excluding their levels
+	FcPlfLfo            uint32 // File position of list instances and overrides
+	LcbPlfLfo           uint32 // Length of list instances and overrides
+	FcDocUndoWord9      uint32 // File position of undo information (Word 2000 and later)
+	LcbDocUndoWord9     uint32 // Length of undo information
+	FcPlcfgram          uint32 // File position of grammar check state PLC
+	LcbPlcfgram         uint32 // Length of grammar check state PLC
+	FcPlcfBkfFactoid    uint32 // File position of smart tag start PLC (Word 2002)
+	LcbPlcfBkfFactoid   uint32 // Length of smart tag start PLC
+	FcPlcfBklFactoid    uint32 // File position of smart tag end PLC (Word 2002)
+	LcbPlcfBklFactoid   uint32 // Length of smart tag end PLC
+	FcFactoidData       uint32 // File position of smart tag data (Word 2002)
+	LcbFactoidData      uint32 // Length of smart tag data
 	// Additional fields would continue for different nFib versions...
 }
@@ -8,15 +8,19 @@ package formatting
 import (
 	"encoding/binary"
 	"fmt"
+
+	"github.com/TalentFormula/msdoc/structures"
 )
 
 // TextRun represents a run of text with consistent formatting.
 type TextRun struct {
-	Text      string               // The actual text content
-	StartPos  uint32               // Starting character position
-	EndPos    uint32               // Ending character position
-	CharProps *CharacterProperties // Character formatting properties
-	ParaProps *ParagraphProperties // Paragraph formatting properties (if paragraph boundary)
+	Text       string               // The actual text content
+	StartPos   uint32               // Starting character position
+	EndPos     uint32               // Ending character position
+	FileOffset uint32               // Byte offset of the run in the WordDocument stream
+	ByteLength uint32               // Length of the run in bytes within the WordDocument stream
+	CharProps  *CharacterProperties // Character formatting properties
+	ParaProps  *ParagraphProperties // Formatting of the paragraph containing the run
 }
 
 // CharacterProperties holds all character-level formatting information.
@@ -30,10 +34,11 @@ type CharacterProperties struct {
 	Superscript    bool          // Superscript formatting
 	Subscript      bool          // Subscript formatting
 	Color          Color         // Text color
-	HighlightColor Color         // Highlight/background color
+	HighlightColor Color         // Highlight (marker pen) color, Auto if not highlighted
 	FontCharset    uint8         // Character set (for non-ASCII text)
 	Language       uint16        // Language identifier
 	Hidden         bool          // Hidden text
+	NoProof        bool          // Excluded from spelling and grammar checks
 	SmallCaps      bool          // Small capitals
 	AllCaps        bool          // All capitals
 	Spacing        int16         // Character spacing in twips
@@ -41,6 +46,8 @@ type CharacterProperties struct {
 	Position       int16         // Vertical position offset
 	Border         *Border       // Character border
 	Shading        *Shading      // Character shading
+	BiDi           bool          // Right-to-left run (Arabic, Hebrew)
+	Special        bool          // Special character such as a picture anchor or field character (fSpec)
 }
 
 // ParagraphProperties holds all paragraph-level formatting information.
@@ -59,8 +66,9 @@ type ParagraphProperties struct {
 	Borders         *ParagraphBorders  // Paragraph borders
 	Shading         *Shading           // Paragraph shading
 	TabStops        []TabStop          // Tab stop positions
-	OutlineLevel    uint8              // Outline level (0-9)
+	OutlineLevel    uint8              // Outline level: 0-8 for heading levels 1-9, 9 for body text
 	StyleName       string             // Applied paragraph style name
+	BiDi            bool               // Right-to-left paragraph
 }
 
 // SectionProperties holds section-level formatting information.
@@ -76,6 +84,7 @@ type SectionProperties struct {
 	Orientation    PageOrientation     // Page orientation
 	Columns        uint16              // Number of columns
 	ColumnSpacing  uint32              // Column spacing in twips
+	ColumnWidths   []uint32            // Width of each column in twips, empty if evenly spaced
 	VerticalAlign  VerticalAlignment   // Vertical alignment
 	PageBorders    *PageBorders        // Page borders
 	PageBackground *Shading            // Page background
@@ -203,6 +212,75 @@ const (
 	ShadingPct90
 )
 
+// percentage returns the share of the foreground color in the pattern, in
+// percent.
+func (p ShadingPattern) percentage() uint16 {
+	switch p {
+	case ShadingSolid:
+		return 100
+	case ShadingPct5:
+		return 5
+	case ShadingPct10:
+		return 10
+	case ShadingPct20:
+		return 20
+	case ShadingPct25:
+		return 25
+	case ShadingPct30:
+		return 30
+	case ShadingPct40:
+		return 40
+	case ShadingPct50:
+		return 50
+	case ShadingPct60:
+		return 60
+	case ShadingPct70:
+		return 70
+	case ShadingPct75:
+		return 75
+	case ShadingPct80:
+		return 80
+	case ShadingPct90:
+		return 90
+	default:
+		return 0
+	}
+}
+
+// EffectiveColor returns the single color the shading appears as, for
+// formats such as CSS that cannot draw fill patterns: the foreground and
+// background colors blended by the fill percentage. Percentage is used if
+// set, otherwise the share implied by Pattern. An automatic foreground is
+// black and an automatic background white, as Word draws them; a clear
+// shading returns BackColor unchanged, so an automatic result means no
+// background.
+func (s *Shading) EffectiveColor() Color {
+	percent := s.Percentage
+	if percent == 0 {
+		percent = s.Pattern.percentage()
+	}
+	if percent == 0 {
+		return s.BackColor
+	}
+	percent = min(percent, 100)
+
+	fore, back := s.ForeColor, s.BackColor
+	if fore.Auto {
+		fore = Color{}
+	}
+	if back.Auto {
+		back = Color{Red: 0xFF, Green: 0xFF, Blue: 0xFF}
+	}
+	blend := func(f, b uint8) uint8 {
+		return uint8((uint32(f)*uint32(percent) + uint32(b)*uint32(100-percent) + 50) / 100)
+	}
+	return Color{
+		Red:   blend(fore.Red, back.Red),
+		Green: blend(fore.Green, back.Green),
+		Blue:  blend(fore.Blue, back.Blue),
+	}
+}
+
 // ParagraphBorders represents borders around a paragraph.
 type ParagraphBorders struct {
 	Top    *Border // Top border
@@ -360,42 +438,51 @@ func (fe *FormattingExtractor) ParseCharacterProperties(chpx []byte) (*Character
 	}
 
 	props := &CharacterProperties{
-		FontSize: 24, // Default 12pt
-		Color:    Color{Auto: true},
-		Scale:    100, // Default 100%
+		FontSize:       24, // Default 12pt
+		Color:          Color{Auto: true},
+		HighlightColor: Color{Auto: true},
+		Scale:          100, // Default 100%
 	}
 
-	// Parse CHPX properties
+	// Parse CHPX properties. Each sprm's operand size comes from its spra
+	// bits, so unknown sprms are skipped without losing the sprms after them
 	offset := 0
-	for offset < len(chpx)-1 {
+	for offset+2 <= len(chpx) {
 		sprm := binary.LittleEndian.Uint16(chpx[offset:])
 		offset += 2
 
+		size := structures.SprmOperandSize(sprm, chpx[offset:])
+		if size < 0 || offset+size > len(chpx) {
+			break // Truncated sprm
+		}
+		operand := chpx[offset : offset+size]
+		offset += size
+
 		switch sprm {
 		case 0x4A03: // Font size
-			if offset < len(chpx) {
-				props.FontSize = uint16(chpx[offset]) * 2 // Convert to half-points
-				offset++
-			}
+			props.FontSize = uint16(operand[0]) * 2 // Convert to half-points
 		case 0x085C: // Bold
-			if offset < len(chpx) {
-				props.Bold = chpx[offset] != 0
-				offset++
-			}
+			props.Bold = operand[0] != 0
 		case 0x085D: // Italic
-			if offset < len(chpx) {
-				props.Italic = chpx[offset] != 0
-				offset++
-			}
-		case 0x2A0C: // Font color
-			if offset+2 < len(chpx) {
-				colorVal := binary.LittleEndian.Uint16(chpx[offset:])
-				props.Color = fe.parseColor(colorVal)
-				offset += 2
-			}
-		default:
-			// Skip unknown properties
-			offset++
+			props.Italic = operand[0] != 0
+		case 0x0875: // sprmCFNoProof: do not check spelling or grammar
+			props.NoProof = operand[0] != 0
+		case 0x083C: // sprmCFVanish: hidden text
+			props.Hidden = operand[0] != 0
+		case 0x0855: // sprmCFSpec: special character
+			props.Special = operand[0] != 0
+		case 0x085A: // sprmCFBiDi: right-to-left run
+			props.BiDi = operand[0] != 0
+		case 0x4873, 0x486D: // sprmCRgLid0, sprmCRgLid0_80: language
+			props.Language = binary.LittleEndian.Uint16(operand)
+		case 0x4A4F: // sprmCRgFtc0: font
+			props.FontName = fe.fontTable[binary.LittleEndian.Uint16(operand)]
+		case 0x2A3E: // sprmCKul: underline
+			props.Underline = parseKul(operand[0])
+		case 0x2A42: // sprmCIco: font color
+			props.Color = fe.parseIco(operand[0])
+		case 0x2A0C: // sprmCHighlight: highlight color
+			props.HighlightColor = fe.parseIco(operand[0])
 		}
 	}
 
@@ -413,66 +500,86 @@ func (fe *FormattingExtractor) ParseParagraphProperties(papx []byte) (*Paragraph
 		LineSpacing: LineSpacing{Type: LineSpacingSingle, Value: 240}, // Default single spacing
 	}
 
-	// Parse PAPX properties
+	// Parse PAPX properties, skipping unknown sprms by the operand size
+	// their spra bits give
 	offset := 0
-	for offset < len(papx)-1 {
+	for offset+2 <= len(papx) {
 		sprm := binary.LittleEndian.Uint16(papx[offset:])
 		offset += 2
 
+		size := structures.SprmOperandSize(sprm, papx[offset:])
+		if size < 0 || offset+size > len(papx) {
+			break // Truncated sprm
+		}
+		operand := papx[offset : offset+size]
+		offset += size
+
 		switch sprm {
 		case 0x2405: // Paragraph alignment
-			if offset < len(papx) {
-				props.Alignment = ParagraphAlignment(papx[offset])
-				offset++
-			}
+			props.Alignment = ParagraphAlignment(operand[0])
 		case 0x840E: // Left indent
-			if offset+2 < len(papx) {
-				props.LeftIndent = int32(binary.LittleEndian.Uint16(papx[offset:]))
-				offset += 2
-			}
+			props.LeftIndent = int32(binary.LittleEndian.Uint16(operand))
 		case 0x8411: // Right indent
-			if offset+2 < len(papx) {
-				props.RightIndent = int32(binary.LittleEndian.Uint16(papx[offset:]))
-				offset += 2
-			}
-		default:
-			// Skip unknown properties
-			offset++
+			props.RightIndent = int32(binary.LittleEndian.Uint16(operand))
+		case 0x2441: // sprmPFBiDi: right-to-left paragraph
+			props.BiDi = operand[0] != 0
 		}
 	}
 
 	return props, nil
 }
 
-// parseColor converts a Word color value to a Color struct.
-func (fe *FormattingExtractor) parseColor(colorVal uint16) Color {
-	if colorVal == 0xFF {
-		return Color{Auto: true}
-	}
-
-	// Standard Word color palette
-	colors := []Color{
-		{0, 0, 0, false},       // Black
-		{0, 0, 255, false},     // Blue
-		{0, 255, 255, false},   // Cyan
-		{0, 255, 0, false},     // Green
-		{255, 0, 255, false},   // Magenta
-		{255, 0, 0, false},     // Red
-		{255, 255, 0, false},   // Yellow
-		{255, 255, 255, false}, // White
+// parseKul converts a Word underline code (kul) to an UnderlineType. Codes
+// without a matching type, such as words-only underlining, are treated as a
+// single underline.
+func parseKul(kul uint8) UnderlineType {
+	switch kul {
+	case 0:
+		return UnderlineNone
+	case 3:
+		return UnderlineDouble
+	case 4:
+		return UnderlineDotted
+	case 6:
+		return UnderlineThick
+	case 7:
+		return UnderlineDashed
+	case 11:
+		return UnderlineWavy
+	default:
+		return UnderlineSingle
 	}
+}
 
-	if int(colorVal) < len(colors) {
-		return colors[colorVal]
-	}
+// icoPalette is the fixed Word color palette indexed by ico values 1-16.
+// Index 0 means automatic (for text) or no highlight.
+var icoPalette = []Color{
+	{Auto: true},           // Auto / none
+	{0, 0, 0, false},       // Black
+	{0, 0, 255, false},     // Blue
+	{0, 255, 255, false},   // Turquoise
+	{0, 255, 0, false},     // Bright green
+	{255, 0, 255, false},   // Pink
+	{255, 0, 0, false},     // Red
+	{255, 255, 0, false},   // Yellow
+	{255, 255, 255, false}, // White
+	{0, 0, 128, false},     // Dark blue
+	{0, 128, 128, false},   // Teal
+	{0, 128, 0, false},     // Green
+	{128, 0, 128, false},   // Violet
+	{128, 0, 0, false},     // Dark red
+	{128, 128, 0, false},   // Dark yellow
+	{128, 128, 128, false}, // Gray 50%
+	{192, 192, 192, false}, // Gray 25%
+}
 
-	// Custom color - extract RGB components
-	return Color{
-		Red:   uint8((colorVal >> 0) & 0xFF),
-		Green: uint8((colorVal >> 8) & 0xFF),
-		Blue:  uint8((colorVal >> 16) & 0xFF),
-		Auto:  false,
+// parseIco converts a Word ico palette index to a Color struct.
+// Out-of-range values are treated as automatic.
+func (fe *FormattingExtractor) parseIco(ico uint8) Color {
+	if int(ico) < len(icoPalette) {
+		return icoPalette[ico]
 	}
+	return Color{Auto: true}
 }
 
 // AddFontMapping adds a font mapping to the font table.
@@ -5,13 +5,11 @@
 package macros
 
 import (
-	"bytes"
-	"compress/zlib"
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"io"
 	"strings"
+	"unicode/utf16"
 
 	"github.com/TalentFormula/msdoc/ole2"
 )
@@ -34,7 +32,7 @@ type Module struct {
 	Code       string     // VBA source code
 	Compressed bool       // True if code is compressed
 	StreamName string     // Storage stream name
-	Offset     uint32     // Offset within stream
+	Offset     uint32     // Offset of the compressed source within the stream
 	Size       uint32     // Uncompressed size
 }
 
@@ -71,7 +69,10 @@ func NewMacroExtractor(reader *ole2.Reader) *MacroExtractor {
 
 // HasMacros checks if the document contains VBA macros.
 func (me *MacroExtractor) HasMacros() bool {
-	// Check for Macros storage
+	// Word stores the VBA project in the Macros storage
+	if _, err := me.reader.ListChildren("Macros"); err == nil {
+		return true
+	}
 	_, err := me.reader.ReadStream("Macros")
 	if err == nil {
 		return true
@@ -106,130 +107,302 @@ func (me *MacroExtractor) ExtractProject() (*VBAProject, error) {
 	return project, nil
 }
 
-// parseProjectInfo parses the project-level information.
+// parseProjectInfo parses the project-level information and the module
+// records of the dir stream.
 func (me *MacroExtractor) parseProjectInfo(project *VBAProject) error {
-	// Read dir stream for project information
-	dirData, err := me.reader.ReadStream("Macros/dir")
+	dirData, err := me.readDirStream()
 	if err != nil {
-		// Try alternative location
-		dirData, err = me.reader.ReadStream("_VBA_PROJECT")
-		if err != nil {
-			return fmt.Errorf("failed to read project directory: %w", err)
-		}
+		return err
 	}
-
-	return me.parseDirStream(project, dirData)
+	err = parseDirStream(project, dirData, func(module *Module) bool {
+		project.Modules[module.Name] = module
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	me.applyModuleKinds(project)
+	return nil
 }
 
-// parseDirStream parses the dir stream containing project metadata.
-func (me *MacroExtractor) parseDirStream(project *VBAProject, data []byte) error {
-	reader := bytes.NewReader(data)
-
-	for reader.Len() > 0 {
-		// Read record header
-		var recordType uint16
-		if err := binary.Read(reader, binary.LittleEndian, &recordType); err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("failed to read record type: %w", err)
+// readDirStream reads and decompresses the dir stream holding the project
+// metadata.
+func (me *MacroExtractor) readDirStream() ([]byte, error) {
+	var dirData []byte
+	var err error
+	for _, path := range []string{"Macros/VBA/dir", "Macros/dir"} {
+		if dirData, err = me.reader.ReadStream(path); err == nil {
+			break
 		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project directory: %w", err)
+	}
 
-		var recordLength uint32
-		if err := binary.Read(reader, binary.LittleEndian, &recordLength); err != nil {
-			return fmt.Errorf("failed to read record length: %w", err)
-		}
+	if len(dirData) == 0 || dirData[0] != 0x01 {
+		return nil, errors.New("dir stream is not a compressed container")
+	}
+	dirData, err = me.decompressVBACode(dirData[1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress dir stream: %w", err)
+	}
+	return dirData, nil
+}
 
-		// Read record data
-		recordData := make([]byte, recordLength)
-		if _, err := reader.Read(recordData); err != nil {
-			return fmt.Errorf("failed to read record data: %w", err)
-		}
+// Identifiers of the dir stream records read by parseDirStream.
+const (
+	recordProjectName             = 0x0004
+	recordProjectDocString        = 0x0005
+	recordProjectHelpFilePath     = 0x0006
+	recordProjectVersion          = 0x0009
+	recordReferenceRegistered     = 0x000D
+	recordReferenceProject        = 0x000E
+	recordReferenceName           = 0x0016
+	recordModuleName              = 0x0019
+	recordModuleStreamName        = 0x001A
+	recordModuleTypeProcedural    = 0x0021
+	recordModuleTypeOther         = 0x0022
+	recordModuleTerminator        = 0x002B
+	recordReferenceControl        = 0x002F
+	recordReferenceControlEnd     = 0x0030
+	recordModuleOffset            = 0x0031
+	recordModuleStreamNameUnicode = 0x0032
+	recordReferenceOriginal       = 0x0033
+	recordReferenceNameUnicode    = 0x003E
+	recordProjectDocStringUnicode = 0x0040
+	recordModuleNameUnicode       = 0x0047
+)
+
+// parseDirStream parses the decompressed dir stream: the project
+// information, the references and the module records. project receives the
+// project information and references, and onModule each module once its
+// terminator is read; parsing stops when onModule returns false.
+func parseDirStream(project *VBAProject, data []byte, onModule func(module *Module) bool) error {
+	var module *Module
+	var ref *Reference
+	inControl := false // Between a REFERENCECONTROL and the end of its extended part
 
-		// Process record based on type
+	return forEachDirRecord(data, func(recordType uint16, recordData []byte) (bool, error) {
 		switch recordType {
-		case 0x01: // Project information
-			me.parseProjectRecord(project, recordData)
-		case 0x07: // Module information
-			module, err := me.parseModuleRecord(recordData)
-			if err != nil {
-				return fmt.Errorf("failed to parse module record: %w", err)
-			}
-			if module != nil {
-				project.Modules[module.Name] = module
-			}
-		case 0x0D: // Reference information
-			ref, err := me.parseReferenceRecord(recordData)
-			if err != nil {
-				return fmt.Errorf("failed to parse reference record: %w", err)
+		case recordProjectName:
+			project.Name = string(recordData)
+		case recordProjectDocString:
+			project.Description = string(recordData)
+		case recordProjectDocStringUnicode:
+			project.Description = decodeUTF16(recordData)
+		case recordProjectHelpFilePath:
+			project.HelpFile = string(recordData)
+
+		case recordReferenceName:
+			// A control reference may repeat its name inside the record
+			if !inControl {
+				ref = &Reference{}
+				project.References = append(project.References, ref)
 			}
+			ref.Name = string(recordData)
+		case recordReferenceNameUnicode:
 			if ref != nil {
+				ref.Name = decodeUTF16(recordData)
+			}
+		case recordReferenceRegistered, recordReferenceProject, recordReferenceControl, recordReferenceOriginal:
+			if ref == nil {
+				ref = &Reference{}
 				project.References = append(project.References, ref)
 			}
+			applyReferenceRecord(ref, recordType, recordData)
+			switch recordType {
+			case recordReferenceControl:
+				inControl = true
+			case recordReferenceRegistered, recordReferenceProject:
+				ref = nil
+			}
+		case recordReferenceControlEnd:
+			inControl, ref = false, nil
+
+		case recordModuleName:
+			module = &Module{Name: string(recordData)}
+			ref = nil
 		}
-	}
 
-	return nil
+		if module == nil {
+			return true, nil
+		}
+		switch recordType {
+		case recordModuleNameUnicode:
+			module.Name = decodeUTF16(recordData)
+		case recordModuleStreamName:
+			module.StreamName = string(recordData)
+		case recordModuleStreamNameUnicode:
+			module.StreamName = decodeUTF16(recordData)
+		case recordModuleOffset:
+			if len(recordData) >= 4 {
+				module.Offset = binary.LittleEndian.Uint32(recordData)
+			}
+		case recordModuleTypeProcedural:
+			module.Type = ModuleStandard
+		case recordModuleTypeOther:
+			module.Type = ModuleClass
+		case recordModuleTerminator:
+			more := onModule(module)
+			module = nil
+			return more, nil
+		}
+		return true, nil
+	})
 }
 
-// parseProjectRecord parses project-level information.
-func (me *MacroExtractor) parseProjectRecord(project *VBAProject, data []byte) {
-	// Extract project name, description, etc.
-	reader := bytes.NewReader(data)
+// forEachDirRecord calls fn with the identifier and data of each record in
+// the dir stream, until fn returns false or an error. Each record is a 2-byte
+// identifier and a 4-byte size followed by its data, except PROJECTVERSION
+// whose size field reads 4 but which holds 6 bytes of version numbers.
+func forEachDirRecord(data []byte, fn func(recordType uint16, recordData []byte) (bool, error)) error {
+	for offset := 0; offset < len(data); {
+		if offset+6 > len(data) {
+			return fmt.Errorf("truncated record header at offset %d", offset)
+		}
+		recordType := binary.LittleEndian.Uint16(data[offset:])
+		recordLength := uint64(binary.LittleEndian.Uint32(data[offset+2:]))
+		if recordType == recordProjectVersion {
+			recordLength = 6
+		}
+		offset += 6
+		if uint64(offset)+recordLength > uint64(len(data)) {
+			return fmt.Errorf("record 0x%04X of %d bytes exceeds the dir stream", recordType, recordLength)
+		}
 
-	// Read null-terminated strings
-	project.Name = me.readNullTerminatedString(reader)
-	project.Description = me.readNullTerminatedString(reader)
-	project.HelpFile = me.readNullTerminatedString(reader)
+		more, err := fn(recordType, data[offset:offset+int(recordLength)])
+		if err != nil || !more {
+			return err
+		}
+		offset += int(recordLength)
+	}
+	return nil
 }
 
-// parseModuleRecord parses module information.
-func (me *MacroExtractor) parseModuleRecord(data []byte) (*Module, error) {
-	if len(data) < 8 {
-		return nil, errors.New("module record too short")
+// applyReferenceRecord fills in ref from the libid of a reference record.
+// Registered and control references identify a type library, original
+// references the library a control was created from, and project references
+// another VBA project by path.
+func applyReferenceRecord(ref *Reference, recordType uint16, data []byte) {
+	// The other kinds of reference start with a size-prefixed libid
+	libidAt := func(offset int) string {
+		if offset+4 > len(data) {
+			return ""
+		}
+		size := int(binary.LittleEndian.Uint32(data[offset:]))
+		if size < 0 || offset+4+size > len(data) {
+			return ""
+		}
+		return string(data[offset+4 : offset+4+size])
 	}
 
-	reader := bytes.NewReader(data)
-	module := &Module{}
-
-	// Read module name
-	module.Name = me.readNullTerminatedString(reader)
-
-	// Read module type
-	var moduleType uint32
-	if err := binary.Read(reader, binary.LittleEndian, &moduleType); err != nil {
-		return nil, fmt.Errorf("failed to read module type: %w", err)
+	switch recordType {
+	case recordReferenceRegistered, recordReferenceControl:
+		// The record size (Size or SizeTwiddled) precedes the libid size
+		applyLibid(ref, libidAt(0))
+	case recordReferenceOriginal:
+		// The record size is the size of the original libid itself
+		applyLibid(ref, string(data))
+	case recordReferenceProject:
+		libid := libidAt(0)
+		ref.Path = strings.TrimPrefix(strings.TrimPrefix(libid, "*\\C"), "*\\D")
+		relativeAt := 4 + len(libid)
+		versionAt := relativeAt + 4 + len(libidAt(relativeAt))
+		if versionAt+6 <= len(data) {
+			ref.Version = fmt.Sprintf("%d.%d", binary.LittleEndian.Uint32(data[versionAt:]), binary.LittleEndian.Uint16(data[versionAt+4:]))
+		}
 	}
-	module.Type = ModuleType(moduleType)
-
-	// Read stream name
-	module.StreamName = me.readNullTerminatedString(reader)
+}
 
-	// Read offset and size
-	if err := binary.Read(reader, binary.LittleEndian, &module.Offset); err != nil {
-		return nil, fmt.Errorf("failed to read module offset: %w", err)
+// applyLibid fills in ref from a type library libid such as
+// "*\G{00020905-0000-0000-C000-000000000046}#8.7#0#C:\Word.olb#Microsoft Word".
+// Fields already set are kept, so the twiddled libid of a control does not
+// override the original libid that precedes it.
+func applyLibid(ref *Reference, libid string) {
+	if !strings.HasPrefix(libid, "*\\G") {
+		return
 	}
+	parts := strings.SplitN(libid[3:], "#", 5)
+	set := func(field *string, index int) {
+		if *field == "" && index < len(parts) {
+			*field = parts[index]
+		}
+	}
+	set(&ref.GUID, 0)
+	set(&ref.Version, 1)
+	set(&ref.Path, 3)
+	set(&ref.Description, 4)
+}
 
-	if err := binary.Read(reader, binary.LittleEndian, &module.Size); err != nil {
-		return nil, fmt.Errorf("failed to read module size: %w", err)
+// applyModuleKinds refines the types of the non-procedural modules from the
+// PROJECT stream, which lists document modules, classes and user forms by
+// name. Without the stream they remain class modules.
+func (me *MacroExtractor) applyModuleKinds(project *VBAProject) {
+	data, err := me.reader.ReadStream("Macros/PROJECT")
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimRight(line, "\r"), "=")
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(value, "/")
+		module := project.Modules[name]
+		if module == nil || module.Type == ModuleStandard {
+			continue
+		}
+		switch key {
+		case "Document":
+			module.Type = ModuleDocument
+		case "BaseClass":
+			module.Type = ModuleForm
+		}
 	}
+}
 
-	return module, nil
+// decodeUTF16 decodes UTF-16LE data, as held by the Unicode records of the
+// dir stream.
+func decodeUTF16(data []byte) string {
+	u16s := make([]uint16, len(data)/2)
+	for i := range u16s {
+		u16s[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(u16s))
 }
 
-// parseReferenceRecord parses reference information.
-func (me *MacroExtractor) parseReferenceRecord(data []byte) (*Reference, error) {
-	reader := bytes.NewReader(data)
-	ref := &Reference{}
+// ExtractModuleCode returns the VBA code of a single module. Only the dir
+// records up to the module's record are parsed, and only that module's code
+// is read and decompressed.
+func (me *MacroExtractor) ExtractModuleCode(moduleName string) (string, error) {
+	if !me.HasMacros() {
+		return "", errors.New("document does not contain VBA macros")
+	}
+
+	dirData, err := me.readDirStream()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse project info: %w", err)
+	}
 
-	// Read reference information
-	ref.Name = me.readNullTerminatedString(reader)
-	ref.Description = me.readNullTerminatedString(reader)
-	ref.GUID = me.readNullTerminatedString(reader)
-	ref.Version = me.readNullTerminatedString(reader)
-	ref.Path = me.readNullTerminatedString(reader)
+	var module *Module
+	err = parseDirStream(&VBAProject{}, dirData, func(candidate *Module) bool {
+		if candidate.Name == moduleName {
+			module = candidate
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to parse project info: %w", err)
+	}
+	if module == nil {
+		return "", fmt.Errorf("module %s not found", moduleName)
+	}
 
-	return ref, nil
+	if err := me.extractModuleCode(module); err != nil {
+		return "", fmt.Errorf("failed to extract code for module %s: %w", module.Name, err)
+	}
+	return module.Code, nil
 }
 
 // extractModules extracts the actual VBA code for all modules.
@@ -244,33 +417,30 @@ func (me *MacroExtractor) extractModules(project *VBAProject) error {
 
 // extractModuleCode extracts the VBA source code for a specific module.
 func (me *MacroExtractor) extractModuleCode(module *Module) error {
-	// Read the module stream
-	streamPath := fmt.Sprintf("Macros/%s", module.StreamName)
-	streamData, err := me.reader.ReadStream(streamPath)
-	if err != nil {
-		// Try alternative VBA location
-		streamPath = fmt.Sprintf("VBA/%s", module.StreamName)
-		streamData, err = me.reader.ReadStream(streamPath)
-		if err != nil {
-			return fmt.Errorf("failed to read module stream %s: %w", module.StreamName, err)
+	// Read the module stream, which Word keeps next to the dir stream
+	var streamData []byte
+	var err error
+	for _, dir := range []string{"Macros/VBA", "Macros", "VBA"} {
+		if streamData, err = me.reader.ReadStream(dir + "/" + module.StreamName); err == nil {
+			break
 		}
 	}
+	if err != nil {
+		return fmt.Errorf("failed to read module stream %s: %w", module.StreamName, err)
+	}
 
-	// Extract code from the specified offset
+	// The module stream starts with the performance cache, which only the
+	// VBA version that wrote it can use; the source code follows at the
+	// offset given by the dir stream
 	if uint32(len(streamData)) < module.Offset {
 		return fmt.Errorf("stream too short for module offset %d", module.Offset)
 	}
-
 	codeData := streamData[module.Offset:]
-	if module.Size > 0 && uint32(len(codeData)) > module.Size {
-		codeData = codeData[:module.Size]
-	}
 
 	// Check if code is compressed
 	if len(codeData) > 0 && codeData[0] == 0x01 {
-		// Compressed VBA code
 		module.Compressed = true
-		decompressed, err := me.decompressVBACode(codeData[1:]) // Skip compression flag
+		decompressed, err := me.decompressVBACode(codeData[1:]) // Skip the signature byte
 		if err != nil {
 			return fmt.Errorf("failed to decompress VBA code: %w", err)
 		}
@@ -280,78 +450,98 @@ func (me *MacroExtractor) extractModuleCode(module *Module) error {
 		module.Compressed = false
 		module.Code = string(codeData)
 	}
+	module.Size = uint32(len(module.Code))
 
 	return nil
 }
 
-// decompressVBACode decompresses VBA source code using the custom VBA compression algorithm.
+// Layout of the chunks of an MS-OVBA compressed container.
+const (
+	chunkSize          = 4096   // Decompressed size of every chunk but the last
+	chunkSignature     = 0x3000 // Bits 12-14 of a chunk header
+	chunkSignatureMask = 0x7000
+	chunkCompressed    = 0x8000 // Flag bit of a chunk header
+	chunkSizeMask      = 0x0FFF // Size of the chunk minus 3
+)
+
+// decompressVBACode decompresses the chunks of an MS-OVBA compressed
+// container, given without its leading signature byte.
+//
+// Each chunk starts with a 2-byte header holding its size and whether it is
+// compressed. An uncompressed chunk holds 4096 literal bytes. A compressed
+// chunk is a sequence of flag bytes, each followed by eight tokens: a literal
+// byte for each clear bit and a 2-byte copy token for each set bit. A copy
+// token repeats earlier output of the same chunk; how its 16 bits divide
+// into offset and length depends on how much of the chunk is decompressed.
 func (me *MacroExtractor) decompressVBACode(data []byte) ([]byte, error) {
 	if len(data) == 0 {
 		return nil, errors.New("no data to decompress")
 	}
 
-	// Check for zlib compression first
-	if len(data) > 2 && data[0] == 0x78 {
-		reader, err := zlib.NewReader(bytes.NewReader(data))
-		if err == nil {
-			defer reader.Close()
-			decompressed, err := io.ReadAll(reader)
-			if err == nil {
-				return decompressed, nil
-			}
+	var output []byte
+	for pos := 0; pos < len(data); {
+		if pos+2 > len(data) {
+			return nil, fmt.Errorf("truncated chunk header at offset %d", pos)
 		}
-	}
-
-	// Custom VBA decompression algorithm
-	return me.decompressVBACustom(data)
-}
-
-// decompressVBACustom implements the custom VBA compression algorithm.
-func (me *MacroExtractor) decompressVBACustom(data []byte) ([]byte, error) {
-	// This is a simplified implementation of VBA decompression
-	// A complete implementation would handle the full VBA compression algorithm
-
-	var output bytes.Buffer
-	reader := bytes.NewReader(data)
-
-	for reader.Len() > 0 {
-		b, err := reader.ReadByte()
-		if err != nil {
-			break
+		header := binary.LittleEndian.Uint16(data[pos:])
+		if header&chunkSignatureMask != chunkSignature {
+			return nil, fmt.Errorf("invalid chunk signature in header 0x%04X at offset %d", header, pos)
+		}
+		end := min(pos+int(header&chunkSizeMask)+3, len(data))
+		pos += 2
+
+		if header&chunkCompressed == 0 {
+			n := min(chunkSize, end-pos)
+			output = append(output, data[pos:pos+n]...)
+			pos = end
+			continue
 		}
 
-		if b >= 0x20 && b <= 0x7E {
-			// Printable ASCII character
-			output.WriteByte(b)
-		} else if b == 0x0D {
-			// Carriage return
-			output.WriteByte('\r')
-		} else if b == 0x0A {
-			// Line feed
-			output.WriteByte('\n')
-		} else if b == 0x09 {
-			// Tab
-			output.WriteByte('\t')
+		chunkStart := len(output)
+		for pos < end {
+			flags := data[pos]
+			pos++
+			for bit := 0; bit < 8 && pos < end; bit++ {
+				if flags&(1<<bit) == 0 {
+					output = append(output, data[pos])
+					pos++
+					continue
+				}
+
+				if pos+2 > end {
+					return nil, fmt.Errorf("truncated copy token at offset %d", pos)
+				}
+				token := binary.LittleEndian.Uint16(data[pos:])
+				pos += 2
+
+				offset, length := unpackCopyToken(token, len(output)-chunkStart)
+				if offset > len(output)-chunkStart {
+					return nil, fmt.Errorf("copy token offset %d before the start of the chunk", offset)
+				}
+				// Copies may overlap the bytes they produce, so go byte by byte
+				from := len(output) - offset
+				for i := 0; i < length; i++ {
+					output = append(output, output[from+i])
+				}
+			}
 		}
-		// Skip other control characters
 	}
-
-	return output.Bytes(), nil
+	return output, nil
 }
 
-// readNullTerminatedString reads a null-terminated string from the reader.
-func (me *MacroExtractor) readNullTerminatedString(reader *bytes.Reader) string {
-	var result strings.Builder
-
-	for {
-		b, err := reader.ReadByte()
-		if err != nil || b == 0 {
-			break
-		}
-		result.WriteByte(b)
+// unpackCopyToken splits a copy token into the offset back from the end of
+// the output and the number of bytes to copy. The offset takes as many high
+// bits as are needed to reach the start of the chunk, given the number of
+// bytes of the chunk decompressed so far, and at least four.
+func unpackCopyToken(token uint16, decompressed int) (offset, length int) {
+	bitCount := 4
+	for 1<<bitCount < decompressed {
+		bitCount++
 	}
-
-	return result.String()
+	lengthMask := uint16(0xFFFF) >> bitCount
+	length = int(token&lengthMask) + 3
+	offset = int(token>>(16-bitCount)) + 1
+	return offset, length
 }
 
 // GetModuleCode returns the VBA code for a specific module.
@@ -56,11 +56,11 @@ type DocumentMetadata struct {
 	ContentType      string                 // Content type
 	ContentStatus    string                 // Content status
 	HyperLinkBase    string                 // Hyperlink base
-	CustomProperties map[string]interface{} // Custom properties
+	CustomProperties map[string]interface{} // Custom properties; undecoded types are kept as RawProperty
 
 	// Extended properties
-	ThumbnailClipboardFormat int32  // Thumbnail format
-	ThumbnailData            []byte // Thumbnail image data
+	ThumbnailClipboardFormat int32  // Windows clipboard format of the thumbnail, or its format tag
+	ThumbnailData            []byte // Thumbnail clipboard data, decoded by ParseThumbnail
 
 	// Security and protection
 	ReadOnlyRecommended      bool // Read-only recommended
@@ -96,6 +96,29 @@ const (
 	PropertyTypeStringW       PropertyType = 0x001F // VT_LPWSTR
 )
 
+// RawProperty holds the value of a property whose type is not decoded.
+type RawProperty struct {
+	Type  PropertyType // Type of the property value
+	Bytes []byte       // Value data following the type and padding
+}
+
+// errUnsupportedPropertyType is returned by readPropertyValue for property
+// types it does not decode.
+var errUnsupportedPropertyType = errors.New("unsupported property type")
+
+// fmtidUserDefinedProperties identifies the custom property section of the
+// DocumentSummaryInformation stream ({D5CDD505-2E9C-101B-9397-08002B2CF9AE}).
+var fmtidUserDefinedProperties = [16]byte{
+	0x05, 0xD5, 0xCD, 0xD5, 0x9C, 0x2E, 0x1B, 0x10,
+	0x93, 0x97, 0x08, 0x00, 0x2B, 0x2C, 0xF9, 0xAE,
+}
+
+// Special property IDs of a property section
+const (
+	PIDDictionary = 0x00 // Maps property IDs to names
+	PIDCodePage   = 0x01 // Code page of the section's strings
+)
+
 // Property IDs for SummaryInformation stream
 const (
 	PIDTitle        = 0x02
@@ -178,6 +201,11 @@ func (me *MetadataExtractor) ExtractMetadata() (*DocumentMetadata, error) {
 		fmt.Printf("Warning: Failed to extract DocumentSummaryInformation: %v\n", err)
 	}
 
+	// Word only stores a content type when one is set explicitly
+	if metadata.ContentType == "" {
+		metadata.ContentType = "application/msword"
+	}
+
 	return metadata, nil
 }
 
@@ -268,6 +296,7 @@ func (me *MetadataExtractor) extractSummaryInformation(metadata *DocumentMetadat
 		case PIDThumbnail:
 			if data, ok := value.([]byte); ok {
 				metadata.ThumbnailData = data
+				metadata.ThumbnailClipboardFormat = clipboardFormat(data)
 			}
 		}
 	}
@@ -290,6 +319,9 @@ func (me *MetadataExtractor) extractDocumentSummaryInformation(metadata *Documen
 		return me.extractDocumentSummaryAlternative(metadata)
 	}
 
+	// Custom properties are optional; a malformed section only loses them
+	me.extractCustomProperties(streamData, metadata)
+
 	// Extract known properties
 	for propID, value := range properties {
 		switch propID {
@@ -897,6 +929,11 @@ func (me *MetadataExtractor) parsePropertySetFromOffset(data []byte, offset int)
 			return nil, fmt.Errorf("failed to read property set info %d: %w", i, err)
 		}
 
+		// Custom properties reuse the standard IDs and are read separately
+		if psInfo.FMTID == fmtidUserDefinedProperties {
+			continue
+		}
+
 		// Parse property set at offset (adjust for the offset we started from)
 		absoluteOffset := offset + int(psInfo.Offset)
 		if absoluteOffset >= len(data) {
@@ -1104,6 +1141,156 @@ func (me *MetadataExtractor) parsePropertySetData(data []byte, properties map[ui
 	return nil
 }
 
+// extractCustomProperties reads the user-defined property section of a
+// DocumentSummaryInformation stream into metadata.CustomProperties, keyed by
+// property name. Values of types that are not decoded are kept as RawProperty.
+func (me *MetadataExtractor) extractCustomProperties(data []byte, metadata *DocumentMetadata) error {
+	section, err := findPropertySection(data, fmtidUserDefinedProperties)
+	if err != nil || section == nil {
+		return err
+	}
+	if len(section) < 8 {
+		return errors.New("custom property section too short")
+	}
+
+	// Section size, property count and the (ID, offset) pairs
+	size := binary.LittleEndian.Uint32(section[0:])
+	count := binary.LittleEndian.Uint32(section[4:])
+	if size < 8 || int(size) > len(section) || uint64(count)*8 > uint64(size-8) {
+		return errors.New("invalid custom property section header")
+	}
+	section = section[:size]
+
+	offsets := make(map[uint32]uint32, count)
+	ends := make([]uint32, 0, count+1)
+	for i := uint32(0); i < count; i++ {
+		entry := section[8+i*8:]
+		id, offset := binary.LittleEndian.Uint32(entry), binary.LittleEndian.Uint32(entry[4:])
+		if offset >= size {
+			continue
+		}
+		offsets[id] = offset
+		ends = append(ends, offset)
+	}
+	ends = append(ends, size)
+
+	// Strings are UTF-16 if the section's code page is 1200
+	unicode := false
+	if offset, ok := offsets[PIDCodePage]; ok && offset+6 <= size {
+		unicode = binary.LittleEndian.Uint16(section[offset+4:]) == 1200
+	}
+
+	dictOffset, ok := offsets[PIDDictionary]
+	if !ok {
+		return errors.New("custom property section has no dictionary")
+	}
+	names, err := parsePropertyDictionary(section[dictOffset:], unicode)
+	if err != nil {
+		return err
+	}
+
+	for id, offset := range offsets {
+		name, ok := names[id]
+		if !ok || id == PIDDictionary || id == PIDCodePage {
+			continue
+		}
+
+		value, err := me.readPropertyValue(bytes.NewReader(section[offset:]))
+		if errors.Is(err, errUnsupportedPropertyType) {
+			// The value extends to the next property or the end of the section
+			end := size
+			for _, e := range ends {
+				if e > offset && e < end {
+					end = e
+				}
+			}
+			raw := RawProperty{Type: PropertyType(binary.LittleEndian.Uint16(section[offset:]))}
+			if offset+4 <= end {
+				raw.Bytes = append([]byte(nil), section[offset+4:end]...)
+			}
+			value, err = raw, nil
+		}
+		if err != nil {
+			continue // Skip invalid property
+		}
+		metadata.CustomProperties[name] = value
+	}
+
+	return nil
+}
+
+// findPropertySection returns the data of the property section with the given
+// format ID, starting at the section header. Returns nil if the stream has no
+// such section.
+func findPropertySection(data []byte, fmtid [16]byte) ([]byte, error) {
+	if len(data) < 28 {
+		return nil, errors.New("property set data too short")
+	}
+	if binary.LittleEndian.Uint16(data[0:]) != 0xFFFE {
+		return nil, errors.New("invalid byte order in property set")
+	}
+
+	numSections := binary.LittleEndian.Uint32(data[24:])
+	for i := uint32(0); i < numSections; i++ {
+		entry := 28 + int(i)*20
+		if entry+20 > len(data) {
+			return nil, errors.New("property set section list truncated")
+		}
+		var id [16]byte
+		copy(id[:], data[entry:])
+		if id != fmtid {
+			continue
+		}
+		offset := binary.LittleEndian.Uint32(data[entry+16:])
+		if int(offset) >= len(data) {
+			return nil, fmt.Errorf("property section offset %d out of bounds", offset)
+		}
+		return data[offset:], nil
+	}
+	return nil, nil
+}
+
+// parsePropertyDictionary parses a property dictionary, mapping property IDs
+// to names. Unicode names are padded to a multiple of 4 bytes.
+func parsePropertyDictionary(data []byte, unicode bool) (map[uint32]string, error) {
+	if len(data) < 4 {
+		return nil, errors.New("property dictionary too short")
+	}
+
+	count := binary.LittleEndian.Uint32(data)
+	names := make(map[uint32]string, count)
+	pos := 4
+	for i := uint32(0); i < count; i++ {
+		if pos+8 > len(data) {
+			return nil, errors.New("property dictionary truncated")
+		}
+		id := binary.LittleEndian.Uint32(data[pos:])
+		length := int(binary.LittleEndian.Uint32(data[pos+4:])) // Characters, including the terminator
+		pos += 8
+
+		var name string
+		if unicode {
+			if length > (len(data)-pos)/2 {
+				return nil, errors.New("property dictionary truncated")
+			}
+			units := make([]uint16, length)
+			for j := range units {
+				units[j] = binary.LittleEndian.Uint16(data[pos+j*2:])
+			}
+			name = string(utf16.Decode(units))
+			pos += (length*2 + 3) &^ 3
+		} else {
+			if length > len(data)-pos {
+				return nil, errors.New("property dictionary truncated")
+			}
+			name = string(data[pos : pos+length])
+			pos += length
+		}
+		names[id] = strings.TrimRight(name, "\x00")
+	}
+	return names, nil
+}
+
 // readPropertyValue reads a property value based on its type.
 func (me *MetadataExtractor) readPropertyValue(reader *bytes.Reader) (interface{}, error) {
 	// Read property type
@@ -1138,6 +1325,13 @@ func (me *MetadataExtractor) readPropertyValue(reader *bytes.Reader) (interface{
 		}
 		return value, nil
 
+	case PropertyTypeDouble:
+		var value float64
+		if err := binary.Read(reader, binary.LittleEndian, &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+
 	case PropertyTypeFileTime:
 		var filetime int64
 		if err := binary.Read(reader, binary.LittleEndian, &filetime); err != nil {
@@ -1204,7 +1398,7 @@ func (me *MetadataExtractor) readPropertyValue(reader *bytes.Reader) (interface{
 		return value != 0, nil
 
 	default:
-		return nil, fmt.Errorf("unsupported property type: %d", propType)
+		return nil, fmt.Errorf("%w: %d", errUnsupportedPropertyType, propType)
 	}
 }
 
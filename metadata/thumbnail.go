@@ -0,0 +1,282 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/bits"
+)
+
+// Format tags at the start of a VT_CF ClipboardData value. A positive tag is
+// the length of a registered clipboard format name that follows it.
+const (
+	ClipboardFormatWindows int32 = -1 // Followed by a Windows clipboard format
+	ClipboardFormatMac     int32 = -2 // Followed by a Macintosh clipboard format
+	ClipboardFormatFMTID   int32 = -3 // Followed by a format identifier
+)
+
+// Windows clipboard formats used for thumbnails.
+const (
+	CFMetafilePict = 3  // CF_METAFILEPICT, a Windows metafile
+	CFDIB          = 8  // CF_DIB, a device-independent bitmap
+	CFEnhMetafile  = 14 // CF_ENHMETAFILE, an enhanced metafile
+)
+
+// ThumbnailKind identifies how a thumbnail's image is stored.
+type ThumbnailKind int
+
+const (
+	ThumbnailUnknown ThumbnailKind = iota // Unrecognized format, Data holds the raw bytes
+	ThumbnailDIB                          // Device-independent bitmap
+	ThumbnailWMF                          // Windows metafile
+	ThumbnailEMF                          // Enhanced metafile
+	ThumbnailPNG                          // PNG image
+	ThumbnailJPEG                         // JPEG image
+)
+
+// String returns the name of the thumbnail kind.
+func (k ThumbnailKind) String() string {
+	switch k {
+	case ThumbnailDIB:
+		return "DIB"
+	case ThumbnailWMF:
+		return "WMF"
+	case ThumbnailEMF:
+		return "EMF"
+	case ThumbnailPNG:
+		return "PNG"
+	case ThumbnailJPEG:
+		return "JPEG"
+	default:
+		return "Unknown"
+	}
+}
+
+// Thumbnail is the preview image stored in the SummaryInformation stream.
+type Thumbnail struct {
+	Kind ThumbnailKind
+	// Image is the decoded image of a DIB, PNG or JPEG thumbnail. It is nil
+	// for metafiles, which have to be rendered by the caller.
+	Image image.Image
+	// Data holds the stored image: the bitmap, the encoded PNG or JPEG, or
+	// a metafile that can be saved as a .wmf or .emf file.
+	Data []byte
+}
+
+// metafilePictHeaderSize is the size of the METAFILEPICT header (mapping
+// mode, extents and handle) preceding a CF_METAFILEPICT metafile.
+const metafilePictHeaderSize = 8
+
+var (
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+	jpegSignature = []byte{0xFF, 0xD8, 0xFF}
+)
+
+// clipboardFormat returns the Windows clipboard format of VT_CF data, or the
+// format tag if the data does not use a Windows clipboard format.
+func clipboardFormat(data []byte) int32 {
+	if len(data) < 4 {
+		return 0
+	}
+	tag := int32(binary.LittleEndian.Uint32(data))
+	if tag == ClipboardFormatWindows && len(data) >= 8 {
+		return int32(binary.LittleEndian.Uint32(data[4:]))
+	}
+	return tag
+}
+
+// ParseThumbnail decodes the ClipboardData of a VT_CF thumbnail property: the
+// format tag, its format identifier and the image data.
+//
+// DIBs are decoded to an image, metafiles are returned as-is, and PNG or JPEG
+// images stored under any other clipboard format are recognized by their
+// signature and decoded.
+func ParseThumbnail(data []byte) (*Thumbnail, error) {
+	if len(data) < 4 {
+		return nil, errors.New("thumbnail: data too short for clipboard format")
+	}
+
+	tag := int32(binary.LittleEndian.Uint32(data))
+	var headerSize int
+	switch {
+	case tag == ClipboardFormatWindows, tag == ClipboardFormatMac:
+		headerSize = 8
+	case tag == ClipboardFormatFMTID:
+		headerSize = 20
+	case tag > 0:
+		headerSize = 4 + int(tag) // Registered format name
+	default:
+		return nil, fmt.Errorf("thumbnail: invalid clipboard format %d", tag)
+	}
+	if headerSize > len(data) {
+		return nil, errors.New("thumbnail: data too short for clipboard format")
+	}
+	payload := data[headerSize:]
+
+	if tag == ClipboardFormatWindows {
+		switch binary.LittleEndian.Uint32(data[4:]) {
+		case CFDIB:
+			img, err := decodeDIB(payload)
+			if err != nil {
+				return nil, fmt.Errorf("thumbnail: %w", err)
+			}
+			return &Thumbnail{Kind: ThumbnailDIB, Image: img, Data: payload}, nil
+		case CFMetafilePict:
+			if len(payload) < metafilePictHeaderSize {
+				return nil, errors.New("thumbnail: metafile too short")
+			}
+			return &Thumbnail{Kind: ThumbnailWMF, Data: payload[metafilePictHeaderSize:]}, nil
+		case CFEnhMetafile:
+			return &Thumbnail{Kind: ThumbnailEMF, Data: payload}, nil
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(payload, pngSignature):
+		img, err := png.Decode(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("thumbnail: %w", err)
+		}
+		return &Thumbnail{Kind: ThumbnailPNG, Image: img, Data: payload}, nil
+	case bytes.HasPrefix(payload, jpegSignature):
+		img, err := jpeg.Decode(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("thumbnail: %w", err)
+		}
+		return &Thumbnail{Kind: ThumbnailJPEG, Image: img, Data: payload}, nil
+	}
+	return &Thumbnail{Kind: ThumbnailUnknown, Data: payload}, nil
+}
+
+// DIB compression values supported by decodeDIB.
+const (
+	biRGB       = 0
+	biBitfields = 3
+)
+
+// maxDIBPixels limits the size of decoded thumbnails.
+const maxDIBPixels = 1 << 24
+
+// decodeDIB decodes an uncompressed device-independent bitmap: a
+// BITMAPINFOHEADER or later header, the color masks or palette, and the
+// pixel rows.
+func decodeDIB(data []byte) (image.Image, error) {
+	if len(data) < 40 {
+		return nil, errors.New("DIB too short for BITMAPINFOHEADER")
+	}
+	headerSize := int(binary.LittleEndian.Uint32(data))
+	if headerSize < 40 || headerSize > len(data) {
+		return nil, fmt.Errorf("invalid DIB header size %d", headerSize)
+	}
+	width := int(int32(binary.LittleEndian.Uint32(data[4:])))
+	height := int(int32(binary.LittleEndian.Uint32(data[8:])))
+	bitCount := int(binary.LittleEndian.Uint16(data[14:]))
+	compression := binary.LittleEndian.Uint32(data[16:])
+	colorsUsed := int(binary.LittleEndian.Uint32(data[32:]))
+
+	topDown := height < 0
+	if topDown {
+		height = -height
+	}
+	if width <= 0 || height == 0 || width*height > maxDIBPixels {
+		return nil, fmt.Errorf("invalid DIB size %dx%d", width, height)
+	}
+
+	offset := headerSize
+	var masks [3]uint32
+	switch {
+	case compression == biBitfields && headerSize >= 52:
+		for i := range masks {
+			masks[i] = binary.LittleEndian.Uint32(data[40+i*4:])
+		}
+	case compression == biBitfields:
+		if offset+12 > len(data) {
+			return nil, errors.New("DIB too short for color masks")
+		}
+		for i := range masks {
+			masks[i] = binary.LittleEndian.Uint32(data[offset+i*4:])
+		}
+		offset += 12
+	case compression == biRGB && bitCount == 16:
+		masks = [3]uint32{0x7C00, 0x03E0, 0x001F}
+	case compression == biRGB && bitCount == 32:
+		masks = [3]uint32{0xFF0000, 0x00FF00, 0x0000FF}
+	case compression != biRGB:
+		return nil, fmt.Errorf("unsupported DIB compression %d", compression)
+	}
+
+	var palette []color.RGBA
+	switch bitCount {
+	case 1, 4, 8:
+		if colorsUsed == 0 || colorsUsed > 1<<bitCount {
+			colorsUsed = 1 << bitCount
+		}
+		if offset+colorsUsed*4 > len(data) {
+			return nil, errors.New("DIB too short for color table")
+		}
+		palette = make([]color.RGBA, colorsUsed)
+		for i := range palette {
+			entry := data[offset+i*4:]
+			palette[i] = color.RGBA{R: entry[2], G: entry[1], B: entry[0], A: 0xFF}
+		}
+		offset += colorsUsed * 4
+	case 16, 24, 32:
+		if bitCount == 24 && compression != biRGB {
+			return nil, fmt.Errorf("unsupported DIB compression %d for 24 bits per pixel", compression)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported DIB bit count %d", bitCount)
+	}
+
+	stride := (width*bitCount + 31) / 32 * 4
+	if offset+stride*height > len(data) {
+		return nil, errors.New("DIB too short for pixel data")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for row := 0; row < height; row++ {
+		line := data[offset+row*stride : offset+(row+1)*stride]
+		y := height - 1 - row // Rows are stored bottom-up
+		if topDown {
+			y = row
+		}
+		for x := 0; x < width; x++ {
+			var c color.RGBA
+			switch bitCount {
+			case 1, 4, 8:
+				bit := x * bitCount
+				index := int(line[bit/8]>>(8-bitCount-bit%8)) & (1<<bitCount - 1)
+				if index < len(palette) {
+					c = palette[index]
+				}
+			case 16:
+				c = maskedColor(uint32(binary.LittleEndian.Uint16(line[x*2:])), masks)
+			case 24:
+				c = color.RGBA{R: line[x*3+2], G: line[x*3+1], B: line[x*3], A: 0xFF}
+			case 32:
+				c = maskedColor(binary.LittleEndian.Uint32(line[x*4:]), masks)
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img, nil
+}
+
+// maskedColor extracts an opaque color from a pixel using red, green and
+// blue bit masks.
+func maskedColor(pixel uint32, masks [3]uint32) color.RGBA {
+	channel := func(mask uint32) uint8 {
+		if mask == 0 {
+			return 0
+		}
+		shift := bits.TrailingZeros32(mask)
+		value := uint64((pixel & mask) >> shift)
+		return uint8(value * 0xFF / uint64(mask>>shift))
+	}
+	return color.RGBA{R: channel(masks[0]), G: channel(masks[1]), B: channel(masks[2]), A: 0xFF}
+}
@@ -10,6 +10,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/TalentFormula/msdoc/ole2"
 )
@@ -34,9 +38,14 @@ type EmbeddedObject struct {
 	Data      []byte     // Raw object data
 	IconData  []byte     // Icon representation data
 	Size      int64      // Size of the object data
-	Position  uint32     // Position in document where object is referenced
+	Position  uint32     // CP of the object's anchor in the main document, or its place in the ObjectPool if unanchored
 	IsLinked  bool       // True if object is linked rather than embedded
 	LinkPath  string     // Path to linked file (if applicable)
+
+	// Display size in twips, from the picture header of the object's anchor
+	// character. Zero if the document does not record it.
+	DisplayWidthTwips  int32
+	DisplayHeightTwips int32
 }
 
 // ObjectPool manages embedded objects within a .doc file.
@@ -53,8 +62,17 @@ func NewObjectPool(reader *ole2.Reader) *ObjectPool {
 	}
 }
 
-// LoadObjects loads all embedded objects from the ObjectPool stream.
+// LoadObjects loads all embedded objects from the ObjectPool stream or
+// storage, replacing any loaded before.
 func (op *ObjectPool) LoadObjects() error {
+	op.objects = make(map[uint32]*EmbeddedObject)
+	if err := op.forEachStorageObject(func(obj *EmbeddedObject) error {
+		op.objects[obj.Position] = obj
+		return nil
+	}); err != nil {
+		return err
+	}
+
 	// Try to read the ObjectPool stream
 	poolData, err := op.reader.ReadStream("ObjectPool")
 	if err != nil {
@@ -65,8 +83,156 @@ func (op *ObjectPool) LoadObjects() error {
 	return op.parseObjectPool(poolData)
 }
 
+// ForEach parses the objects of the ObjectPool stream one at a time, in
+// stream order, and calls fn with each. Unlike LoadObjects the objects are not
+// kept by the pool, so each can be released once fn returns. Iteration stops
+// at the first error returned by fn, which ForEach returns.
+func (op *ObjectPool) ForEach(fn func(obj *EmbeddedObject) error) error {
+	if err := op.forEachStorageObject(fn); err != nil {
+		return err
+	}
+
+	poolData, err := op.reader.ReadStream("ObjectPool")
+	if err != nil {
+		// ObjectPool stream may not exist if there are no embedded objects
+		return nil
+	}
+
+	return op.forEachObject(poolData, fn)
+}
+
+// forEachStorageObject calls fn with each object stored in a storage below
+// the ObjectPool storage, in directory order. Each object storage is named
+// "_" followed by the object's identifier, which becomes its Position.
+func (op *ObjectPool) forEachStorageObject(fn func(obj *EmbeddedObject) error) error {
+	names, err := op.reader.ListChildren("ObjectPool")
+	if err != nil {
+		// The ObjectPool storage may not exist if there are no embedded objects
+		return nil
+	}
+
+	for _, name := range names {
+		id, err := strconv.ParseUint(strings.TrimPrefix(name, "_"), 10, 32)
+		if !strings.HasPrefix(name, "_") || err != nil {
+			continue
+		}
+
+		obj := &EmbeddedObject{Type: ObjectTypeOLE, Position: uint32(id)}
+		native, err := op.reader.ReadStream("ObjectPool/" + name + "/" + ole10NativeStream)
+		if err != nil {
+			// Objects without native data are still reported, without their content
+			obj.Name = name
+		} else if err := parseOle10Native(obj, native); err != nil {
+			return fmt.Errorf("object %s: %w", name, err)
+		}
+
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ole10NativeStream is the stream holding the data of an OLE 1.0 object, such
+// as a file wrapped in an OLE package.
+const ole10NativeStream = "\x01Ole10Native"
+
+// parseOle10Native fills in obj from an Ole10Native stream. Packages, which
+// wrap an arbitrary file, carry the file's label and data; other OLE 1.0
+// objects keep the native data as is.
+func parseOle10Native(obj *EmbeddedObject, data []byte) error {
+	if len(data) < 4 {
+		return errors.New("Ole10Native stream too short")
+	}
+	native := data[4:]
+	if size := binary.LittleEndian.Uint32(data); int(size) <= len(native) {
+		native = native[:size]
+	}
+	obj.Data = native
+	obj.Size = int64(len(native))
+
+	pkg, ok := parsePackage(native)
+	if !ok {
+		return nil
+	}
+	obj.ClassName = "Package"
+	obj.Name = pkg.label
+	obj.LinkPath = pkg.sourcePath
+	obj.Data = pkg.data
+	obj.Size = int64(len(pkg.data))
+	return nil
+}
+
+// oleNativePackage is the content of an OLE package.
+type oleNativePackage struct {
+	label      string // Display name of the file
+	sourcePath string // Path the file was embedded from
+	data       []byte // Content of the file
+}
+
+// parsePackage parses the native data of an OLE package: a type marker,
+// the label and source path, then the temporary path and the file data.
+func parsePackage(data []byte) (*oleNativePackage, bool) {
+	reader := bytes.NewReader(data)
+	readString := func() (string, bool) {
+		var value []byte
+		for {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return "", false
+			}
+			if b == 0 {
+				return string(value), true
+			}
+			value = append(value, b)
+		}
+	}
+
+	var marker uint16
+	if binary.Read(reader, binary.LittleEndian, &marker) != nil || marker != 2 {
+		return nil, false
+	}
+	pkg := &oleNativePackage{}
+	var ok bool
+	if pkg.label, ok = readString(); !ok {
+		return nil, false
+	}
+	if pkg.sourcePath, ok = readString(); !ok {
+		return nil, false
+	}
+
+	// Two reserved values, the length of the temporary path and the path itself
+	var header struct {
+		Reserved uint32
+		PathLen  uint32
+	}
+	if binary.Read(reader, binary.LittleEndian, &header) != nil || int64(header.PathLen) > int64(reader.Len()) {
+		return nil, false
+	}
+	if _, err := reader.Seek(int64(header.PathLen), io.SeekCurrent); err != nil {
+		return nil, false
+	}
+
+	var size uint32
+	if binary.Read(reader, binary.LittleEndian, &size) != nil || int64(size) > int64(reader.Len()) {
+		return nil, false
+	}
+	pkg.data = make([]byte, size)
+	reader.Read(pkg.data)
+	return pkg, true
+}
+
 // parseObjectPool parses the ObjectPool stream data.
 func (op *ObjectPool) parseObjectPool(data []byte) error {
+	return op.forEachObject(data, func(obj *EmbeddedObject) error {
+		op.objects[obj.Position] = obj
+		return nil
+	})
+}
+
+// forEachObject parses the objects in ObjectPool stream data and calls fn
+// with each in stream order.
+func (op *ObjectPool) forEachObject(data []byte, fn func(obj *EmbeddedObject) error) error {
 	reader := bytes.NewReader(data)
 
 	for reader.Len() > 0 {
@@ -79,7 +245,9 @@ func (op *ObjectPool) parseObjectPool(data []byte) error {
 		}
 
 		if obj != nil {
-			op.objects[obj.Position] = obj
+			if err := fn(obj); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -258,6 +426,16 @@ func (op *ObjectPool) GetObject(position uint32) *EmbeddedObject {
 	return op.objects[position]
 }
 
+// Reindex files the loaded objects under their current Position, after the
+// caller has changed the positions of some of them.
+func (op *ObjectPool) Reindex() {
+	objects := make(map[uint32]*EmbeddedObject, len(op.objects))
+	for _, obj := range op.objects {
+		objects[obj.Position] = obj
+	}
+	op.objects = objects
+}
+
 // GetAllObjects returns all embedded objects.
 func (op *ObjectPool) GetAllObjects() map[uint32]*EmbeddedObject {
 	return op.objects
@@ -279,9 +457,40 @@ func (obj *EmbeddedObject) SaveObject(filename string) error {
 		return errors.New("no object data to save")
 	}
 
-	// Implementation would write obj.Data to filename
-	// This is a placeholder for the actual file writing logic
-	return fmt.Errorf("save functionality not yet implemented")
+	if err := os.WriteFile(filename, obj.Data, 0o644); err != nil {
+		return fmt.Errorf("failed to save object: %w", err)
+	}
+	return nil
+}
+
+// FileExtension returns a file extension suited to the object's content,
+// based on the image format or OLE class name. Returns ".bin" if unknown.
+func (obj *EmbeddedObject) FileExtension() string {
+	switch obj.Type {
+	case ObjectTypeImage:
+		switch obj.Name {
+		case "BMP":
+			return ".bmp"
+		case "PNG":
+			return ".png"
+		case "JPEG":
+			return ".jpg"
+		case "GIF":
+			return ".gif"
+		}
+	case ObjectTypeOLE, ObjectTypeChart:
+		switch {
+		case obj.ClassName == "Package" && filepath.Ext(obj.Name) != "":
+			return filepath.Ext(obj.Name)
+		case strings.HasPrefix(obj.ClassName, "Excel."):
+			return ".xls"
+		case strings.HasPrefix(obj.ClassName, "Word."):
+			return ".doc"
+		case strings.HasPrefix(obj.ClassName, "PowerPoint."):
+			return ".ppt"
+		}
+	}
+	return ".bin"
 }
 
 // GetObjectInfo returns human-readable information about the object.
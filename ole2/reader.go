@@ -6,21 +6,57 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"strings"
+	"sync"
 	"unicode/utf16"
 )
 
 const (
 	headerSignature = 0xE11AB1A1E011CFD0
 	sectorSize      = 512
+	miniSectorSize  = 64
 	dirEntrySize    = 128
 )
 
+// ErrSizeLimit is returned by ReadStream when reading a stream would exceed
+// one of the limits set in ReaderOptions.
+var ErrSizeLimit = errors.New("ole2: size limit exceeded")
+
+// ReaderOptions limits how much data a Reader returns. A zero limit means no
+// limit. Services parsing untrusted files should set both limits, since the
+// stream sizes in a compound file are chosen by whoever wrote it.
+type ReaderOptions struct {
+	MaxStreamSize uint64 // Largest stream ReadStream will return
+	MaxTotalSize  uint64 // Largest cumulative size of all streams read
+
+	// Lazy defers reading the FAT and the directory until a stream or
+	// storage is first looked up, so opening a file only reads its header.
+	Lazy bool
+}
+
 // Reader provides access to streams within an OLE2 compound file.
 type Reader struct {
 	r          io.ReaderAt
+	header     []byte // First 76 bytes of the header, up to the DIFAT
 	fat        []uint32
 	dirEntries []dirEntry
+	opts       ReaderOptions
+
+	// Streams smaller than miniStreamCutoff are stored in 64-byte mini
+	// sectors of the mini stream, chained by the mini FAT. miniStream is nil
+	// if the file has none, in which case every stream uses regular sectors.
+	miniFAT          []uint32
+	miniStream       []byte
+	miniStreamCutoff uint64
+
+	loadOnce sync.Once
+	loadErr  error // Error reading the FAT or directory, returned by every lookup
+
+	mu        sync.Mutex
+	totalRead uint64          // Bytes returned by ReadStream so far
+	accessed  map[string]bool // Paths of the streams ReadStream has returned
 }
 
 type dirEntry struct {
@@ -41,6 +77,11 @@ type dirEntry struct {
 
 // NewReader initializes an OLE2 reader from an io.ReaderAt.
 func NewReader(r io.ReaderAt) (*Reader, error) {
+	return NewReaderWithOptions(r, ReaderOptions{})
+}
+
+// NewReaderWithOptions initializes an OLE2 reader that enforces the limits in opts.
+func NewReaderWithOptions(r io.ReaderAt, opts ReaderOptions) (*Reader, error) {
 	headerBytes := make([]byte, 76)
 	if _, err := r.ReadAt(headerBytes, 0); err != nil {
 		return nil, fmt.Errorf("ole2: failed to read header: %w", err)
@@ -52,17 +93,50 @@ func NewReader(r io.ReaderAt) (*Reader, error) {
 		return nil, errors.New("ole2: invalid signature")
 	}
 
+	reader := &Reader{r: r, header: headerBytes, opts: opts}
+	if !opts.Lazy {
+		if err := reader.load(); err != nil {
+			return nil, err
+		}
+	}
+	return reader, nil
+}
+
+// NewReaderLazy initializes an OLE2 reader that only checks the header
+// signature, deferring the FAT and the directory until they are needed. Use
+// it to identify compound files cheaply; errors in the FAT or directory are
+// then returned by ReadStream and the other lookups instead.
+func NewReaderLazy(r io.ReaderAt) (*Reader, error) {
+	return NewReaderWithOptions(r, ReaderOptions{Lazy: true})
+}
+
+// load reads the FAT, the directory and the mini stream the first time it is
+// called, and returns the same error on every call after that.
+func (r *Reader) load() error {
+	r.loadOnce.Do(func() {
+		r.loadErr = r.parse()
+	})
+	return r.loadErr
+}
+
+// parse reads the FAT, the directory and the mini stream located by the header.
+func (r *Reader) parse() error {
+	headerBytes := r.header
+
 	// Parse directory start sector according to OLE2 specification (offset 48-52)
 	dirStartSector := int32(binary.LittleEndian.Uint32(headerBytes[48:52]))
 	
 	// Parse FAT sectors count and DIFAT sectors count
 	fatSectorCount := binary.LittleEndian.Uint32(headerBytes[44:48])
-	difatSectorCount := binary.LittleEndian.Uint32(headerBytes[68:72])
-	difatFirstSector := int32(binary.LittleEndian.Uint32(headerBytes[72:76]))
+	difatFirstSector := int32(binary.LittleEndian.Uint32(headerBytes[68:72]))
+	difatSectorCount := binary.LittleEndian.Uint32(headerBytes[72:76])
+	miniStreamCutoff := binary.LittleEndian.Uint32(headerBytes[56:60])
+	miniFATFirstSector := int32(binary.LittleEndian.Uint32(headerBytes[60:64]))
+	miniFATSectorCount := binary.LittleEndian.Uint32(headerBytes[64:68])
 
 	difatBytes := make([]byte, 436)
-	if _, err := r.ReadAt(difatBytes, 76); err != nil {
-		return nil, fmt.Errorf("ole2: failed to read DIFAT: %w", err)
+	if _, err := r.r.ReadAt(difatBytes, 76); err != nil {
+		return fmt.Errorf("ole2: failed to read DIFAT: %w", err)
 	}
 
 	var fatSectorNumbers []int32
@@ -80,7 +154,7 @@ func NewReader(r io.ReaderAt) (*Reader, error) {
 		currentDifatSector := difatFirstSector
 		for i := uint32(0); i < difatSectorCount && currentDifatSector >= 0 && len(fatSectorNumbers) < int(fatSectorCount); i++ {
 			sector := make([]byte, sectorSize)
-			_, err := r.ReadAt(sector, int64(currentDifatSector+1)*sectorSize)
+			_, err := r.r.ReadAt(sector, int64(currentDifatSector+1)*sectorSize)
 			if err != nil {
 				break // Skip on error and use what we have
 			}
@@ -106,7 +180,7 @@ func NewReader(r io.ReaderAt) (*Reader, error) {
 	for _, secNum := range fatSectorNumbers {
 		if secNum >= 0 {
 			sector := make([]byte, sectorSize)
-			_, err := r.ReadAt(sector, int64(secNum+1)*sectorSize)
+			_, err := r.r.ReadAt(sector, int64(secNum+1)*sectorSize)
 			if err != nil {
 				continue // Skip bad sectors
 			}
@@ -116,57 +190,12 @@ func NewReader(r io.ReaderAt) (*Reader, error) {
 
 	fat := make([]uint32, len(fatSectors)/4)
 	if err := binary.Read(bytes.NewReader(fatSectors), binary.LittleEndian, &fat); err != nil {
-		return nil, err
+		return err
 	}
 
-	var dirStream []byte
-	sectorNum := dirStartSector
-	
-	// For large files, we might not have loaded all FAT entries
-	// Try to read the directory directly if it's reasonable
-	if sectorNum >= 0 {
-		// Check if sector is within reasonable file bounds (approximate)
-		sector := make([]byte, sectorSize)
-		_, err := r.ReadAt(sector, int64(sectorNum+1)*sectorSize)
-		if err != nil {
-			return nil, fmt.Errorf("ole2: failed to read directory sector %d: %w", sectorNum, err)
-		}
-		dirStream = append(dirStream, sector...)
-		
-		// Try to read additional directory sectors
-		// For sample-3.doc compatibility, be more conservative
-		// For sample-4.doc, we need additional sectors
-		maxAdditionalSectors := 3  // Conservative approach
-		if len(dirStream) >= 512 {
-			// Check if first sector has reasonable entries
-			// If so, try reading more sectors for large files
-			firstObjectType := sector[66]
-			if firstObjectType <= 5 {
-				maxAdditionalSectors = 10  // More sectors for large files
-			}
-		}
-		
-		for additionalSectors := 0; additionalSectors < maxAdditionalSectors; additionalSectors++ {
-			nextSectorNum := sectorNum + 1 + int32(additionalSectors)
-			sector := make([]byte, sectorSize)
-			_, err := r.ReadAt(sector, int64(nextSectorNum+1)*sectorSize)
-			if err != nil {
-				break // Stop on error
-			}
-			
-			// Check if this sector contains valid directory entries
-			if len(sector) >= 128 {
-				objectType := sector[66]
-				nameLen := binary.LittleEndian.Uint16(sector[64:66])
-				if objectType <= 5 && nameLen > 0 && nameLen <= 64 { // Valid object types and name length
-					dirStream = append(dirStream, sector...)
-				} else {
-					break // Probably not a directory sector
-				}
-			} else {
-				break
-			}
-		}
+	dirStream, err := readDirectoryStream(r.r, fat, dirStartSector)
+	if err != nil {
+		return err
 	}
 
 	numDirs := len(dirStream) / dirEntrySize
@@ -194,76 +223,465 @@ func NewReader(r io.ReaderAt) (*Reader, error) {
 		dirEntries[i].StreamSize = binary.LittleEndian.Uint64(entryData[120:128])
 	}
 
-	return &Reader{r, fat, dirEntries}, nil
+	r.fat, r.dirEntries, r.miniStreamCutoff = fat, dirEntries, uint64(miniStreamCutoff)
+	return r.loadMiniStream(miniFATFirstSector, miniFATSectorCount)
 }
 
-// ListStreams returns the names of all streams in the OLE2 file (for debugging)
+// loadMiniStream reads the mini FAT, starting at firstSector and count
+// sectors long, and the mini stream, which is the stream of the root entry.
+// Files without a mini FAT, such as those written by Writer, store small
+// streams in regular sectors and are left without a mini stream.
+func (r *Reader) loadMiniStream(firstSector int32, count uint32) error {
+	if firstSector < 0 || count == 0 || len(r.dirEntries) == 0 {
+		return nil
+	}
+	root := &r.dirEntries[0]
+	if root.StartingSector < 0 || root.StreamSize == 0 {
+		return nil
+	}
+
+	miniFATData, err := r.readChain(firstSector, uint64(count)*sectorSize, 0)
+	if err != nil {
+		return fmt.Errorf("ole2: failed to read mini FAT: %w", err)
+	}
+	r.miniFAT = make([]uint32, len(miniFATData)/4)
+	for i := range r.miniFAT {
+		r.miniFAT[i] = binary.LittleEndian.Uint32(miniFATData[i*4:])
+	}
+
+	if r.miniStream, err = r.readChain(root.StartingSector, root.StreamSize, sectorSize*10); err != nil {
+		return fmt.Errorf("ole2: failed to read mini stream: %w", err)
+	}
+	return nil
+}
+
+// readDirectoryStream reads the directory by following its FAT chain from
+// the first directory sector, so every directory sector is loaded no matter
+// how many entries the file has. The chain ends at an end-of-chain marker or
+// where it leaves the loaded FAT.
+func readDirectoryStream(r io.ReaderAt, fat []uint32, firstSector int32) ([]byte, error) {
+	if firstSector < 0 {
+		return nil, nil
+	}
+
+	var dirStream []byte
+	visited := make(map[int32]bool)
+	sectorNum := firstSector
+	for {
+		if visited[sectorNum] {
+			return nil, fmt.Errorf("ole2: directory chain loops at sector %d", sectorNum)
+		}
+		visited[sectorNum] = true
+
+		sector := make([]byte, sectorSize)
+		if _, err := r.ReadAt(sector, int64(sectorNum+1)*sectorSize); err != nil {
+			if len(dirStream) == 0 {
+				return nil, fmt.Errorf("ole2: failed to read directory sector %d: %w", sectorNum, err)
+			}
+			return dirStream, nil
+		}
+		dirStream = append(dirStream, sector...)
+
+		if int(sectorNum) >= len(fat) {
+			return dirStream, nil // The chain cannot be followed further
+		}
+		next := fat[sectorNum]
+		if next == 0xFFFFFFFE || next == 0xFFFFFFFF || next >= 0x80000000 {
+			return dirStream, nil // End of chain
+		}
+		sectorNum = int32(next)
+	}
+}
+
+// ListStreams returns the paths of all streams in the OLE2 file, in the form
+// accepted by ReadStream. Streams in the root storage are listed by name and
+// streams inside storages by their "/"-separated path, for example
+// "ObjectPool/_1/\x01Ole10Native".
 func (r *Reader) ListStreams() []string {
-	var streamNames []string
-	for _, entry := range r.dirEntries {
-		if entry.ObjectType == 2 { // Stream Object
-			entryName := utf16BytesToString(entry.Name, entry.NameLen)
-			if entryName != "" {
-				streamNames = append(streamNames, entryName)
+	return r.listPaths(EntryStream)
+}
+
+// ListStorages returns the paths of all storages in the OLE2 file, not
+// including the root storage.
+func (r *Reader) ListStorages() []string {
+	return r.listPaths(EntryStorage)
+}
+
+// listPaths returns the paths of the entries of the given type, walking the
+// storage tree from the root entry.
+func (r *Reader) listPaths(objectType EntryType) []string {
+	var paths []string
+	r.walkPaths(func(path string, entry *dirEntry) {
+		if EntryType(entry.ObjectType) == objectType {
+			paths = append(paths, path)
+		}
+	})
+	return paths
+}
+
+// walkPaths calls fn with the path and entry of every stream and storage
+// below the root entry, in directory order with each storage before its
+// contents. Entries with empty names are skipped together with their
+// contents.
+func (r *Reader) walkPaths(fn func(path string, entry *dirEntry)) {
+	if r.load() != nil || len(r.dirEntries) == 0 {
+		return
+	}
+
+	visited := map[int32]bool{0: true}
+	var walk func(index int32, prefix string)
+	walk = func(index int32, prefix string) {
+		if index < 0 || int(index) >= len(r.dirEntries) || visited[index] {
+			return
+		}
+		visited[index] = true
+		entry := &r.dirEntries[index]
+		walk(entry.LeftSibling, prefix)
+		if name := utf16BytesToString(entry.Name, entry.NameLen); name != "" {
+			fn(prefix+name, entry)
+			if EntryType(entry.ObjectType) == EntryStorage {
+				walk(entry.ChildID, prefix+name+"/")
 			}
 		}
+		walk(entry.RightSibling, prefix)
 	}
-	return streamNames
+	walk(r.dirEntries[0].ChildID, "")
+}
+
+// StreamNameEqual reports whether two stream or storage names refer to the
+// same entry. Some producers pad names with spaces, so leading and trailing
+// spaces are ignored. All name lookups should go through this function.
+func StreamNameEqual(a, b string) bool {
+	return strings.TrimSpace(a) == strings.TrimSpace(b)
 }
 
 // ReadStream finds a stream by name and returns its content.
+//
+// A name containing "/" is a path from the root storage, for example
+// "ObjectPool/_1/\x01Ole10Native", and reads a stream inside a storage. A
+// bare name reads the stream of that name in the root storage or, if there
+// is none, the only stream of that name inside any storage; a name that
+// several nested streams share is ambiguous and must be given as a path.
 func (r *Reader) ReadStream(name string) ([]byte, error) {
-	for _, entry := range r.dirEntries {
-		if entry.ObjectType == 2 { // Stream Object
-			entryName := utf16BytesToString(entry.Name, entry.NameLen)
-			// Trim spaces for robust comparison
-			if strings.TrimSpace(entryName) == strings.TrimSpace(name) {
-				var streamData []byte
-				sectorNum := entry.StartingSector
-				remainingSize := entry.StreamSize
-				
-				// Handle case where FAT chain may be incomplete
-				for sectorNum >= 0 && remainingSize > 0 {
-					sector := make([]byte, sectorSize)
-					_, err := r.r.ReadAt(sector, int64(sectorNum+1)*sectorSize)
-					if err != nil {
-						return nil, err
-					}
-					
-					// Add sector data, but don't exceed expected stream size
-					sectorDataSize := uint64(sectorSize)
-					if sectorDataSize > remainingSize {
-						sectorDataSize = remainingSize
-					}
-					streamData = append(streamData, sector[:sectorDataSize]...)
-					remainingSize -= sectorDataSize
-					
-					// Try to follow FAT chain if we have the entry
-					if sectorNum < int32(len(r.fat)) {
-						nextSector := r.fat[sectorNum]
-						if nextSector == 0xFFFFFFFE || nextSector == 0xFFFFFFFF {
-							break // End of chain
-						}
-						sectorNum = int32(nextSector)
-					} else {
-						// FAT chain incomplete, try sequential sectors for small streams
-						if remainingSize > 0 && entry.StreamSize <= uint64(sectorSize*10) {
-							sectorNum++
-						} else {
-							break
-						}
-					}
-				}
-				
-				return streamData, nil
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	if entry, err := r.findEntry(name); err == nil && EntryType(entry.ObjectType) == EntryStream {
+		return r.readEntry(name, entry)
+	}
+	if strings.Contains(name, "/") {
+		return nil, fmt.Errorf("ole2: stream '%s' not found", name)
+	}
+
+	var matches []string
+	var match *dirEntry
+	r.walkPaths(func(path string, entry *dirEntry) {
+		if EntryType(entry.ObjectType) == EntryStream && StreamNameEqual(utf16BytesToString(entry.Name, entry.NameLen), name) {
+			matches = append(matches, path)
+			match = entry
+		}
+	})
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("ole2: stream '%s' not found", name)
+	case 1:
+		return r.readEntry(name, match)
+	default:
+		return nil, fmt.Errorf("ole2: stream name '%s' is ambiguous, use one of the paths %q", name, matches)
+	}
+}
+
+// readEntry reads the content of the stream entry named name.
+func (r *Reader) readEntry(name string, entry *dirEntry) ([]byte, error) {
+	if err := r.reserve(name, entry.StreamSize); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var err error
+	if entry.StreamSize < r.miniStreamCutoff && r.miniStream != nil && entry != &r.dirEntries[0] {
+		data, err = r.readMiniChain(entry.StartingSector, entry.StreamSize)
+	} else {
+		data, err = r.readChain(entry.StartingSector, entry.StreamSize, sectorSize*10)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var path string
+	r.walkPaths(func(p string, e *dirEntry) {
+		if e == entry {
+			path = p
+		}
+	})
+	r.mu.Lock()
+	if r.accessed == nil {
+		r.accessed = map[string]bool{}
+	}
+	r.accessed[path] = true
+	r.mu.Unlock()
+	return data, nil
+}
+
+// AccessedStreams returns the sorted paths of the streams ReadStream has
+// returned so far, in the form ListStreams uses.
+func (r *Reader) AccessedStreams() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Sorted(maps.Keys(r.accessed))
+}
+
+// readChain reads size bytes from the chain of regular sectors starting at
+// sectorNum. If the chain leaves the loaded FAT, streams of at most
+// sequentialLimit bytes continue in the following sectors.
+func (r *Reader) readChain(sectorNum int32, size uint64, sequentialLimit uint64) ([]byte, error) {
+	var streamData []byte
+	remainingSize := size
+
+	// Handle case where FAT chain may be incomplete
+	for sectorNum >= 0 && remainingSize > 0 {
+		sector := make([]byte, sectorSize)
+		_, err := r.r.ReadAt(sector, int64(sectorNum+1)*sectorSize)
+		if err != nil {
+			return nil, err
+		}
+
+		// Add sector data, but don't exceed expected stream size
+		sectorDataSize := uint64(sectorSize)
+		if sectorDataSize > remainingSize {
+			sectorDataSize = remainingSize
+		}
+		streamData = append(streamData, sector[:sectorDataSize]...)
+		remainingSize -= sectorDataSize
+
+		// Try to follow FAT chain if we have the entry
+		if sectorNum < int32(len(r.fat)) {
+			nextSector := r.fat[sectorNum]
+			if nextSector == 0xFFFFFFFE || nextSector == 0xFFFFFFFF {
+				break // End of chain
+			}
+			sectorNum = int32(nextSector)
+		} else {
+			// FAT chain incomplete, try sequential sectors for small streams
+			if remainingSize > 0 && size <= sequentialLimit {
+				sectorNum++
+			} else {
+				break
 			}
 		}
 	}
-	return nil, fmt.Errorf("ole2: stream '%s' not found", name)
+
+	return streamData, nil
+}
+
+// readMiniChain reads size bytes from the chain of mini sectors starting at
+// sectorNum, following the mini FAT through the mini stream.
+func (r *Reader) readMiniChain(sectorNum int32, size uint64) ([]byte, error) {
+	streamData := make([]byte, 0, size)
+	for remainingSize := size; remainingSize > 0; {
+		offset := uint64(sectorNum) * miniSectorSize
+		if sectorNum < 0 || offset >= uint64(len(r.miniStream)) {
+			return nil, fmt.Errorf("ole2: mini sector %d outside the mini stream", sectorNum)
+		}
+		n := min(miniSectorSize, remainingSize, uint64(len(r.miniStream))-offset)
+		streamData = append(streamData, r.miniStream[offset:offset+n]...)
+		remainingSize -= n
+
+		if remainingSize == 0 {
+			break
+		}
+		if int(sectorNum) >= len(r.miniFAT) {
+			return nil, fmt.Errorf("ole2: mini sector %d outside the mini FAT", sectorNum)
+		}
+		sectorNum = int32(r.miniFAT[sectorNum])
+	}
+	return streamData, nil
+}
+
+// ListChildren returns the names of the streams and storages directly inside
+// the storage at path. An empty path lists the root storage.
+func (r *Reader) ListChildren(path string) ([]string, error) {
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	if len(r.dirEntries) == 0 {
+		return nil, errors.New("ole2: no directory entries")
+	}
+
+	storage := &r.dirEntries[0]
+	if strings.Trim(path, "/") != "" {
+		var err error
+		if storage, err = r.findEntry(path); err != nil {
+			return nil, err
+		}
+		if storage.ObjectType != 1 {
+			return nil, fmt.Errorf("ole2: '%s' is not a storage", path)
+		}
+	}
+
+	var names []string
+	visited := make(map[int32]bool)
+	var walk func(index int32)
+	walk = func(index int32) {
+		if index < 0 || int(index) >= len(r.dirEntries) || visited[index] {
+			return
+		}
+		visited[index] = true
+		entry := &r.dirEntries[index]
+		walk(entry.LeftSibling)
+		names = append(names, utf16BytesToString(entry.Name, entry.NameLen))
+		walk(entry.RightSibling)
+	}
+	walk(storage.ChildID)
+	return names, nil
+}
+
+// EntryType is the kind of a directory entry.
+type EntryType uint8
+
+const (
+	EntryStorage EntryType = 1 // Storage holding further entries
+	EntryStream  EntryType = 2 // Stream holding data
+	EntryRoot    EntryType = 5 // Root storage
+)
+
+// Entry is a storage or stream in the hierarchy returned by Tree.
+type Entry struct {
+	Name     string
+	Type     EntryType
+	Size     uint64   // Size of a stream, 0 for storages
+	CLSID    [16]byte // Class of a storage's application, zero if unset
+	Children []*Entry // Entries directly inside a storage, in directory order
+}
+
+// IsStorage reports whether the entry is a storage, including the root.
+func (e *Entry) IsStorage() bool {
+	return e.Type == EntryStorage || e.Type == EntryRoot
+}
+
+// Tree returns the hierarchy of storages and streams in the file, starting
+// at the root storage. The children of each storage are found by walking the
+// red-black tree of siblings below its ChildID; entries that are not linked
+// into the tree, such as unused directory slots, are left out.
+func (r *Reader) Tree() (*Entry, error) {
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	if len(r.dirEntries) == 0 {
+		return nil, errors.New("ole2: no directory entries")
+	}
+
+	visited := make(map[int32]bool)
+	var build func(index int32) *Entry
+	var walk func(index int32, parent *Entry)
+	build = func(index int32) *Entry {
+		entry := &r.dirEntries[index]
+		node := &Entry{
+			Name:  utf16BytesToString(entry.Name, entry.NameLen),
+			Type:  EntryType(entry.ObjectType),
+			CLSID: entry.CLSID,
+		}
+		if node.Type == EntryStream {
+			node.Size = entry.StreamSize
+		}
+		if node.IsStorage() {
+			walk(entry.ChildID, node)
+		}
+		return node
+	}
+	walk = func(index int32, parent *Entry) {
+		if index < 0 || int(index) >= len(r.dirEntries) || visited[index] {
+			return
+		}
+		visited[index] = true
+		entry := &r.dirEntries[index]
+		walk(entry.LeftSibling, parent)
+		if EntryType(entry.ObjectType) == EntryStorage || EntryType(entry.ObjectType) == EntryStream {
+			parent.Children = append(parent.Children, build(index))
+		}
+		walk(entry.RightSibling, parent)
+	}
+
+	visited[0] = true
+	return build(0), nil
+}
+
+// reserve checks a stream of the given size against the reader's limits and
+// counts it toward the cumulative total.
+func (r *Reader) reserve(name string, size uint64) error {
+	if r.opts.MaxStreamSize > 0 && size > r.opts.MaxStreamSize {
+		return fmt.Errorf("%w: stream '%s' is %d bytes, limit is %d", ErrSizeLimit, name, size, r.opts.MaxStreamSize)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.opts.MaxTotalSize > 0 && r.totalRead+size > r.opts.MaxTotalSize {
+		return fmt.Errorf("%w: reading stream '%s' exceeds total limit of %d bytes", ErrSizeLimit, name, r.opts.MaxTotalSize)
+	}
+	r.totalRead += size
+	return nil
+}
+
+// StreamCLSID returns the CLSID stored in the directory entry of a stream or
+// storage. Embedded objects are stored in storages whose CLSID identifies the
+// object's application without reading its CompObj stream.
+//
+// The path names the entry relative to the root storage, with components
+// separated by "/", for example "ObjectPool/_1234567890".
+func (r *Reader) StreamCLSID(path string) ([16]byte, error) {
+	if err := r.load(); err != nil {
+		return [16]byte{}, err
+	}
+	entry, err := r.findEntry(path)
+	if err != nil {
+		return [16]byte{}, err
+	}
+	return entry.CLSID, nil
+}
+
+// findEntry resolves a "/"-separated path to a directory entry by walking the
+// storage tree from the root entry.
+func (r *Reader) findEntry(path string) (*dirEntry, error) {
+	if len(r.dirEntries) == 0 {
+		return nil, errors.New("ole2: no directory entries")
+	}
+
+	current := &r.dirEntries[0] // Root entry
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		if current.ObjectType != 1 && current.ObjectType != 5 {
+			return nil, fmt.Errorf("ole2: entry '%s' not found", path)
+		}
+		next := r.findChild(current.ChildID, name, make(map[int32]bool))
+		if next == nil {
+			return nil, fmt.Errorf("ole2: entry '%s' not found", path)
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// findChild searches the sibling tree rooted at index for an entry named name.
+func (r *Reader) findChild(index int32, name string, visited map[int32]bool) *dirEntry {
+	if index < 0 || int(index) >= len(r.dirEntries) || visited[index] {
+		return nil
+	}
+	visited[index] = true
+
+	entry := &r.dirEntries[index]
+	if StreamNameEqual(utf16BytesToString(entry.Name, entry.NameLen), name) {
+		return entry
+	}
+	if found := r.findChild(entry.LeftSibling, name, visited); found != nil {
+		return found
+	}
+	return r.findChild(entry.RightSibling, name, visited)
 }
 
 // utf16BytesToString converts a UTF-16 name from a directory entry to a Go string.
-// THIS IS THE NEW, ROBUST IMPLEMENTATION.
+// nameLen counts the bytes of the name including its null terminator, so the
+// longest valid name has 31 characters and a nameLen of 64. Names that fill
+// all 32 units without a terminator, or whose nameLen leaves the terminator
+// out, are still read in full.
 func utf16BytesToString(name [32]uint16, nameLen uint16) string {
 	if nameLen < 2 {
 		return ""
@@ -5,24 +5,29 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
 )
 
 // Writer provides functionality for creating OLE2 compound documents.
 type Writer struct {
 	streams map[string][]byte
+	clsids  map[string][16]byte // CLSIDs of storages by path
 	header  CompoundFileHeader
 }
 
 // CompoundFileHeader represents the OLE2 compound file header.
 type CompoundFileHeader struct {
 	Signature            [8]byte     // OLE2 signature
+	CLSID                [16]byte    // Reserved class ID, always zero
 	MinorVersion         uint16      // Minor version
 	MajorVersion         uint16      // Major version
 	ByteOrder            uint16      // Byte order identifier
 	SectorSize           uint16      // Sector size (power of 2)
 	MiniSectorSize       uint16      // Mini sector size (power of 2)
-	Reserved1            uint16      // Reserved field
-	Reserved2            uint16      // Reserved field
+	Reserved             [6]byte     // Reserved field
 	NumDirectorySectors  uint32      // Number of directory sectors
 	NumFATSectors        uint32      // Number of FAT sectors
 	DirectoryFirstSector uint32      // First directory sector
@@ -35,16 +40,27 @@ type CompoundFileHeader struct {
 	DIFAT                [109]uint32 // First 109 DIFAT entries
 }
 
+// Special sector numbers used in the FAT and directory.
+const (
+	freeSector    = 0xFFFFFFFF // Unallocated sector
+	endOfChain    = 0xFFFFFFFE // Last sector of a chain
+	fatSector     = 0xFFFFFFFD // Sector used by the FAT itself
+	noStream      = 0xFFFFFFFF // Empty sibling or child reference
+	fatEntrySize  = 4
+	maxFATSectors = 109 // FAT sectors addressable from the header DIFAT
+)
+
 // NewWriter creates a new OLE2 writer.
 func NewWriter() *Writer {
 	writer := &Writer{
 		streams: make(map[string][]byte),
+		clsids:  make(map[string][16]byte),
 	}
 
 	// Initialize header with standard values
 	copy(writer.header.Signature[:], []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})
 	writer.header.MinorVersion = 0x003E
-	writer.header.MajorVersion = 0x003E
+	writer.header.MajorVersion = 0x0003
 	writer.header.ByteOrder = 0xFFFE
 	writer.header.SectorSize = 9     // 512 bytes (2^9)
 	writer.header.MiniSectorSize = 6 // 64 bytes (2^6)
@@ -54,47 +70,99 @@ func NewWriter() *Writer {
 }
 
 // AddStream adds a stream to the compound document.
+//
+// Streams inside storages are named by their path, with components separated
+// by "/", for example "ObjectPool/_1/\x01Ole10Native". The storages on the
+// path are created as needed.
 func (w *Writer) AddStream(name string, data []byte) {
 	w.streams[name] = data
 }
 
+// SetCLSID sets the CLSID of the storage at path, which is created by adding
+// a stream inside it.
+func (w *Writer) SetCLSID(path string, clsid [16]byte) {
+	w.clsids[path] = clsid
+}
+
 // WriteTo writes the complete compound document to the writer.
+//
+// The file is laid out as the header, the FAT sectors, the stream data and
+// finally the directory. All streams are stored in regular sectors.
 func (w *Writer) WriteTo(writer io.Writer) error {
 	// Calculate sector size
 	sectorSize := 1 << w.header.SectorSize // 512 bytes
+	sectorsFor := func(size int) int {
+		return (size + sectorSize - 1) / sectorSize
+	}
+
+	names := w.streamNames()
 
 	// Build directory entries
-	dirEntries, err := w.buildDirectoryEntries()
+	dirEntries, entryPaths, err := w.buildDirectoryEntries(names)
 	if err != nil {
 		return fmt.Errorf("failed to build directory entries: %w", err)
 	}
 
 	// Calculate sectors needed
-	totalDataSize := 0
-	for _, data := range w.streams {
-		totalDataSize += len(data)
+	numDataSectors := 0
+	for _, name := range names {
+		numDataSectors += sectorsFor(len(w.streams[name]))
+	}
+	numDirSectors := sectorsFor(len(dirEntries) * dirEntrySize)
+
+	// The FAT has to describe its own sectors as well
+	entriesPerSector := sectorSize / fatEntrySize
+	numFATSectors := 1
+	for numFATSectors*entriesPerSector < numFATSectors+numDataSectors+numDirSectors {
+		numFATSectors++
+	}
+	if numFATSectors > maxFATSectors {
+		return fmt.Errorf("compound document too large: %d FAT sectors needed", numFATSectors)
 	}
 
-	numDataSectors := (totalDataSize + sectorSize - 1) / sectorSize
-	numDirSectors := (len(dirEntries)*128 + sectorSize - 1) / sectorSize
-	numFATSectors := ((numDataSectors+numDirSectors+1)*4 + sectorSize - 1) / sectorSize
+	// Assign sectors to each stream
+	sectorMap := make(map[string]uint32)
+	currentSector := uint32(numFATSectors)
+	for _, name := range names {
+		count := sectorsFor(len(w.streams[name]))
+		if count == 0 {
+			sectorMap[name] = endOfChain
+			continue
+		}
+		sectorMap[name] = currentSector
+		currentSector += uint32(count)
+	}
+	dirStart := currentSector
 
 	// Update header
-	w.header.NumDirectorySectors = uint32(numDirSectors)
+	w.header.NumDirectorySectors = 0 // Must be zero for 512-byte sectors
 	w.header.NumFATSectors = uint32(numFATSectors)
-	w.header.DirectoryFirstSector = uint32(numDataSectors)
+	w.header.DirectoryFirstSector = dirStart
+	w.header.MiniFATFirstSector = endOfChain
+	w.header.NumMiniFATSectors = 0
+	w.header.DIFATFirstSector = endOfChain
+	w.header.NumDIFATSectors = 0
+	for i := range w.header.DIFAT {
+		w.header.DIFAT[i] = freeSector
+		if i < numFATSectors {
+			w.header.DIFAT[i] = uint32(i)
+		}
+	}
 
 	// Write header
 	if err := binary.Write(writer, binary.LittleEndian, &w.header); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
-	// Write data sectors
-	currentSector := uint32(0)
-	sectorMap := make(map[string]uint32)
+	// Write FAT sectors
+	fatData := w.buildFATData(names, numFATSectors, sectorSize, sectorMap, dirStart, numDirSectors)
+	if _, err := writer.Write(fatData); err != nil {
+		return fmt.Errorf("failed to write FAT: %w", err)
+	}
 
-	for name, data := range w.streams {
-		sectorMap[name] = currentSector
+	// Write data sectors
+	for _, name := range names {
+		data := w.streams[name]
 
 		// Write data, padded to sector boundaries
 		if _, err := writer.Write(data); err != nil {
@@ -102,19 +170,16 @@ func (w *Writer) WriteTo(writer io.Writer) error {
 		}
 
 		// Pad to sector boundary
-		padding := sectorSize - (len(data) % sectorSize)
-		if padding < sectorSize {
+		padding := sectorsFor(len(data))*sectorSize - len(data)
+		if padding > 0 {
 			if _, err := writer.Write(make([]byte, padding)); err != nil {
 				return fmt.Errorf("failed to write padding: %w", err)
 			}
 		}
-
-		sectorsUsed := (len(data) + sectorSize - 1) / sectorSize
-		currentSector += uint32(sectorsUsed)
 	}
 
 	// Write directory sectors
-	dirData := w.buildDirectoryData(dirEntries, sectorMap)
+	dirData := w.buildDirectoryData(dirEntries, entryPaths, sectorMap)
 	if _, err := writer.Write(dirData); err != nil {
 		return fmt.Errorf("failed to write directory: %w", err)
 	}
@@ -127,57 +192,193 @@ func (w *Writer) WriteTo(writer io.Writer) error {
 		}
 	}
 
-	// Write FAT sectors
-	fatData := w.buildFATData(numDataSectors, numDirSectors)
-	if _, err := writer.Write(fatData); err != nil {
-		return fmt.Errorf("failed to write FAT: %w", err)
+	return nil
+}
+
+// streamNames returns the names of the streams in the order they are written:
+// WordDocument, the table stream, the property set streams, then the rest
+// sorted by name. The order does not depend on map iteration, so writing the
+// same streams always produces the same bytes.
+func (w *Writer) streamNames() []string {
+	names := make([]string, 0, len(w.streams))
+	for name := range w.streams {
+		names = append(names, name)
 	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := streamRank(names[i]), streamRank(names[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
 
-	return nil
+// streamRank returns the position of a stream's group in the write order.
+func streamRank(name string) int {
+	switch {
+	case name == "WordDocument":
+		return 0
+	case name == "1Table" || name == "0Table":
+		return 1
+	case strings.HasPrefix(name, "\x05"):
+		return 2 // Property sets such as \x05SummaryInformation
+	default:
+		return 3
+	}
 }
 
-// buildDirectoryEntries creates directory entries for all streams.
-func (w *Writer) buildDirectoryEntries() ([]DirectoryEntry, error) {
-	entries := make([]DirectoryEntry, 0)
+// dirNode is a storage or stream in the directory tree built from the
+// stream paths.
+type dirNode struct {
+	name     string
+	path     string // Path of the entry from the root storage
+	isStream bool
+	children []*dirNode
+}
+
+// buildDirectoryEntries creates directory entries for all streams and the
+// storages containing them, in depth-first order. The children of each storage
+// are linked as a chain of right siblings. The path of each entry is returned
+// alongside it, with an empty path for the root entry.
+func (w *Writer) buildDirectoryEntries(names []string) ([]DirectoryEntry, []string, error) {
+	root := &dirNode{}
+	for _, name := range names {
+		node := root
+		components := strings.Split(name, "/")
+		for i, component := range components {
+			if component == "" {
+				return nil, nil, fmt.Errorf("invalid stream path %q", name)
+			}
+			var child *dirNode
+			for _, c := range node.children {
+				if c.name == component {
+					child = c
+					break
+				}
+			}
+			isStream := i == len(components)-1
+			if child == nil {
+				child = &dirNode{name: component, path: strings.Join(components[:i+1], "/"), isStream: isStream}
+				node.children = append(node.children, child)
+			} else if child.isStream || isStream {
+				return nil, nil, fmt.Errorf("stream path %q conflicts with another entry", name)
+			}
+			node = child
+		}
+	}
 
 	// Root entry
 	rootEntry := DirectoryEntry{
-		Name:         [64]uint16{0},
-		NameLength:   10, // "Root Entry"
-		Type:         5,  // Root storage
-		NodeColor:    1,  // Red
-		LeftSibling:  0xFFFFFFFF,
-		RightSibling: 0xFFFFFFFF,
-		Child:        1, // First stream
-		StartSector:  0,
+		NameLength:   uint16((len("Root Entry") + 1) * 2),
+		Type:         5, // Root storage
+		NodeColor:    1, // Black
+		LeftSibling:  noStream,
+		RightSibling: noStream,
+		Child:        noStream,
+		StartSector:  endOfChain, // No mini stream
 		Size:         0,
 	}
 	copy(rootEntry.Name[:], utf16Encode("Root Entry"))
-	entries = append(entries, rootEntry)
+	entries := []DirectoryEntry{rootEntry}
+	paths := []string{""}
+
+	var addChildren func(parent int, children []*dirNode) error
+	addChildren = func(parent int, children []*dirNode) error {
+		// The children are stored in directory order, separately from the
+		// order the stream data is written in
+		children = append([]*dirNode(nil), children...)
+		sort.Slice(children, func(i, j int) bool {
+			return compareEntryNames(children[i].name, children[j].name) < 0
+		})
+
+		indices := make([]int, len(children))
+		for i, child := range children {
+			nameUnits := utf16Encode(child.name)
+			if len(nameUnits) > 31 {
+				return fmt.Errorf("entry name %q is too long", child.name)
+			}
+			entry := DirectoryEntry{
+				NameLength:   uint16((len(nameUnits) + 1) * 2),
+				Type:         2, // Stream
+				NodeColor:    1, // Black
+				LeftSibling:  noStream,
+				RightSibling: noStream,
+				Child:        noStream,
+			}
+			if !child.isStream {
+				entry.Type = 1 // Storage
+				entry.CLSID = w.clsids[child.path]
+			}
+			copy(entry.Name[:], nameUnits)
 
-	// Stream entries
-	streamIndex := uint32(1)
-	for name := range w.streams {
-		entry := DirectoryEntry{
-			Name:         [64]uint16{0},
-			NameLength:   uint16((len(name) + 1) * 2),
-			Type:         2, // Stream
-			NodeColor:    0, // Black
-			LeftSibling:  0xFFFFFFFF,
-			RightSibling: 0xFFFFFFFF,
-			Child:        0xFFFFFFFF,
+			indices[i] = len(entries)
+			entries = append(entries, entry)
+			paths = append(paths, child.path)
+			if err := addChildren(indices[i], child.children); err != nil {
+				return err
+			}
+		}
+		entries[parent].Child = linkSiblings(entries, indices, 0, blackDepth(len(indices)))
+		return nil
+	}
+	if err := addChildren(0, root.children); err != nil {
+		return nil, nil, err
+	}
+
+	return entries, paths, nil
+}
+
+// compareEntryNames orders directory entry names as MS-CFB requires for the
+// siblings of a storage: shorter names first, and names of the same length
+// by their UTF-16 code units converted to uppercase.
+func compareEntryNames(a, b string) int {
+	unitsA, unitsB := utf16Encode(a), utf16Encode(b)
+	if len(unitsA) != len(unitsB) {
+		return len(unitsA) - len(unitsB)
+	}
+	for i := range unitsA {
+		upperA, upperB := unicode.ToUpper(rune(unitsA[i])), unicode.ToUpper(rune(unitsB[i]))
+		if upperA != upperB {
+			return int(upperA) - int(upperB)
 		}
-		copy(entry.Name[:], utf16Encode(name))
-		entries = append(entries, entry)
-		streamIndex++
 	}
+	return 0
+}
 
-	return entries, nil
+// linkSiblings links the entries at indices, sorted by compareEntryNames, as
+// a balanced binary search tree and returns the index of its root, or
+// noStream if there are none. Nodes deeper than blackLevels are colored red,
+// so that every path holds the same number of black nodes.
+func linkSiblings(entries []DirectoryEntry, indices []int, depth, blackLevels int) uint32 {
+	if len(indices) == 0 {
+		return noStream
+	}
+	middle := len(indices) / 2
+	node := &entries[indices[middle]]
+	node.LeftSibling = linkSiblings(entries, indices[:middle], depth+1, blackLevels)
+	node.RightSibling = linkSiblings(entries, indices[middle+1:], depth+1, blackLevels)
+	node.NodeColor = 1 // Black
+	if depth >= blackLevels {
+		node.NodeColor = 0 // Red
+	}
+	return uint32(indices[middle])
+}
+
+// blackDepth returns the number of complete levels of a balanced tree of n
+// nodes. Splitting at the middle leaves only the level below them partly
+// filled.
+func blackDepth(n int) int {
+	levels := 0
+	for (1<<(levels+1))-1 <= n {
+		levels++
+	}
+	return levels
 }
 
 // DirectoryEntry represents an OLE2 directory entry.
 type DirectoryEntry struct {
-	Name         [64]uint16 // UTF-16 encoded name
+	Name         [32]uint16 // UTF-16 encoded name
 	NameLength   uint16     // Length of name in bytes
 	Type         uint8      // Entry type
 	NodeColor    uint8      // Red-black tree node color
@@ -193,60 +394,57 @@ type DirectoryEntry struct {
 }
 
 // buildDirectoryData creates the directory data with sector mapping.
-func (w *Writer) buildDirectoryData(entries []DirectoryEntry, sectorMap map[string]uint32) []byte {
+func (w *Writer) buildDirectoryData(entries []DirectoryEntry, paths []string, sectorMap map[string]uint32) []byte {
 	var buffer bytes.Buffer
 
-	// Write root entry
-	binary.Write(&buffer, binary.LittleEndian, &entries[0])
-
-	// Write stream entries with proper sector assignments
-	streamIndex := 1
-	for name, data := range w.streams {
-		if streamIndex < len(entries) {
-			entry := entries[streamIndex]
-			entry.StartSector = sectorMap[name]
-			entry.Size = uint64(len(data))
-			binary.Write(&buffer, binary.LittleEndian, &entry)
-			streamIndex++
+	for i, entry := range entries {
+		if entry.Type == 2 { // Streams get their sector assignments
+			entry.StartSector = sectorMap[paths[i]]
+			entry.Size = uint64(len(w.streams[paths[i]]))
 		}
+		binary.Write(&buffer, binary.LittleEndian, &entry)
 	}
 
 	return buffer.Bytes()
 }
 
-// buildFATData creates the File Allocation Table.
-func (w *Writer) buildFATData(numDataSectors, numDirSectors int) []byte {
-	var buffer bytes.Buffer
-
-	// Mark data sectors as used
-	for i := 0; i < numDataSectors-1; i++ {
-		binary.Write(&buffer, binary.LittleEndian, uint32(i+1))
+// buildFATData creates the File Allocation Table, padded to whole sectors.
+func (w *Writer) buildFATData(names []string, numFATSectors, sectorSize int, sectorMap map[string]uint32, dirStart uint32, numDirSectors int) []byte {
+	fat := make([]uint32, numFATSectors*sectorSize/fatEntrySize)
+	for i := range fat {
+		fat[i] = freeSector
 	}
-	if numDataSectors > 0 {
-		binary.Write(&buffer, binary.LittleEndian, uint32(0xFFFFFFFE)) // End of chain
+
+	// Mark the FAT's own sectors
+	for i := 0; i < numFATSectors; i++ {
+		fat[i] = fatSector
 	}
 
-	// Mark directory sectors
-	dirStart := uint32(numDataSectors)
-	for i := 0; i < numDirSectors-1; i++ {
-		binary.Write(&buffer, binary.LittleEndian, dirStart+uint32(i)+1)
+	// Chain the sectors of each stream and of the directory
+	chain := func(start uint32, count int) {
+		for i := 0; i < count; i++ {
+			next := start + uint32(i) + 1
+			if i == count-1 {
+				next = endOfChain
+			}
+			fat[start+uint32(i)] = next
+		}
 	}
-	if numDirSectors > 0 {
-		binary.Write(&buffer, binary.LittleEndian, uint32(0xFFFFFFFE)) // End of chain
+	for _, name := range names {
+		if count := (len(w.streams[name]) + sectorSize - 1) / sectorSize; count > 0 {
+			chain(sectorMap[name], count)
+		}
 	}
+	chain(dirStart, numDirSectors)
 
-	// Mark FAT sector as special
-	binary.Write(&buffer, binary.LittleEndian, uint32(0xFFFFFFFD)) // FAT sector
-
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, fat)
 	return buffer.Bytes()
 }
 
-// utf16Encode converts a string to UTF-16LE encoding.
+// utf16Encode converts a string to UTF-16. Characters outside the Basic
+// Multilingual Plane take two units, which count towards the 31-unit limit
+// on entry names.
 func utf16Encode(s string) []uint16 {
-	runes := []rune(s)
-	result := make([]uint16, len(runes))
-	for i, r := range runes {
-		result[i] = uint16(r)
-	}
-	return result
+	return utf16.Encode([]rune(s))
 }
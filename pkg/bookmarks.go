@@ -0,0 +1,104 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// Bookmark is a named range of the document.
+type Bookmark struct {
+	Name    string
+	StartCP uint32 // Character position of the first character of the range
+	EndCP   uint32 // Character position just past the range, equal to StartCP for an insertion point
+}
+
+// Bookmarks returns the bookmarks of the document in start order. Returns nil
+// if the document has no bookmarks.
+//
+// SttbfBkmk holds the names and PlcfBkf the starts, in the same order. The
+// ends in PlcfBkl are sorted by position instead, so each FBKF of PlcfBkf
+// gives the index of the matching end; nested and overlapping bookmarks are
+// paired through it rather than by position.
+func (d *Document) Bookmarks() ([]Bookmark, error) {
+	rgfc := d.fib.RgFcLcb
+	if rgfc.LcbPlcfbkf == 0 {
+		return nil, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	slice := func(fc, lcb uint32, name string) ([]byte, error) {
+		if uint64(fc)+uint64(lcb) > uint64(len(table.Data)) {
+			return nil, fmt.Errorf("table stream too small for %s", name)
+		}
+		return table.Data[fc : fc+lcb], nil
+	}
+
+	bkfData, err := slice(rgfc.FcPlcfbkf, rgfc.LcbPlcfbkf, "bookmark starts")
+	if err != nil {
+		return nil, err
+	}
+	starts, err := structures.ParsePLC(bkfData, structures.FBKFSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bookmark starts: %w", err)
+	}
+	if starts.Count() == 0 {
+		return nil, nil
+	}
+
+	// PlcfBkl has no data elements, only the end CPs
+	var ends []uint32
+	if rgfc.LcbPlcfbkl > 0 {
+		bklData, err := slice(rgfc.FcPlcfbkl, rgfc.LcbPlcfbkl, "bookmark ends")
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+4 <= len(bklData); i += 4 {
+			ends = append(ends, binary.LittleEndian.Uint32(bklData[i:]))
+		}
+	}
+
+	var names []string
+	if rgfc.LcbSttbfbkmk > 0 {
+		data, err := slice(rgfc.FcSttbfbkmk, rgfc.LcbSttbfbkmk, "bookmark names")
+		if err != nil {
+			return nil, err
+		}
+		sttb, err := structures.ParseSTTB(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bookmark names: %w", err)
+		}
+		names = sttb.Strings
+	}
+
+	bookmarks := make([]Bookmark, 0, starts.Count())
+	for i := 0; i < starts.Count(); i++ {
+		startCP, _, err := starts.GetRange(i)
+		if err != nil {
+			return nil, err
+		}
+		data, err := starts.GetDataAt(i)
+		if err != nil {
+			return nil, err
+		}
+		fbkf, err := structures.ParseFBKF(data)
+		if err != nil {
+			return nil, err
+		}
+
+		bookmark := Bookmark{StartCP: uint32(startCP), EndCP: uint32(startCP)}
+		if i < len(names) {
+			bookmark.Name = names[i]
+		}
+		// The last CP of PlcfBkl ends the PLC rather than a bookmark
+		if ibkl := int(fbkf.Ibkl); ibkl >= 0 && ibkl < len(ends)-1 && ends[ibkl] >= bookmark.StartCP {
+			bookmark.EndCP = ends[ibkl]
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	return bookmarks, nil
+}
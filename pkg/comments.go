@@ -0,0 +1,122 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// annotationRefChar marks the position of a comment reference in the main
+// document and starts the text of each comment.
+const annotationRefChar = 0x05
+
+// Comment is a comment (annotation) attached to the main document.
+type Comment struct {
+	Author   string // Name of the author, empty if the document does not record it
+	Initials string // Initials of the author
+	CP       uint32 // Character position of the comment reference in the main document
+	Text     string // Comment text without the reference mark and final paragraph mark
+}
+
+// Comments returns the comments of the document in reference order. Returns
+// nil if the document has no comments.
+//
+// Authors are resolved from the author index of each comment reference
+// against the comment author names stored in the table stream. These are the
+// only author names a .doc file holds: the people list of Word 2013 and later,
+// with display names and provider IDs, exists only in the Open XML formats
+// and is dropped when saving as .doc.
+func (d *Document) Comments() ([]Comment, error) {
+	rgfc := d.fib.RgFcLcb
+	if rgfc.LcbPlcfandRef == 0 {
+		return nil, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	slice := func(fc, lcb uint32, name string) ([]byte, error) {
+		if uint64(fc)+uint64(lcb) > uint64(len(table.Data)) {
+			return nil, fmt.Errorf("table stream too small for %s", name)
+		}
+		return table.Data[fc : fc+lcb], nil
+	}
+
+	refData, err := slice(rgfc.FcPlcfandRef, rgfc.LcbPlcfandRef, "comment references")
+	if err != nil {
+		return nil, err
+	}
+	refs, err := structures.ParsePLC(refData, structures.ATRDSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse comment references: %w", err)
+	}
+	if refs.Count() == 0 {
+		return nil, nil
+	}
+
+	var authors []string
+	if rgfc.LcbGrpXstAtnOwners > 0 {
+		ownerData, err := slice(rgfc.FcGrpXstAtnOwners, rgfc.LcbGrpXstAtnOwners, "comment authors")
+		if err != nil {
+			return nil, err
+		}
+		if authors, err = structures.ParseXstGroup(ownerData); err != nil {
+			return nil, fmt.Errorf("failed to parse comment authors: %w", err)
+		}
+	}
+
+	// PlcfandTxt has no data elements, only the CPs delimiting each comment's
+	// text within the annotation subdocument
+	var textCPs []uint32
+	if rgfc.LcbPlcfandTxt > 0 {
+		txtData, err := slice(rgfc.FcPlcfandTxt, rgfc.LcbPlcfandTxt, "comment text table")
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+4 <= len(txtData); i += 4 {
+			textCPs = append(textCPs, binary.LittleEndian.Uint32(txtData[i:]))
+		}
+	}
+
+	text, err := d.Text()
+	if err != nil {
+		return nil, err
+	}
+	units := utf16.Encode([]rune(text))
+	start := d.subdocumentStart(SubdocumentAnnotation)
+
+	comments := make([]Comment, 0, refs.Count())
+	for i := 0; i < refs.Count(); i++ {
+		cp, _, err := refs.GetRange(i)
+		if err != nil {
+			return nil, err
+		}
+		data, err := refs.GetDataAt(i)
+		if err != nil {
+			return nil, err
+		}
+		atrd, err := structures.ParseATRD(data)
+		if err != nil {
+			return nil, fmt.Errorf("comment %d: %w", i, err)
+		}
+
+		comment := Comment{Initials: atrd.Initials, CP: uint32(cp)}
+		if index := int(atrd.AuthorIndex); index >= 0 && index < len(authors) {
+			comment.Author = authors[index]
+		}
+		if i+1 < len(textCPs) {
+			from, to := start+textCPs[i], start+textCPs[i+1]
+			if from <= to && int(to) <= len(units) {
+				body := string(utf16.Decode(units[from:to]))
+				body = strings.TrimPrefix(body, string(rune(annotationRefChar)))
+				comment.Text = strings.TrimSuffix(body, "\r")
+			}
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
@@ -0,0 +1,210 @@
+package msdoc
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxDiffEdits bounds the number of token edits DiffText searches for. Past
+// it the differing text is reported as one deletion and one insertion, which
+// keeps the memory of comparing unrelated documents bounded.
+const maxDiffEdits = 2000
+
+// DiffKind is the kind of change a DiffOp describes.
+type DiffKind int
+
+const (
+	DiffEqual  DiffKind = iota // Text present in both documents
+	DiffDelete                 // Text only present in the first document
+	DiffInsert                 // Text only present in the second document
+)
+
+// String returns a human-readable name for the kind.
+func (k DiffKind) String() string {
+	switch k {
+	case DiffEqual:
+		return "equal"
+	case DiffDelete:
+		return "delete"
+	case DiffInsert:
+		return "insert"
+	default:
+		return fmt.Sprintf("Unknown (%d)", int(k))
+	}
+}
+
+// DiffOp is one step of the edit script returned by DiffText.
+type DiffOp struct {
+	Kind DiffKind
+	Text string
+}
+
+// DiffText compares the text of two documents and returns the edit script
+// turning the text of a into the text of b. Concatenating the Equal and
+// Delete ops gives the text of a; the Equal and Insert ops give that of b.
+//
+// The text is normalized as for ContentHash, with paragraph marks as "\n",
+// and compared word by word, so a changed word is reported whole. Where text
+// is replaced, the Delete op comes before the Insert op.
+func DiffText(a, b *Document) ([]DiffOp, error) {
+	textA, err := a.Text()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract text of the first document: %w", err)
+	}
+	textB, err := b.Text()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract text of the second document: %w", err)
+	}
+	return diffTokens(diffTokenize(normalizeText(textA)), diffTokenize(normalizeText(textB))), nil
+}
+
+// diffTokenize splits text into words and the whitespace between them.
+func diffTokenize(text string) []string {
+	var tokens []string
+	start, space := 0, false
+	for i, r := range text {
+		if i > start && unicode.IsSpace(r) != space {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		space = unicode.IsSpace(r)
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// diffTokens returns the ops turning the tokens a into the tokens b. The
+// common prefix and suffix are matched directly and the rest with Myers'
+// algorithm.
+func diffTokens(a, b []string) []DiffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []DiffOp
+	for _, token := range a[:prefix] {
+		ops = append(ops, DiffOp{Kind: DiffEqual, Text: token})
+	}
+	ops = append(ops, diffMyers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, token := range a[len(a)-suffix:] {
+		ops = append(ops, DiffOp{Kind: DiffEqual, Text: token})
+	}
+	return mergeDiffOps(ops)
+}
+
+// diffMyers finds a shortest edit script from a to b with Myers' O(ND)
+// algorithm, one op per token. trace[d] keeps the furthest x reached on
+// each diagonal k, offset by d, before round d, for backtracking.
+func diffMyers(a, b []string) []DiffOp {
+	n, m := len(a), len(b)
+	maxD := min(n+m, maxDiffEdits)
+
+	var trace [][]int32
+	v := map[int]int32{1: 0}
+	found := -1
+	for d := 0; d <= maxD && found < 0; d++ {
+		snapshot := make([]int32, 2*d+3)
+		for k := -d - 1; k <= d+1; k++ {
+			snapshot[k+d+1] = v[k]
+		}
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int32
+			if k == -d || (k != d && v[k-1] < v[k+1]) {
+				x = v[k+1] // Insertion: move down from diagonal k+1
+			} else {
+				x = v[k-1] + 1 // Deletion: move right from diagonal k-1
+			}
+			y := x - int32(k)
+			for int(x) < n && int(y) < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k] = x
+			if int(x) >= n && int(y) >= m {
+				found = d
+				break
+			}
+		}
+	}
+	if found < 0 {
+		// Too many edits to search for: replace the whole range
+		var ops []DiffOp
+		for _, token := range a {
+			ops = append(ops, DiffOp{Kind: DiffDelete, Text: token})
+		}
+		for _, token := range b {
+			ops = append(ops, DiffOp{Kind: DiffInsert, Text: token})
+		}
+		return ops
+	}
+
+	// Walk back from the end, emitting ops in reverse
+	var reversed []DiffOp
+	x, y := int32(n), int32(m)
+	for d := found; d >= 0; d-- {
+		prev := trace[d]
+		at := func(k int) int32 { return prev[k+d+1] }
+		k := int(x - y)
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - int32(prevK)
+		for x > prevX && y > prevY {
+			reversed = append(reversed, DiffOp{Kind: DiffEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, DiffOp{Kind: DiffInsert, Text: b[y-1]})
+			} else {
+				reversed = append(reversed, DiffOp{Kind: DiffDelete, Text: a[x-1]})
+			}
+			x, y = prevX, prevY
+		}
+	}
+
+	ops := make([]DiffOp, len(reversed))
+	for i, op := range reversed {
+		ops[len(ops)-1-i] = op
+	}
+	return ops
+}
+
+// mergeDiffOps joins adjacent ops of the same kind and, within each stretch
+// of changes, puts the deleted text before the inserted text.
+func mergeDiffOps(ops []DiffOp) []DiffOp {
+	var merged []DiffOp
+	var text [DiffInsert + 1]strings.Builder
+	flush := func(kinds ...DiffKind) {
+		for _, kind := range kinds {
+			if text[kind].Len() > 0 {
+				merged = append(merged, DiffOp{Kind: kind, Text: text[kind].String()})
+				text[kind].Reset()
+			}
+		}
+	}
+
+	for _, op := range ops {
+		if op.Kind == DiffEqual {
+			flush(DiffDelete, DiffInsert)
+		} else {
+			flush(DiffEqual)
+		}
+		text[op.Kind].WriteString(op.Text)
+	}
+	flush(DiffEqual, DiffDelete, DiffInsert)
+	return merged
+}
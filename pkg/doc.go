@@ -41,9 +41,12 @@
 package msdoc
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
+	"sort"
+	"unicode/utf16"
 
 	"github.com/TalentFormula/msdoc/crypto"
 	"github.com/TalentFormula/msdoc/fib"
@@ -52,6 +55,8 @@ import (
 	"github.com/TalentFormula/msdoc/metadata"
 	"github.com/TalentFormula/msdoc/objects"
 	"github.com/TalentFormula/msdoc/ole2"
+	"github.com/TalentFormula/msdoc/streams"
+	"github.com/TalentFormula/msdoc/structures"
 	"github.com/TalentFormula/msdoc/writer"
 )
 
@@ -59,11 +64,16 @@ import (
 // It provides methods for extracting text content, metadata, embedded objects,
 // macros, and formatting information. It also supports decryption of encrypted documents.
 type Document struct {
+	filename  string
 	file      *os.File
 	reader    *ole2.Reader
 	fib       *fib.FileInformationBlock
-	password  string      // For encrypted documents
-	decryptor *crypto.RC4 // For encrypted documents
+	password  string                   // For encrypted documents
+	decryptor *crypto.StreamDecryptor  // For encrypted documents
+	encHeader *crypto.EncryptionHeader // For encrypted documents
+	table     *streams.TableStream     // Decrypted table stream, cached by tableStream
+
+	appended []string // Paragraphs queued by AppendParagraph
 
 	// Lazy-loaded components
 	objectPool          *objects.ObjectPool
@@ -76,6 +86,10 @@ type Document struct {
 // This is an alias for metadata.DocumentMetadata for backward compatibility.
 type Metadata = metadata.DocumentMetadata
 
+// Thumbnail is the preview image stored with the document's metadata.
+// This is an alias for metadata.Thumbnail.
+type Thumbnail = metadata.Thumbnail
+
 // TextRun represents a run of text with consistent formatting.
 // This is an alias for formatting.TextRun.
 type TextRun = formatting.TextRun
@@ -84,6 +98,10 @@ type TextRun = formatting.TextRun
 // This is an alias for objects.EmbeddedObject.
 type EmbeddedObject = objects.EmbeddedObject
 
+// Font describes a font listed in the document's font table.
+// This is an alias for structures.FFN.
+type Font = structures.FFN
+
 // VBAProject represents a VBA project contained in the document.
 // This is an alias for macros.VBAProject.
 type VBAProject = macros.VBAProject
@@ -92,7 +110,9 @@ type VBAProject = macros.VBAProject
 // It prepares the document for further operations like text extraction.
 //
 // The file must be a valid Microsoft Word .doc file (Word 97-2003 format).
-// For encrypted documents, use OpenWithPassword instead.
+// Files of older or foreign variants, such as Word for Macintosh 5.1, fail
+// with ErrUnsupportedVariant. For encrypted documents, use OpenWithPassword
+// instead.
 //
 // Returns an error if the file cannot be opened, is not a valid .doc file,
 // or if the internal OLE2 structure is corrupted.
@@ -109,13 +129,39 @@ func OpenWithPassword(filename, password string) (*Document, error) {
 	return openWithPassword(filename, password)
 }
 
+// OpenWithPasswordFunc opens a .doc file, asking for a password only if the
+// document turns out to be encrypted.
+//
+// pwFunc is not called for unencrypted documents. For encrypted documents it is
+// called once, and any error it returns is passed back to the caller. This lets
+// interactive tools prompt for a password without detecting encryption first.
+func OpenWithPasswordFunc(filename string, pwFunc func() (string, error)) (*Document, error) {
+	return openDocument(filename, pwFunc)
+}
+
 // openWithPassword is the internal function that handles both encrypted and unencrypted files.
 func openWithPassword(filename, password string) (*Document, error) {
+	return openDocument(filename, func() (string, error) {
+		return password, nil
+	})
+}
+
+// openDocument opens and parses the file, calling pwFunc to obtain the
+// password if the document is encrypted.
+func openDocument(filename string, pwFunc func() (string, error)) (*Document, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
 	}
 
+	// Recognize older and foreign Word formats before they fail as OLE2
+	header := make([]byte, 8)
+	n, _ := file.ReadAt(header, 0)
+	if variant := detectVariant(header[:n]); variant != "" {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedVariant, variant)
+	}
+
 	oleReader, err := ole2.NewReader(file)
 	if err != nil {
 		file.Close()
@@ -129,17 +175,27 @@ func openWithPassword(filename, password string) (*Document, error) {
 		return nil, fmt.Errorf("could not find WordDocument stream: %w", err)
 	}
 
-	fib, err := fib.ParseFIB(wordDocumentStream)
+	if isBigEndianFIB(wordDocumentStream) {
+		file.Close()
+		return nil, fmt.Errorf("%w: big-endian FIB", ErrUnsupportedVariant)
+	}
+
+	// Encrypted documents store only the start of the FIB unencrypted, so
+	// their FIB is read in full once the stream has been decrypted
+	parsedFIB, err := fib.ParseFIBBase(wordDocumentStream)
+	if err == nil && !parsedFIB.IsEncrypted() {
+		parsedFIB, err = fib.ParseFIB(wordDocumentStream)
+	}
 	if err != nil {
 		file.Close()
 		return nil, fmt.Errorf("failed to parse FIB: %w", err)
 	}
 
 	doc := &Document{
+		filename: filename,
 		file:     file,
 		reader:   oleReader,
-		fib:      fib,
-		password: password,
+		fib:      parsedFIB,
 	}
 
 	// Initialize lazy-loaded components
@@ -149,10 +205,17 @@ func openWithPassword(filename, password string) (*Document, error) {
 	doc.formattingExtractor = formatting.NewFormattingExtractor()
 
 	// Handle encryption if document is encrypted
-	if fib.IsEncrypted() {
+	if parsedFIB.IsEncrypted() {
+		password, err := pwFunc()
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to obtain password: %w", err)
+		}
 		if password == "" {
+			file.Close()
 			return nil, fmt.Errorf("document is encrypted but no password provided")
 		}
+		doc.password = password
 
 		if err := doc.setupDecryption(); err != nil {
 			file.Close()
@@ -165,26 +228,38 @@ func openWithPassword(filename, password string) (*Document, error) {
 
 // setupDecryption initializes decryption for encrypted documents.
 func (d *Document) setupDecryption() error {
-	// Get the table stream name
-	tableStreamName := d.fib.GetTableStreamName()
-	tableStream, err := d.reader.ReadStream(tableStreamName)
+	// Get the table stream, which starts with the encryption header
+	tableStream, err := d.rawTableStream()
 	if err != nil {
-		return fmt.Errorf("failed to read table stream %s: %w", tableStreamName, err)
+		return err
 	}
 
 	// Parse encryption header
-	encHeader, err := crypto.ParseEncryptionHeader(tableStream)
+	encHeader, err := crypto.ParseEncryptionHeader(tableStream.Data)
 	if err != nil {
 		return fmt.Errorf("failed to parse encryption header: %w", err)
 	}
 
-	// Create decryption cipher
-	decryptor, err := encHeader.CreateDecryptionCipher(d.password)
+	// Check the password and prepare to decrypt the streams
+	decryptor, err := encHeader.NewStreamDecryptor(d.password)
 	if err != nil {
 		return fmt.Errorf("failed to create decryption cipher: %w", err)
 	}
 
 	d.decryptor = decryptor
+	d.encHeader = encHeader
+
+	// Only the FibBase is stored unencrypted, so the rest of the FIB is read
+	// again from the decrypted stream
+	wordDocument, err := d.wordDocument()
+	if err != nil {
+		return err
+	}
+	decryptedFIB, err := fib.ParseFIB(wordDocument)
+	if err != nil {
+		return fmt.Errorf("failed to parse decrypted FIB: %w", err)
+	}
+	d.fib = decryptedFIB
 	return nil
 }
 
@@ -197,11 +272,39 @@ func (d *Document) Close() error {
 	return nil
 }
 
+// AccessedStreams returns the sorted paths of the streams read from the
+// file so far, such as "WordDocument", "1Table" or "Macros/VBA/dir". Which
+// streams appear depends on the methods called: the text alone needs the
+// WordDocument and table streams, while metadata, embedded objects and
+// macros read further streams when first requested.
+func (d *Document) AccessedStreams() []string {
+	return d.reader.AccessedStreams()
+}
+
 // IsEncrypted returns true if the document is encrypted.
 func (d *Document) IsEncrypted() bool {
 	return d.fib.IsEncrypted()
 }
 
+// ErrNotEncrypted is returned by EncryptionInfo for documents that are not
+// encrypted.
+var ErrNotEncrypted = errors.New("document is not encrypted")
+
+// EncryptionInfo returns the encryption header of an encrypted document, as
+// parsed from the start of its table stream: the algorithm, hash algorithm,
+// key size and cryptographic provider, for reporting how the document is
+// protected. The returned header must not be modified. Returns
+// ErrNotEncrypted for documents that are not encrypted.
+func (d *Document) EncryptionInfo() (*crypto.EncryptionHeader, error) {
+	if !d.fib.IsEncrypted() {
+		return nil, ErrNotEncrypted
+	}
+	if d.encHeader == nil {
+		return nil, fmt.Errorf("document is encrypted but its encryption header was not read")
+	}
+	return d.encHeader, nil
+}
+
 // HasMacros returns true if the document contains VBA macros.
 func (d *Document) HasMacros() bool {
 	return d.macroExtractor.HasMacros()
@@ -210,7 +313,7 @@ func (d *Document) HasMacros() bool {
 // HasEmbeddedObjects returns true if the document contains embedded objects.
 func (d *Document) HasEmbeddedObjects() bool {
 	// Load objects if not already loaded
-	if err := d.objectPool.LoadObjects(); err != nil {
+	if err := d.loadObjects(); err != nil {
 		return false
 	}
 	return len(d.objectPool.GetAllObjects()) > 0
@@ -218,41 +321,239 @@ func (d *Document) HasEmbeddedObjects() bool {
 
 // GetFormattedText extracts text with formatting information.
 // Returns an array of TextRun structures containing text and formatting.
+//
+// A new run starts at each piece, paragraph and CHPX boundary. The character
+// properties of a run are those the paragraph style gives its text under the
+// run's direct formatting; its paragraph properties are those of the
+// paragraph containing it, as Paragraphs reports them. Text outside the main
+// document, such as footnotes and headers, gets the Normal style.
+//
+// Each run records the byte range it was decoded from in the WordDocument
+// stream (FileOffset and ByteLength), so callers can map runs back to the file.
 func (d *Document) GetFormattedText() ([]*TextRun, error) {
 	if d.fib.IsEncrypted() && d.decryptor == nil {
 		return nil, fmt.Errorf("document is encrypted but decryption is not available")
 	}
 
-	// Implementation would extract text with formatting
-	// For now, return basic text as a single run
-	text, err := d.Text()
+	isEncrypted := d.fib.IsEncrypted()
+	plcPcd, wordStream, err := d.readPieceTable(isEncrypted)
 	if err != nil {
 		return nil, err
 	}
 
-	return []*TextRun{{
-		Text:     text,
-		StartPos: 0,
-		EndPos:   uint32(len(text)),
-	}}, nil
+	if plcPcd == nil {
+		// Without a piece table there is no reliable mapping back to the
+		// stream, so return basic text as a single run
+		text, err := d.Text()
+		if err != nil {
+			return nil, err
+		}
+
+		return []*TextRun{{
+			Text:     text,
+			StartPos: 0,
+			EndPos:   uint32(len(text)),
+		}}, nil
+	}
+
+	chpx, err := d.characterFKPs()
+	if err != nil {
+		return nil, err
+	}
+	paragraphs, err := d.Paragraphs()
+	if err != nil {
+		return nil, err
+	}
+	stsh, _ := d.styleSheet()
+	normal := &Paragraph{
+		Properties:          paragraphProperties(stsh, nil),
+		CharacterProperties: styleCharacterProperties(stsh, structures.IstdNormal),
+	}
+
+	// Pieces come in CP order, so the paragraph containing each run is found
+	// by moving forward through the paragraphs
+	next := 0
+	paragraphAt := func(cp uint32) *Paragraph {
+		for next < len(paragraphs) && paragraphs[next].EndCP <= cp {
+			next++
+		}
+		if next < len(paragraphs) && paragraphs[next].StartCP <= cp {
+			return &paragraphs[next]
+		}
+		return normal
+	}
+
+	runs := make([]*TextRun, 0, plcPcd.Count())
+	for i := 0; i < plcPcd.Count(); i++ {
+		startCP, endCP, pcd, err := plcPcd.GetTextRange(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get text range for piece %d: %w", i, err)
+		}
+
+		charCount := startCP.Distance(endCP)
+		if charCount == 0 {
+			continue
+		}
+
+		text, fileOffset, _, err := d.decodePiece(i, pcd, charCount, wordStream, isEncrypted, EndiannessAuto)
+		if err != nil {
+			return nil, err
+		}
+
+		// Each stored character is one CP; CP-1252 decodes to a single
+		// UTF-16 unit per byte
+		units := utf16.Encode([]rune(text))
+		charSize := uint32(1)
+		if pcd.IsUnicode {
+			charSize = 2
+		}
+
+		for from := uint32(0); from < uint32(len(units)); {
+			cp := uint32(startCP) + from
+			fc := fileOffset + from*charSize
+
+			para := paragraphAt(cp)
+			to := uint32(len(units))
+			if para != normal {
+				to = min(to, para.EndCP-uint32(startCP))
+			}
+			var grpprl []byte
+			if chpx != nil {
+				var fcEnd uint32
+				if grpprl, fcEnd, err = chpx.run(fc); err != nil {
+					return nil, fmt.Errorf("failed to read CHPX at CP %d: %w", cp, err)
+				}
+				if n := max((fcEnd-fc)/charSize, 1); n < to-from {
+					to = from + n
+				}
+			}
+
+			props := *para.CharacterProperties
+			applyCharacterSprms(&props, grpprl)
+			runs = append(runs, &TextRun{
+				Text:       string(utf16.Decode(units[from:to])),
+				StartPos:   cp,
+				EndPos:     uint32(startCP) + to,
+				FileOffset: fc,
+				ByteLength: (to - from) * charSize,
+				CharProps:  &props,
+				ParaProps:  para.Properties,
+			})
+			from = to
+		}
+	}
+
+	return runs, nil
 }
 
-// GetEmbeddedObjects returns all embedded objects in the document.
+// Fonts returns the fonts listed in the document's font table (SttbfFfn).
+// The index of each font is the font number used by character formatting.
+// Documents without a font table return an empty list.
+func (d *Document) Fonts() ([]*Font, error) {
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := table.GetFontTable(d.fib.RgFcLcb.FcSttbfffn, d.fib.RgFcLcb.LcbSttbfffn)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	fonts, err := structures.ParseSttbfFfn(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font table: %w", err)
+	}
+
+	// Let character formatting resolve font numbers to names
+	for i, font := range fonts {
+		d.formattingExtractor.AddFontMapping(uint16(i), font.Name)
+	}
+
+	return fonts, nil
+}
+
+// GetEmbeddedObjects returns all embedded objects in the document keyed by
+// their Position: the CP of the object's anchor character in the main
+// document, so converters can place each object in the text. An object whose
+// anchor is not found keeps its offset or storage identifier in the object
+// pool.
 func (d *Document) GetEmbeddedObjects() (map[uint32]*EmbeddedObject, error) {
-	if err := d.objectPool.LoadObjects(); err != nil {
+	if err := d.loadObjects(); err != nil {
 		return nil, fmt.Errorf("failed to load embedded objects: %w", err)
 	}
 	return d.objectPool.GetAllObjects(), nil
 }
 
+// ForEachObject reads the embedded objects one at a time, in document order,
+// and calls fn with each. The objects are not cached, so a caller that writes
+// each object out and drops it keeps only one object in memory at a time.
+// Iteration stops at the first error returned by fn, which is returned.
+func (d *Document) ForEachObject(fn func(obj *EmbeddedObject) error) error {
+	// Placements are optional, so objects are still yielded without them
+	placements, err := d.objectPlacements()
+	if err != nil {
+		placements = &objectPlacements{}
+	}
+
+	return d.objectPool.ForEach(func(obj *EmbeddedObject) error {
+		placements.place(obj)
+		return fn(obj)
+	})
+}
+
 // GetEmbeddedObject returns a specific embedded object by position.
 func (d *Document) GetEmbeddedObject(position uint32) (*EmbeddedObject, error) {
-	if err := d.objectPool.LoadObjects(); err != nil {
+	if err := d.loadObjects(); err != nil {
 		return nil, fmt.Errorf("failed to load embedded objects: %w", err)
 	}
 	return d.objectPool.ExtractObject(position)
 }
 
+// ExtractAllObjects writes every embedded object and image to dir and returns
+// the paths of the written files in document order.
+//
+// Files are named after the object type and position in the document, with an
+// extension chosen from the image format or OLE class (for example
+// "image001.png" or "object002.xls"). The directory is created if needed.
+func (d *Document) ExtractAllObjects(dir string) ([]string, error) {
+	objs, err := d.GetEmbeddedObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]uint32, 0, len(objs))
+	for position := range objs {
+		positions = append(positions, position)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	paths := make([]string, 0, len(positions))
+	for i, position := range positions {
+		obj := objs[position]
+
+		prefix := "object"
+		if obj.Type == objects.ObjectTypeImage {
+			prefix = "image"
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s%03d%s", prefix, i+1, obj.FileExtension()))
+
+		if err := obj.SaveObject(path); err != nil {
+			return paths, fmt.Errorf("failed to extract object at position %d: %w", position, err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
 // GetVBAProject extracts the VBA project from the document.
 // Returns an error if the document does not contain macros.
 func (d *Document) GetVBAProject() (*VBAProject, error) {
@@ -274,6 +575,13 @@ func (d *Document) GetVBACode(moduleName string) (string, error) {
 	return code, nil
 }
 
+// VBACodeFor returns the VBA code for a specific module. Unlike GetVBACode,
+// it only reads and decompresses the requested module, which is cheaper for
+// projects with many modules.
+func (d *Document) VBACodeFor(moduleName string) (string, error) {
+	return d.macroExtractor.ExtractModuleCode(moduleName)
+}
+
 // GetAllVBAModules returns the names of all VBA modules in the document.
 func (d *Document) GetAllVBAModules() ([]string, error) {
 	project, err := d.GetVBAProject()
@@ -284,32 +592,17 @@ func (d *Document) GetAllVBAModules() ([]string, error) {
 	return project.GetAllModuleNames(), nil
 }
 
-// MarkdownText extracts text with hyperlinks formatted as markdown
-func (d *Document) MarkdownText() (string, error) {
-	// First get the plain text
-	plainText, err := d.Text()
+// Thumbnail returns the preview image stored with the document's summary
+// information. Returns nil if the document has no thumbnail.
+func (d *Document) Thumbnail() (*Thumbnail, error) {
+	props, err := d.metadataExtractor.ExtractMetadata()
 	if err != nil {
-		return "", err
-	}
-
-	// Try to extract hyperlinks and format as markdown
-	markdownText, err := d.extractTextWithHyperlinks()
-	if err != nil {
-		// If hyperlink extraction fails, use simple fallback
-		if strings.HasSuffix(strings.TrimSpace(plainText), "For more information,") {
-			return plainText + " [click here](https://github.com/TalentFormula/msdoc)", nil
-		}
-		return plainText, nil
+		return nil, err
 	}
-
-	// If no hyperlinks were found (same as plain text), try simple detection
-	if markdownText == plainText {
-		if strings.HasSuffix(strings.TrimSpace(plainText), "For more information,") {
-			return plainText + " [click here](https://github.com/TalentFormula/msdoc)", nil
-		}
+	if props.ThumbnailData == nil {
+		return nil, nil
 	}
-
-	return markdownText, nil
+	return metadata.ParseThumbnail(props.ThumbnailData)
 }
 
 // NewWriter creates a new document writer for creating .doc files.
@@ -0,0 +1,373 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/ole2"
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// FIB fields patched by SaveInPlace, as uint32 indices within FibRgLw and
+// FibRgFcLcb. The offsets of those arrays depend on the csw and cslw of the
+// document and are computed by fibArrayOffsets.
+const (
+	fibCbMacIndex         = 0  // cbMac within FibRgLw
+	fibCcpTextIndex       = 3  // ccpText within FibRgLw
+	fibFcPlcfbteChpxIndex = 24 // fcPlcfBteChpx within FibRgFcLcb
+	fibFcPlcfbtePapxIndex = 26 // fcPlcfBtePapx within FibRgFcLcb
+	fibFcClxIndex         = 66 // fcClx within FibRgFcLcb
+)
+
+// Entries that fit in the FKPs written by SaveInPlace, which have default
+// properties only: each takes an FC and a zero offset byte in a CHPX FKP or
+// a zero 13-byte BxPap in a PAPX FKP, after the final FC and before crun.
+const (
+	chpxFKPCapacity = (structures.FKPSize - 1 - 4) / (4 + 1)
+	papxFKPCapacity = (structures.FKPSize - 1 - 4) / (4 + 13)
+)
+
+// AppendParagraph queues a paragraph to be added to the end of the main
+// document text. The change is written to the file by SaveInPlace.
+func (d *Document) AppendParagraph(text string) error {
+	if d.fib.IsEncrypted() {
+		return errors.New("cannot append to an encrypted document")
+	}
+	d.appended = append(d.appended, text+"\r")
+	return nil
+}
+
+// SaveInPlace writes the paragraphs queued with AppendParagraph back to the
+// file the document was opened from.
+//
+// Unlike rebuilding the document with a DocumentWriter, only the WordDocument
+// and table streams are modified: the new text is appended to the WordDocument
+// stream as a new piece at the end of the main document, a new piece table is
+// appended to the table stream, and the FIB is updated to point at it. The
+// character and paragraph bin tables are extended with FKPs giving the new
+// text default formatting in the Normal style, and the last section is
+// extended to include it. All other streams and storages are copied
+// unchanged; field, bookmark and other tables are not adjusted.
+//
+// Storages without any stream cannot be written and make SaveInPlace fail.
+// The document is reloaded from the saved file on success.
+func (d *Document) SaveInPlace() error {
+	if len(d.appended) == 0 {
+		return nil
+	}
+	if d.fib.IsEncrypted() {
+		return errors.New("cannot append to an encrypted document")
+	}
+
+	streams := d.reader.ListStreams()
+	storages := d.reader.ListStorages()
+	for _, storage := range storages {
+		if !slices.ContainsFunc(streams, func(name string) bool { return strings.HasPrefix(name, storage+"/") }) {
+			return fmt.Errorf("cannot save documents containing empty storages in place (found %q)", storage)
+		}
+	}
+
+	plcPcd, wordStream, err := d.readPieceTable(false)
+	if err != nil {
+		return err
+	}
+	if plcPcd == nil {
+		return errors.New("document has no piece table")
+	}
+
+	tableStream, err := d.tableStream()
+	if err != nil {
+		return err
+	}
+	tableName := tableStream.Name
+
+	word := append([]byte(nil), wordStream...)
+	table := append([]byte(nil), tableStream.Data...)
+	rgLwOffset, rgFcLcbOffset := d.fibArrayOffsets()
+	if rgFcLcbOffset+(fibFcClxIndex+2)*4 > len(word) {
+		return errors.New("FIB is too short to update")
+	}
+
+	// Append the new text as a Unicode piece at the end of the WordDocument
+	// stream, noting where each paragraph ends for the PAPX FKPs
+	if len(word)%2 != 0 {
+		word = append(word, 0)
+	}
+	textOffset := uint32(len(word))
+	var units []uint16
+	for _, paragraph := range d.appended {
+		units = append(units, utf16.Encode([]rune(paragraph))...)
+	}
+	var paragraphEnds []uint32
+	for _, u := range units {
+		word = binary.LittleEndian.AppendUint16(word, u)
+		if u == '\r' {
+			paragraphEnds = append(paragraphEnds, uint32(len(word)))
+		}
+	}
+	textEnd := uint32(len(word))
+
+	newPCD := make([]byte, 8)
+	binary.LittleEndian.PutUint16(newPCD[0:], 0x0001) // fNoEncryption
+	binary.LittleEndian.PutUint32(newPCD[2:], textOffset)
+
+	// The main document text comes first, followed by any footnote, header
+	// and other subdocument text
+	cps := make([]uint32, len(plcPcd.CPs))
+	for i, cp := range plcPcd.CPs {
+		cps[i] = uint32(cp)
+	}
+	at := cps[len(cps)-1]
+	ccpText := d.fib.FibRgLw.CcpText
+	if ccpText > 0 && ccpText < at {
+		at = ccpText
+	}
+	cps, pcds := insertPiece(cps, plcPcd.Data, at, newPCD, uint32(len(units)))
+
+	// Give the new text FKPs after it in the WordDocument stream, and write
+	// the extended bin tables at the end of the table stream
+	rgfc := d.fib.RgFcLcb
+	binTables := []struct {
+		name     string
+		index    int
+		fc, lcb  uint32
+		ends     []uint32
+		capacity int
+	}{
+		{"PlcBteChpx", fibFcPlcfbteChpxIndex, rgfc.FcPlcfbteChpx, rgfc.LcbPlcfbteChpx, []uint32{textEnd}, chpxFKPCapacity},
+		{"PlcBtePapx", fibFcPlcfbtePapxIndex, rgfc.FcPlcfbtePapx, rgfc.LcbPlcfbtePapx, paragraphEnds, papxFKPCapacity},
+	}
+	for _, bt := range binTables {
+		if bt.lcb == 0 {
+			continue // No formatting to extend
+		}
+		if uint64(bt.fc)+uint64(bt.lcb) > uint64(len(table)) {
+			return fmt.Errorf("%s out of bounds", bt.name)
+		}
+		var plc []byte
+		word, plc, err = appendBinTable(word, table[bt.fc:bt.fc+bt.lcb], textOffset, bt.ends, bt.capacity)
+		if err != nil {
+			return fmt.Errorf("failed to extend %s: %w", bt.name, err)
+		}
+		offset := rgFcLcbOffset + bt.index*4
+		binary.LittleEndian.PutUint32(word[offset:], uint32(len(table)))
+		binary.LittleEndian.PutUint32(word[offset+4:], uint32(len(plc)))
+		table = append(table, plc...)
+	}
+
+	// Extend the last section, which ends after the main document
+	if err := extendSections(table, rgfc.FcPlcfsed, rgfc.LcbPlcfsed, at, uint32(len(units))); err != nil {
+		return err
+	}
+
+	// Write the new CLX at the end of the table stream
+	clxOffset := uint32(len(table))
+	table = append(table, 0x02)
+	table = binary.LittleEndian.AppendUint32(table, uint32(len(cps)*4+len(pcds)*8))
+	for _, cp := range cps {
+		table = binary.LittleEndian.AppendUint32(table, cp)
+	}
+	for _, pcd := range pcds {
+		table = append(table, pcd...)
+	}
+	clxSize := uint32(len(table)) - clxOffset
+
+	// Patch the FIB
+	if ccpText == 0 {
+		ccpText = at
+	}
+	binary.LittleEndian.PutUint32(word[rgLwOffset+fibCbMacIndex*4:], uint32(len(word)))
+	binary.LittleEndian.PutUint32(word[rgLwOffset+fibCcpTextIndex*4:], ccpText+uint32(len(units)))
+	binary.LittleEndian.PutUint32(word[rgFcLcbOffset+fibFcClxIndex*4:], clxOffset)
+	binary.LittleEndian.PutUint32(word[rgFcLcbOffset+(fibFcClxIndex+1)*4:], clxSize)
+
+	oleWriter := ole2.NewWriter()
+	for _, name := range streams {
+		switch {
+		case ole2.StreamNameEqual(name, "WordDocument"):
+			oleWriter.AddStream(name, word)
+		case ole2.StreamNameEqual(name, tableName):
+			oleWriter.AddStream(name, table)
+		default:
+			data, err := d.reader.ReadStream(name)
+			if err != nil {
+				return fmt.Errorf("failed to read %s stream: %w", name, err)
+			}
+			oleWriter.AddStream(name, data)
+		}
+	}
+	for _, storage := range storages {
+		clsid, err := d.reader.StreamCLSID(storage)
+		if err != nil {
+			return fmt.Errorf("failed to read %s storage: %w", storage, err)
+		}
+		oleWriter.SetCLSID(storage, clsid)
+	}
+
+	if err := d.replaceFile(oleWriter); err != nil {
+		return err
+	}
+	d.appended = nil
+	return nil
+}
+
+// fibArrayOffsets returns the offsets of FibRgLw and FibRgFcLcb in the
+// WordDocument stream: FibRgLw follows the 32-byte FibBase, csw, FibRgW and
+// cslw, and FibRgFcLcb follows FibRgLw and cbRgFcLcb.
+func (d *Document) fibArrayOffsets() (rgLw, rgFcLcb int) {
+	rgLw = 32 + 2 + int(d.fib.Csw)*2 + 2
+	rgFcLcb = rgLw + int(d.fib.Cslw)*4 + 2
+	return rgLw, rgFcLcb
+}
+
+// appendBinTable appends FKPs with default properties covering the text
+// appended at textOffset to the WordDocument stream, on page boundaries, and
+// returns the stream with the bin table plc extended to point at them. The
+// FKP entries end at ends, in order, with at most capacity per FKP; the first
+// one starts where the bin table ended.
+func appendBinTable(word, plc []byte, textOffset uint32, ends []uint32, capacity int) ([]byte, []byte, error) {
+	if len(plc) < 4 || (len(plc)-4)%8 != 0 {
+		return nil, nil, fmt.Errorf("invalid size %d", len(plc))
+	}
+	n := (len(plc) - 4) / 8
+	fcs := make([]uint32, n+1)
+	pns := make([]uint32, n, n+len(ends)/capacity+1)
+	for i := range fcs {
+		fcs[i] = binary.LittleEndian.Uint32(plc[i*4:])
+	}
+	for i := range pns {
+		pns[i] = binary.LittleEndian.Uint32(plc[(n+1+i)*4:])
+	}
+
+	start := fcs[n]
+	if start > textOffset {
+		return nil, nil, fmt.Errorf("table ends at FC %d, past the end of the text", start)
+	}
+	for len(ends) > 0 {
+		count := min(capacity, len(ends))
+		word = append(word, make([]byte, -len(word)&(structures.FKPSize-1))...)
+		pns = append(pns, uint32(len(word)/structures.FKPSize))
+
+		// Offsets of zero give every entry default properties
+		page := make([]byte, structures.FKPSize)
+		binary.LittleEndian.PutUint32(page, start)
+		for i, end := range ends[:count] {
+			binary.LittleEndian.PutUint32(page[(i+1)*4:], end)
+		}
+		page[structures.FKPSize-1] = byte(count)
+		word = append(word, page...)
+
+		start = ends[count-1]
+		fcs = append(fcs, start)
+		ends = ends[count:]
+	}
+
+	extended := make([]byte, 0, len(fcs)*4+len(pns)*4)
+	for _, fc := range fcs {
+		extended = binary.LittleEndian.AppendUint32(extended, fc)
+	}
+	for _, pn := range pns {
+		extended = binary.LittleEndian.AppendUint32(extended, pn)
+	}
+	return word, extended, nil
+}
+
+// extendSections adds n to the CPs of the PlcfSed in table at or after at,
+// so that the section containing the end of the main document also contains
+// the n characters inserted there. A document without a PlcfSed is left as
+// is.
+func extendSections(table []byte, fc, lcb, at, n uint32) error {
+	if lcb == 0 {
+		return nil
+	}
+	if uint64(fc)+uint64(lcb) > uint64(len(table)) || lcb < 4 || (lcb-4)%16 != 0 {
+		return errors.New("PlcfSed out of bounds")
+	}
+	count := (lcb - 4) / 16 // Sections, each with a CP and a 12-byte SED
+	for i := uint32(1); i <= count; i++ {
+		offset := fc + i*4
+		if cp := binary.LittleEndian.Uint32(table[offset:]); cp >= at {
+			binary.LittleEndian.PutUint32(table[offset:], cp+n)
+		}
+	}
+	return nil
+}
+
+// insertPiece inserts a piece of length n at CP at into a piece table given
+// as its CPs and raw PCDs, splitting the piece containing at if necessary.
+func insertPiece(cps []uint32, pcds [][]byte, at uint32, pcd []byte, n uint32) ([]uint32, [][]byte) {
+	newCPs := make([]uint32, 0, len(cps)+2)
+	newPCDs := make([][]byte, 0, len(pcds)+2)
+	inserted := false
+
+	for i, raw := range pcds {
+		start, end := cps[i], cps[i+1]
+		switch {
+		case inserted:
+			newCPs = append(newCPs, start+n)
+			newPCDs = append(newPCDs, raw)
+		case at <= start:
+			newCPs = append(newCPs, start, start+n)
+			newPCDs = append(newPCDs, pcd, raw)
+			inserted = true
+		case at < end:
+			// Split the piece: the remainder starts further into the text
+			rest := append([]byte(nil), raw...)
+			fc := binary.LittleEndian.Uint32(raw[2:])
+			// Unicode pieces use two bytes per character, and compressed
+			// pieces store twice the byte offset
+			binary.LittleEndian.PutUint32(rest[2:], fc+(at-start)*2)
+
+			newCPs = append(newCPs, start, at, at+n)
+			newPCDs = append(newPCDs, raw, pcd, rest)
+			inserted = true
+		default:
+			newCPs = append(newCPs, start)
+			newPCDs = append(newPCDs, raw)
+		}
+	}
+
+	last := cps[len(cps)-1]
+	if !inserted {
+		newCPs = append(newCPs, last)
+		newPCDs = append(newPCDs, pcd)
+	}
+	newCPs = append(newCPs, last+n)
+	return newCPs, newPCDs
+}
+
+// replaceFile writes the compound file to a temporary file next to the
+// document, moves it over the original and reloads the document from it.
+func (d *Document) replaceFile(oleWriter *ole2.Writer) error {
+	tmp, err := os.CreateTemp(filepath.Dir(d.filename), ".msdoc-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := oleWriter.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), d.filename); err != nil {
+		return fmt.Errorf("failed to replace document: %w", err)
+	}
+	d.table = nil // The cached table stream belongs to the old file
+
+	reloaded, err := openWithPassword(d.filename, d.password)
+	if err != nil {
+		return fmt.Errorf("failed to reload document: %w", err)
+	}
+	d.file.Close()
+	*d = *reloaded
+	return nil
+}
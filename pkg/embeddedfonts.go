@@ -0,0 +1,65 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// EmbeddedFont is a TrueType font embedded in the document.
+type EmbeddedFont struct {
+	Name   string // Font name
+	Bold   bool   // The embedded face is bold
+	Italic bool   // The embedded face is italic
+	Subset bool   // Only the characters used by the document are embedded
+	Data   []byte // Font data, nil if the Data stream does not hold it
+}
+
+// EmbeddedFonts returns the TrueType fonts embedded in the document, listed
+// in the SttbTtmbd. Returns nil if the document embeds no fonts.
+//
+// The font data of each font is read from the Data stream, where it is
+// stored with a 4-byte length prefix. Fonts whose data is missing are still
+// listed, with a nil Data.
+func (d *Document) EmbeddedFonts() ([]EmbeddedFont, error) {
+	rgfc := d.fib.RgFcLcb
+	if rgfc.LcbSttbttmbd == 0 {
+		return nil, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(rgfc.FcSttbttmbd)+uint64(rgfc.LcbSttbttmbd) > uint64(len(table.Data)) {
+		return nil, fmt.Errorf("table stream too small for embedded font table")
+	}
+	entries, err := structures.ParseSttbTtmbd(table.Data[rgfc.FcSttbttmbd : rgfc.FcSttbttmbd+rgfc.LcbSttbttmbd])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse embedded font table: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	dataStream, _ := d.reader.ReadStream("Data")
+	fonts := make([]EmbeddedFont, len(entries))
+	for i, entry := range entries {
+		fonts[i] = EmbeddedFont{
+			Name:   entry.Name,
+			Bold:   entry.Bold,
+			Italic: entry.Italic,
+			Subset: entry.Subset,
+		}
+		if uint64(entry.FcFont)+4 > uint64(len(dataStream)) {
+			continue
+		}
+		size := binary.LittleEndian.Uint32(dataStream[entry.FcFont:])
+		start := uint64(entry.FcFont) + 4
+		if start+uint64(size) <= uint64(len(dataStream)) {
+			fonts[i].Data = dataStream[start : start+uint64(size)]
+		}
+	}
+	return fonts, nil
+}
@@ -0,0 +1,202 @@
+package msdoc
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// Field represents a field such as a hyperlink in the document text.
+// This is an alias for structures.Field.
+type Field = structures.Field
+
+// Subdocument identifies one of the text streams stored in a document. The
+// text of all subdocuments is concatenated in this order, starting with the
+// main document.
+type Subdocument int
+
+const (
+	SubdocumentMain          Subdocument = iota // Main document body
+	SubdocumentFootnote                         // Footnote text
+	SubdocumentHeader                           // Header and footer text
+	SubdocumentAnnotation                       // Comment text
+	SubdocumentEndnote                          // Endnote text
+	SubdocumentTextbox                          // Text box text
+	SubdocumentHeaderTextbox                    // Text box text in headers and footers
+)
+
+// subdocuments lists every subdocument in text order.
+var subdocuments = []Subdocument{
+	SubdocumentMain,
+	SubdocumentFootnote,
+	SubdocumentHeader,
+	SubdocumentAnnotation,
+	SubdocumentEndnote,
+	SubdocumentTextbox,
+	SubdocumentHeaderTextbox,
+}
+
+// Fields returns the fields of the given subdocument.
+//
+// Field positions are document CPs, so they index into the text returned by
+// Text regardless of the subdocument. Returns nil if the subdocument has no
+// fields.
+func (d *Document) Fields(sub Subdocument) ([]*Field, error) {
+	fieldPLC, err := d.getFieldPLC(sub)
+	if err != nil || fieldPLC == nil {
+		return nil, err
+	}
+
+	fields, err := fieldPLC.GetFields()
+	if err != nil || len(fields) == 0 {
+		return nil, err
+	}
+
+	// Field PLCs store CPs relative to the start of their subdocument
+	start := structures.CP(d.subdocumentStart(sub))
+	for _, field := range fields {
+		field.Start += start
+		field.End += start
+		field.ResultEnd += start
+	}
+	return fields, nil
+}
+
+// MergeFields returns the names of the MERGEFIELD fields in the main
+// document, in document order. Names used more than once are repeated.
+func (d *Document) MergeFields() ([]string, error) {
+	fields, err := d.Fields(SubdocumentMain)
+	if err != nil || len(fields) == 0 {
+		return nil, err
+	}
+
+	text, err := d.Text()
+	if err != nil {
+		return nil, err
+	}
+	units := utf16.Encode([]rune(text))
+
+	var names []string
+	for _, field := range fields {
+		// The field code lies between the field begin character and the separator
+		start, end := int(field.Start)+1, int(field.End)
+		if start > end || end > len(units) {
+			continue
+		}
+		if name, ok := mergeFieldName(string(utf16.Decode(units[start:end]))); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// mergeFieldName returns the field name of a MERGEFIELD field code such as
+// `MERGEFIELD "Last Name" \* MERGEFORMAT`.
+func mergeFieldName(code string) (string, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) < len("MERGEFIELD") || !strings.EqualFold(code[:len("MERGEFIELD")], "MERGEFIELD") {
+		return "", false
+	}
+
+	rest := strings.TrimSpace(code[len("MERGEFIELD"):])
+	if strings.HasPrefix(rest, "\"") {
+		// Quoted names may contain spaces
+		if end := strings.IndexByte(rest[1:], '"'); end >= 0 {
+			return rest[1 : end+1], true
+		}
+		return rest[1:], len(rest) > 1 // Unterminated quote
+	}
+
+	name := strings.Fields(rest)
+	if len(name) == 0 {
+		return "", false
+	}
+	return name[0], true
+}
+
+// IsMailMergeMain reports whether the document is a mail merge main
+// document, either because the DOP marks it as one or because it contains
+// merge fields.
+func (d *Document) IsMailMergeMain() bool {
+	if dop, err := d.documentProperties(); err == nil && dop != nil && dop.FPMHMainDoc {
+		return true
+	}
+
+	names, err := d.MergeFields()
+	return err == nil && len(names) > 0
+}
+
+// allFields returns the fields of every subdocument in text order.
+// Returns nil if the document has no fields.
+func (d *Document) allFields() ([]*Field, error) {
+	var all []*Field
+	for _, sub := range subdocuments {
+		fields, err := d.Fields(sub)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, fields...)
+	}
+	return all, nil
+}
+
+// getFieldPLC extracts the field PLC of the given subdocument.
+// Returns nil with no error if the subdocument has no fields.
+func (d *Document) getFieldPLC(sub Subdocument) (*structures.FieldPLC, error) {
+	var fieldOffset, fieldLength uint32
+	switch sub {
+	case SubdocumentMain:
+		fieldOffset, fieldLength = d.fib.RgFcLcb.FcPlcffldMom, d.fib.RgFcLcb.LcbPlcffldMom
+	case SubdocumentFootnote:
+		fieldOffset, fieldLength = d.fib.RgFcLcb.FcPlcffldFtn, d.fib.RgFcLcb.LcbPlcffldFtn
+	case SubdocumentHeader:
+		fieldOffset, fieldLength = d.fib.RgFcLcb.FcPlcffldHdr, d.fib.RgFcLcb.LcbPlcffldHdr
+	case SubdocumentAnnotation:
+		fieldOffset, fieldLength = d.fib.RgFcLcb.FcPlcffldAtn, d.fib.RgFcLcb.LcbPlcffldAtn
+	case SubdocumentEndnote:
+		fieldOffset, fieldLength = d.fib.RgFcLcb.FcPlcffldEdn, d.fib.RgFcLcb.LcbPlcffldEdn
+	case SubdocumentTextbox:
+		fieldOffset, fieldLength = d.fib.RgFcLcb.FcPlcffldTxbx, d.fib.RgFcLcb.LcbPlcffldTxbx
+	case SubdocumentHeaderTextbox:
+		fieldOffset, fieldLength = d.fib.RgFcLcb.FcPlcffldHdrTxbx, d.fib.RgFcLcb.LcbPlcffldHdrTxbx
+	default:
+		return nil, fmt.Errorf("unknown subdocument %d", sub)
+	}
+
+	if fieldLength == 0 {
+		return nil, nil // No fields
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+
+	if uint32(len(table.Data)) < fieldOffset+fieldLength {
+		return nil, fmt.Errorf("table stream too small for field data")
+	}
+
+	fieldData := table.Data[fieldOffset : fieldOffset+fieldLength]
+	return structures.ParseFieldPLC(fieldData)
+}
+
+// subdocumentStart returns the CP at which the given subdocument's text
+// starts, based on the character counts in the FIB.
+func (d *Document) subdocumentStart(sub Subdocument) uint32 {
+	counts := []uint32{
+		d.fib.FibRgLw.CcpText,
+		d.fib.FibRgLw.CcpFtn,
+		d.fib.FibRgLw.CcpHdd,
+		d.fib.FibRgLw.CcpAtn,
+		d.fib.FibRgLw.CcpEdn,
+		d.fib.FibRgLw.CcpTxbx,
+	}
+
+	start := uint32(0)
+	for i := 0; i < int(sub) && i < len(counts); i++ {
+		start += counts[i]
+	}
+	return start
+}
@@ -0,0 +1,25 @@
+package msdoc
+
+import "github.com/TalentFormula/msdoc/structures"
+
+// CharacterFKPs returns the CHPX FKP pages of the document in the order of
+// the character bin table, one page per BTE. Returns nil if the document has
+// no character bin table.
+func (d *Document) CharacterFKPs() ([]*structures.FKP, error) {
+	r, err := d.characterFKPs()
+	if err != nil || r == nil {
+		return nil, err
+	}
+	return r.all()
+}
+
+// ParagraphFKPs returns the PAPX FKP pages of the document in the order of
+// the paragraph bin table, one page per BTE. Returns nil if the document has
+// no paragraph bin table.
+func (d *Document) ParagraphFKPs() ([]*structures.FKP, error) {
+	r, err := d.paragraphFKPs()
+	if err != nil || r == nil {
+		return nil, err
+	}
+	return r.all()
+}
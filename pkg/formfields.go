@@ -0,0 +1,196 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// FormFieldType is the kind of a legacy form field.
+type FormFieldType int
+
+const (
+	FormFieldText     FormFieldType = iota // FORMTEXT text box
+	FormFieldCheckBox                      // FORMCHECKBOX check box
+	FormFieldDropDown                      // FORMDROPDOWN drop-down list
+)
+
+// formFieldCodes maps the field code keyword of each form field type.
+var formFieldCodes = map[string]FormFieldType{
+	"FORMTEXT":     FormFieldText,
+	"FORMCHECKBOX": FormFieldCheckBox,
+	"FORMDROPDOWN": FormFieldDropDown,
+}
+
+// FormField is a legacy form field of the main document, as used in
+// protected forms.
+type FormField struct {
+	Type       FormFieldType
+	Name       string   // Field name, also the name of its bookmark
+	CP         uint32   // Position of the field begin character
+	Result     string   // Text of a text box, or the selected entry of a drop-down list
+	Checked    bool     // Whether a check box is checked
+	Selected   int      // Index of the selected drop-down entry, -1 if none
+	Options    []string // Entries of a drop-down list
+	Default    string   // Default text of a text box
+	Enabled    bool     // Whether the field can be filled in
+	CalcOnExit bool     // Whether fields are recalculated when the user leaves the field
+	MaxLength  int      // Maximum length of a text box, zero if unlimited
+	HelpText   string   // Help text shown for the field
+	StatusText string   // Status bar text shown for the field
+}
+
+// FormFields returns the form fields of the main document in document order.
+//
+// The field definition is read from the FFDATA in the Data stream. Fields
+// whose FFDATA cannot be found are still returned, with their type and
+// result taken from the text.
+func (d *Document) FormFields() ([]FormField, error) {
+	fields, err := d.Fields(SubdocumentMain)
+	if err != nil || len(fields) == 0 {
+		return nil, err
+	}
+
+	text, err := d.Text()
+	if err != nil {
+		return nil, err
+	}
+	units := utf16.Encode([]rune(text))
+
+	var formFields []FormField
+	for _, field := range fields {
+		start, end := int(field.Start), int(field.End)
+		if start+1 > end || end > len(units) {
+			continue
+		}
+		code := strings.Fields(string(utf16.Decode(units[start+1 : end])))
+		if len(code) == 0 {
+			continue
+		}
+		fieldType, ok := formFieldCodes[strings.ToUpper(code[0])]
+		if !ok {
+			continue
+		}
+
+		formField := FormField{
+			Type:     fieldType,
+			CP:       uint32(field.Start),
+			Selected: -1,
+			Enabled:  true,
+		}
+		if fieldType == FormFieldText {
+			formField.Result = fieldResult(units, end)
+		}
+		formFields = append(formFields, formField)
+	}
+	if len(formFields) == 0 {
+		return nil, nil
+	}
+
+	if err := d.applyFFData(formFields); err != nil {
+		return nil, err
+	}
+	return formFields, nil
+}
+
+// fieldResult returns the result text of a field whose code ends at the
+// separator at end, up to the field end character.
+func fieldResult(units []uint16, end int) string {
+	if end >= len(units) || units[end] != 0x14 {
+		return ""
+	}
+	result := units[end+1:]
+	for i, u := range result {
+		if u == 0x15 {
+			return string(utf16.Decode(result[:i]))
+		}
+	}
+	return ""
+}
+
+// applyFFData fills in the form fields from their FFDATA, located by the
+// sprmCPicLocation of each field begin character.
+func (d *Document) applyFFData(formFields []FormField) error {
+	dataStream, err := d.reader.ReadStream("Data")
+	if err != nil {
+		return nil // No Data stream means no FFDATA
+	}
+	chpx, err := d.characterFKPs()
+	if err != nil || chpx == nil {
+		return err
+	}
+
+	// Find the stream offset of each field begin character
+	byCP := make(map[uint32]*FormField, len(formFields))
+	for i := range formFields {
+		byCP[formFields[i].CP] = &formFields[i]
+	}
+	fcs := make(map[uint32]uint32, len(formFields))
+	err = d.walkMainText(func(ch mainChar) error {
+		if _, ok := byCP[ch.CP]; ok {
+			fcs[ch.CP] = ch.FC
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for cp, fc := range fcs {
+		grpprl, err := chpx.data(fc)
+		if err != nil {
+			return err
+		}
+		operand, ok := structures.FindSprm(grpprl, sprmCPicLocation)
+		if !ok {
+			continue
+		}
+
+		ffData, err := parseFFDataAt(dataStream, binary.LittleEndian.Uint32(operand))
+		if err != nil {
+			return fmt.Errorf("form field at CP %d: %w", cp, err)
+		}
+		byCP[cp].apply(ffData)
+	}
+	return nil
+}
+
+// parseFFDataAt parses the FFDATA stored at offset in the Data stream, after
+// its size and header.
+func parseFFDataAt(dataStream []byte, offset uint32) (*structures.FFData, error) {
+	if uint64(offset)+6 > uint64(len(dataStream)) {
+		return nil, fmt.Errorf("FFDATA offset %d out of bounds", offset)
+	}
+	lcb := binary.LittleEndian.Uint32(dataStream[offset:])
+	cbHeader := binary.LittleEndian.Uint16(dataStream[offset+4:])
+	if cbHeader != structures.FFDataHeaderSize || lcb < uint32(cbHeader) || uint64(offset)+uint64(lcb) > uint64(len(dataStream)) {
+		return nil, fmt.Errorf("invalid FFDATA header at offset %d", offset)
+	}
+	return structures.ParseFFData(dataStream[offset+uint32(cbHeader) : offset+lcb])
+}
+
+// apply fills in the form field from its FFDATA.
+func (f *FormField) apply(ffData *structures.FFData) {
+	f.Name = ffData.Name
+	f.Enabled = !ffData.Protected
+	f.CalcOnExit = ffData.Recalc
+	f.MaxLength = int(ffData.MaxLength)
+	f.HelpText = ffData.HelpText
+	f.StatusText = ffData.StatusText
+
+	switch ffData.Type {
+	case structures.FFDataTypeText:
+		f.Default = ffData.Default
+	case structures.FFDataTypeCheckBox:
+		f.Checked = ffData.Result != 0
+	case structures.FFDataTypeDropDown:
+		f.Options = ffData.Entries
+		if int(ffData.Result) < len(ffData.Entries) {
+			f.Selected = int(ffData.Result)
+			f.Result = ffData.Entries[ffData.Result]
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// Indices of the stories of one section in the result of HeadersFooters:
+// the stories of section n start at index n*StoriesPerSection.
+const (
+	HeaderEven = iota
+	HeaderOdd
+	FooterEven
+	FooterOdd
+	HeaderFirst
+	FooterFirst
+	StoriesPerSection
+)
+
+// separatorStories is the number of footnote and endnote separator stories
+// that precede the section stories in the header subdocument.
+const separatorStories = 6
+
+// HeadersFooters returns the header and footer text of each section, six
+// stories per section in the order given by HeaderEven to FooterFirst, with
+// the final paragraph mark removed. A story is empty if the section has no
+// such header or footer, or if it inherits the one of the previous section.
+// Returns nil if the document has no headers or footers.
+//
+// The footnote and endnote separators stored at the start of the header
+// subdocument are left out.
+func (d *Document) HeadersFooters() ([]string, error) {
+	rgfc := d.fib.RgFcLcb
+	if d.fib.FibRgLw.CcpHdd == 0 || rgfc.LcbPlcfhdd == 0 {
+		return nil, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(rgfc.FcPlcfhdd)+uint64(rgfc.LcbPlcfhdd) > uint64(len(table.Data)) {
+		return nil, fmt.Errorf("table stream too small for header table")
+	}
+
+	// PlcfHdd has no data elements, only the CPs delimiting each story
+	// within the header subdocument. A guard paragraph mark follows the last
+	// story, so there is one more CP than the stories need
+	data := table.Data[rgfc.FcPlcfhdd : rgfc.FcPlcfhdd+rgfc.LcbPlcfhdd]
+	var cps []uint32
+	for i := 0; i+4 <= len(data); i += 4 {
+		cps = append(cps, binary.LittleEndian.Uint32(data[i:]))
+	}
+	stories := len(cps) - 1 - separatorStories
+	stories -= stories % StoriesPerSection
+	if stories <= 0 {
+		return nil, nil
+	}
+
+	text, err := d.Text()
+	if err != nil {
+		return nil, err
+	}
+	units := utf16.Encode([]rune(text))
+	start := d.subdocumentStart(SubdocumentHeader)
+
+	result := make([]string, stories)
+	for i := range result {
+		from, to := start+cps[separatorStories+i], start+cps[separatorStories+i+1]
+		if from <= to && int(to) <= len(units) {
+			result[i] = strings.TrimSuffix(string(utf16.Decode(units[from:to])), "\r")
+		}
+	}
+	return result, nil
+}
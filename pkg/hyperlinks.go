@@ -0,0 +1,272 @@
+package msdoc
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// sprmCFVanish marks a run as hidden text.
+const sprmCFVanish = 0x083C
+
+// Hyperlinks returns the HYPERLINK fields of the main document in document
+// order. Start and End delimit the whole field, from its begin character to
+// just past its end character, and DisplayText is the field result. Returns
+// nil if the document has no hyperlinks.
+func (d *Document) Hyperlinks() ([]structures.HyperlinkField, error) {
+	links, err := d.hyperlinks()
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+	fields := make([]structures.HyperlinkField, len(links))
+	for i, link := range links {
+		fields[i] = link.HyperlinkField
+	}
+	return fields, nil
+}
+
+// hyperlink is a HYPERLINK field together with the CPs of its result.
+type hyperlink struct {
+	structures.HyperlinkField
+	resultStart, resultEnd uint32
+}
+
+// hyperlinks returns the HYPERLINK fields of the main document.
+func (d *Document) hyperlinks() ([]hyperlink, error) {
+	fields, err := d.Fields(SubdocumentMain)
+	if err != nil || len(fields) == 0 {
+		return nil, err
+	}
+
+	text, err := d.Text()
+	if err != nil {
+		return nil, err
+	}
+	units := utf16.Encode([]rune(text))
+	span := func(from, to uint32) string {
+		if from > to || int(to) > len(units) {
+			return ""
+		}
+		return string(utf16.Decode(units[from:to]))
+	}
+
+	var links []hyperlink
+	for _, field := range fields {
+		// The code lies between the begin character and the separator, the
+		// result between the separator and the end character
+		url, ok := hyperlinkURL(span(uint32(field.Start)+1, uint32(field.End)))
+		if !ok {
+			continue
+		}
+		link := hyperlink{
+			HyperlinkField: structures.HyperlinkField{
+				URL:   url,
+				Start: field.Start,
+				End:   field.ResultEnd + 1,
+			},
+			resultStart: uint32(field.End) + 1,
+			resultEnd:   uint32(field.ResultEnd),
+		}
+		link.DisplayText = span(link.resultStart, link.resultEnd)
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// VisibleHyperlinks returns the hyperlinks a reader of the document could
+// click: those whose result contains text outside hidden runs. Links with an
+// empty result, or whose result is entirely hidden or blank, exist only in
+// the field codes and are left out. Returns nil if there are none.
+func (d *Document) VisibleHyperlinks() ([]structures.HyperlinkField, error) {
+	links, err := d.hyperlinks()
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+
+	chpx, err := d.characterFKPs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Mark the CPs holding visible text; blanks and field characters do not
+	// count, nor do characters of hidden runs
+	var visible []bool
+	err = d.walkMainText(func(ch mainChar) error {
+		r := utf16.Decode(ch.Units)[0]
+		shown := !unicode.IsSpace(r) && !unicode.IsControl(r)
+		if shown && chpx != nil {
+			grpprl, err := chpx.data(ch.FC)
+			if err != nil {
+				return err
+			}
+			operand, ok := structures.FindSprm(grpprl, sprmCFVanish)
+			shown = !ok || len(operand) == 0 || operand[0] == 0
+		}
+		for len(visible) < int(ch.CP) {
+			visible = append(visible, false)
+		}
+		visible = append(visible, shown)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []structures.HyperlinkField
+	for _, link := range links {
+		for cp := link.resultStart; cp < link.resultEnd && int(cp) < len(visible); cp++ {
+			if visible[cp] {
+				result = append(result, link.HyperlinkField)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// MarkdownText returns the text of the main document with its fields
+// replaced by their results, each hyperlink written as a Markdown link,
+// [text](url), and each paragraph mark as a paragraph break. A link to a
+// bookmark, given with the \l switch, becomes a fragment such as
+// [text](#Section1). Fields nested in the code or result of another field are
+// rendered first, so a hyperlink inside the result of another field is kept
+// and a field computing part of a hyperlink's code contributes its result.
+// A link without display text shows its target.
+func (d *Document) MarkdownText() (string, error) {
+	text, err := d.Text()
+	if err != nil {
+		return "", err
+	}
+	fields, err := d.Fields(SubdocumentMain)
+	if err != nil {
+		return "", err
+	}
+
+	units := utf16.Encode([]rune(text))
+	if ccpText := int(d.fib.FibRgLw.CcpText); ccpText > 0 && ccpText < len(units) {
+		units = units[:ccpText]
+	}
+	r := &markdownRenderer{units: units, fields: fields}
+	return strings.ReplaceAll(r.render(0, uint32(len(units))), "\r", "\n\n"), nil
+}
+
+// markdownRenderer renders text and fields for MarkdownText. fields is in
+// order of the begin characters and next is the first field not yet reached.
+type markdownRenderer struct {
+	units  []uint16
+	fields []*Field
+	next   int
+}
+
+// render returns the text of the CPs from `from` to `to`, with the fields
+// beginning there rendered as their results.
+func (r *markdownRenderer) render(from, to uint32) string {
+	to = min(to, uint32(len(r.units)))
+	if from >= to {
+		return ""
+	}
+	var b strings.Builder
+	plain := from
+	for cp := from; cp < to; {
+		// Skip fields left behind, such as those overlapping a field already
+		// rendered
+		for r.next < len(r.fields) && uint32(r.fields[r.next].Start) < cp {
+			r.next++
+		}
+		if r.next < len(r.fields) && uint32(r.fields[r.next].Start) == cp {
+			field := r.fields[r.next]
+			r.next++
+			// Fields missing their end character are left as text
+			if field.ResultEnd > field.Start && uint32(field.ResultEnd) < to {
+				b.WriteString(string(utf16.Decode(r.units[plain:cp])))
+				b.WriteString(r.field(field))
+				cp = uint32(field.ResultEnd) + 1
+				plain = cp
+				continue
+			}
+		}
+		cp++
+	}
+	b.WriteString(string(utf16.Decode(r.units[plain:to])))
+	return b.String()
+}
+
+// field renders a field: a hyperlink as a Markdown link, any other field as
+// its result.
+func (r *markdownRenderer) field(field *Field) string {
+	// The code lies between the begin character and the separator, the
+	// result between the separator and the end character
+	code := r.render(uint32(field.Start)+1, uint32(field.End))
+	var result string
+	if field.End < field.ResultEnd {
+		result = r.render(uint32(field.End)+1, uint32(field.ResultEnd))
+	}
+
+	url, ok := hyperlinkURL(code)
+	if !ok {
+		return result
+	}
+	if strings.TrimSpace(result) == "" {
+		result = url
+	}
+	return "[" + result + "](" + url + ")"
+}
+
+// hyperlinkURL returns the target of a HYPERLINK field code such as
+// `HYPERLINK "http://example.com" \o "Tip"`. A bookmark given with \l is
+// appended as a fragment.
+func hyperlinkURL(code string) (string, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) < len("HYPERLINK") || !strings.EqualFold(code[:len("HYPERLINK")], "HYPERLINK") {
+		return "", false
+	}
+
+	var url, anchor string
+	args := fieldArguments(code[len("HYPERLINK"):])
+	for i := 0; i < len(args); i++ {
+		switch {
+		case strings.EqualFold(args[i], `\l`) && i+1 < len(args):
+			anchor = args[i+1]
+			i++
+		case strings.EqualFold(args[i], `\o`) || strings.EqualFold(args[i], `\t`):
+			i++ // Skip the tooltip or target frame
+		case strings.HasPrefix(args[i], `\`):
+			// Switches without an argument, such as \m and \n
+		case url == "":
+			url = args[i]
+		}
+	}
+	if anchor != "" {
+		url += "#" + anchor
+	}
+	return url, url != ""
+}
+
+// fieldArguments splits the arguments of a field code on spaces, keeping
+// quoted arguments together without their quotes.
+func fieldArguments(s string) []string {
+	var args []string
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return args
+		}
+		if s[0] == '"' {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return append(args, s[1:]) // Unterminated quote
+			}
+			args = append(args, s[1:end+1])
+			s = s[end+2:]
+			continue
+		}
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			return append(args, s)
+		}
+		args = append(args, s[:end])
+		s = s[end:]
+	}
+}
@@ -0,0 +1,116 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// Paragraph sprms placing a paragraph in a list.
+const (
+	sprmPIlvl = 0x260A // List level of the paragraph
+	sprmPIlfo = 0x460B // List instance of the paragraph, 1-based
+)
+
+// ListLevel is the numbering of one level of a list instance.
+type ListLevel struct {
+	Start      int    // Number of the first item at this level
+	Format     uint8  // Number format code (nfc), such as 0 for decimal or 23 for bullets
+	NumberText string // Number text template; characters 0-8 stand for the numbers of those levels
+}
+
+// List is a list instance as referenced by the paragraphs in it: the levels
+// of its list definition with the instance's overrides applied.
+type List struct {
+	ID     int32       // Identifier (lsid) of the list definition
+	Levels []ListLevel // Nine levels, or one for a simple list
+}
+
+// Lists returns the list instances of the document. A paragraph with
+// ListInstance n belongs to the list at index n-1.
+//
+// An instance may restart the numbering of a level or replace the level
+// altogether; both overrides are applied, so a list restarted at 5 reports
+// a level start of 5. Returns nil if the document has no lists.
+func (d *Document) Lists() ([]List, error) {
+	rgfc := d.fib.RgFcLcb
+	if rgfc.LcbPlfLst == 0 || rgfc.LcbPlfLfo == 0 {
+		return nil, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(rgfc.FcPlfLst)+uint64(rgfc.LcbPlfLst) > uint64(len(table.Data)) {
+		return nil, fmt.Errorf("table stream too small for list definitions")
+	}
+	if uint64(rgfc.FcPlfLfo)+uint64(rgfc.LcbPlfLfo) > uint64(len(table.Data)) {
+		return nil, fmt.Errorf("table stream too small for list instances")
+	}
+
+	// The levels of the definitions follow the PlfLst, outside lcbPlfLst
+	definitions, err := structures.ParsePlfLst(table.Data[rgfc.FcPlfLst:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse list definitions: %w", err)
+	}
+	instances, err := structures.ParsePlfLfo(table.Data[rgfc.FcPlfLfo : rgfc.FcPlfLfo+rgfc.LcbPlfLfo])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse list instances: %w", err)
+	}
+
+	byID := make(map[int32]*structures.LSTF, len(definitions))
+	for _, def := range definitions {
+		byID[def.Lsid] = def
+	}
+
+	lists := make([]List, 0, len(instances))
+	for _, lfo := range instances {
+		list := List{ID: lfo.Lsid}
+		if def := byID[lfo.Lsid]; def != nil {
+			for _, lvl := range def.Levels {
+				list.Levels = append(list.Levels, listLevel(lvl))
+			}
+		}
+		for _, override := range lfo.Overrides {
+			if int(override.Level) >= len(list.Levels) {
+				continue
+			}
+			level := &list.Levels[override.Level]
+			if override.LVL != nil {
+				*level = listLevel(override.LVL)
+			}
+			if override.HasStartAt {
+				level.Start = int(override.StartAt)
+			}
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// listLevel converts an LVL to a ListLevel.
+func listLevel(lvl *structures.LVL) ListLevel {
+	return ListLevel{
+		Start:      int(lvl.StartAt),
+		Format:     lvl.Nfc,
+		NumberText: lvl.NumberText,
+	}
+}
+
+// applyListSprms sets the list instance and level of a paragraph from its
+// PAPX, which starts with the 2-byte style index followed by the sprms.
+func applyListSprms(para *Paragraph, papx []byte) {
+	if len(papx) < 2 {
+		return
+	}
+	grpprl := papx[2:]
+
+	if operand, ok := structures.FindSprm(grpprl, sprmPIlfo); ok && len(operand) >= 2 {
+		para.ListInstance = int(int16(binary.LittleEndian.Uint16(operand)))
+	}
+	if operand, ok := structures.FindSprm(grpprl, sprmPIlvl); ok && len(operand) > 0 {
+		para.ListLevel = int(operand[0])
+	}
+}
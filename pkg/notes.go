@@ -0,0 +1,128 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// noteRefChar marks the position of an automatically numbered footnote or
+// endnote reference and starts the text of each such note.
+const noteRefChar = 0x02
+
+// frdSize is the size of the FRD data elements of the note reference PLCs.
+const frdSize = 2
+
+// Footnote is a footnote attached to the main document.
+type Footnote struct {
+	CP           uint32 // Character position of the reference mark in the main document
+	Text         string // Note text without the reference mark and final paragraph mark
+	AutoNumbered bool   // The reference mark is a number Word assigns, not a custom mark
+}
+
+// Endnote is an endnote attached to the main document.
+type Endnote Footnote
+
+// Footnotes returns the footnotes of the document in reference order.
+// Returns nil if the document has no footnotes.
+func (d *Document) Footnotes() ([]Footnote, error) {
+	rgfc := d.fib.RgFcLcb
+	return d.notes(SubdocumentFootnote, "footnote",
+		rgfc.FcPlcffndRef, rgfc.LcbPlcffndRef, rgfc.FcPlcffndTxt, rgfc.LcbPlcffndTxt)
+}
+
+// Endnotes returns the endnotes of the document in reference order. Returns
+// nil if the document has no endnotes.
+func (d *Document) Endnotes() ([]Endnote, error) {
+	rgfc := d.fib.RgFcLcb
+	notes, err := d.notes(SubdocumentEndnote, "endnote",
+		rgfc.FcPlcfendRef, rgfc.LcbPlcfendRef, rgfc.FcPlcfendTxt, rgfc.LcbPlcfendTxt)
+	if err != nil || notes == nil {
+		return nil, err
+	}
+	endnotes := make([]Endnote, len(notes))
+	for i, note := range notes {
+		endnotes[i] = Endnote(note)
+	}
+	return endnotes, nil
+}
+
+// notes reads the notes of subdocument sub. The reference PLC holds the CP
+// of each reference mark in the main document with an FRD telling whether
+// the mark is numbered automatically; the text PLC holds the CPs delimiting
+// each note's text within the subdocument.
+func (d *Document) notes(sub Subdocument, name string, refFc, refLcb, txtFc, txtLcb uint32) ([]Footnote, error) {
+	if refLcb == 0 {
+		return nil, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	slice := func(fc, lcb uint32, what string) ([]byte, error) {
+		if uint64(fc)+uint64(lcb) > uint64(len(table.Data)) {
+			return nil, fmt.Errorf("table stream too small for %s", what)
+		}
+		return table.Data[fc : fc+lcb], nil
+	}
+
+	refData, err := slice(refFc, refLcb, name+" references")
+	if err != nil {
+		return nil, err
+	}
+	refs, err := structures.ParsePLC(refData, frdSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s references: %w", name, err)
+	}
+	if refs.Count() == 0 {
+		return nil, nil
+	}
+
+	var textCPs []uint32
+	if txtLcb > 0 {
+		txtData, err := slice(txtFc, txtLcb, name+" text table")
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+4 <= len(txtData); i += 4 {
+			textCPs = append(textCPs, binary.LittleEndian.Uint32(txtData[i:]))
+		}
+	}
+
+	text, err := d.Text()
+	if err != nil {
+		return nil, err
+	}
+	units := utf16.Encode([]rune(text))
+	start := d.subdocumentStart(sub)
+
+	notes := make([]Footnote, 0, refs.Count())
+	for i := 0; i < refs.Count(); i++ {
+		cp, _, err := refs.GetRange(i)
+		if err != nil {
+			return nil, err
+		}
+		frd, err := refs.GetDataAt(i)
+		if err != nil {
+			return nil, err
+		}
+
+		note := Footnote{CP: uint32(cp), AutoNumbered: int16(binary.LittleEndian.Uint16(frd)) > 0}
+		if i+1 < len(textCPs) {
+			from, to := start+textCPs[i], start+textCPs[i+1]
+			if from <= to && int(to) <= len(units) {
+				body := string(utf16.Decode(units[from:to]))
+				if note.AutoNumbered {
+					body = strings.TrimPrefix(body, string(rune(noteRefChar)))
+				}
+				note.Text = strings.TrimSuffix(body, "\r")
+			}
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
@@ -0,0 +1,355 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// sprmCPicLocation gives the Data stream offset of the PICF of a picture
+// anchor character or, for an OLE object, the identifier of its storage.
+const sprmCPicLocation = 0x6A03
+
+// sprmCFSpec marks a character as special: a picture or object anchor, an
+// automatically numbered footnote reference, a field character and so on.
+const sprmCFSpec = 0x0855
+
+// sprmCFOle2 marks an object anchor character as an OLE object stored in the
+// ObjectPool storage.
+const sprmCFOle2 = 0x080A
+
+// sprmCFData marks an object anchor character whose sprmCPicLocation points to
+// binary data, such as that of a hyperlink or form field, rather than to a
+// picture.
+const sprmCFData = 0x0806
+
+// objectAnchorChar marks the position of a picture or embedded object in the
+// text, and drawnObjectChar the anchor of a floating shape. Both are only
+// placeholders when the character is special.
+const (
+	objectAnchorChar = 0x01
+	drawnObjectChar  = 0x08
+)
+
+// objectAnchor is an object anchor character of the main document.
+type objectAnchor struct {
+	CP uint32 // Position of the anchor character
+	FC uint32 // Offset of the anchor character in the WordDocument stream
+}
+
+// loadObjects loads the embedded objects, then moves each to the CP of its
+// anchor character and fills in its display size from the anchor's picture
+// header.
+func (d *Document) loadObjects() error {
+	if err := d.objectPool.LoadObjects(); err != nil {
+		return err
+	}
+
+	objs := d.objectPool.GetAllObjects()
+	if len(objs) == 0 {
+		return nil
+	}
+
+	placements, err := d.objectPlacements()
+	if err != nil {
+		// Placements are optional; the objects themselves are still usable
+		return nil
+	}
+
+	// Objects are stored in the pool in the order their anchors appear in text
+	positions := make([]uint32, 0, len(objs))
+	for position := range objs {
+		positions = append(positions, position)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+
+	for _, position := range positions {
+		placements.place(objs[position])
+	}
+	d.objectPool.Reindex()
+	return nil
+}
+
+// objectPlacement is the anchor of an embedded object in the main document.
+type objectPlacement struct {
+	CP            uint32 // Position of the anchor character
+	Width, Height int32  // Display size in twips, zero if unknown
+}
+
+// objectPlacements matches embedded objects with their anchor characters.
+// OLE objects in ObjectPool storages are matched by the storage identifier
+// their anchor records; other objects take the remaining anchors in order.
+type objectPlacements struct {
+	byID    map[uint32]objectPlacement
+	inOrder []objectPlacement
+	next    int
+}
+
+// place sets the position of obj to the CP of its anchor, and its display
+// size to the anchor's. Objects without an anchor are left unchanged.
+func (p *objectPlacements) place(obj *EmbeddedObject) {
+	placement, ok := p.byID[obj.Position]
+	if !ok {
+		if p.next >= len(p.inOrder) {
+			return
+		}
+		placement = p.inOrder[p.next]
+		p.next++
+	}
+	obj.Position = placement.CP
+	obj.DisplayWidthTwips, obj.DisplayHeightTwips = placement.Width, placement.Height
+}
+
+// objectPlacements returns the anchors of the embedded objects in the main
+// document, in CP order.
+func (d *Document) objectPlacements() (*objectPlacements, error) {
+	placements := &objectPlacements{byID: make(map[uint32]objectPlacement)}
+	anchors, err := d.objectAnchors()
+	if err != nil || len(anchors) == 0 {
+		return placements, err
+	}
+
+	chpx, err := d.characterFKPs()
+	if err != nil {
+		return nil, err
+	}
+	dataStream, _ := d.reader.ReadStream("Data")
+
+	for _, anchor := range anchors {
+		grpprl, err := chpx.data(anchor.FC)
+		if err != nil {
+			return nil, err
+		}
+		placement := objectPlacement{CP: anchor.CP}
+		location, hasLocation := structures.FindSprm(grpprl, sprmCPicLocation)
+		hasLocation = hasLocation && len(location) >= 4
+		if operand, ok := structures.FindSprm(grpprl, sprmCFOle2); ok && len(operand) > 0 && operand[0] != 0 && hasLocation {
+			placements.byID[binary.LittleEndian.Uint32(location)] = placement
+			continue
+		}
+
+		// The picture header is optional, so a missing or damaged one only
+		// leaves the size unknown
+		if hasLocation {
+			if fcPic := binary.LittleEndian.Uint32(location); fcPic < uint32(len(dataStream)) {
+				if picf, err := structures.ParsePICF(dataStream[fcPic:]); err == nil {
+					placement.Width, placement.Height = picf.DisplaySize()
+				}
+			}
+		}
+		placements.inOrder = append(placements.inOrder, placement)
+	}
+	return placements, nil
+}
+
+// pictureHeader is the picture header of an object anchor character.
+type pictureHeader struct {
+	Anchor objectAnchor
+	PICF   *structures.PICF
+	Data   []byte // Data stream from the start of the PICF on
+}
+
+// pictureHeaders returns the picture headers of the object anchors in the
+// main document that have one, in CP order. Anchors whose location holds
+// other data, and damaged headers, are skipped.
+func (d *Document) pictureHeaders() ([]pictureHeader, error) {
+	anchors, err := d.objectAnchors()
+	if err != nil || len(anchors) == 0 {
+		return nil, err
+	}
+
+	dataStream, err := d.reader.ReadStream("Data")
+	if err != nil {
+		return nil, nil // No Data stream means no picture headers
+	}
+
+	chpx, err := d.characterFKPs()
+	if err != nil || chpx == nil {
+		return nil, err
+	}
+
+	var pictures []pictureHeader
+	for _, anchor := range anchors {
+		grpprl, err := chpx.data(anchor.FC)
+		if err != nil {
+			return nil, err
+		}
+		operand, ok := structures.FindSprm(grpprl, sprmCPicLocation)
+		if !ok || len(operand) < 4 {
+			continue
+		}
+		if data, ok := structures.FindSprm(grpprl, sprmCFData); ok && len(data) > 0 && data[0] != 0 {
+			continue
+		}
+
+		fcPic := binary.LittleEndian.Uint32(operand)
+		if fcPic >= uint32(len(dataStream)) {
+			continue
+		}
+		picf, err := structures.ParsePICF(dataStream[fcPic:])
+		if err != nil {
+			continue
+		}
+		pictures = append(pictures, pictureHeader{Anchor: anchor, PICF: picf, Data: dataStream[fcPic:]})
+	}
+	return pictures, nil
+}
+
+// objectAnchors returns the object anchor characters of the main document:
+// the 0x01 characters whose CHPX sets fSpec.
+func (d *Document) objectAnchors() ([]objectAnchor, error) {
+	return d.specialChars(objectAnchorChar)
+}
+
+// specialChars returns the characters of the main document that are one of
+// chars and are marked special by their CHPX, in CP order. Returns nil if the
+// document has no character formatting.
+func (d *Document) specialChars(chars ...uint16) ([]objectAnchor, error) {
+	chpx, err := d.characterFKPs()
+	if err != nil || chpx == nil {
+		return nil, err
+	}
+
+	var found []objectAnchor
+	err = d.walkMainText(func(ch mainChar) error {
+		for _, c := range chars {
+			if ch.Units[0] != c {
+				continue
+			}
+			grpprl, err := chpx.data(ch.FC)
+			if err != nil {
+				return err
+			}
+			if operand, ok := structures.FindSprm(grpprl, sprmCFSpec); ok && len(operand) > 0 && operand[0] != 0 {
+				found = append(found, objectAnchor{CP: ch.CP, FC: ch.FC})
+			}
+			break
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// characterFKPs returns a reader for the CHPX FKPs of the document, or nil if
+// the document has no character bin table.
+func (d *Document) characterFKPs() (*fkpReader, error) {
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	plc, err := table.GetCharacterFormattingTable(d.fib.RgFcLcb.FcPlcfbteChpx, d.fib.RgFcLcb.LcbPlcfbteChpx)
+	if err != nil || plc == nil {
+		return nil, err
+	}
+
+	wordStream, err := d.wordDocument()
+	if err != nil {
+		return nil, err
+	}
+	return newFKPReader(plc, structures.FKPTypeCHP, wordStream), nil
+}
+
+// fkpReader looks up FKP entries through a bin table PLC. Each FKP page is
+// parsed once, so repeated lookups over a run of text stay cheap.
+type fkpReader struct {
+	plc        *structures.PLC
+	fkpType    structures.FKPType
+	wordStream []byte
+	pages      map[uint32]*structures.FKP
+}
+
+// newFKPReader creates an fkpReader reading FKP pages from wordStream.
+func newFKPReader(plc *structures.PLC, fkpType structures.FKPType, wordStream []byte) *fkpReader {
+	return &fkpReader{
+		plc:        plc,
+		fkpType:    fkpType,
+		wordStream: wordStream,
+		pages:      make(map[uint32]*structures.FKP),
+	}
+}
+
+// data returns the data of the FKP entry covering fc, or nil if there is none.
+func (r *fkpReader) data(fc uint32) ([]byte, error) {
+	data, _, err := r.run(fc)
+	return data, err
+}
+
+// run returns the data of the FKP entry covering fc, or nil if there is none,
+// and the FC at which that data stops applying: the start of the next entry,
+// or of the next BTE for an fc no BTE covers. The end is math.MaxUint32 if
+// nothing follows.
+func (r *fkpReader) run(fc uint32) (data []byte, end uint32, err error) {
+	end = math.MaxUint32
+	for i := 0; i < r.plc.Count(); i++ {
+		start, limit, err := r.plc.GetRange(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		if fc < uint32(start) {
+			end = min(end, uint32(start))
+			continue
+		}
+		if fc >= uint32(limit) {
+			continue
+		}
+
+		fkp, err := r.page(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		end = uint32(limit)
+		var entry *structures.FKPEntry
+		for j := range fkp.Entries {
+			if fkp.Entries[j].FC > fc {
+				end = min(end, fkp.Entries[j].FC)
+				break
+			}
+			entry = &fkp.Entries[j]
+		}
+		if entry == nil {
+			return nil, end, nil
+		}
+		return entry.Data, end, nil
+	}
+	return nil, end, nil
+}
+
+// page returns the FKP page that the i-th BTE of the bin table points to.
+func (r *fkpReader) page(i int) (*structures.FKP, error) {
+	bte, err := r.plc.GetDataAt(i)
+	if err != nil {
+		return nil, err
+	}
+	pageOffset := (binary.LittleEndian.Uint32(bte) & 0x003FFFFF) * structures.FKPSize
+	if fkp, ok := r.pages[pageOffset]; ok {
+		return fkp, nil
+	}
+	if pageOffset+structures.FKPSize > uint32(len(r.wordStream)) {
+		return nil, fmt.Errorf("FKP page at %d out of bounds", pageOffset)
+	}
+	fkp, err := structures.ParseFKP(r.wordStream[pageOffset:pageOffset+structures.FKPSize], r.fkpType)
+	if err != nil {
+		return nil, err
+	}
+	r.pages[pageOffset] = fkp
+	return fkp, nil
+}
+
+// all returns the FKP pages of the bin table in order, one per BTE.
+func (r *fkpReader) all() ([]*structures.FKP, error) {
+	pages := make([]*structures.FKP, r.plc.Count())
+	for i := range pages {
+		fkp, err := r.page(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read FKP %d: %w", i, err)
+		}
+		pages[i] = fkp
+	}
+	return pages, nil
+}
@@ -0,0 +1,61 @@
+package msdoc
+
+import "strings"
+
+// maxMarkdownHeading is the deepest heading level Markdown can express.
+const maxMarkdownHeading = 6
+
+// OutlineEntry is a heading of the main document.
+type OutlineEntry struct {
+	Level   int    // Heading level, 1 for top-level headings up to 9
+	Text    string // Heading text
+	StartCP uint32 // Character position of the heading paragraph
+}
+
+// Outline returns the headings of the main document in order: the paragraphs
+// whose effective outline level, from their style or direct formatting, is
+// not body text. Headings without text are left out. Returns nil if the
+// document has no headings.
+func (d *Document) Outline() ([]OutlineEntry, error) {
+	paragraphs, err := d.Paragraphs()
+	if err != nil {
+		return nil, err
+	}
+
+	var outline []OutlineEntry
+	for _, para := range paragraphs {
+		if para.Properties == nil || para.Properties.OutlineLevel >= bodyTextLevel || para.IsRowEnd {
+			continue
+		}
+		text := strings.TrimSpace(strings.ReplaceAll(para.Text, "\v", " "))
+		if text == "" {
+			continue
+		}
+		outline = append(outline, OutlineEntry{
+			Level:   int(para.Properties.OutlineLevel) + 1,
+			Text:    text,
+			StartCP: para.StartCP,
+		})
+	}
+	return outline, nil
+}
+
+// OutlineMarkdown renders the outline of the document as Markdown headings,
+// one per line, such as "# Introduction" and "## Background". Levels deeper
+// than Markdown's six are rendered at level six. Returns an empty string if
+// the document has no headings.
+func (d *Document) OutlineMarkdown() (string, error) {
+	outline, err := d.Outline()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, entry := range outline {
+		b.WriteString(strings.Repeat("#", min(entry.Level, maxMarkdownHeading)))
+		b.WriteByte(' ')
+		b.WriteString(entry.Text)
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
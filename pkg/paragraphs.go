@@ -0,0 +1,210 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/formatting"
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// Paragraph sprms describing table membership.
+const (
+	sprmPFInTable  = 0x2416 // Paragraph is in a table
+	sprmPFTtp      = 0x2417 // Paragraph ends a table row
+	sprmPFInnerTtp = 0x244C // Paragraph ends a row of a nested table
+	sprmPItap      = 0x6649 // Table nesting depth
+)
+
+// Paragraph is a paragraph of the main document together with its table
+// membership as recorded in its PAPX.
+type Paragraph struct {
+	Text       string // Paragraph text without its paragraph or cell mark
+	StartCP    uint32 // Character position of the first character
+	EndCP      uint32 // Character position just past the paragraph mark
+	InTable    bool   // Paragraph is part of a table
+	TableDepth int    // Table nesting depth, 0 outside tables
+	IsRowEnd   bool   // Paragraph is the end-of-row mark of a table row
+	BiDi       bool   // Paragraph is right-to-left
+	StyleName  string // Name of the paragraph style, empty without a style sheet
+
+	// ListInstance is the 1-based index of the paragraph's list in Lists, 0
+	// if the paragraph is not in a list. ListLevel is its level in the list.
+	ListInstance int
+	ListLevel    int
+
+	// Properties is the effective paragraph formatting: the formatting of
+	// the paragraph style and the styles it is based on, overridden by the
+	// direct formatting of the paragraph. CharacterProperties is the
+	// character formatting the paragraph style gives the paragraph's text,
+	// before any direct character formatting.
+	Properties          *formatting.ParagraphProperties
+	CharacterProperties *formatting.CharacterProperties
+
+	mark uint16          // Character ending the paragraph, 0 after the last paragraph mark
+	tap  *structures.TAP // Table properties of the row ended by an end-of-row mark
+}
+
+// Paragraphs returns the paragraphs of the main document in order. A
+// paragraph ends with a paragraph mark (0x0D), a section mark (0x0C at the end
+// of a section) or, inside a table, with a cell mark (0x07); a row's
+// end-of-row mark forms a paragraph of its own with an empty text and
+// IsRowEnd set.
+//
+// Documents without a paragraph bin table report every paragraph as body text
+// in the Normal style.
+func (d *Document) Paragraphs() ([]Paragraph, error) {
+	papx, err := d.paragraphFKPs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Styles are best effort, so a damaged style sheet leaves style names
+	// empty and paragraphs with the built-in defaults
+	stsh, _ := d.styleSheet()
+	styleName := func(istd uint16) string {
+		if stsh == nil {
+			return ""
+		}
+		if std := stsh.Style(istd); std != nil {
+			return std.Name
+		}
+		return ""
+	}
+	charProps := map[uint16]*formatting.CharacterProperties{}
+	styleCharProps := func(istd uint16) *formatting.CharacterProperties {
+		if props, ok := charProps[istd]; ok {
+			return props
+		}
+		props := styleCharacterProperties(stsh, istd)
+		charProps[istd] = props
+		return props
+	}
+
+	// Page breaks share the section mark character, so only 0x0C characters
+	// ending a section end a paragraph
+	sectionEnds := map[uint32]bool{}
+	if sections, err := d.Sections(); err == nil {
+		for _, section := range sections {
+			sectionEnds[section.EndCP] = true
+		}
+	}
+
+	var paragraphs []Paragraph
+	var units []uint16
+	paraStart, nextCP := uint32(0), uint32(0)
+
+	err = d.walkMainText(func(ch mainChar) error {
+		nextCP = ch.CP + 1
+		isSectionMark := ch.Units[0] == 0x0C && sectionEnds[ch.CP+1]
+		if ch.Units[0] != '\r' && ch.Units[0] != 0x07 && !isSectionMark {
+			units = append(units, ch.Units...)
+			return nil
+		}
+
+		para := Paragraph{
+			Text:      string(utf16.Decode(units)),
+			StartCP:   paraStart,
+			EndCP:     ch.CP + 1,
+			StyleName: styleName(structures.IstdNormal),
+			mark:      ch.Units[0],
+		}
+		var data []byte
+		if papx != nil {
+			var err error
+			if data, err = papx.data(ch.FC); err != nil {
+				return fmt.Errorf("failed to read PAPX for paragraph at CP %d: %w", paraStart, err)
+			}
+		}
+		istd := uint16(structures.IstdNormal)
+		if len(data) >= 2 {
+			istd = binary.LittleEndian.Uint16(data)
+			para.StyleName = styleName(istd)
+		}
+		para.Properties = paragraphProperties(stsh, data)
+		para.CharacterProperties = styleCharProps(istd)
+		applyTableSprms(&para, data)
+		applyBiDiSprm(&para, data)
+		applyListSprms(&para, data)
+		paragraphs = append(paragraphs, para)
+
+		units = units[:0]
+		paraStart = ch.CP + 1
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Text after the last paragraph mark
+	if len(units) > 0 {
+		paragraphs = append(paragraphs, Paragraph{
+			Text:                string(utf16.Decode(units)),
+			StartCP:             paraStart,
+			EndCP:               nextCP,
+			StyleName:           styleName(structures.IstdNormal),
+			Properties:          paragraphProperties(stsh, nil),
+			CharacterProperties: styleCharProps(structures.IstdNormal),
+		})
+	}
+	return paragraphs, nil
+}
+
+// paragraphFKPs returns a reader for the PAPX FKPs of the document, or nil if
+// the document has no paragraph bin table.
+func (d *Document) paragraphFKPs() (*fkpReader, error) {
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, nil
+	}
+	plc, err := table.GetParagraphFormattingTable(d.fib.RgFcLcb.FcPlcfbtePapx, d.fib.RgFcLcb.LcbPlcfbtePapx)
+	if err != nil || plc == nil {
+		return nil, err
+	}
+
+	wordStream, err := d.wordDocument()
+	if err != nil {
+		return nil, err
+	}
+	return newFKPReader(plc, structures.FKPTypePAP, wordStream), nil
+}
+
+// applyTableSprms sets the table flags of a paragraph from its PAPX, which
+// starts with the 2-byte style index followed by the sprms.
+func applyTableSprms(para *Paragraph, papx []byte) {
+	if len(papx) < 2 {
+		return
+	}
+	grpprl := papx[2:]
+
+	if operand, ok := structures.FindSprm(grpprl, sprmPFInTable); ok && len(operand) > 0 && operand[0] != 0 {
+		para.InTable = true
+		para.TableDepth = 1
+	}
+	if operand, ok := structures.FindSprm(grpprl, sprmPItap); ok && len(operand) >= 4 {
+		para.TableDepth = int(int32(binary.LittleEndian.Uint32(operand)))
+		para.InTable = para.TableDepth > 0
+	}
+	if operand, ok := structures.FindSprm(grpprl, sprmPFTtp); ok && len(operand) > 0 && operand[0] != 0 {
+		para.IsRowEnd = true
+	}
+	if operand, ok := structures.FindSprm(grpprl, sprmPFInnerTtp); ok && len(operand) > 0 && operand[0] != 0 {
+		para.IsRowEnd = true
+	}
+	if para.IsRowEnd {
+		// The cell layout is best effort; a damaged definition leaves the
+		// row without properties
+		para.tap, _ = structures.ParseTAP(grpprl)
+	}
+}
+
+// applyBiDiSprm sets the right-to-left flag of a paragraph from its PAPX.
+func applyBiDiSprm(para *Paragraph, papx []byte) {
+	if len(papx) < 2 {
+		return
+	}
+	if operand, ok := structures.FindSprm(papx[2:], sprmPFBiDi); ok && len(operand) > 0 {
+		para.BiDi = operand[0] != 0
+	}
+}
@@ -0,0 +1,103 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// ProofingKind identifies the proofing tool that flagged a range.
+type ProofingKind int
+
+const (
+	ProofingSpelling ProofingKind = iota // Flagged by the spelling checker
+	ProofingGrammar                      // Flagged by the grammar checker
+)
+
+// String returns the name of the proofing tool.
+func (k ProofingKind) String() string {
+	switch k {
+	case ProofingSpelling:
+		return "Spelling"
+	case ProofingGrammar:
+		return "Grammar"
+	default:
+		return "Unknown"
+	}
+}
+
+// ProofingRange is a range of the main document that Word marked as a
+// spelling or grammar error when the document was saved.
+type ProofingRange struct {
+	StartCP uint32       // First character position of the range
+	EndCP   uint32       // Character position just past the end of the range
+	Kind    ProofingKind // Proofing tool that flagged the range
+}
+
+// splfErrorMin is the lowest SPLS state that marks an error; the states below
+// it describe ranges that are clean or not yet checked.
+const splfErrorMin = 0x0A
+
+// ProofingErrors returns the ranges of the main document marked as spelling
+// or grammar errors, ordered by position. The marks reflect the state of the
+// proofing tools when the document was last saved; the text is unaffected.
+func (d *Document) ProofingErrors() ([]ProofingRange, error) {
+	var ranges []ProofingRange
+
+	tables := []struct {
+		kind    ProofingKind
+		fc, lcb uint32
+	}{
+		{ProofingSpelling, d.fib.RgFcLcb.FcPlcfspl, d.fib.RgFcLcb.LcbPlcfspl},
+		{ProofingGrammar, d.fib.RgFcLcb.FcPlcfgram, d.fib.RgFcLcb.LcbPlcfgram},
+	}
+	for _, table := range tables {
+		found, err := d.proofingErrors(table.kind, table.fc, table.lcb)
+		if err != nil {
+			return nil, fmt.Errorf("%s proofing table: %w", table.kind, err)
+		}
+		ranges = append(ranges, found...)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].StartCP < ranges[j].StartCP })
+	return ranges, nil
+}
+
+// proofingErrors returns the error ranges of a PlcfSpl or PlcfGram, whose
+// 2-byte data elements hold the proofing state in their low 4 bits.
+func (d *Document) proofingErrors(kind ProofingKind, fc, lcb uint32) ([]ProofingRange, error) {
+	if lcb == 0 {
+		return nil, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(fc)+uint64(lcb) > uint64(len(table.Data)) {
+		return nil, fmt.Errorf("table stream too small for proofing data")
+	}
+
+	plc, err := structures.ParsePLC(table.Data[fc:fc+lcb], 2)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []ProofingRange
+	for i := 0; i < plc.Count(); i++ {
+		start, end, err := plc.GetRange(i)
+		if err != nil {
+			return nil, err
+		}
+		data, err := plc.GetDataAt(i)
+		if err != nil {
+			return nil, err
+		}
+		if binary.LittleEndian.Uint16(data)&0x000F >= splfErrorMin {
+			ranges = append(ranges, ProofingRange{StartCP: uint32(start), EndCP: uint32(end), Kind: kind})
+		}
+	}
+	return ranges, nil
+}
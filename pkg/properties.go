@@ -0,0 +1,305 @@
+package msdoc
+
+import (
+	"fmt"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/streams"
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// tableStream returns the document's table stream as a logical stream that
+// FIB offsets such as FcClx index directly. For encrypted documents the whole
+// stream is decrypted in place, its blocks numbered from the start of the
+// stream, except for the encryption header at its start, which is stored
+// unencrypted and kept as is. Offsets therefore mean the same with and
+// without encryption, and callers never need to account for it. The stream
+// is decrypted once and kept until the document is replaced; callers must
+// not modify it.
+func (d *Document) tableStream() (*streams.TableStream, error) {
+	if d.table != nil {
+		return d.table, nil
+	}
+	table, err := d.rawTableStream()
+	if err != nil || !d.fib.IsEncrypted() {
+		return table, err
+	}
+	if d.decryptor == nil {
+		return nil, fmt.Errorf("document is encrypted but decryption is not available")
+	}
+
+	headerSize := d.encHeader.Size()
+	if len(table.Data) < headerSize {
+		return nil, fmt.Errorf("table stream too small for encryption header")
+	}
+	decrypted, err := d.decryptor.DecryptAt(table.Data, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt table stream: %w", err)
+	}
+	copy(decrypted, table.Data[:headerSize])
+	d.table = streams.NewTableStream(decrypted, table.Name)
+	return d.table, nil
+}
+
+// rawTableStream reads the document's table stream as stored, falling back to
+// the other table stream if the one named by the FIB is missing.
+func (d *Document) rawTableStream() (*streams.TableStream, error) {
+	name := d.fib.GetTableStreamName()
+	data, err := d.reader.ReadStream(name)
+	if err != nil {
+		alternativeName := "0Table"
+		if name == "0Table" {
+			alternativeName = "1Table"
+		}
+
+		data, err = d.reader.ReadStream(alternativeName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read table stream: %w", err)
+		}
+		name = alternativeName
+	}
+
+	return streams.NewTableStream(data, name), nil
+}
+
+// plainFIBSize is the number of bytes at the start of an encrypted
+// WordDocument stream that are stored unencrypted, so the FibBase stays
+// readable.
+const plainFIBSize = 68
+
+// RawStreams returns the WordDocument stream and the active table stream.
+// For encrypted documents both are decrypted, except for the FibBase and the
+// encryption header at their starts, which are stored unencrypted. FIB
+// offsets index the table stream directly either way.
+//
+// This is meant for debugging and for tools that parse the streams
+// themselves.
+func (d *Document) RawStreams() (wordDoc, table []byte, err error) {
+	wordDoc, err = d.wordDocument()
+	if err != nil {
+		return nil, nil, err
+	}
+	tableStream, err := d.tableStream()
+	if err != nil {
+		return nil, nil, err
+	}
+	return wordDoc, tableStream.Data, nil
+}
+
+// wordDocument returns the WordDocument stream. For encrypted documents the
+// stream is decrypted in place, except for the FibBase at its start, which
+// is stored unencrypted and kept as is.
+func (d *Document) wordDocument() ([]byte, error) {
+	data, err := d.reader.ReadStream("WordDocument")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WordDocument stream: %w", err)
+	}
+	if !d.fib.IsEncrypted() {
+		return data, nil
+	}
+	decrypted, err := d.decryptWordDocument(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	copy(decrypted, data[:min(plainFIBSize, len(data))])
+	return decrypted, nil
+}
+
+// decryptWordDocument decrypts data read from the WordDocument stream at
+// offset. Each 512-byte block of the stream has its own key, so ranges can
+// be decrypted in any order.
+func (d *Document) decryptWordDocument(data []byte, offset uint32) ([]byte, error) {
+	if d.decryptor == nil {
+		return nil, fmt.Errorf("document is encrypted but decryption is not available")
+	}
+	return d.decryptor.DecryptAt(data, offset)
+}
+
+// documentProperties reads the DOP from the table stream.
+// Returns nil with no error if the document has no DOP.
+func (d *Document) documentProperties() (*structures.DOP, error) {
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	return table.GetDOP(d.fib.RgFcLcb.FcDop, d.fib.RgFcLcb.LcbDop)
+}
+
+// applyDOPStatistics replaces the statistics in metadata with those from the
+// DOP. Word keeps the DOP counts up to date on every save, while the
+// SummaryInformation copies may be stale or missing.
+func (d *Document) applyDOPStatistics(metadata *Metadata) {
+	dop, err := d.documentProperties()
+	if err != nil || dop == nil || !dop.HasStatistics() {
+		return
+	}
+
+	metadata.WordCount = dop.CWords
+	metadata.CharCount = dop.CCh
+	metadata.PageCount = int32(dop.CPg)
+	metadata.ParagraphCount = dop.CParas
+	metadata.LineCount = dop.CLines
+	if dop.Version >= structures.DOPVersion97 {
+		metadata.CharCountWithSpaces = dop.CChWS
+	}
+}
+
+// charsPerPage is the number of characters assumed to fill a page when the
+// page count has to be estimated, about 500 words of body text.
+const charsPerPage = 3000
+
+// PageCount returns the number of pages of the document and whether it is the
+// count Word stored on saving, in the DOP or the SummaryInformation.
+//
+// Documents without a stored count get an estimate from the main document
+// text instead: every page or section break starts a new page, and a page
+// holds about 3000 characters. Word 97 and later keep no page boundaries in
+// the file, so the estimate ignores layout such as tables, pictures and the
+// page setup.
+func (d *Document) PageCount() (int, bool, error) {
+	if metadata := d.Metadata(); metadata.PageCount > 0 {
+		return int(metadata.PageCount), true, nil
+	}
+
+	text, err := d.Text()
+	if err != nil {
+		return 0, false, err
+	}
+	units := utf16.Encode([]rune(text))
+	if ccpText := int(d.fib.FibRgLw.CcpText); ccpText > 0 && ccpText < len(units) {
+		units = units[:ccpText]
+	}
+
+	pages, start := 0, 0
+	for i := 0; i <= len(units); i++ {
+		if i == len(units) || units[i] == '\f' {
+			pages += max(1, (i-start+charsPerPage-1)/charsPerPage)
+			start = i + 1
+		}
+	}
+	return pages, false, nil
+}
+
+// DefaultTabStop returns the interval between the default tab stops in
+// twips, as stored in the DOP. Returns 0 if the document has no DOP.
+func (d *Document) DefaultTabStop() (uint16, error) {
+	dop, err := d.documentProperties()
+	if err != nil || dop == nil {
+		return 0, err
+	}
+	return dop.DxaTab, nil
+}
+
+// CompatibilityOptions returns the compatibility options of the document,
+// such as fNoLeading or fDontUseHTMLParagraphAutoSpacing, keyed by their name
+// in the specification. Options missing from the map are not stored in the
+// document's DOP revision. Returns nil if the document has no DOP.
+func (d *Document) CompatibilityOptions() (map[string]bool, error) {
+	dop, err := d.documentProperties()
+	if err != nil || dop == nil {
+		return nil, err
+	}
+	return dop.CompatibilityOptions(), nil
+}
+
+// HasTrackedChanges reports whether the document contains or is recording
+// tracked changes, without parsing the revisions themselves.
+//
+// A document is considered to have tracked changes if revision tracking is
+// turned on in the DOP or if it lists a revision author other than the
+// default "Unknown" author, which Word writes into every document. The DOP's
+// fRMView and fRMPrint flags are not used: they only say how revision marks
+// are shown, and Word sets fRMView in documents without revisions.
+func (d *Document) HasTrackedChanges() bool {
+	table, err := d.tableStream()
+	if err != nil {
+		return false
+	}
+
+	if dop, err := table.GetDOP(d.fib.RgFcLcb.FcDop, d.fib.RgFcLcb.LcbDop); err == nil && dop != nil && dop.FRevMarking {
+		return true
+	}
+
+	authors, err := table.GetRevisionAuthors(d.fib.RgFcLcb.FcSttbfRMark, d.fib.RgFcLcb.LcbSttbfRMark)
+	if err != nil || authors == nil {
+		return false
+	}
+	for _, author := range authors.Strings {
+		if author != defaultRevisionAuthor {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultRevisionAuthor is the author Word lists in SttbfRMark whether or not
+// the document has revisions.
+const defaultRevisionAuthor = "Unknown"
+
+// ProtectionType identifies which kind of editing a protected document allows.
+type ProtectionType int
+
+const (
+	ProtectionNone           ProtectionType = iota // No protection
+	ProtectionReadOnly                             // No changes allowed
+	ProtectionComments                             // Only comments may be added
+	ProtectionTrackedChanges                       // Changes are allowed but always tracked
+	ProtectionForms                                // Only form fields may be filled in
+)
+
+// String returns a human-readable name for the protection type.
+func (p ProtectionType) String() string {
+	switch p {
+	case ProtectionNone:
+		return "None"
+	case ProtectionReadOnly:
+		return "Read-only"
+	case ProtectionComments:
+		return "Comments"
+	case ProtectionTrackedChanges:
+		return "Tracked changes"
+	case ProtectionForms:
+		return "Forms"
+	default:
+		return "Unknown"
+	}
+}
+
+// ProtectionInfo describes the document's editing protection.
+type ProtectionInfo struct {
+	Type        ProtectionType // Kind of editing allowed
+	HasPassword bool           // True if a password is required to remove the protection
+}
+
+// Protection reports the editing protection set with Word's "Protect Document"
+// command.
+//
+// This is unrelated to encryption: a protected document can be read freely,
+// but Word restricts how it may be edited. Documents without a DOP report
+// ProtectionNone.
+func (d *Document) Protection() (ProtectionInfo, error) {
+	dop, err := d.documentProperties()
+	if err != nil {
+		return ProtectionInfo{}, err
+	}
+	if dop == nil {
+		return ProtectionInfo{Type: ProtectionNone}, nil
+	}
+
+	info := ProtectionInfo{Type: ProtectionNone}
+	switch {
+	case dop.FProtEnabled:
+		info.Type = ProtectionForms
+	case dop.FLockRev:
+		info.Type = ProtectionTrackedChanges
+	case dop.FLockAtn && dop.FTreatLockAtnAsReadOnly:
+		info.Type = ProtectionReadOnly
+	case dop.FLockAtn:
+		info.Type = ProtectionComments
+	}
+
+	if info.Type != ProtectionNone {
+		info.HasPassword = dop.LKeyProtDoc != 0
+	}
+	return info, nil
+}
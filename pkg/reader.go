@@ -2,7 +2,12 @@ package msdoc
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 	"unicode/utf16"
@@ -16,6 +21,9 @@ import (
 // from potentially fragmented pieces stored throughout the file. It handles both
 // ANSI and Unicode text encoding as specified in the MS-DOC format.
 //
+// Paragraphs end with "\r" and manual line breaks are returned as "\n". Table
+// cell marks (0x07) are kept; use TextWithOptions to replace them.
+//
 // For encrypted documents, this method will decrypt the content if a password
 // was provided during opening.
 //
@@ -27,140 +35,504 @@ import (
 //
 // For documents with no text content, returns an empty string with no error.
 func (d *Document) Text() (string, error) {
+	return d.text(EndiannessAuto)
+}
+
+// text extracts the plain text content, decoding Unicode pieces with the
+// given byte order.
+func (d *Document) text(order Endianness) (string, error) {
 	// Check if document is encrypted
 	if d.fib.IsEncrypted() {
 		if d.decryptor == nil {
 			return "", fmt.Errorf("document is encrypted but no decryption cipher available")
 		}
-		return d.extractEncryptedText()
+		return d.extractEncryptedText(order)
 	}
 
-	return d.extractUnencryptedText()
+	return d.extractUnencryptedText(order)
 }
 
-// extractUnencryptedText extracts text from unencrypted documents.
-func (d *Document) extractUnencryptedText() (string, error) {
-	// Get the appropriate table stream
-	tableStreamName := d.fib.GetTableStreamName()
-	tableStream, err := d.reader.ReadStream(tableStreamName)
+// Endianness is the byte order of the UTF-16 text of Unicode pieces.
+type Endianness int
+
+const (
+	EndiannessAuto   Endianness = iota // Detect the byte order of each piece
+	EndiannessLittle                   // Little-endian, as Word writes
+	EndiannessBig                      // Big-endian, as some faulty exporters write
+)
+
+// TextOptions controls how TextWithOptions renders the document text.
+type TextOptions struct {
+	// TableCellSeparator replaces the cell mark (0x07) ending each table cell
+	// except the last one in a row. If empty, cell marks are left as-is.
+	TableCellSeparator string
+
+	// TableRowSeparator replaces the end of each table row, that is the last
+	// cell mark of the row together with the row end mark, which its
+	// paragraph properties mark as ending the row. If empty, the marks are
+	// left as-is.
+	TableRowSeparator string
+
+	// EmitBOM prepends a UTF-8 byte order mark (U+FEFF), which some Windows
+	// tools expect at the start of UTF-8 text.
+	EmitBOM bool
+
+	// StrictPieceTable makes TextWithOptions fail with ErrPieceTableCoverage
+	// when the piece table does not end at the character count recorded in
+	// the FIB, instead of returning possibly truncated text.
+	StrictPieceTable bool
+
+	// PrefixStyleNames starts each paragraph of the main document with the
+	// name of its style in brackets, as in "[Heading 1] Introduction".
+	// Paragraphs whose style has no name and table row end marks are left
+	// unchanged.
+	PrefixStyleNames bool
+
+	// OmitObjectAnchors removes the placeholder characters of pictures,
+	// embedded objects and floating shapes from the main document. Only
+	// characters marked special (fSpec) by their character formatting are
+	// removed, so literal 0x01 and 0x08 characters are kept.
+	OmitObjectAnchors bool
+
+	// Endianness overrides the byte order of the text of Unicode pieces. By
+	// default each piece is decoded as little-endian unless it starts with a
+	// big-endian byte order mark or its bytes look swapped, with the zero
+	// bytes of Latin text in the low instead of the high byte of each
+	// character over more than a few characters.
+	Endianness Endianness
+}
+
+// ErrPieceTableCoverage is returned in strict mode when the piece table does
+// not cover exactly the characters recorded in the FIB.
+var ErrPieceTableCoverage = errors.New("piece table does not match the document's character count")
+
+// TextInfo describes how the text returned by TextWithInfo was extracted.
+type TextInfo struct {
+	PieceCount  int      // Number of pieces in the piece table
+	LastCP      uint32   // CP at which the piece table ends
+	ExpectedCPs uint32   // Characters recorded in the FIB, zero if not recorded
+	Warnings    []string // Inconsistencies found in the document structure
+}
+
+// TextWithOptions extracts the plain text content like Text, rendering it
+// according to opts.
+//
+// Setting TableCellSeparator to "\t" and TableRowSeparator to "\n" turns each
+// table into tab-separated cells with one row per line.
+func (d *Document) TextWithOptions(opts TextOptions) (string, error) {
+	text, err := d.text(opts.Endianness)
 	if err != nil {
-		// If the requested table stream doesn't exist, try the alternative
-		alternativeStreamName := "0Table"
-		if tableStreamName == "0Table" {
-			alternativeStreamName = "1Table"
-		}
-		
-		tableStream, err = d.reader.ReadStream(alternativeStreamName)
+		return "", err
+	}
+
+	if opts.StrictPieceTable {
+		info, err := d.pieceTableCoverage()
 		if err != nil {
-			// If neither table stream exists, use fallback text extraction
-			return d.extractTextFallback()
+			return "", err
+		}
+		if len(info.Warnings) > 0 {
+			return "", fmt.Errorf("%w: %s", ErrPieceTableCoverage, info.Warnings[0])
 		}
-		tableStreamName = alternativeStreamName
 	}
 
-	// Get the piece table location from FIB
-	clxOffset := d.fib.RgFcLcb.FcClx
-	clxSize := d.fib.RgFcLcb.LcbClx
+	// Removed anchors shift the CPs that follow them
+	var removed []uint32
+	if opts.OmitObjectAnchors {
+		anchors, err := d.specialChars(objectAnchorChar, drawnObjectChar)
+		if err != nil {
+			return "", err
+		}
+		for _, anchor := range anchors {
+			removed = append(removed, anchor.CP)
+		}
+		text = removeCPs(text, removed)
+	}
+	tableMarks := opts.TableCellSeparator != "" || opts.TableRowSeparator != ""
+	if opts.PrefixStyleNames || tableMarks {
+		paragraphs, err := d.Paragraphs()
+		if err != nil {
+			return "", err
+		}
+		shift := func(cp uint32) uint32 {
+			return cp - uint32(sort.Search(len(removed), func(j int) bool { return removed[j] >= cp }))
+		}
+		var rowEnds []uint32
+		for i := range paragraphs {
+			paragraphs[i].StartCP = shift(paragraphs[i].StartCP)
+			if paragraphs[i].IsRowEnd && paragraphs[i].EndCP > 0 {
+				rowEnds = append(rowEnds, shift(paragraphs[i].EndCP-1))
+			}
+		}
+		if opts.PrefixStyleNames {
+			text = prefixStyleNames(text, paragraphs, rowEnds)
+		}
+		if tableMarks {
+			text = replaceTableMarks(text, opts.TableCellSeparator, opts.TableRowSeparator, rowEnds)
+		}
+	}
+	if opts.EmitBOM {
+		text = "\uFEFF" + text
+	}
+	return text, nil
+}
 
-	if clxSize == 0 {
-		// Fallback: Try to read text directly from WordDocument stream
-		// Many older Word documents store text starting at offset 2048
-		return d.extractTextFallback()
+// prefixStyleNames inserts the bracketed style name of each paragraph at its
+// start CP in text. The CPs in cps, in ascending order, are moved along with
+// the characters they point at.
+func prefixStyleNames(text string, paragraphs []Paragraph, cps []uint32) string {
+	units := utf16.Encode([]rune(text))
+	var out []uint16
+	next, moved := 0, 0
+	for _, para := range paragraphs {
+		if para.StyleName == "" || para.IsRowEnd || int(para.StartCP) > len(units) || int(para.StartCP) < next {
+			continue
+		}
+		for ; moved < len(cps) && cps[moved] < para.StartCP; moved++ {
+			cps[moved] += uint32(len(out) - next)
+		}
+		out = append(out, units[next:para.StartCP]...)
+		out = append(out, utf16.Encode([]rune("["+para.StyleName+"] "))...)
+		next = int(para.StartCP)
 	}
+	for ; moved < len(cps); moved++ {
+		cps[moved] += uint32(len(out) - next)
+	}
+	out = append(out, units[next:]...)
+	return string(utf16.Decode(out))
+}
 
-	if uint32(len(tableStream)) < clxOffset+clxSize {
-		return "", fmt.Errorf("table stream too small for CLX data")
+// removeCPs removes the characters at the given CPs, in ascending order,
+// from text.
+func removeCPs(text string, cps []uint32) string {
+	if len(cps) == 0 {
+		return text
+	}
+	units := utf16.Encode([]rune(text))
+	out := make([]uint16, 0, len(units))
+	next := 0
+	for _, cp := range cps {
+		if int(cp) < next || int(cp) >= len(units) {
+			continue
+		}
+		out = append(out, units[next:cp]...)
+		next = int(cp) + 1
 	}
+	out = append(out, units[next:]...)
+	return string(utf16.Decode(out))
+}
 
-	clx := tableStream[clxOffset : clxOffset+clxSize]
+// TextWithInfo extracts the plain text content like Text and reports how it
+// was extracted, including warnings about inconsistencies such as a piece
+// table that does not cover the whole document.
+func (d *Document) TextWithInfo() (string, *TextInfo, error) {
+	text, err := d.Text()
+	if err != nil {
+		return "", nil, err
+	}
 
-	// The CLX should start with a PlcPcd indicator (0x02)
-	if len(clx) == 0 || clx[0] != 0x02 {
-		return "", fmt.Errorf("invalid CLX structure, expected PlcPcd marker")
+	info, err := d.pieceTableCoverage()
+	if err != nil {
+		return "", nil, err
 	}
+	return text, info, nil
+}
 
-	// Parse the piece table
-	plcPcdData := clx[1:] // Skip the marker byte
-	plcPcd, err := structures.ParsePlcPcd(plcPcdData)
+// ContentHash returns the hex-encoded SHA-256 hash of the document text.
+//
+// The hash only covers the text, after normalizing paragraph and line breaks
+// to "\n" and dropping trailing whitespace, so it is stable across re-saves
+// that change metadata, timestamps, formatting or how the text is split into
+// pieces, but not the text itself.
+func (d *Document) ContentHash() (string, error) {
+	text, err := d.Text()
 	if err != nil {
-		return "", fmt.Errorf("failed to parse piece table: %w", err)
+		return "", err
 	}
 
-	// Get the WordDocument stream for text content
-	wordStream, err := d.reader.ReadStream("WordDocument")
+	sum := sha256.Sum256([]byte(normalizeText(text)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// normalizeText converts paragraph marks to "\n" and trims
+// trailing whitespace.
+func normalizeText(text string) string {
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	return strings.TrimRight(text, " \t\n")
+}
+
+// pieceTableCoverage compares the end of the piece table with the character
+// counts in the FIB. The piece table covers the main document followed by
+// every subdocument and, if there are any subdocuments, one final paragraph
+// mark.
+func (d *Document) pieceTableCoverage() (*TextInfo, error) {
+	info := &TextInfo{}
+
+	plcPcd, _, err := d.readPieceTable(d.fib.IsEncrypted())
+	if err != nil || plcPcd == nil {
+		return info, err
+	}
+	info.PieceCount = plcPcd.Count()
+	if len(plcPcd.CPs) > 0 {
+		info.LastCP = uint32(plcPcd.CPs[len(plcPcd.CPs)-1])
+	}
+
+	lw := d.fib.FibRgLw
+	if lw.CcpText == 0 {
+		return info, nil // Character counts not recorded
+	}
+	subdocs := lw.CcpFtn + lw.CcpHdd + lw.CcpAtn + lw.CcpEdn + lw.CcpTxbx + lw.CcpHdrTxbx
+	info.ExpectedCPs = lw.CcpText + subdocs
+	if subdocs > 0 {
+		info.ExpectedCPs++
+	}
+
+	switch {
+	case info.LastCP < info.ExpectedCPs:
+		info.Warnings = append(info.Warnings, fmt.Sprintf("piece table ends at CP %d but the FIB records %d characters; text is truncated", info.LastCP, info.ExpectedCPs))
+	case info.LastCP > info.ExpectedCPs:
+		info.Warnings = append(info.Warnings, fmt.Sprintf("piece table ends at CP %d but the FIB records only %d characters", info.LastCP, info.ExpectedCPs))
+	}
+	return info, nil
+}
+
+// replaceTableMarks replaces cell and row end marks in text. Each table row
+// ends with a row end mark at one of the CPs in rowEnds, which the PAPX of the
+// mark identifies; the row separator replaces it together with the mark of the
+// last cell directly before it.
+func replaceTableMarks(text, cellSep, rowSep string, rowEnds []uint32) string {
+	const cellMark = 0x07
+	if cellSep == "" {
+		cellSep = "\x07"
+	}
+	if rowSep == "" {
+		rowSep = "\x07\x07"
+	}
+
+	units := utf16.Encode([]rune(text))
+	isRowEnd := make(map[int]bool, len(rowEnds))
+	for _, cp := range rowEnds {
+		if int(cp) < len(units) && units[cp] == cellMark {
+			isRowEnd[int(cp)] = true
+		}
+	}
+
+	var b strings.Builder
+	next := 0
+	for i := 0; i < len(units); i++ {
+		if units[i] != cellMark {
+			continue
+		}
+		b.WriteString(string(utf16.Decode(units[next:i])))
+		switch {
+		case isRowEnd[i+1]:
+			b.WriteString(rowSep)
+			i++
+		case isRowEnd[i]:
+			b.WriteString(rowSep)
+		default:
+			b.WriteString(cellSep)
+		}
+		next = i + 1
+	}
+	b.WriteString(string(utf16.Decode(units[next:])))
+	return b.String()
+}
+
+// extractUnencryptedText extracts text from unencrypted documents.
+func (d *Document) extractUnencryptedText(order Endianness) (string, error) {
+	plcPcd, wordStream, err := d.readPieceTable(false)
 	if err != nil {
-		return "", fmt.Errorf("failed to read WordDocument stream: %w", err)
+		return "", err
+	}
+	if plcPcd == nil {
+		// Fallback: Try to read text directly from WordDocument stream
+		// Many older Word documents store text starting at offset 2048
+		return d.extractTextFallback()
 	}
 
-	return d.extractTextFromPieces(plcPcd, wordStream, false)
+	return d.extractTextFromPieces(plcPcd, wordStream, false, order)
 }
 
 // extractEncryptedText extracts text from encrypted documents.
-func (d *Document) extractEncryptedText() (string, error) {
-	// Get the appropriate table stream
-	tableStreamName := d.fib.GetTableStreamName()
-	tableStream, err := d.reader.ReadStream(tableStreamName)
+func (d *Document) extractEncryptedText(order Endianness) (string, error) {
+	plcPcd, wordStream, err := d.readPieceTable(true)
+	if err != nil {
+		return "", err
+	}
+	if plcPcd == nil {
+		return "", nil // No text content
+	}
+
+	return d.extractTextFromPieces(plcPcd, wordStream, true, order)
+}
+
+// readPieceTable locates and parses the piece table (CLX) and returns it together
+// with the WordDocument stream that the pieces point into.
+//
+// The CLX normally lives in the table stream. Some older documents store it in
+// the WordDocument stream instead, so when the table stream does not hold a
+// valid CLX at FcClx the same location is retried in the WordDocument stream.
+//
+// A nil piece table with a nil error means the document carries no usable CLX,
+// in which case callers should fall back to heuristic extraction.
+func (d *Document) readPieceTable(isEncrypted bool) (*structures.PlcPcd, []byte, error) {
+	if d.fib.RgFcLcb.LcbClx == 0 {
+		return nil, nil, nil
+	}
+
+	// Get the WordDocument stream for text content
+	wordStream, err := d.reader.ReadStream("WordDocument")
 	if err != nil {
-		// If the requested table stream doesn't exist, try the alternative
-		alternativeStreamName := "0Table"
-		if tableStreamName == "0Table" {
-			alternativeStreamName = "1Table"
+		return nil, nil, fmt.Errorf("failed to read WordDocument stream: %w", err)
+	}
+
+	// The table stream is already decrypted, with FcClx indexing it directly
+	table, err := d.tableStream()
+	if err != nil {
+		if isEncrypted {
+			return nil, nil, err
 		}
-		
-		tableStream, err = d.reader.ReadStream(alternativeStreamName)
+		// Neither table stream exists, so only the WordDocument stream can hold the CLX
+		clx, wordErr := d.clxAt(wordStream)
+		if wordErr != nil {
+			return nil, nil, nil
+		}
+		plcPcd, err := parseCLX(clx, isEncrypted)
 		if err != nil {
-			// If neither table stream exists, use fallback text extraction
-			return d.extractTextFallback()
+			return nil, nil, err
 		}
-		tableStreamName = alternativeStreamName
+		return plcPcd, wordStream, nil
 	}
 
-	// Skip encryption header and get piece table
-	encHeaderSize := uint32(116) // Standard encryption header size
-	if uint32(len(tableStream)) < encHeaderSize {
-		return "", fmt.Errorf("table stream too small for encryption header")
+	clx, err := d.clxAt(table.Data)
+	if err != nil && !isEncrypted {
+		// Retry the same location in the WordDocument stream
+		wordClx, wordErr := d.clxAt(wordStream)
+		if wordErr == nil {
+			clx, err = wordClx, nil
+		}
 	}
+	if err != nil {
+		if isEncrypted {
+			return nil, nil, fmt.Errorf("%w after decryption", err)
+		}
+		return nil, nil, err
+	}
+
+	plcPcd, err := parseCLX(clx, isEncrypted)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plcPcd, wordStream, nil
+}
 
-	// Get the piece table location from FIB (adjusted for encryption header)
-	clxOffset := d.fib.RgFcLcb.FcClx + encHeaderSize
+// clxAt slices the CLX described by the FIB out of stream and returns its
+// PlcPcd. The CLX holds any number of Prcs, each a 0x01 marker, a 2-byte size
+// and sprms, followed by the Pcdt: a 0x02 marker, the 4-byte size of the
+// PlcPcd and the PlcPcd itself.
+func (d *Document) clxAt(stream []byte) ([]byte, error) {
+	clxOffset := d.fib.RgFcLcb.FcClx
 	clxSize := d.fib.RgFcLcb.LcbClx
 
-	if clxSize == 0 {
-		return "", nil // No text content
+	if uint64(len(stream)) < uint64(clxOffset)+uint64(clxSize) {
+		return nil, fmt.Errorf("stream too small for CLX data")
 	}
 
-	if uint32(len(tableStream)) < clxOffset+clxSize {
-		return "", fmt.Errorf("table stream too small for CLX data")
+	clx := stream[clxOffset : clxOffset+clxSize]
+	for len(clx) >= 3 && clx[0] == 0x01 {
+		size := 3 + int(binary.LittleEndian.Uint16(clx[1:]))
+		if size > len(clx) {
+			return nil, fmt.Errorf("invalid CLX structure, Prc exceeds the CLX")
+		}
+		clx = clx[size:]
 	}
+	if len(clx) < 5 || clx[0] != 0x02 {
+		return nil, fmt.Errorf("invalid CLX structure, expected PlcPcd marker")
+	}
+	lcb := binary.LittleEndian.Uint32(clx[1:])
+	if uint64(lcb) > uint64(len(clx)-5) {
+		return nil, fmt.Errorf("invalid CLX structure, PlcPcd size %d exceeds the CLX", lcb)
+	}
+	return clx[5 : 5+lcb], nil
+}
 
-	clx := tableStream[clxOffset : clxOffset+clxSize]
+// parseCLX parses the PlcPcd returned by clxAt.
+func parseCLX(plc []byte, isEncrypted bool) (*structures.PlcPcd, error) {
+	plcPcd, err := structures.ParsePlcPcd(plc)
+	if err != nil {
+		if isEncrypted {
+			return nil, fmt.Errorf("failed to parse encrypted piece table: %w", err)
+		}
+		return nil, fmt.Errorf("failed to parse piece table: %w", err)
+	}
+	return plcPcd, nil
+}
 
-	// Decrypt the CLX data
-	decryptedCLX := d.decryptor.Decrypt(clx)
+// mainChar is a character of the main document.
+type mainChar struct {
+	CP    uint32   // Character position
+	FC    uint32   // Offset of the stored character in the WordDocument stream
+	Units []uint16 // UTF-16 encoding of the character
+}
 
-	// The CLX should start with a PlcPcd indicator (0x02)
-	if len(decryptedCLX) == 0 || decryptedCLX[0] != 0x02 {
-		return "", fmt.Errorf("invalid CLX structure after decryption, expected PlcPcd marker")
+// walkMainText calls fn with each character of the main document in CP order.
+// Every stored character is one CP: a UTF-16 unit in Unicode pieces and one
+// byte, decoded to a single rune, in ANSI pieces.
+func (d *Document) walkMainText(fn func(ch mainChar) error) error {
+	isEncrypted := d.fib.IsEncrypted()
+	if isEncrypted && d.decryptor == nil {
+		return fmt.Errorf("document is encrypted but decryption is not available")
 	}
 
-	// Parse the piece table
-	plcPcdData := decryptedCLX[1:] // Skip the marker byte
-	plcPcd, err := structures.ParsePlcPcd(plcPcdData)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse encrypted piece table: %w", err)
+	plcPcd, wordStream, err := d.readPieceTable(isEncrypted)
+	if err != nil || plcPcd == nil {
+		return err
 	}
 
-	// Get the WordDocument stream for text content
-	wordStream, err := d.reader.ReadStream("WordDocument")
-	if err != nil {
-		return "", fmt.Errorf("failed to read WordDocument stream: %w", err)
-	}
+	mainEnd := d.fib.FibRgLw.CcpText
+	for i := 0; i < plcPcd.Count(); i++ {
+		startCP, endCP, pcd, err := plcPcd.GetTextRange(i)
+		if err != nil {
+			return fmt.Errorf("failed to get text range for piece %d: %w", i, err)
+		}
 
-	return d.extractTextFromPieces(plcPcd, wordStream, true)
+		charCount := startCP.Distance(endCP)
+		if charCount == 0 {
+			continue
+		}
+		text, fileOffset, _, err := d.decodePiece(i, pcd, charCount, wordStream, isEncrypted, EndiannessAuto)
+		if err != nil {
+			return err
+		}
+
+		var chars [][]uint16
+		charSize := uint32(1)
+		if pcd.IsUnicode {
+			for _, u := range utf16.Encode([]rune(text)) {
+				chars = append(chars, []uint16{u})
+			}
+			charSize = 2
+		} else {
+			for _, r := range text {
+				chars = append(chars, utf16.Encode([]rune{r}))
+			}
+		}
+
+		for j, units := range chars {
+			cp := uint32(startCP) + uint32(j)
+			if mainEnd != 0 && cp >= mainEnd {
+				return nil
+			}
+			if err := fn(mainChar{CP: cp, FC: fileOffset + uint32(j)*charSize, Units: units}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 // extractTextFromPieces extracts text from piece descriptors.
-func (d *Document) extractTextFromPieces(plcPcd *structures.PlcPcd, wordStream []byte, isEncrypted bool) (string, error) {
+func (d *Document) extractTextFromPieces(plcPcd *structures.PlcPcd, wordStream []byte, isEncrypted bool, order Endianness) (string, error) {
 	// Extract text from each piece
 	var textBuilder bytes.Buffer
 
@@ -175,52 +547,172 @@ func (d *Document) extractTextFromPieces(plcPcd *structures.PlcPcd, wordStream [
 			continue
 		}
 
-		// Get the file position for this piece
-		filePos := pcd.GetActualFC()
+		text, _, _, err := d.decodePiece(i, pcd, charCount, wordStream, isEncrypted, order)
+		if err != nil {
+			return "", err
+		}
+		textBuilder.WriteString(text)
+	}
+
+	// Manual line breaks start a new line within the same paragraph. Each is
+	// replaced by a single character so text offsets still match CPs.
+	return strings.ReplaceAll(textBuilder.String(), "\v", "\n"), nil
+}
+
+// PieceInfo describes a single entry of the document's piece table.
+type PieceInfo struct {
+	StartCP     uint32 // First character position covered by the piece
+	EndCP       uint32 // Character position just past the end of the piece
+	FileOffset  uint32 // Byte offset of the piece text in the WordDocument stream
+	ByteLength  uint32 // Length of the piece text in bytes
+	IsUnicode   bool   // True if the text is stored as UTF-16LE, false for ANSI
+	IsEncrypted bool   // True if the piece text is encrypted in the file
+	Encoding    string // Character encoding of the piece text, e.g. "UTF-16LE" or "CP1252"
+}
+
+// Pieces returns the document's piece table in CP order.
+//
+// This exposes how the text is fragmented across the WordDocument stream, for
+// example after fast saves, and is useful for custom extraction. Documents
+// without a piece table return an empty list.
+func (d *Document) Pieces() ([]PieceInfo, error) {
+	isEncrypted := d.fib.IsEncrypted()
+	if isEncrypted && d.decryptor == nil {
+		return nil, fmt.Errorf("document is encrypted but decryption is not available")
+	}
+
+	plcPcd, _, err := d.readPieceTable(isEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	if plcPcd == nil {
+		return nil, nil
+	}
+
+	pieces := make([]PieceInfo, 0, plcPcd.Count())
+	for i := 0; i < plcPcd.Count(); i++ {
+		startCP, endCP, pcd, err := plcPcd.GetTextRange(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get text range for piece %d: %w", i, err)
+		}
 
+		byteLength := startCP.Distance(endCP)
 		if pcd.IsUnicode {
-			// Unicode text (UTF-16LE)
-			byteCount := charCount * 2
-			if uint32(len(wordStream)) < filePos+byteCount {
-				return "", fmt.Errorf("WordDocument stream too small for Unicode text at piece %d", i)
-			}
+			byteLength *= 2
+		}
+
+		pieces = append(pieces, PieceInfo{
+			StartCP:     uint32(startCP),
+			EndCP:       uint32(endCP),
+			FileOffset:  pcd.GetActualFC(),
+			ByteLength:  byteLength,
+			IsUnicode:   pcd.IsUnicode,
+			IsEncrypted: isEncrypted && !pcd.FNoEncryption,
+			Encoding:    d.pieceEncoding(pcd),
+		})
+	}
 
-			utf16bytes := wordStream[filePos : filePos+byteCount]
+	return pieces, nil
+}
+
+// pieceEncoding returns the label of the character encoding used by a piece.
+func (d *Document) pieceEncoding(pcd *structures.PCD) string {
+	if pcd.IsUnicode {
+		return "UTF-16LE"
+	}
+	return fmt.Sprintf("CP%d", d.fib.ANSICodePage())
+}
 
-			// Decrypt if necessary
-			if isEncrypted && !pcd.FNoEncryption {
-				utf16bytes = d.decryptor.Decrypt(utf16bytes)
+// decodePiece decodes the text of a single piece and reports the byte range it
+// occupies in the WordDocument stream. Unicode text is decoded with the given
+// byte order, or the one detectEndianness finds for EndiannessAuto.
+func (d *Document) decodePiece(index int, pcd *structures.PCD, charCount uint32, wordStream []byte, isEncrypted bool, order Endianness) (text string, fileOffset, byteLength uint32, err error) {
+	// Get the file position for this piece
+	filePos := pcd.GetActualFC()
+
+	if pcd.IsUnicode {
+		// Unicode text (UTF-16LE)
+		byteCount := charCount * 2
+		if uint32(len(wordStream)) < filePos+byteCount {
+			return "", 0, 0, fmt.Errorf("WordDocument stream too small for Unicode text at piece %d", index)
+		}
+
+		utf16bytes := wordStream[filePos : filePos+byteCount]
+
+		// Decrypt if necessary
+		if isEncrypted && !pcd.FNoEncryption {
+			if utf16bytes, err = d.decryptWordDocument(utf16bytes, filePos); err != nil {
+				return "", 0, 0, err
 			}
+		}
 
-			// Convert UTF-16LE to Go string
-			u16s := make([]uint16, charCount)
-			for j := uint32(0); j < charCount; j++ {
-				if (j*2)+1 < uint32(len(utf16bytes)) {
-					u16s[j] = uint16(utf16bytes[j*2]) | (uint16(utf16bytes[j*2+1]) << 8)
+		if order == EndiannessAuto {
+			order = detectEndianness(utf16bytes)
+		}
+
+		// Convert UTF-16 to Go string
+		u16s := make([]uint16, charCount)
+		for j := uint32(0); j < charCount; j++ {
+			if (j*2)+1 < uint32(len(utf16bytes)) {
+				lo, hi := utf16bytes[j*2], utf16bytes[j*2+1]
+				if order == EndiannessBig {
+					lo, hi = hi, lo
 				}
+				u16s[j] = uint16(lo) | uint16(hi)<<8
 			}
-			runes := utf16.Decode(u16s)
-			textBuilder.WriteString(string(runes))
-		} else {
-			// ANSI text (CP-1252 encoding)
-			if uint32(len(wordStream)) < filePos+charCount {
-				return "", fmt.Errorf("WordDocument stream too small for ANSI text at piece %d", i)
-			}
+		}
+		runes := utf16.Decode(u16s)
+		return string(runes), filePos, byteCount, nil
+	}
 
-			ansiBytes := wordStream[filePos : filePos+charCount]
+	// ANSI text (CP-1252 encoding)
+	if uint32(len(wordStream)) < filePos+charCount {
+		return "", 0, 0, fmt.Errorf("WordDocument stream too small for ANSI text at piece %d", index)
+	}
 
-			// Decrypt if necessary
-			if isEncrypted && !pcd.FNoEncryption {
-				ansiBytes = d.decryptor.Decrypt(ansiBytes)
-			}
+	ansiBytes := wordStream[filePos : filePos+charCount]
 
-			// For basic ASCII/CP-1252, direct conversion works for most characters
-			// A complete implementation would use proper character encoding conversion
-			textBuilder.Write(ansiBytes)
+	// Decrypt if necessary
+	if isEncrypted && !pcd.FNoEncryption {
+		if ansiBytes, err = d.decryptWordDocument(ansiBytes, filePos); err != nil {
+			return "", 0, 0, err
 		}
 	}
 
-	return textBuilder.String(), nil
+	// Word 97 and later always store 8-bit text as CP-1252. The code pages of
+	// older documents have no mapping here and are decoded the same way,
+	// which keeps their ASCII characters intact
+	return decodeCP1252(ansiBytes), filePos, charCount, nil
+}
+
+// minSwappedChars is the number of characters that must look byte-swapped
+// before detectEndianness takes text to be big-endian. Single characters such
+// as U+4E00 or U+3000 have the same pattern, so short pieces are too small a
+// sample to tell.
+const minSwappedChars = 8
+
+// detectEndianness guesses the byte order of UTF-16 text. Text starting with
+// a big-endian byte order mark is big-endian, as is text in which most of at
+// least minSwappedChars characters have a zero first byte and a non-zero
+// second byte, the pattern of byte-swapped Latin text. Anything else is taken
+// to be little-endian.
+func detectEndianness(b []byte) Endianness {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		return EndiannessBig
+	}
+	var swapped, straight int
+	for i := 0; i+1 < len(b); i += 2 {
+		switch {
+		case b[i] == 0 && b[i+1] != 0:
+			swapped++
+		case b[i] != 0 && b[i+1] == 0:
+			straight++
+		}
+	}
+	if swapped >= minSwappedChars && swapped > straight && swapped*2 >= len(b)/2 {
+		return EndiannessBig
+	}
+	return EndiannessLittle
 }
 
 // extractTextFallback attempts to extract text when piece table parsing fails.
@@ -295,124 +787,6 @@ func (d *Document) extractRawTextFromOffset(wordStream []byte, offset int) strin
 	return strings.TrimSpace(textBuilder.String())
 }
 
-// extractTextWithHyperlinks attempts to extract text with hyperlinks formatted as markdown
-func (d *Document) extractTextWithHyperlinks() (string, error) {
-	// Get the plain text first
-	plainText, err := d.Text()
-	if err != nil {
-		return "", err
-	}
-
-	// Try to get field PLC for main document
-	fieldPLC, err := d.getFieldPLC()
-	if err != nil {
-		// If field PLC extraction fails, use simple detection
-		return d.extractTextWithSimpleHyperlinkDetection(plainText)
-	}
-
-	// If no field PLC, return plain text
-	if fieldPLC == nil {
-		return plainText, nil
-	}
-
-	// Extract fields
-	fields, err := fieldPLC.GetFields()
-	if err != nil {
-		return "", err
-	}
-
-	// Extract hyperlinks
-	hyperlinks, err := structures.ExtractHyperlinks(plainText, fields)
-	if err != nil {
-		return "", err
-	}
-
-	// If no hyperlinks found, try a different approach for hyperlink detection
-	if len(hyperlinks) == 0 {
-		return d.extractTextWithSimpleHyperlinkDetection(plainText)
-	}
-
-	// Replace hyperlinks with markdown format
-	return d.replaceHyperlinksWithMarkdown(plainText, hyperlinks), nil
-}
-
-// getFieldPLC extracts the field PLC from the document
-func (d *Document) getFieldPLC() (*structures.FieldPLC, error) {
-	// Get field PLC location from FIB
-	fieldOffset := d.fib.RgFcLcb.FcPlcffldMom
-	fieldLength := d.fib.RgFcLcb.LcbPlcffldMom
-
-	if fieldLength == 0 {
-		return nil, nil // No fields
-	}
-
-	// Get the table stream
-	tableStreamName := d.fib.GetTableStreamName()
-	tableStream, err := d.reader.ReadStream(tableStreamName)
-	if err != nil {
-		// Try alternative table stream
-		alternativeStreamName := "0Table"
-		if tableStreamName == "0Table" {
-			alternativeStreamName = "1Table"
-		}
-		
-		tableStream, err = d.reader.ReadStream(alternativeStreamName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read table stream: %w", err)
-		}
-	}
-
-	if uint32(len(tableStream)) < fieldOffset+fieldLength {
-		return nil, fmt.Errorf("table stream too small for field data")
-	}
-
-	fieldData := tableStream[fieldOffset : fieldOffset+fieldLength]
-	return structures.ParseFieldPLC(fieldData)
-}
-
-// replaceHyperlinksWithMarkdown replaces hyperlink ranges with markdown format
-func (d *Document) replaceHyperlinksWithMarkdown(text string, hyperlinks []*structures.HyperlinkField) string {
-	// Sort hyperlinks by start position (descending) to replace from end to beginning
-	// This prevents position shifts during replacement
-	for i := 0; i < len(hyperlinks); i++ {
-		for j := i + 1; j < len(hyperlinks); j++ {
-			if hyperlinks[i].Start < hyperlinks[j].Start {
-				hyperlinks[i], hyperlinks[j] = hyperlinks[j], hyperlinks[i]
-			}
-		}
-	}
-
-	result := text
-	for _, hl := range hyperlinks {
-		startPos := int(hl.Start)
-		endPos := int(hl.End)
-
-		if startPos >= 0 && endPos >= startPos && endPos <= len(result) {
-			before := result[:startPos]
-			after := result[endPos:]
-			markdownLink := hl.FormatAsMarkdown()
-			result = before + markdownLink + after
-		}
-	}
-
-	return result
-}
-
-// extractTextWithSimpleHyperlinkDetection tries to detect hyperlinks in a simpler way
-// This is a fallback when field PLC parsing doesn't work
-func (d *Document) extractTextWithSimpleHyperlinkDetection(plainText string) (string, error) {
-	// For sample-2.doc, we know it ends with "For more information," and should have a link
-	// Let's try to detect common hyperlink patterns and add the missing link text
-	
-	if strings.HasSuffix(strings.TrimSpace(plainText), "For more information,") {
-		// This suggests there should be a hyperlink after this text
-		// Let's add a placeholder hyperlink for now
-		return plainText + " [click here](https://github.com/TalentFormula/msdoc)", nil
-	}
-
-	return plainText, nil
-}
-
 // Metadata extracts comprehensive metadata from the document.
 //
 // This method parses both the SummaryInformation and DocumentSummaryInformation
@@ -429,11 +803,16 @@ func (d *Document) Metadata() *Metadata {
 	if err != nil {
 		// Return basic metadata from FIB if extraction fails
 		return &Metadata{
-			Title:   "N/A",
-			Author:  "N/A",
-			Created: time.Time{},
+			Title:               "N/A",
+			Author:              "N/A",
+			Created:             time.Time{},
+			ReadOnlyRecommended: d.fib.IsReadOnlyRecommended(),
 		}
 	}
 
+	// Protection flags live in the FIB rather than the property sets
+	metadata.ReadOnlyRecommended = d.fib.IsReadOnlyRecommended()
+	d.applyDOPStatistics(metadata)
+
 	return metadata
 }
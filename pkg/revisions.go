@@ -0,0 +1,177 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"time"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// Character sprms marking tracked changes.
+const (
+	sprmCFRMarkDel    = 0x0800 // Run is a tracked deletion
+	sprmCFRMarkIns    = 0x0801 // Run is a tracked insertion
+	sprmCIbstRMark    = 0x4804 // Author of the insertion, index into SttbfRMark
+	sprmCDttmRMark    = 0x6805 // Date of the insertion
+	sprmCIbstRMarkDel = 0x4863 // Author of the deletion, index into SttbfRMark
+	sprmCDttmRMarkDel = 0x6864 // Date of the deletion
+)
+
+// RevisionType identifies the kind of tracked change.
+type RevisionType int
+
+const (
+	RevisionInsertion RevisionType = iota // Text inserted while tracking changes
+	RevisionDeletion                      // Text deleted while tracking changes
+)
+
+// String returns the name of the revision type.
+func (t RevisionType) String() string {
+	switch t {
+	case RevisionInsertion:
+		return "Insertion"
+	case RevisionDeletion:
+		return "Deletion"
+	default:
+		return "Unknown"
+	}
+}
+
+// Revision is a tracked change covering a span of the main document. A span
+// is a run of consecutive characters with the same revision marks.
+type Revision struct {
+	Type    RevisionType
+	Author  string    // Name of the author, empty if the document does not record it
+	Date    time.Time // Time of the change as recorded by Word, in UTC; zero if not recorded
+	StartCP uint32    // First character position of the span
+	EndCP   uint32    // Character position just past the end of the span
+	Text    string    // Text of the span; deleted text is still stored in the document
+}
+
+// revisionMark is the revision state of one character for one revision type.
+type revisionMark struct {
+	author int
+	dttm   uint32
+}
+
+// Revisions returns the tracked insertions and deletions in the main document,
+// ordered by position. A span that is both inserted and deleted is reported
+// once as each type. Returns nil if the document has no tracked changes.
+//
+// Authors are resolved against SttbfRMark, as the binary format has no
+// people list; see Comments.
+func (d *Document) Revisions() ([]Revision, error) {
+	chpx, err := d.characterFKPs()
+	if err != nil || chpx == nil {
+		return nil, err
+	}
+
+	var authors []string
+	if table, err := d.tableStream(); err == nil {
+		sttb, err := table.GetRevisionAuthors(d.fib.RgFcLcb.FcSttbfRMark, d.fib.RgFcLcb.LcbSttbfRMark)
+		if err != nil {
+			return nil, err
+		}
+		if sttb != nil {
+			authors = sttb.Strings
+		}
+	}
+
+	var revisions []Revision
+	open := map[RevisionType]int{} // Index in revisions of the span still being extended
+	last := map[RevisionType]revisionMark{}
+
+	err = d.walkMainText(func(ch mainChar) error {
+		grpprl, err := chpx.data(ch.FC)
+		if err != nil {
+			return err
+		}
+		marks := revisionMarks(grpprl)
+
+		for _, revType := range []RevisionType{RevisionInsertion, RevisionDeletion} {
+			mark, marked := marks[revType]
+			index, isOpen := open[revType]
+			if isOpen && (!marked || mark != last[revType] || revisions[index].EndCP != ch.CP) {
+				delete(open, revType)
+				isOpen = false
+			}
+			if !marked {
+				continue
+			}
+
+			if !isOpen {
+				revision := Revision{Type: revType, Date: parseDTTM(mark.dttm), StartCP: ch.CP, EndCP: ch.CP}
+				if mark.author >= 0 && mark.author < len(authors) {
+					revision.Author = authors[mark.author]
+				}
+				revisions = append(revisions, revision)
+				index = len(revisions) - 1
+				open[revType] = index
+				last[revType] = mark
+			}
+			revisions[index].EndCP = ch.CP + 1
+			revisions[index].Text += string(utf16.Decode(ch.Units))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// revisionMarks returns the revision marks set by a CHPX. The author and date
+// of a deletion fall back to those of the insertion when the CHPX has no
+// deletion-specific sprms, as written by Word 97.
+func revisionMarks(grpprl []byte) map[RevisionType]revisionMark {
+	flag := func(sprm uint16) bool {
+		operand, ok := structures.FindSprm(grpprl, sprm)
+		return ok && len(operand) > 0 && operand[0] != 0
+	}
+	author := func(sprm uint16) (int, bool) {
+		operand, ok := structures.FindSprm(grpprl, sprm)
+		if !ok || len(operand) < 2 {
+			return 0, false
+		}
+		return int(int16(binary.LittleEndian.Uint16(operand))), true
+	}
+	date := func(sprm uint16) (uint32, bool) {
+		operand, ok := structures.FindSprm(grpprl, sprm)
+		if !ok || len(operand) < 4 {
+			return 0, false
+		}
+		return binary.LittleEndian.Uint32(operand), true
+	}
+
+	marks := map[RevisionType]revisionMark{}
+	insAuthor, _ := author(sprmCIbstRMark)
+	insDate, _ := date(sprmCDttmRMark)
+	if flag(sprmCFRMarkIns) {
+		marks[RevisionInsertion] = revisionMark{author: insAuthor, dttm: insDate}
+	}
+	if flag(sprmCFRMarkDel) {
+		mark := revisionMark{author: insAuthor, dttm: insDate}
+		if a, ok := author(sprmCIbstRMarkDel); ok {
+			mark.author = a
+		}
+		if dttm, ok := date(sprmCDttmRMarkDel); ok {
+			mark.dttm = dttm
+		}
+		marks[RevisionDeletion] = mark
+	}
+	return marks
+}
+
+// parseDTTM decodes a DTTM date and time. Returns the zero time for a zero DTTM.
+func parseDTTM(dttm uint32) time.Time {
+	if dttm == 0 {
+		return time.Time{}
+	}
+	minute := int(dttm & 0x3F)
+	hour := int(dttm >> 6 & 0x1F)
+	day := int(dttm >> 11 & 0x1F)
+	month := time.Month(dttm >> 16 & 0x0F)
+	year := 1900 + int(dttm>>20&0x1FF)
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
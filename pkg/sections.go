@@ -0,0 +1,119 @@
+package msdoc
+
+import (
+	"fmt"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// Section describes a section of the main document and its properties.
+type Section struct {
+	StartCP    uint32          // First character position of the section
+	EndCP      uint32          // Character position just past the end of the section
+	Properties *structures.SEP // Page setup, numbering and column settings
+}
+
+// Sections returns the sections of the main document in order.
+//
+// Sections without explicit properties use structures.DefaultSEP. Documents
+// without a section table return an empty list.
+func (d *Document) Sections() ([]*Section, error) {
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+
+	plc, err := table.GetSectionTable(d.fib.RgFcLcb.FcPlcfsed, d.fib.RgFcLcb.LcbPlcfsed)
+	if err != nil || plc == nil {
+		return nil, err
+	}
+
+	wordStream, err := d.wordDocument()
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make([]*Section, 0, plc.Count())
+	for i := 0; i < plc.Count(); i++ {
+		start, end, err := plc.GetRange(i)
+		if err != nil {
+			return nil, err
+		}
+		sedData, err := plc.GetDataAt(i)
+		if err != nil {
+			return nil, err
+		}
+		sed, err := structures.ParseSED(sedData)
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %w", i, err)
+		}
+
+		sep := structures.DefaultSEP()
+		if sed.HasSEPX() {
+			if sed.FcSepx >= uint32(len(wordStream)) {
+				return nil, fmt.Errorf("section %d: SEPX offset %d out of bounds", i, sed.FcSepx)
+			}
+			sepx, err := structures.ParseSEPX(wordStream[sed.FcSepx:])
+			if err != nil {
+				return nil, fmt.Errorf("section %d: %w", i, err)
+			}
+			if sep, err = sepx.ParseSEP(); err != nil {
+				return nil, fmt.Errorf("section %d: %w", i, err)
+			}
+		}
+
+		sections = append(sections, &Section{
+			StartCP:    uint32(start),
+			EndCP:      uint32(end),
+			Properties: sep,
+		})
+	}
+
+	return sections, nil
+}
+
+// SectionContent is a section of the main document together with its content.
+type SectionContent struct {
+	Section    *Section    // Character range and page setup of the section
+	Text       string      // Text of the section, as returned by Text
+	Paragraphs []Paragraph // Paragraphs starting in the section
+}
+
+// SectionsWithContent returns the sections of the main document in order,
+// each with its text and the paragraphs that start within it.
+//
+// Documents without a section table return an empty list.
+func (d *Document) SectionsWithContent() ([]SectionContent, error) {
+	sections, err := d.Sections()
+	if err != nil || len(sections) == 0 {
+		return nil, err
+	}
+
+	paragraphs, err := d.Paragraphs()
+	if err != nil {
+		return nil, err
+	}
+	text, err := d.Text()
+	if err != nil {
+		return nil, err
+	}
+	units := utf16.Encode([]rune(text))
+
+	contents := make([]SectionContent, 0, len(sections))
+	next := 0
+	for _, section := range sections {
+		content := SectionContent{Section: section}
+		if start, end := section.StartCP, section.EndCP; start <= end && int(end) <= len(units) {
+			content.Text = string(utf16.Decode(units[start:end]))
+		}
+		for next < len(paragraphs) && paragraphs[next].StartCP < section.EndCP {
+			if paragraphs[next].StartCP >= section.StartCP {
+				content.Paragraphs = append(content.Paragraphs, paragraphs[next])
+			}
+			next++
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
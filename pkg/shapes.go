@@ -0,0 +1,197 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// PICF mapping modes of pictures whose header is followed by an inline shape
+// container: mmShape for embedded pictures, mmShapeFile for pictures whose
+// header is followed by the name of the linked picture file.
+const (
+	mmShape     = 0x0064
+	mmShapeFile = 0x0066
+)
+
+// spaSize is the size of an SPA, the data element of the shape anchor PLC.
+const spaSize = 26
+
+// Image is an inline picture of the main document.
+type Image struct {
+	CP                 uint32 // Character position of the picture's anchor character
+	DisplayWidthTwips  int32  // Display width in twips
+	DisplayHeightTwips int32  // Display height in twips
+	Name               string // Shape name, empty if not set
+	AltText            string // Alternative text (wzDescription), empty if not set
+}
+
+// Shape is a floating shape, such as a picture or text box, drawn on the main
+// document.
+type Shape struct {
+	ID        uint32 // Shape ID
+	Type      uint16 // MSOSPT shape type, such as 75 for a picture frame
+	CP        uint32 // Character position of the shape's anchor
+	IsPicture bool   // Shape displays a picture from the BLIP store
+	Name      string // Shape name, empty if not set
+	AltText   string // Alternative text (wzDescription), empty if not set
+}
+
+// Images returns the inline pictures of the main document in CP order,
+// together with the name and alternative text stored in the shape properties
+// that follow each picture header. A picture whose shape properties cannot be
+// parsed is returned without a name or alternative text.
+func (d *Document) Images() ([]Image, error) {
+	pictures, err := d.pictureHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]Image, 0, len(pictures))
+	for _, picture := range pictures {
+		image := Image{CP: picture.Anchor.CP}
+		image.DisplayWidthTwips, image.DisplayHeightTwips = picture.PICF.DisplaySize()
+
+		if shape, err := inlineShape(picture); err == nil && shape != nil {
+			image.Name = shape.StringProperty(structures.ShapePropName)
+			image.AltText = shape.StringProperty(structures.ShapePropDescription)
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// inlineShape parses the shape container stored after a picture header.
+// Returns nil if the picture has none: only pictures with the mapping mode
+// mmShape or mmShapeFile are followed by one, others by a metafile.
+func inlineShape(picture pictureHeader) (*structures.ShapeProperties, error) {
+	if picture.PICF.Mm != mmShape && picture.PICF.Mm != mmShapeFile {
+		return nil, nil
+	}
+	end := len(picture.Data)
+	if lcb := int(picture.PICF.Lcb); lcb < end {
+		end = lcb
+	}
+	offset := int(picture.PICF.CbHeader)
+	if picture.PICF.Mm == mmShapeFile && offset < end {
+		offset += 1 + int(picture.Data[offset]) // cchPicName and stPicName
+	}
+	if offset >= end {
+		return nil, nil
+	}
+
+	records, err := structures.ParseOfficeArtRecords(picture.Data[offset:end])
+	if len(records) == 0 || records[0].Type != structures.OfficeArtSpContainer {
+		return nil, err
+	}
+	return structures.ParseShapeContainer(&records[0])
+}
+
+// Shapes returns the floating shapes drawn on the main document, in drawing
+// order, with the name and alternative text from their shape properties.
+// Returns nil if the document has no drawings.
+func (d *Document) Shapes() ([]Shape, error) {
+	rgfc := d.fib.RgFcLcb
+	if rgfc.LcbDggInfo == 0 {
+		return nil, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(rgfc.FcDggInfo)+uint64(rgfc.LcbDggInfo) > uint64(len(table.Data)) {
+		return nil, fmt.Errorf("table stream too small for drawing data")
+	}
+	dggInfo := table.Data[rgfc.FcDggInfo : rgfc.FcDggInfo+rgfc.LcbDggInfo]
+
+	anchors, err := d.shapeAnchors()
+	if err != nil {
+		return nil, err
+	}
+
+	// OfficeArtContent: the drawing group container, then each drawing
+	// container preceded by a byte telling whether it belongs to the main
+	// document (0) or to the headers (1)
+	records, err := structures.ParseOfficeArtRecords(dggInfo)
+	if len(records) == 0 {
+		return nil, err
+	}
+	offset := len(records[0].Data) + 8
+
+	var shapes []Shape
+	for offset < len(dggInfo) {
+		dgglbl := dggInfo[offset]
+		offset++
+		drawing, err := structures.ParseOfficeArtRecords(dggInfo[offset:])
+		if len(drawing) == 0 {
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse drawing: %w", err)
+			}
+			break
+		}
+		offset += len(drawing[0].Data) + 8
+		if dgglbl != 0 {
+			continue
+		}
+
+		containers, err := structures.FindShapeContainers(drawing[0].Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse drawing: %w", err)
+		}
+		for _, container := range containers {
+			sp, err := structures.ParseShapeContainer(&container)
+			if err != nil {
+				return nil, err
+			}
+			if !sp.IsDrawn() {
+				continue
+			}
+			_, hasPicture := sp.Properties[structures.ShapePropBlipID]
+			shapes = append(shapes, Shape{
+				ID:        sp.ID,
+				Type:      sp.ShapeType,
+				CP:        anchors[sp.ID],
+				IsPicture: hasPicture,
+				Name:      sp.StringProperty(structures.ShapePropName),
+				AltText:   sp.StringProperty(structures.ShapePropDescription),
+			})
+		}
+	}
+	return shapes, nil
+}
+
+// shapeAnchors maps shape IDs to the CPs of their anchors in the main document.
+func (d *Document) shapeAnchors() (map[uint32]uint32, error) {
+	anchors := make(map[uint32]uint32)
+	rgfc := d.fib.RgFcLcb
+	if rgfc.LcbPlcSpaMom == 0 {
+		return anchors, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(rgfc.FcPlcSpaMom)+uint64(rgfc.LcbPlcSpaMom) > uint64(len(table.Data)) {
+		return nil, fmt.Errorf("table stream too small for shape anchors")
+	}
+	plc, err := structures.ParsePLC(table.Data[rgfc.FcPlcSpaMom:rgfc.FcPlcSpaMom+rgfc.LcbPlcSpaMom], spaSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse shape anchors: %w", err)
+	}
+
+	for i := 0; i < plc.Count(); i++ {
+		cp, _, err := plc.GetRange(i)
+		if err != nil {
+			return nil, err
+		}
+		spa, err := plc.GetDataAt(i)
+		if err != nil {
+			return nil, err
+		}
+		anchors[binary.LittleEndian.Uint32(spa[0:4])] = uint32(cp)
+	}
+	return anchors, nil
+}
@@ -0,0 +1,134 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// SmartTag is a legacy smart tag: an entity, such as an address or a person's
+// name, that a recognizer annotated in the main document.
+type SmartTag struct {
+	Type       string            // Tag name, such as "address" or "PersonName"
+	Namespace  string            // Namespace URI of the recognizer
+	StartCP    uint32            // Character position of the first tagged character
+	EndCP      uint32            // Character position just past the tagged text
+	Text       string            // Tagged text
+	Properties map[string]string // Properties recorded by the recognizer, nil if none
+}
+
+// SmartTags returns the smart tags of the main document in order. Returns nil
+// if the document has no smart tags.
+//
+// Smart tags are stored as bookmarks in PlcfBkfFactoid and PlcfBklFactoid,
+// with the type and properties of each tag in the SmartTagData. Word 2002 and
+// later write them; older FIBs have no room for them.
+func (d *Document) SmartTags() ([]SmartTag, error) {
+	rgfc := d.fib.RgFcLcb
+	if rgfc.LcbPlcfBkfFactoid == 0 {
+		return nil, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	slice := func(fc, lcb uint32, name string) ([]byte, error) {
+		if uint64(fc)+uint64(lcb) > uint64(len(table.Data)) {
+			return nil, fmt.Errorf("table stream too small for %s", name)
+		}
+		return table.Data[fc : fc+lcb], nil
+	}
+
+	bkfData, err := slice(rgfc.FcPlcfBkfFactoid, rgfc.LcbPlcfBkfFactoid, "smart tag starts")
+	if err != nil {
+		return nil, err
+	}
+	starts, err := structures.ParsePLC(bkfData, structures.FBKFDSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse smart tag starts: %w", err)
+	}
+	if starts.Count() == 0 {
+		return nil, nil
+	}
+
+	var ends *structures.PLC
+	if rgfc.LcbPlcfBklFactoid > 0 {
+		bklData, err := slice(rgfc.FcPlcfBklFactoid, rgfc.LcbPlcfBklFactoid, "smart tag ends")
+		if err != nil {
+			return nil, err
+		}
+		if ends, err = structures.ParsePLC(bklData, structures.FBKLDSize); err != nil {
+			return nil, fmt.Errorf("failed to parse smart tag ends: %w", err)
+		}
+	}
+
+	var tagData *structures.SmartTagData
+	if rgfc.LcbFactoidData > 0 {
+		data, err := slice(rgfc.FcFactoidData, rgfc.LcbFactoidData, "smart tag data")
+		if err != nil {
+			return nil, err
+		}
+		if tagData, err = structures.ParseSmartTagData(data); err != nil {
+			return nil, fmt.Errorf("failed to parse smart tag data: %w", err)
+		}
+	}
+
+	text, err := d.Text()
+	if err != nil {
+		return nil, err
+	}
+	units := utf16.Encode([]rune(text))
+
+	tags := make([]SmartTag, 0, starts.Count())
+	for i := 0; i < starts.Count(); i++ {
+		startCP, _, err := starts.GetRange(i)
+		if err != nil {
+			return nil, err
+		}
+		tag := SmartTag{StartCP: uint32(startCP), EndCP: uint32(startCP)}
+
+		// The FBKFD starts with ibkl, the index of the matching end
+		fbkfd, err := starts.GetDataAt(i)
+		if err != nil {
+			return nil, err
+		}
+		if ibkl := int(int16(binary.LittleEndian.Uint16(fbkfd))); ends != nil && ibkl >= 0 && ibkl < ends.Count() {
+			endCP, _, err := ends.GetRange(ibkl)
+			if err != nil {
+				return nil, err
+			}
+			tag.EndCP = uint32(endCP)
+		}
+		if tag.StartCP <= tag.EndCP && int(tag.EndCP) <= len(units) {
+			tag.Text = string(utf16.Decode(units[tag.StartCP:tag.EndCP]))
+		}
+
+		if tagData != nil && i < len(tagData.Bags) {
+			applySmartTagData(&tag, tagData, tagData.Bags[i])
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// applySmartTagData sets the type, namespace and properties of a smart tag
+// from its property bag.
+func applySmartTagData(tag *SmartTag, data *structures.SmartTagData, bag structures.PropertyBag) {
+	if factoidType := data.Type(uint32(bag.TypeID)); factoidType != nil {
+		tag.Type = factoidType.Tag
+		tag.Namespace = factoidType.URI
+	}
+	for _, prop := range bag.Properties {
+		key, value := prop[0], prop[1]
+		if int(key) >= len(data.Strings) || int(value) >= len(data.Strings) {
+			continue
+		}
+		if tag.Properties == nil {
+			tag.Properties = make(map[string]string)
+		}
+		tag.Properties[data.Strings[key]] = data.Strings[value]
+	}
+}
@@ -0,0 +1,337 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/TalentFormula/msdoc/formatting"
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// Paragraph sprms applied by style-based paragraph properties.
+const (
+	sprmPJc80             = 0x2403
+	sprmPFKeep            = 0x2405
+	sprmPFKeepFollow      = 0x2406
+	sprmPFPageBreakBefore = 0x2407
+	sprmPDxaRight80       = 0x840E
+	sprmPDxaLeft80        = 0x840F
+	sprmPDxaLeft180       = 0x8411
+	sprmPDyaLine          = 0x6412
+	sprmPDyaBefore        = 0xA413
+	sprmPDyaAfter         = 0xA414
+	sprmPFWidowControl    = 0x2431
+	sprmPFBiDi            = 0x2441
+	sprmPOutLvl           = 0x2640
+	sprmPDxaRight         = 0x845D
+	sprmPDxaLeft          = 0x845E
+	sprmPDxaLeft1         = 0x8460
+	sprmPJc               = 0x2461
+)
+
+// Character sprms applied by style-based character properties.
+const (
+	sprmCFBold      = 0x0835
+	sprmCFItalic    = 0x0836
+	sprmCFStrike    = 0x0837
+	sprmCFSmallCaps = 0x083A
+	sprmCFCaps      = 0x083B
+	sprmCHps        = 0x4A43
+)
+
+const (
+	// maxStyleDepth bounds istdBase chains, which may be cyclic in damaged files
+	maxStyleDepth = 16
+
+	// singleLineSpacing is the LSPD value of single line spacing
+	singleLineSpacing = 240
+
+	// defaultFontSize is the font size, in half-points, of text without a
+	// font size sprm
+	defaultFontSize = 20
+
+	// bodyTextLevel is the outline level of paragraphs that are not headings
+	bodyTextLevel = 9
+)
+
+// styleSheet parses the document's style sheet (STSH).
+// Returns nil with no error if the document has no style sheet.
+func (d *Document) styleSheet() (*structures.STSH, error) {
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := table.GetStyleSheet(d.fib.RgFcLcb.FcStshf, d.fib.RgFcLcb.LcbStshf)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	stsh, err := structures.ParseSTSH(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse style sheet: %w", err)
+	}
+	return stsh, nil
+}
+
+// Style is a style defined in the document's style sheet.
+type Style struct {
+	Index   uint16               // Style index (istd) referenced by paragraph and character formatting
+	Name    string               // Style name, such as "Heading 1"
+	Type    structures.StyleType // Paragraph, character, table or numbering style
+	BuiltIn bool                 // The style is one of Word's built-in styles
+	BasedOn string               // Name of the style this one inherits from, empty if none
+	Next    string               // Name of the style of the paragraph following one in this style
+}
+
+// Styles returns the styles of the document's style sheet in index order,
+// skipping empty slots. Returns nil if the document has no style sheet.
+//
+// Reading the styles also lets the formatting extractor resolve style
+// indices to names.
+func (d *Document) Styles() ([]Style, error) {
+	stsh, err := d.styleSheet()
+	if err != nil || stsh == nil {
+		return nil, err
+	}
+
+	name := func(istd uint16) string {
+		if std := stsh.Style(istd); std != nil {
+			return std.Name
+		}
+		return ""
+	}
+
+	var styles []Style
+	for i, std := range stsh.Styles {
+		if std == nil {
+			continue
+		}
+		style := Style{
+			Index:   uint16(i),
+			Name:    std.Name,
+			Type:    std.Type,
+			BuiltIn: std.Sti != structures.StiUser,
+			Next:    name(std.IstdNext),
+		}
+		if std.HasBase() {
+			style.BasedOn = name(std.IstdBase)
+		}
+		styles = append(styles, style)
+		d.formattingExtractor.AddStyleMapping(style.Index, style.Name)
+	}
+	return styles, nil
+}
+
+// DefaultParagraphProperties returns the paragraph properties of the Normal
+// style, which every paragraph inherits unless its own style or formatting
+// overrides them.
+//
+// Documents without a style sheet return Word's built-in defaults: left
+// aligned, single spaced and without indents.
+func (d *Document) DefaultParagraphProperties() (*formatting.ParagraphProperties, error) {
+	stsh, err := d.styleSheet()
+	if err != nil {
+		return nil, err
+	}
+
+	props := defaultParagraphProperties()
+	if stsh != nil {
+		applyStyleParagraphSprms(props, stsh, structures.IstdNormal, 0)
+	}
+	return props, nil
+}
+
+// defaultParagraphProperties returns the paragraph properties that apply when
+// no sprm says otherwise.
+func defaultParagraphProperties() *formatting.ParagraphProperties {
+	return &formatting.ParagraphProperties{
+		Alignment:    formatting.AlignLeft,
+		LineSpacing:  formatting.LineSpacing{Type: formatting.LineSpacingSingle, Value: singleLineSpacing},
+		OutlineLevel: bodyTextLevel,
+	}
+}
+
+// applyStyleParagraphSprms applies the paragraph sprms of the style istd to
+// props, after those of the styles it is based on.
+func applyStyleParagraphSprms(props *formatting.ParagraphProperties, stsh *structures.STSH, istd uint16, depth int) {
+	std := stsh.Style(istd)
+	if std == nil || depth > maxStyleDepth {
+		return
+	}
+	if std.HasBase() {
+		applyStyleParagraphSprms(props, stsh, std.IstdBase, depth+1)
+	}
+	if std.Type == structures.StyleTypeParagraph {
+		props.StyleName = std.Name
+	}
+	applyParagraphSprms(props, std.ParagraphGrpprl)
+}
+
+// paragraphProperties returns the effective paragraph properties of a
+// paragraph with the given PAPX: the properties of its style, which the PAPX
+// names with its leading istd, under the direct formatting of its sprms.
+func paragraphProperties(stsh *structures.STSH, papx []byte) *formatting.ParagraphProperties {
+	props := defaultParagraphProperties()
+	istd := uint16(structures.IstdNormal)
+	if len(papx) >= 2 {
+		istd = binary.LittleEndian.Uint16(papx)
+	}
+	if stsh != nil {
+		applyStyleParagraphSprms(props, stsh, istd, 0)
+	}
+	if len(papx) > 2 {
+		applyParagraphSprms(props, papx[2:])
+	}
+	return props
+}
+
+// styleCharacterProperties returns the character properties that the style
+// istd, together with the styles it is based on, gives its text.
+func styleCharacterProperties(stsh *structures.STSH, istd uint16) *formatting.CharacterProperties {
+	props := &formatting.CharacterProperties{
+		FontSize:       defaultFontSize,
+		Color:          formatting.Color{Auto: true},
+		HighlightColor: formatting.Color{Auto: true},
+		Scale:          100,
+	}
+	if stsh != nil {
+		applyStyleCharacterSprms(props, stsh, istd, 0)
+	}
+	return props
+}
+
+// applyStyleCharacterSprms applies the character sprms of the style istd to
+// props, after those of the styles it is based on.
+func applyStyleCharacterSprms(props *formatting.CharacterProperties, stsh *structures.STSH, istd uint16, depth int) {
+	std := stsh.Style(istd)
+	if std == nil || depth > maxStyleDepth {
+		return
+	}
+	if std.HasBase() {
+		applyStyleCharacterSprms(props, stsh, std.IstdBase, depth+1)
+	}
+	applyCharacterSprms(props, std.CharacterGrpprl)
+}
+
+// applyCharacterSprms applies the character sprms in grpprl to props. The
+// toggle operands 0x80 and 0x81 keep and invert the inherited value.
+func applyCharacterSprms(props *formatting.CharacterProperties, grpprl []byte) {
+	toggleOperand := func(sprm uint16, flag *bool) {
+		operand, ok := structures.FindSprm(grpprl, sprm)
+		if !ok || len(operand) == 0 {
+			return
+		}
+		switch operand[0] {
+		case 0x00:
+			*flag = false
+		case 0x01:
+			*flag = true
+		case 0x81:
+			*flag = !*flag
+		}
+	}
+
+	toggleOperand(sprmCFBold, &props.Bold)
+	toggleOperand(sprmCFItalic, &props.Italic)
+	toggleOperand(sprmCFStrike, &props.Strikethrough)
+	toggleOperand(sprmCFSmallCaps, &props.SmallCaps)
+	toggleOperand(sprmCFCaps, &props.AllCaps)
+	toggleOperand(sprmCFVanish, &props.Hidden)
+	if operand, ok := structures.FindSprm(grpprl, sprmCHps); ok && len(operand) >= 2 {
+		props.FontSize = binary.LittleEndian.Uint16(operand)
+	}
+}
+
+// applyParagraphSprms applies the paragraph sprms in grpprl to props.
+// The newer form of each sprm is checked last so that it takes precedence.
+func applyParagraphSprms(props *formatting.ParagraphProperties, grpprl []byte) {
+	int16Operand := func(sprm uint16) (int32, bool) {
+		operand, ok := structures.FindSprm(grpprl, sprm)
+		if !ok || len(operand) < 2 {
+			return 0, false
+		}
+		return int32(int16(binary.LittleEndian.Uint16(operand))), true
+	}
+	flagOperand := func(sprm uint16, flag *bool) {
+		if operand, ok := structures.FindSprm(grpprl, sprm); ok && len(operand) > 0 {
+			*flag = operand[0] != 0
+		}
+	}
+
+	for _, sprm := range []uint16{sprmPJc80, sprmPJc} {
+		if operand, ok := structures.FindSprm(grpprl, sprm); ok && len(operand) > 0 {
+			props.Alignment = paragraphAlignment(operand[0])
+		}
+	}
+	for _, sprm := range []uint16{sprmPDxaLeft80, sprmPDxaLeft} {
+		if value, ok := int16Operand(sprm); ok {
+			props.LeftIndent = value
+		}
+	}
+	for _, sprm := range []uint16{sprmPDxaRight80, sprmPDxaRight} {
+		if value, ok := int16Operand(sprm); ok {
+			props.RightIndent = value
+		}
+	}
+	for _, sprm := range []uint16{sprmPDxaLeft180, sprmPDxaLeft1} {
+		if value, ok := int16Operand(sprm); ok {
+			props.FirstLineIndent = value
+		}
+	}
+	if value, ok := int16Operand(sprmPDyaBefore); ok {
+		props.SpaceBefore = uint16(value)
+	}
+	if value, ok := int16Operand(sprmPDyaAfter); ok {
+		props.SpaceAfter = uint16(value)
+	}
+	if operand, ok := structures.FindSprm(grpprl, sprmPDyaLine); ok && len(operand) >= 4 {
+		props.LineSpacing = lineSpacing(int16(binary.LittleEndian.Uint16(operand)), binary.LittleEndian.Uint16(operand[2:]) != 0)
+	}
+	flagOperand(sprmPFKeep, &props.KeepTogether)
+	flagOperand(sprmPFKeepFollow, &props.KeepWithNext)
+	flagOperand(sprmPFPageBreakBefore, &props.PageBreakBefore)
+	flagOperand(sprmPFWidowControl, &props.WidowControl)
+	flagOperand(sprmPFBiDi, &props.BiDi)
+	if operand, ok := structures.FindSprm(grpprl, sprmPOutLvl); ok && len(operand) > 0 {
+		props.OutlineLevel = operand[0]
+	}
+}
+
+// paragraphAlignment converts a jc value to a paragraph alignment. The
+// Asian justification variants are treated as distributed.
+func paragraphAlignment(jc byte) formatting.ParagraphAlignment {
+	switch jc {
+	case 0:
+		return formatting.AlignLeft
+	case 1:
+		return formatting.AlignCenter
+	case 2:
+		return formatting.AlignRight
+	case 3:
+		return formatting.AlignJustify
+	default:
+		return formatting.AlignDistribute
+	}
+}
+
+// lineSpacing converts an LSPD to a line spacing. Multiple spacing is in
+// 240ths of a line; otherwise dyaLine is an "at least" height in twips, or an
+// exact height when negative.
+func lineSpacing(dyaLine int16, multiple bool) formatting.LineSpacing {
+	if multiple {
+		switch dyaLine {
+		case singleLineSpacing:
+			return formatting.LineSpacing{Type: formatting.LineSpacingSingle, Value: uint16(dyaLine)}
+		case singleLineSpacing * 3 / 2:
+			return formatting.LineSpacing{Type: formatting.LineSpacingOneAndHalf, Value: uint16(dyaLine)}
+		case singleLineSpacing * 2:
+			return formatting.LineSpacing{Type: formatting.LineSpacingDouble, Value: uint16(dyaLine)}
+		}
+		return formatting.LineSpacing{Type: formatting.LineSpacingMultiple, Value: uint16(dyaLine)}
+	}
+	if dyaLine < 0 {
+		return formatting.LineSpacing{Type: formatting.LineSpacingExact, Value: uint16(-dyaLine)}
+	}
+	return formatting.LineSpacing{Type: formatting.LineSpacingAtLeast, Value: uint16(dyaLine)}
+}
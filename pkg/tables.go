@@ -0,0 +1,127 @@
+package msdoc
+
+import (
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// cellMark ends each table cell and each table row.
+const cellMark = 0x07
+
+// Table is a table of the main document.
+type Table struct {
+	Rows    []TableRow
+	StartCP uint32 // Character position of the first character of the table
+	EndCP   uint32 // Character position just past the last end-of-row mark
+}
+
+// TableRow is a row of a table.
+type TableRow struct {
+	Cells []TableCell
+
+	// Properties holds the cell edges and cell properties from the row's
+	// end-of-row mark, nil if the mark carries no table definition
+	Properties *structures.TAP
+}
+
+// TableCell is a cell of a table row.
+type TableCell struct {
+	Text    string     // Cell text without the cell mark; paragraphs are separated by paragraph marks
+	StartCP uint32     // Character position of the first character of the cell
+	EndCP   uint32     // Character position just past the cell mark
+	Runs    []*TextRun // Runs of the cell text, cut from GetFormattedText at the cell boundaries
+
+	// Properties holds the borders, shading and merging of the cell, nil if
+	// the row has no table definition or fewer cells than it defines
+	Properties *structures.TC
+}
+
+// Tables returns the tables of the main document in document order. Returns
+// nil if the document has no tables.
+//
+// A table is a run of paragraphs marked as in-table in their PAPX. Each cell
+// ends with a cell mark, and each row with an end-of-row mark whose PAPX holds
+// the table properties of the row. Nested tables are not broken out: their
+// text becomes part of the text of the enclosing cell.
+func (d *Document) Tables() ([]Table, error) {
+	paragraphs, err := d.Paragraphs()
+	if err != nil {
+		return nil, err
+	}
+	runs, err := d.GetFormattedText()
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []Table
+	var table *Table
+	var row TableRow
+	var cell *TableCell
+	for _, para := range paragraphs {
+		if !para.InTable {
+			table, row, cell = nil, TableRow{}, nil
+			continue
+		}
+		if table == nil {
+			tables = append(tables, Table{StartCP: para.StartCP})
+			table = &tables[len(tables)-1]
+		}
+
+		if para.IsRowEnd {
+			if para.TableDepth > 1 {
+				continue // The cells of a nested row are already part of the enclosing cell
+			}
+			for i := range row.Cells {
+				if para.tap != nil && i < len(para.tap.Cells) {
+					row.Cells[i].Properties = para.tap.Cells[i]
+				}
+			}
+			row.Properties = para.tap
+			table.Rows = append(table.Rows, row)
+			table.EndCP = para.EndCP
+			row, cell = TableRow{}, nil
+			continue
+		}
+
+		if cell == nil {
+			row.Cells = append(row.Cells, TableCell{StartCP: para.StartCP})
+			cell = &row.Cells[len(row.Cells)-1]
+		} else {
+			cell.Text += "\r"
+		}
+		cell.Text += para.Text
+		cell.EndCP = para.EndCP
+		if para.mark == cellMark && para.TableDepth <= 1 {
+			cell.Runs = cutRuns(runs, cell.StartCP, cell.EndCP-1)
+			cell = nil
+		}
+	}
+	return tables, nil
+}
+
+// cutRuns returns the parts of runs that lie within the CPs from start to
+// end, with their text, positions and stream offsets adjusted to the part.
+func cutRuns(runs []*TextRun, start, end uint32) []*TextRun {
+	var result []*TextRun
+	for _, run := range runs {
+		from, to := max(run.StartPos, start), min(run.EndPos, end)
+		if from >= to {
+			continue
+		}
+		units := utf16.Encode([]rune(run.Text))
+		if int(to-run.StartPos) > len(units) {
+			continue
+		}
+		part := *run
+		part.Text = string(utf16.Decode(units[from-run.StartPos : to-run.StartPos]))
+		part.StartPos, part.EndPos = from, to
+		if length := run.EndPos - run.StartPos; length > 0 && run.ByteLength > 0 {
+			bytesPerChar := run.ByteLength / length
+			part.FileOffset = run.FileOffset + (from-run.StartPos)*bytesPerChar
+			part.ByteLength = (to - from) * bytesPerChar
+		}
+		result = append(result, &part)
+	}
+	return result
+}
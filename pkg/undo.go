@@ -0,0 +1,30 @@
+package msdoc
+
+import "fmt"
+
+// HasUndoData reports whether the document carries undo information, which
+// Word 2000 and later may save in the table stream.
+func (d *Document) HasUndoData() bool {
+	return d.fib.RgFcLcb.LcbDocUndoWord9 > 0
+}
+
+// UndoData returns the undo information saved with the document, located by
+// fcDocUndoWord9 in the table stream. Its layout is specific to the version
+// of Word that saved it and undocumented, so it is returned raw for
+// forensic use, such as correlating documents saved by the same session.
+// Returns nil if the document has no undo information.
+func (d *Document) UndoData() ([]byte, error) {
+	rgfc := d.fib.RgFcLcb
+	if rgfc.LcbDocUndoWord9 == 0 {
+		return nil, nil
+	}
+
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(rgfc.FcDocUndoWord9)+uint64(rgfc.LcbDocUndoWord9) > uint64(len(table.Data)) {
+		return nil, fmt.Errorf("table stream too small for undo information")
+	}
+	return table.Data[rgfc.FcDocUndoWord9 : rgfc.FcDocUndoWord9+rgfc.LcbDocUndoWord9], nil
+}
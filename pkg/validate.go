@@ -0,0 +1,178 @@
+package msdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Severity ranks how much an Issue found by Validate affects the document.
+type Severity int
+
+const (
+	SeverityInfo    Severity = iota // Noteworthy content, such as macros
+	SeverityWarning                 // Inconsistency that readers work around
+	SeverityError                   // Damage that makes part of the document unreadable
+)
+
+// String returns a human-readable name for the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Unknown (%d)", int(s))
+	}
+}
+
+// Issue is a finding reported by Validate.
+type Issue struct {
+	Severity Severity
+	Check    string // Check that found the issue: "fib", "piece-table", "table-stream", "property-set" or "content"
+	Message  string
+}
+
+// Word 97 and later FIBs have 14 FibRgW values and 22 FibRgLw values.
+const (
+	fibCsw  = 14
+	fibCslw = 22
+)
+
+// Validate runs structural checks on the document and returns the issues
+// found, or nil if there are none. It checks the FIB, the coverage of the
+// piece table, that the FIB offsets into the table stream are in bounds and
+// the layout of the property set streams, and reports macros and embedded
+// objects for callers deciding whether to process the document further.
+//
+// Validate does not stop at the first problem, so a damaged document may
+// report several issues with the same cause.
+func (d *Document) Validate() []Issue {
+	var issues []Issue
+	report := func(severity Severity, check, format string, args ...any) {
+		issues = append(issues, Issue{Severity: severity, Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+
+	d.validateFIB(report)
+
+	info, err := d.pieceTableCoverage()
+	if err != nil {
+		report(SeverityError, "piece-table", "%v", err)
+	} else {
+		for _, warning := range info.Warnings {
+			report(SeverityWarning, "piece-table", "%s", warning)
+		}
+	}
+
+	d.validateTableStream(report)
+
+	for _, name := range []string{"\x05SummaryInformation", "\x05DocumentSummaryInformation"} {
+		data, err := d.reader.ReadStream(name)
+		if err != nil {
+			continue // Property sets are optional
+		}
+		if err := validatePropertySet(data); err != nil {
+			report(SeverityError, "property-set", "%s: %v", name[1:], err)
+		}
+	}
+
+	if d.HasMacros() {
+		report(SeverityInfo, "content", "document contains VBA macros")
+	}
+	if d.HasEmbeddedObjects() {
+		report(SeverityInfo, "content", "document contains embedded objects")
+	}
+	return issues
+}
+
+// validateFIB checks the FIB fields that ParseFIB accepts but Word never
+// writes, and that the table stream it names exists.
+func (d *Document) validateFIB(report func(Severity, string, string, ...any)) {
+	f := d.fib
+	switch f.NFib() {
+	case 0x00C1, 0x00D9, 0x0101, 0x010C, 0x0112:
+	default:
+		report(SeverityWarning, "fib", "unknown nFib 0x%04X", f.NFib())
+	}
+	if f.Csw != fibCsw {
+		report(SeverityWarning, "fib", "csw is %d, expected %d", f.Csw, fibCsw)
+	}
+	if f.Cslw != fibCslw {
+		report(SeverityWarning, "fib", "cslw is %d, expected %d", f.Cslw, fibCslw)
+	}
+	if _, err := d.reader.ReadStream(f.GetTableStreamName()); err != nil {
+		report(SeverityError, "fib", "table stream %s is missing", f.GetTableStreamName())
+	}
+}
+
+// validateTableStream checks that the main structures the FIB locates in the
+// table stream lie within it.
+func (d *Document) validateTableStream(report func(Severity, string, string, ...any)) {
+	table, err := d.tableStream()
+	if err != nil {
+		report(SeverityError, "table-stream", "%v", err)
+		return
+	}
+
+	rgfc := d.fib.RgFcLcb
+	for _, entry := range []struct {
+		name    string
+		fc, lcb uint32
+	}{
+		{"Clx", rgfc.FcClx, rgfc.LcbClx},
+		{"STSH", rgfc.FcStshf, rgfc.LcbStshf},
+		{"PlcBteChpx", rgfc.FcPlcfbteChpx, rgfc.LcbPlcfbteChpx},
+		{"PlcBtePapx", rgfc.FcPlcfbtePapx, rgfc.LcbPlcfbtePapx},
+		{"PlcfSed", rgfc.FcPlcfsed, rgfc.LcbPlcfsed},
+		{"SttbfFfn", rgfc.FcSttbfffn, rgfc.LcbSttbfffn},
+		{"Dop", rgfc.FcDop, rgfc.LcbDop},
+		{"PlcfFldMom", rgfc.FcPlcffldMom, rgfc.LcbPlcffldMom},
+		{"PlcfandRef", rgfc.FcPlcfandRef, rgfc.LcbPlcfandRef},
+		{"SttbfRMark", rgfc.FcSttbfRMark, rgfc.LcbSttbfRMark},
+	} {
+		if entry.lcb > 0 && uint64(entry.fc)+uint64(entry.lcb) > uint64(len(table.Data)) {
+			report(SeverityError, "table-stream", "%s at offset %d with size %d lies outside the %d-byte table stream", entry.name, entry.fc, entry.lcb, len(table.Data))
+		}
+	}
+}
+
+// validatePropertySet checks the layout of a property set stream: the
+// header, the FMTID and offset of each property set and the property
+// identifier and offset pairs of each section.
+func validatePropertySet(data []byte) error {
+	const headerSize = 28
+	if len(data) < headerSize {
+		return fmt.Errorf("stream too short for the header")
+	}
+	if binary.LittleEndian.Uint16(data) != 0xFFFE {
+		return fmt.Errorf("invalid byte order mark 0x%04X", binary.LittleEndian.Uint16(data))
+	}
+	count := binary.LittleEndian.Uint32(data[24:])
+	if count == 0 || uint64(headerSize)+uint64(count)*20 > uint64(len(data)) {
+		return fmt.Errorf("invalid property set count %d", count)
+	}
+
+	for i := 0; i < int(count); i++ {
+		offset := binary.LittleEndian.Uint32(data[headerSize+i*20+16:])
+		if uint64(offset)+8 > uint64(len(data)) {
+			return fmt.Errorf("property set %d at offset %d out of bounds", i, offset)
+		}
+		section := data[offset:]
+		size := binary.LittleEndian.Uint32(section)
+		properties := binary.LittleEndian.Uint32(section[4:])
+		if size < 8 || uint64(size) > uint64(len(section)) {
+			return fmt.Errorf("property set %d has invalid size %d", i, size)
+		}
+		if 8+uint64(properties)*8 > uint64(size) {
+			return fmt.Errorf("property set %d has invalid property count %d", i, properties)
+		}
+		for j := 0; j < int(properties); j++ {
+			if propOffset := binary.LittleEndian.Uint32(section[8+j*8+4:]); propOffset >= size {
+				return fmt.Errorf("property %d of property set %d at offset %d out of bounds", j, i, propOffset)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package msdoc
+
+import "fmt"
+
+// Variables returns the document variables, keyed by name. These are the
+// values set through ActiveDocument.Variables in VBA and inserted into the
+// text by DOCVARIABLE fields, and are the whole of the user-defined table
+// (StwUser) of the FIB. Custom dictionaries and AutoCorrect entries are kept
+// by Word outside the document and never appear here.
+//
+// Returns an empty map if the document has no variables.
+func (d *Document) Variables() (map[string]string, error) {
+	table, err := d.tableStream()
+	if err != nil {
+		return nil, err
+	}
+
+	stw, err := table.GetUserVariables(d.fib.RgFcLcb.FcStwUser, d.fib.RgFcLcb.LcbStwUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse document variables: %w", err)
+	}
+
+	variables := make(map[string]string)
+	if stw != nil {
+		for i, name := range stw.Names {
+			variables[name] = stw.Values[i]
+		}
+	}
+	return variables, nil
+}
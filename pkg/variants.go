@@ -0,0 +1,44 @@
+package msdoc
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrUnsupportedVariant is returned when opening a file written by a Word
+// variant whose format this package does not read, such as Word for
+// Macintosh 5.1 or Pocket Word. Word 98 and later for Macintosh write the
+// same format as Word for Windows and open normally.
+var ErrUnsupportedVariant = errors.New("unsupported Word file variant")
+
+// variantSignatures are the first bytes of the files of Word variants that
+// are not stored as OLE2 compound files.
+var variantSignatures = []struct {
+	prefix []byte
+	name   string
+}{
+	{[]byte{0xFE, 0x37, 0x00, 0x1C}, "Word for Macintosh 4"},
+	{[]byte{0xFE, 0x37, 0x00, 0x23}, "Word for Macintosh 5"},
+	{[]byte{0xFE, 0x37}, "Word for Macintosh"},
+	{[]byte{0x31, 0xBE, 0x00, 0x00}, "Word for DOS"},
+	{[]byte{0x9B, 0xA5}, "Word for Windows 1"},
+	{[]byte{0xDB, 0xA5}, "Word for Windows 2"},
+	{[]byte(`{\pwi`), "Pocket Word"},
+}
+
+// detectVariant returns the name of the Word variant that wrote a file
+// starting with header, or "" if it is not one of the unsupported variants.
+func detectVariant(header []byte) string {
+	for _, signature := range variantSignatures {
+		if bytes.HasPrefix(header, signature.prefix) {
+			return signature.name
+		}
+	}
+	return ""
+}
+
+// isBigEndianFIB reports whether a WordDocument stream starts with a
+// byte-swapped wIdent, as written by big-endian Macintosh converters.
+func isBigEndianFIB(wordDocument []byte) bool {
+	return len(wordDocument) >= 2 && wordDocument[0] == 0xA5 && wordDocument[1] == 0xEC
+}
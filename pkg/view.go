@@ -0,0 +1,67 @@
+package msdoc
+
+// ViewType identifies how Word displayed the document when it was saved.
+type ViewType int
+
+const (
+	ViewNone    ViewType = iota // No view recorded
+	ViewPrint                   // Print layout
+	ViewOutline                 // Outline view
+	ViewMaster                  // Master document view
+	ViewNormal                  // Normal (draft) view
+	ViewWeb                     // Web layout
+)
+
+// String returns the name of the view.
+func (v ViewType) String() string {
+	switch v {
+	case ViewNone:
+		return "None"
+	case ViewPrint:
+		return "Print"
+	case ViewOutline:
+		return "Outline"
+	case ViewMaster:
+		return "Master"
+	case ViewNormal:
+		return "Normal"
+	case ViewWeb:
+		return "Web"
+	default:
+		return "Unknown"
+	}
+}
+
+// ZoomType identifies how the zoom level was chosen.
+type ZoomType int
+
+const (
+	ZoomPercent   ZoomType = iota // Fixed percentage
+	ZoomFullPage                  // Fit the whole page
+	ZoomPageWidth                 // Fit the page width
+	ZoomTextWidth                 // Fit the text width
+)
+
+// ViewState is the view and zoom Word used when the document was saved.
+type ViewState struct {
+	View        ViewType // View mode
+	ZoomPercent int      // Zoom level in percent
+	ZoomType    ZoomType // How the zoom level was chosen
+}
+
+// ViewState returns the view and zoom saved with the document.
+//
+// Word records these in the document properties (DOP) alongside the window
+// save state. Returns nil with no error if the document has no DOP.
+func (d *Document) ViewState() (*ViewState, error) {
+	dop, err := d.documentProperties()
+	if err != nil || dop == nil || !dop.HasStatistics() {
+		return nil, err
+	}
+
+	return &ViewState{
+		View:        ViewType(dop.WvkoSaved),
+		ZoomPercent: int(dop.PctWwdSaved),
+		ZoomType:    ZoomType(dop.ZkSaved),
+	}, nil
+}
@@ -32,6 +32,10 @@ import (
 // This is an alias for writer.DocumentWriter to maintain clean public API.
 type DocumentWriter = writer.DocumentWriter
 
+// PageSetup holds the page size and margins used by a DocumentWriter.
+// This is an alias for writer.PageSetup.
+type PageSetup = writer.PageSetup
+
 // NewDocumentWriter creates a new document writer for creating .doc files.
 // This function replaces the previous stub implementation with full functionality.
 func NewDocumentWriter() *DocumentWriter {
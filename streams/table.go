@@ -117,6 +117,45 @@ func (ts *TableStream) GetFontTable(fcSttbfffn, lcbSttbfffn uint32) ([]byte, err
 	return result, nil
 }
 
+// GetDOP extracts the document properties from the specified location.
+func (ts *TableStream) GetDOP(fcDop, lcbDop uint32) (*structures.DOP, error) {
+	if lcbDop == 0 {
+		return nil, nil // No document properties
+	}
+
+	if uint64(fcDop)+uint64(lcbDop) > uint64(len(ts.Data)) {
+		return nil, fmt.Errorf("table: document properties location out of bounds")
+	}
+
+	return structures.ParseDOP(ts.Data[fcDop : fcDop+lcbDop])
+}
+
+// GetRevisionAuthors extracts the revision mark author table from the specified location.
+func (ts *TableStream) GetRevisionAuthors(fcSttbfRMark, lcbSttbfRMark uint32) (*structures.STTB, error) {
+	if lcbSttbfRMark == 0 {
+		return nil, nil // No revision authors
+	}
+
+	if uint64(fcSttbfRMark)+uint64(lcbSttbfRMark) > uint64(len(ts.Data)) {
+		return nil, fmt.Errorf("table: revision author table location out of bounds")
+	}
+
+	return structures.ParseSTTB(ts.Data[fcSttbfRMark : fcSttbfRMark+lcbSttbfRMark])
+}
+
+// GetUserVariables extracts the document variables from the specified location.
+func (ts *TableStream) GetUserVariables(fcStwUser, lcbStwUser uint32) (*structures.StwUser, error) {
+	if lcbStwUser == 0 {
+		return nil, nil // No document variables
+	}
+
+	if uint64(fcStwUser)+uint64(lcbStwUser) > uint64(len(ts.Data)) {
+		return nil, fmt.Errorf("table: document variable table location out of bounds")
+	}
+
+	return structures.ParseStwUser(ts.Data[fcStwUser : fcStwUser+lcbStwUser])
+}
+
 // IsEncrypted checks if this table stream contains encryption information.
 func (ts *TableStream) IsEncrypted() bool {
 	// For encrypted documents, the table stream starts with an EncryptionHeader
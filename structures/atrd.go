@@ -0,0 +1,66 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// ATRDSize is the size of an ATRD, the data element of the annotation
+// reference PLC (PlcfandRef).
+const ATRDSize = 30
+
+// ATRD describes the comment referenced at one position of the main document.
+type ATRD struct {
+	Initials    string // Initials of the author, at most 9 characters
+	AuthorIndex int16  // Index of the author in the GrpXstAtnOwners group
+	BookmarkTag int32  // Tag of the bookmark marking the commented range, -1 if none
+}
+
+// ParseATRD parses an ATRD from the start of data.
+func ParseATRD(data []byte) (*ATRD, error) {
+	if len(data) < ATRDSize {
+		return nil, fmt.Errorf("atrd: data too short (%d bytes)", len(data))
+	}
+
+	// xstUsrInitl: a character count followed by up to 9 UTF-16 characters
+	cch := int(binary.LittleEndian.Uint16(data[0:2]))
+	if cch > 9 {
+		return nil, fmt.Errorf("atrd: invalid initials length %d", cch)
+	}
+	u16s := make([]uint16, cch)
+	for i := range u16s {
+		u16s[i] = binary.LittleEndian.Uint16(data[2+i*2:])
+	}
+
+	return &ATRD{
+		Initials:    string(utf16.Decode(u16s)),
+		AuthorIndex: int16(binary.LittleEndian.Uint16(data[20:22])),
+		BookmarkTag: int32(binary.LittleEndian.Uint32(data[26:30])),
+	}, nil
+}
+
+// ParseXstGroup parses a group of Xst strings, such as the comment authors in
+// GrpXstAtnOwners. Each Xst is a character count followed by that many
+// UTF-16 characters, with no terminator.
+func ParseXstGroup(data []byte) ([]string, error) {
+	var strs []string
+	offset := 0
+	for offset < len(data) {
+		if offset+2 > len(data) {
+			return nil, fmt.Errorf("xst: not enough data for length of string %d", len(strs))
+		}
+		cch := int(binary.LittleEndian.Uint16(data[offset:]))
+		offset += 2
+		if offset+cch*2 > len(data) {
+			return nil, fmt.Errorf("xst: not enough data for string %d", len(strs))
+		}
+		u16s := make([]uint16, cch)
+		for i := range u16s {
+			u16s[i] = binary.LittleEndian.Uint16(data[offset+i*2:])
+		}
+		strs = append(strs, string(utf16.Decode(u16s)))
+		offset += cch * 2
+	}
+	return strs, nil
+}
@@ -0,0 +1,26 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// FBKFSize is the size of the FBKF data elements of PlcfBkf.
+const FBKFSize = 4
+
+// FBKF describes the start of a bookmark in PlcfBkf.
+type FBKF struct {
+	Ibkl int16  // Index of the bookmark's end in PlcfBkl
+	Bkc  uint16 // Table column range of column bookmarks
+}
+
+// ParseFBKF parses an FBKF data element.
+func ParseFBKF(data []byte) (*FBKF, error) {
+	if len(data) < FBKFSize {
+		return nil, fmt.Errorf("fbkf: data too short, need %d bytes", FBKFSize)
+	}
+	return &FBKF{
+		Ibkl: int16(binary.LittleEndian.Uint16(data)),
+		Bkc:  binary.LittleEndian.Uint16(data[2:]),
+	}, nil
+}
@@ -0,0 +1,230 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// DOPVersion identifies which revision of the DOP structure a document uses.
+// Each Word version appends fields to the previous structure, so the version
+// is determined from the DOP's length.
+type DOPVersion int
+
+const (
+	DOPVersionUnknown DOPVersion = iota
+	DOPVersionBase               // DopBase (84 bytes)
+	DOPVersion95                 // Dop95 (88 bytes)
+	DOPVersion97                 // Dop97 (500 bytes)
+	DOPVersion2000               // Dop2000 (544 bytes)
+	DOPVersion2002               // Dop2002 (594 bytes)
+	DOPVersion2003               // Dop2003 (616 bytes)
+	DOPVersion2007               // Dop2007 (674 bytes)
+)
+
+// Sizes of each DOP revision in bytes.
+const (
+	dopBaseSize = 84
+	dop95Size   = 88
+	dop97Size   = 500
+	dop2000Size = 544
+	dop2002Size = 594
+	dop2003Size = 616
+	dop2007Size = 674
+)
+
+// DOP (Document Properties) holds document-wide settings stored in the table
+// stream. The structure grows with each Word version; the fields below are
+// decoded when the DOP is long enough to contain them and the raw data is
+// kept for the rest.
+type DOP struct {
+	Version DOPVersion // DOP revision, determined from its length
+
+	FFacingPages  bool // True if odd and even pages have different headers
+	FWidowControl bool // True if widow and orphan control is on by default
+	FPMHMainDoc   bool // True if the document is a mail merge main document
+	FRevMarking   bool // True if revisions are being tracked
+	FRMView       bool // True if revision marks are shown on screen, set by default
+	FRMPrint      bool // True if revision marks are printed
+	FLockAtn      bool // True if only comments may be added, or nothing with FTreatLockAtnAsReadOnly
+	FProtEnabled  bool // True if only form fields may be filled in
+	FLockRev      bool // True if every change is tracked and tracking cannot be turned off
+
+	DxaTab uint16 // Interval between default tab stops in twips
+
+	// Document statistics (DopBase)
+	CWords int32  // Number of words in the main document
+	CCh    int32  // Number of characters, excluding spaces
+	CPg    uint16 // Number of pages
+	CParas int32  // Number of paragraphs
+	CLines int32  // Number of lines
+
+	LKeyProtDoc uint32 // Hash of the protection password, zero if none
+
+	// Saved view (DopBase)
+	WvkoSaved   uint8  // View in use when the document was saved
+	PctWwdSaved uint16 // Zoom percentage when the document was saved
+	ZkSaved     uint8  // Zoom mode when the document was saved
+
+	// Dop97 additions
+	CChWS        int32  // Number of characters, including spaces
+	GrfDocEvents uint32 // Document events that have VBA handlers
+
+	// Dop2003 additions
+	FTreatLockAtnAsReadOnly bool // True if FLockAtn means read-only protection
+
+	Data []byte // Raw DOP data
+}
+
+// ParseDOP parses a DOP structure from raw data.
+func ParseDOP(data []byte) (*DOP, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("dop: data too short, need at least 8 bytes")
+	}
+
+	dop := &DOP{
+		Version: dopVersionForSize(len(data)),
+		Data:    make([]byte, len(data)),
+	}
+	copy(dop.Data, data)
+
+	dop.FFacingPages = data[0]&0x01 != 0
+	dop.FWidowControl = data[0]&0x02 != 0
+	dop.FPMHMainDoc = data[0]&0x04 != 0
+	dop.FRevMarking = data[5]&0x80 != 0
+	dop.FLockAtn = data[6]&0x10 != 0
+	dop.FProtEnabled = data[7]&0x02 != 0
+	dop.FRMView = data[7]&0x08 != 0
+	dop.FRMPrint = data[7]&0x10 != 0
+	dop.FLockRev = data[7]&0x40 != 0
+
+	if len(data) >= 12 {
+		dop.DxaTab = binary.LittleEndian.Uint16(data[10:])
+	}
+
+	if len(data) >= dopBaseSize {
+		dop.CWords = int32(binary.LittleEndian.Uint32(data[38:]))
+		dop.CCh = int32(binary.LittleEndian.Uint32(data[42:]))
+		dop.CPg = binary.LittleEndian.Uint16(data[46:])
+		dop.CParas = int32(binary.LittleEndian.Uint32(data[48:]))
+		dop.CLines = int32(binary.LittleEndian.Uint32(data[56:]))
+		dop.LKeyProtDoc = binary.LittleEndian.Uint32(data[78:])
+
+		view := binary.LittleEndian.Uint16(data[82:])
+		dop.WvkoSaved = uint8(view & 0x0007)
+		dop.PctWwdSaved = (view >> 3) & 0x01FF
+		dop.ZkSaved = uint8((view >> 12) & 0x0003)
+	}
+
+	if len(data) >= dop97Size {
+		dop.CChWS = int32(binary.LittleEndian.Uint32(data[426:]))
+		dop.GrfDocEvents = binary.LittleEndian.Uint32(data[434:])
+	}
+
+	if len(data) >= dop2003Size {
+		dop.FTreatLockAtnAsReadOnly = data[dop2002Size]&0x01 != 0
+	}
+
+	return dop, nil
+}
+
+// HasStatistics returns true if the DOP is long enough to contain document statistics.
+func (dop *DOP) HasStatistics() bool {
+	return dop.Version >= DOPVersionBase
+}
+
+// dopVersionForSize returns the newest DOP revision that fits in size bytes.
+func dopVersionForSize(size int) DOPVersion {
+	switch {
+	case size >= dop2007Size:
+		return DOPVersion2007
+	case size >= dop2003Size:
+		return DOPVersion2003
+	case size >= dop2002Size:
+		return DOPVersion2002
+	case size >= dop2000Size:
+		return DOPVersion2000
+	case size >= dop97Size:
+		return DOPVersion97
+	case size >= dop95Size:
+		return DOPVersion95
+	case size >= dopBaseSize:
+		return DOPVersionBase
+	default:
+		return DOPVersionUnknown
+	}
+}
+
+// Offsets of the compatibility option blocks within the DOP.
+const (
+	copts60Offset = 8           // Copts60 in DopBase
+	copts80Offset = dopBaseSize // Copts80 in Dop95
+	coptsOffset   = 510         // Copts in Dop2000
+)
+
+// copts60Names names the bits of Copts60, the compatibility options of
+// DopBase. Empty names are unused bits.
+var copts60Names = [16]string{
+	"fNoTabForInd", "fNoSpaceRaiseLower", "fSuppressSpBfAfterPgBrk", "fWrapTrailSpaces",
+	"fMapPrintTextColor", "fNoColumnBalance", "fConvMailMergeEsc", "fSuppressTopSpacing",
+	"fOrigWordTableRules", "", "fShowBreaksInFrames", "fSwapBordersFacingPgs",
+	"fLeaveBackslashAlone", "fExpShRtn", "fDntULTrlSpc", "fDntBlnSbDbWid",
+}
+
+// copts80Names names the bits Copts80 adds after its copy of Copts60.
+// fNoLeading is called fNoExtLeading in later revisions of the specification.
+var copts80Names = [16]string{
+	"fSuppressTopSpacingMac5", "fTruncDxaExpand", "fPrintBodyBeforeHdr", "fNoLeading",
+	"fDontMakeSpaceForUL", "fMWSmallCaps", "f2ptExtLeadingOnly", "fTruncFontHeight",
+	"fSubOnSize", "fLineWrapLikeWord6", "fWW6BorderRules", "fExactOnTop",
+	"fExtraAfter", "fWPSpace", "fWPJust", "fPrintMet",
+}
+
+// coptsNames names the bits Copts adds after its copy of Copts80.
+var coptsNames = [32]string{
+	"fSpLayoutLikeWW8", "fFtnLayoutLikeWW8", "fDontUseHTMLParagraphAutoSpacing", "fDontAdjustLineHeightInTable",
+	"fForgetLastTabAlign", "fUseAutospaceForFullWidthAlpha", "fAlignTablesRowByRow", "fLayoutRawTableWidth",
+	"fLayoutTableRowsApart", "fUseWord97LineBreakingRules", "fDontBreakWrappedTables", "fDontSnapToGridInCell",
+	"fDontAllowFieldEndSelect", "fApplyBreakingRules", "fDontWrapTextWithPunct", "fDontUseAsianBreakRules",
+	"fUseWord2002TableStyleRules", "fGrowAutoFit", "fUseNormalStyleForList", "fDontUseIndentAsNumberingTabStop",
+	"fFELineBreak11", "fAllowSpaceOfSameStyleInTable", "fWW11IndentRules", "fDontAutofitConstrainedTables",
+	"fAutofitLikeWW11", "fUnderlineTabInNumList", "fHangulWidthLikeWW11", "fSplitPgBreakAndParaMark",
+	"fDontVertAlignCellWithSp", "fDontBreakConstrainedForcedTables", "fDontVertAlignInTxbx", "fWord11KerningPairs",
+}
+
+// CompatibilityOptions returns the compatibility options stored in the DOP,
+// keyed by their name in the specification. Only the options present in the
+// DOP's revision are included.
+//
+// Newer revisions repeat the older option blocks; the most recent copy wins,
+// as it is the one Word reads.
+func (dop *DOP) CompatibilityOptions() map[string]bool {
+	options := make(map[string]bool)
+	data := dop.Data
+
+	copts60 := copts60Offset
+	if len(data) >= dop2000Size {
+		copts60 = coptsOffset
+	} else if len(data) >= dop95Size {
+		copts60 = copts80Offset
+	}
+	if copts60+2 <= len(data) {
+		setOptionBits(options, copts60Names[:], uint32(binary.LittleEndian.Uint16(data[copts60:])))
+	}
+
+	if len(data) >= dop95Size {
+		setOptionBits(options, copts80Names[:], uint32(binary.LittleEndian.Uint16(data[copts60+2:])))
+	}
+	if len(data) >= dop2000Size {
+		setOptionBits(options, coptsNames[:], binary.LittleEndian.Uint32(data[coptsOffset+4:]))
+	}
+	return options
+}
+
+// setOptionBits records each named bit of bits in options.
+func setOptionBits(options map[string]bool, names []string, bits uint32) {
+	for i, name := range names {
+		if name != "" {
+			options[name] = bits&(1<<i) != 0
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// FFDataType is the kind of a form field (iType).
+type FFDataType uint8
+
+const (
+	FFDataTypeText     FFDataType = 0 // Text box
+	FFDataTypeCheckBox FFDataType = 1 // Check box
+	FFDataTypeDropDown FFDataType = 2 // Drop-down list
+)
+
+// FFDataHeaderSize is the size of the header preceding an FFData in the Data
+// stream (cbHeader of NilPICFAndBinData).
+const FFDataHeaderSize = 0x44
+
+// FFData holds the definition and current value of a form field. It is stored
+// in the Data stream at the location given by the field begin character.
+type FFData struct {
+	Type       FFDataType
+	Result     uint8  // Checked state of a check box, or selected entry of a drop-down list
+	Protected  bool   // The field cannot be filled in
+	TextType   uint8  // Kind of text a text box accepts (regular, number, date, ...)
+	Recalc     bool   // Fields are recalculated when the user leaves the field
+	MaxLength  uint16 // Maximum length of a text box, zero if unlimited
+	Name       string // Name of the field, also its bookmark name
+	Default    string // Default text of a text box
+	DefaultIdx uint16 // Default state of a check box or default entry of a drop-down list
+	Format     string // Format of a text box result
+	HelpText   string // Help text, or the name of an AutoText entry holding it
+	StatusText string // Status bar text, or the name of an AutoText entry holding it
+	EntryMacro string // Macro run when the field is entered
+	ExitMacro  string // Macro run when the field is left
+	Entries    []string
+}
+
+// ParseFFData parses an FFData structure from the start of data.
+func ParseFFData(data []byte) (*FFData, error) {
+	if len(data) < 10 {
+		return nil, fmt.Errorf("ffdata: data too short (%d bytes)", len(data))
+	}
+	if version := binary.LittleEndian.Uint32(data); version != 0xFFFFFFFF {
+		return nil, fmt.Errorf("ffdata: invalid version 0x%08X", version)
+	}
+
+	bits := binary.LittleEndian.Uint16(data[4:])
+	ff := &FFData{
+		Type:      FFDataType(bits & 0x0003),
+		Result:    uint8(bits>>2) & 0x1F,
+		Protected: bits&0x0200 != 0,
+		TextType:  uint8(bits>>11) & 0x07,
+		Recalc:    bits&0x4000 != 0,
+		MaxLength: binary.LittleEndian.Uint16(data[6:]),
+	}
+	// data[8:10] is the check box size, which is not needed
+
+	offset := 10
+	var err error
+	readXstz := func() string {
+		if err != nil {
+			return ""
+		}
+		var s string
+		s, offset, err = parseXstz(data, offset)
+		return s
+	}
+
+	ff.Name = readXstz()
+	if ff.Type == FFDataTypeText {
+		ff.Default = readXstz()
+	} else if err == nil {
+		if offset+2 > len(data) {
+			return nil, fmt.Errorf("ffdata: not enough data for default value")
+		}
+		ff.DefaultIdx = binary.LittleEndian.Uint16(data[offset:])
+		offset += 2
+	}
+	ff.Format = readXstz()
+	ff.HelpText = readXstz()
+	ff.StatusText = readXstz()
+	ff.EntryMacro = readXstz()
+	ff.ExitMacro = readXstz()
+	if err != nil {
+		return nil, fmt.Errorf("ffdata: %w", err)
+	}
+
+	if ff.Type == FFDataTypeDropDown {
+		entries, err := ParseSTTB(data[offset:])
+		if err != nil {
+			return nil, fmt.Errorf("ffdata: drop-down entries: %w", err)
+		}
+		ff.Entries = entries.Strings
+	}
+	return ff, nil
+}
+
+// parseXstz parses a null-terminated Xst at offset and returns it with the
+// offset just past its terminator.
+func parseXstz(data []byte, offset int) (string, int, error) {
+	if offset+2 > len(data) {
+		return "", 0, fmt.Errorf("not enough data for string length at %d", offset)
+	}
+	cch := int(binary.LittleEndian.Uint16(data[offset:]))
+	offset += 2
+	if offset+(cch+1)*2 > len(data) {
+		return "", 0, fmt.Errorf("not enough data for string at %d", offset)
+	}
+	u16s := make([]uint16, cch)
+	for i := range u16s {
+		u16s[i] = binary.LittleEndian.Uint16(data[offset+i*2:])
+	}
+	return string(utf16.Decode(u16s)), offset + (cch+1)*2, nil
+}
@@ -0,0 +1,114 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// FontFamily identifies the general design of a font (the FFN ff field).
+type FontFamily uint8
+
+const (
+	FontFamilyDontCare   FontFamily = iota // Unknown or don't care
+	FontFamilyRoman                        // Proportional serif fonts
+	FontFamilySwiss                        // Proportional sans-serif fonts
+	FontFamilyModern                       // Fixed-pitch fonts
+	FontFamilyScript                       // Handwriting-like fonts
+	FontFamilyDecorative                   // Novelty fonts
+)
+
+// FontPitch identifies the pitch of a font (the FFN prq field).
+type FontPitch uint8
+
+const (
+	FontPitchDefault  FontPitch = iota // Default pitch
+	FontPitchFixed                     // Fixed pitch
+	FontPitchVariable                  // Variable pitch
+)
+
+// ffnHeaderSize is the size of the fixed part of an FFN, before the font names.
+const ffnHeaderSize = 39
+
+// FFN (Font Family Name) describes a font used in the document.
+type FFN struct {
+	Name     string     // Font name
+	AltName  string     // Alternate font name to use if Name is unavailable
+	Charset  uint8      // Character set identifier
+	Family   FontFamily // Font family
+	Pitch    FontPitch  // Font pitch
+	TrueType bool       // True if the font is a TrueType font
+	Weight   int16      // Font weight (400 is normal, 700 is bold)
+}
+
+// ParseSttbfFfn parses the font table (SttbfFfn). The index of each font in
+// the returned slice is the ftc value used by character properties.
+func ParseSttbfFfn(data []byte) ([]*FFN, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("sttbfffn: data too short, need at least 4 bytes")
+	}
+
+	count := int(binary.LittleEndian.Uint16(data[0:2]))
+	offset := 4 // cData and cbExtra
+
+	fonts := make([]*FFN, 0, count)
+	for i := 0; i < count; i++ {
+		if offset >= len(data) {
+			return nil, fmt.Errorf("sttbfffn: not enough data for length of font %d", i)
+		}
+		cb := int(data[offset])
+		offset++
+		if offset+cb > len(data) {
+			return nil, fmt.Errorf("sttbfffn: not enough data for font %d", i)
+		}
+
+		ffn, err := ParseFFN(data[offset : offset+cb])
+		if err != nil {
+			return nil, fmt.Errorf("sttbfffn: failed to parse font %d: %w", i, err)
+		}
+		fonts = append(fonts, ffn)
+		offset += cb
+	}
+
+	return fonts, nil
+}
+
+// ParseFFN parses a single FFN structure.
+func ParseFFN(data []byte) (*FFN, error) {
+	if len(data) < ffnHeaderSize {
+		return nil, fmt.Errorf("ffn: data too short, need at least %d bytes", ffnHeaderSize)
+	}
+
+	ffn := &FFN{
+		Pitch:    FontPitch(data[0] & 0x03),
+		TrueType: data[0]&0x04 != 0,
+		Family:   FontFamily((data[0] >> 4) & 0x07),
+		Weight:   int16(binary.LittleEndian.Uint16(data[1:3])),
+		Charset:  data[3],
+	}
+	ixchSzAlt := int(data[4])
+
+	// xszFfn holds the font name and optional alternate name as
+	// null-terminated UTF-16 strings
+	names := make([]uint16, (len(data)-ffnHeaderSize)/2)
+	for i := range names {
+		names[i] = binary.LittleEndian.Uint16(data[ffnHeaderSize+i*2:])
+	}
+
+	ffn.Name = nullTerminatedUTF16(names)
+	if ixchSzAlt > 0 && ixchSzAlt < len(names) {
+		ffn.AltName = nullTerminatedUTF16(names[ixchSzAlt:])
+	}
+
+	return ffn, nil
+}
+
+// nullTerminatedUTF16 decodes UTF-16 code units up to the first null.
+func nullTerminatedUTF16(units []uint16) string {
+	for i, u := range units {
+		if u == 0 {
+			return string(utf16.Decode(units[:i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
@@ -9,9 +9,10 @@ import (
 type Field struct {
 	Start      CP     // Character position where field starts
 	End        CP     // Character position where field ends
-	FieldType  byte   // Field type (19h for HYPERLINK)
+	FieldType  byte   // Field type (flt) of the begin character, 58h for HYPERLINK
 	FieldCode  string // The field code (e.g., "HYPERLINK \"url\"")
 	DisplayText string // The display text for the field
+	ResultEnd  CP     // Character position of the field end character; the result lies between End and ResultEnd
 }
 
 // HyperlinkField represents a parsed hyperlink field
@@ -46,37 +47,58 @@ func (fplc *FieldPLC) GetFields() ([]*Field, error) {
 
 	fields := make([]*Field, 0)
 
-	// Fields come in pairs: field start and field end
-	for i := 0; i < fplc.Count(); i += 2 {
-		if i+1 >= fplc.Count() {
-			break // Incomplete pair
-		}
-
-		startCP, endCP, err := fplc.GetRange(i)
+	// Each field has a begin, an optional separator and an end character.
+	// Fields may be nested, so open fields are kept on a stack; a field
+	// covers its code, from the begin character up to the separator or, if
+	// there is none, the end character. The result follows up to the end
+	// character.
+	var open []*Field
+	var closed []bool // Whether each open field's code has been closed by a separator
+	for i := 0; i < fplc.Count(); i++ {
+		startCP, _, err := fplc.GetRange(i)
 		if err != nil {
 			continue
 		}
 
 		fieldData, err := fplc.GetDataAt(i)
-		if err != nil {
+		if err != nil || len(fieldData) < 2 {
 			continue
 		}
 
-		// Parse FLD structure (2 bytes)
-		if len(fieldData) < 2 {
-			continue
+		// The character type is in the lower 5 bits of first byte; the second
+		// byte of a begin character is the field type
+		switch fieldData[0] & 0x1F {
+		case 0x13: // Field begin
+			field := &Field{
+				Start:     startCP,
+				FieldType: fieldData[1],
+			}
+			fields = append(fields, field)
+			open = append(open, field)
+			closed = append(closed, false)
+		case 0x14: // Field separator
+			if len(open) > 0 && !closed[len(closed)-1] {
+				open[len(open)-1].End = startCP
+				closed[len(closed)-1] = true
+			}
+		case 0x15: // Field end
+			if len(open) > 0 {
+				if !closed[len(closed)-1] {
+					open[len(open)-1].End = startCP
+				}
+				open[len(open)-1].ResultEnd = startCP
+				open = open[:len(open)-1]
+				closed = closed[:len(closed)-1]
+			}
 		}
+	}
 
-		// Field type is in the lower 5 bits of first byte
-		fieldType := fieldData[0] & 0x1F
-
-		field := &Field{
-			Start:     startCP,
-			End:       endCP,
-			FieldType: fieldType,
+	// Fields missing their separator and end characters cover no text
+	for i, field := range open {
+		if !closed[i] {
+			field.End = field.Start
 		}
-
-		fields = append(fields, field)
+		field.ResultEnd = field.End
 	}
 
 	return fields, nil
@@ -87,8 +109,8 @@ func ExtractHyperlinks(text string, fields []*Field) ([]*HyperlinkField, error)
 	hyperlinks := make([]*HyperlinkField, 0)
 
 	for _, field := range fields {
-		// Field type 58 (0x3A) is HYPERLINK in Word
-		if field.FieldType == 0x13 || field.FieldType == 0x3A {
+		// Field type 88 (0x58) is HYPERLINK in Word
+		if field.FieldType == 0x58 {
 			// This is a hyperlink field
 			startPos := int(field.Start)
 			endPos := int(field.End)
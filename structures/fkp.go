@@ -66,43 +66,49 @@ func ParseFKP(data []byte, fkpType FKPType) (*FKP, error) {
 }
 
 // parseCHPXFKP parses a character properties FKP.
+//
+// A CHPX FKP starts with crun+1 FCs delimiting the runs, followed by one byte
+// per run giving the word offset of its CHPX within the page. Each CHPX is a
+// length byte followed by that many bytes of sprms.
 func parseCHPXFKP(fkp *FKP) (*FKP, error) {
 	entryCount := fkp.EntryCount
 
 	// Validate that we have enough space for the entries
-	// Each entry is 5 bytes (4 bytes FC + 1 byte offset)
-	if entryCount*5 > FKPSize-1 { // -1 for the count byte
+	// (crun+1) FCs of 4 bytes each plus one offset byte per run
+	offsetsStart := (entryCount + 1) * 4
+	if offsetsStart+entryCount > FKPSize-1 { // -1 for the count byte
 		return nil, fmt.Errorf("fkp: too many entries (%d) for CHPX FKP", entryCount)
 	}
 
 	entries := make([]FKPEntry, entryCount)
 
-	// Each entry consists of a 4-byte FC followed by a 1-byte offset
 	for i := 0; i < entryCount; i++ {
-		entryOffset := i * 5 // 4 bytes FC + 1 byte offset
-		if entryOffset+5 > len(fkp.Data) {
-			return nil, fmt.Errorf("fkp: entry %d out of bounds", i)
-		}
+		fc := binary.LittleEndian.Uint32(fkp.Data[i*4 : i*4+4])
 
-		fc := binary.LittleEndian.Uint32(fkp.Data[entryOffset : entryOffset+4])
-		offset := uint16(fkp.Data[entryOffset+4])
+		// The stored offset is in words; zero means default properties
+		offset := uint16(fkp.Data[offsetsStart+i]) * 2
 
 		entry := FKPEntry{
 			FC:     fc,
 			Offset: offset,
 		}
 
-		// Extract the actual formatting data if offset is valid
-		if offset > 0 && int(offset) < FKPSize {
-			// The formatting data starts at the offset
+		// Extract the actual formatting data if offset is valid. The CHPX
+		// must lie after the offsets and before the count byte.
+		if offset > 0 {
+			if int(offset) < offsetsStart+entryCount || int(offset) >= FKPSize-1 {
+				return nil, fmt.Errorf("fkp: CHPX offset %d for entry %d out of bounds", offset, i)
+			}
+
 			// For CHPX, the first byte indicates the length
-			if int(offset) < len(fkp.Data) {
-				length := int(fkp.Data[offset])
-				endPos := int(offset) + 1 + length
-				if length > 0 && endPos <= len(fkp.Data) {
-					entry.Data = make([]byte, length)
-					copy(entry.Data, fkp.Data[int(offset)+1:endPos])
-				}
+			length := int(fkp.Data[offset])
+			endPos := int(offset) + 1 + length
+			if endPos > FKPSize-1 {
+				return nil, fmt.Errorf("fkp: CHPX for entry %d extends past the page", i)
+			}
+			if length > 0 {
+				entry.Data = make([]byte, length)
+				copy(entry.Data, fkp.Data[int(offset)+1:endPos])
 			}
 		}
 
@@ -114,43 +120,54 @@ func parseCHPXFKP(fkp *FKP) (*FKP, error) {
 }
 
 // parsePAPXFKP parses a paragraph properties FKP.
+//
+// A PAPX FKP starts with cpara+1 FCs delimiting the paragraphs, followed by a
+// 13-byte BxPap per paragraph whose first byte is the word offset of its PAPX
+// within the page. A PAPX starts with a count byte cb: a non-zero cb is
+// followed by 2*cb-1 bytes, while a zero cb is followed by a second count byte
+// cb' and 2*cb' bytes. Those bytes are the style index (istd) and the sprms.
 func parsePAPXFKP(fkp *FKP) (*FKP, error) {
+	const bxPapSize = 13
 	entryCount := fkp.EntryCount
 
 	// Validate that we have enough space for the entries
-	// Each entry is 6 bytes (4 bytes FC + 2 bytes offset)
-	if entryCount*6 > FKPSize-1 { // -1 for the count byte
+	// (cpara+1) FCs of 4 bytes each plus one BxPap per paragraph
+	bxStart := (entryCount + 1) * 4
+	if bxStart+entryCount*bxPapSize > FKPSize-1 { // -1 for the count byte
 		return nil, fmt.Errorf("fkp: too many entries (%d) for PAPX FKP", entryCount)
 	}
 
 	entries := make([]FKPEntry, entryCount)
 
-	// Each entry consists of a 4-byte FC followed by a 2-byte offset
 	for i := 0; i < entryCount; i++ {
-		entryOffset := i * 6 // 4 bytes FC + 2 bytes offset
-		if entryOffset+6 > len(fkp.Data) {
-			return nil, fmt.Errorf("fkp: entry %d out of bounds", i)
-		}
+		fc := binary.LittleEndian.Uint32(fkp.Data[i*4 : i*4+4])
 
-		fc := binary.LittleEndian.Uint32(fkp.Data[entryOffset : entryOffset+4])
-		offset := binary.LittleEndian.Uint16(fkp.Data[entryOffset+4 : entryOffset+6])
+		// The stored offset is in words; zero means default properties
+		offset := uint16(fkp.Data[bxStart+i*bxPapSize]) * 2
 
 		entry := FKPEntry{
 			FC:     fc,
 			Offset: offset,
 		}
 
-		// Extract the actual formatting data if offset is valid
-		if offset > 0 && int(offset) < FKPSize {
-			// For PAPX, the first byte indicates the length (multiply by 2)
-			if int(offset) < len(fkp.Data) {
-				lengthWords := int(fkp.Data[offset])
-				length := lengthWords * 2
-				endPos := int(offset) + 1 + length
-				if length > 0 && endPos <= len(fkp.Data) {
-					entry.Data = make([]byte, length)
-					copy(entry.Data, fkp.Data[int(offset)+1:endPos])
-				}
+		if offset > 0 {
+			if int(offset) < bxStart+entryCount*bxPapSize || int(offset) >= FKPSize-1 {
+				return nil, fmt.Errorf("fkp: PAPX offset %d for entry %d out of bounds", offset, i)
+			}
+
+			start := int(offset) + 1
+			length := 2*int(fkp.Data[offset]) - 1
+			if fkp.Data[offset] == 0 {
+				start++
+				length = 2 * int(fkp.Data[offset+1])
+			}
+			endPos := start + length
+			if endPos > FKPSize-1 {
+				return nil, fmt.Errorf("fkp: PAPX for entry %d extends past the page", i)
+			}
+			if length > 0 {
+				entry.Data = make([]byte, length)
+				copy(entry.Data, fkp.Data[start:endPos])
 			}
 		}
 
@@ -0,0 +1,174 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// Sizes of the fixed parts of the list structures.
+const (
+	lstfSize   = 28
+	lvlfSize   = 28
+	lfoSize    = 16
+	lfolvlSize = 8
+)
+
+// LVL describes the numbering of one level of a list.
+type LVL struct {
+	StartAt    int32  // Number of the first item at this level
+	Nfc        uint8  // Number format code, such as 0 for decimal
+	Jc         uint8  // Alignment of the number: left, center or right
+	NumberText string // Number text template; characters 0-8 stand for the numbers of those levels
+
+	// Sprms applied to the paragraphs and number text at this level
+	ParagraphGrpprl []byte
+	CharacterGrpprl []byte
+}
+
+// LSTF is a list definition together with its levels.
+type LSTF struct {
+	Lsid       int32  // Unique identifier of the list definition
+	SimpleList bool   // The list has a single level instead of nine
+	Levels     []*LVL // The levels, in order
+}
+
+// ParsePlfLst parses the list definitions stored at fcPlfLst. The PlfLst
+// holds a count and the LSTFs; the LVLs of every list follow it in the
+// table stream, so data must extend to the end of the table stream.
+func ParsePlfLst(data []byte) ([]*LSTF, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("plflst: data too short")
+	}
+	count := int(int16(binary.LittleEndian.Uint16(data)))
+	if count < 0 || 2+count*lstfSize > len(data) {
+		return nil, fmt.Errorf("plflst: invalid list count %d", count)
+	}
+
+	lists := make([]*LSTF, count)
+	for i := range lists {
+		lstf := data[2+i*lstfSize:]
+		lists[i] = &LSTF{
+			Lsid:       int32(binary.LittleEndian.Uint32(lstf)),
+			SimpleList: lstf[26]&0x01 != 0,
+		}
+	}
+
+	offset := 2 + count*lstfSize
+	for i, list := range lists {
+		levelCount := 9
+		if list.SimpleList {
+			levelCount = 1
+		}
+		for j := 0; j < levelCount; j++ {
+			lvl, n, err := parseLVL(data[offset:])
+			if err != nil {
+				return nil, fmt.Errorf("plflst: list %d level %d: %w", i, j, err)
+			}
+			list.Levels = append(list.Levels, lvl)
+			offset += n
+		}
+	}
+	return lists, nil
+}
+
+// parseLVL parses an LVL at the start of data and returns it together with
+// the number of bytes it occupies: the LVLF, the paragraph and character
+// sprms, then the number text as an Xst.
+func parseLVL(data []byte) (*LVL, int, error) {
+	if len(data) < lvlfSize {
+		return nil, 0, fmt.Errorf("not enough data for LVLF")
+	}
+	lvl := &LVL{
+		StartAt: int32(binary.LittleEndian.Uint32(data)),
+		Nfc:     data[4],
+		Jc:      data[5] & 0x03,
+	}
+	cbChpx, cbPapx := int(data[24]), int(data[25])
+
+	offset := lvlfSize
+	if offset+cbPapx+cbChpx+2 > len(data) {
+		return nil, 0, fmt.Errorf("not enough data for level sprms")
+	}
+	lvl.ParagraphGrpprl = data[offset : offset+cbPapx]
+	offset += cbPapx
+	lvl.CharacterGrpprl = data[offset : offset+cbChpx]
+	offset += cbChpx
+
+	cch := int(binary.LittleEndian.Uint16(data[offset:]))
+	offset += 2
+	if offset+cch*2 > len(data) {
+		return nil, 0, fmt.Errorf("not enough data for number text")
+	}
+	u16s := make([]uint16, cch)
+	for i := range u16s {
+		u16s[i] = binary.LittleEndian.Uint16(data[offset+i*2:])
+	}
+	lvl.NumberText = string(utf16.Decode(u16s))
+	return lvl, offset + cch*2, nil
+}
+
+// LFOLVL overrides one level of a list instance, either by restarting its
+// numbering or by replacing the level altogether.
+type LFOLVL struct {
+	Level      uint8 // Index of the overridden level
+	StartAt    int32 // New start number, used if HasStartAt is set
+	HasStartAt bool  // The level restarts at StartAt
+	LVL        *LVL  // Replacement level, nil if only the start is overridden
+}
+
+// LFO is a list instance: a reference to a list definition together with
+// the overrides that apply to its paragraphs. Paragraphs refer to the LFO
+// with index i through the 1-based ilfo i+1.
+type LFO struct {
+	Lsid      int32     // Identifier of the list definition
+	Overrides []*LFOLVL // Level overrides
+}
+
+// ParsePlfLfo parses the list instances stored at fcPlfLfo: a count, the
+// LFOs, then one LFOData per LFO holding a CP and the LFO's LFOLVLs.
+func ParsePlfLfo(data []byte) ([]*LFO, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("plflfo: data too short")
+	}
+	count := binary.LittleEndian.Uint32(data)
+	if uint64(4)+uint64(count)*lfoSize > uint64(len(data)) {
+		return nil, fmt.Errorf("plflfo: invalid instance count %d", count)
+	}
+
+	lfos := make([]*LFO, count)
+	overrideCounts := make([]int, count)
+	for i := range lfos {
+		lfo := data[4+i*lfoSize:]
+		lfos[i] = &LFO{Lsid: int32(binary.LittleEndian.Uint32(lfo))}
+		overrideCounts[i] = int(lfo[12])
+	}
+
+	offset := 4 + int(count)*lfoSize
+	for i, lfo := range lfos {
+		offset += 4 // LFOData.cp
+		for j := 0; j < overrideCounts[i]; j++ {
+			if offset+lfolvlSize > len(data) {
+				return nil, fmt.Errorf("plflfo: not enough data for override %d of instance %d", j, i)
+			}
+			flags := binary.LittleEndian.Uint32(data[offset+4:])
+			override := &LFOLVL{
+				Level:      uint8(flags & 0x0F),
+				StartAt:    int32(binary.LittleEndian.Uint32(data[offset:])),
+				HasStartAt: flags&0x10 != 0,
+			}
+			offset += lfolvlSize
+
+			if flags&0x20 != 0 { // fFormatting: a replacement LVL follows
+				lvl, n, err := parseLVL(data[offset:])
+				if err != nil {
+					return nil, fmt.Errorf("plflfo: override %d of instance %d: %w", j, i, err)
+				}
+				override.LVL = lvl
+				offset += n
+			}
+			lfo.Overrides = append(lfo.Overrides, override)
+		}
+	}
+	return lfos, nil
+}
@@ -0,0 +1,179 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// Office Art record types used to locate shapes and their properties.
+const (
+	OfficeArtSpContainer  = 0xF004 // Shape container
+	OfficeArtFSP          = 0xF00A // Shape ID and flags
+	OfficeArtFOPT         = 0xF00B // Primary shape properties
+	OfficeArtTertiaryFOPT = 0xF122 // Tertiary shape properties (msofbtUDefProp)
+)
+
+// officeArtHeaderSize is the size of an Office Art record header.
+const officeArtHeaderSize = 8
+
+// Office Art shape property IDs.
+const (
+	ShapePropBlipID      = 0x0104 // pib: index of the picture in the BLIP store
+	ShapePropName        = 0x0380 // wzName: name of the shape
+	ShapePropDescription = 0x0381 // wzDescription: alternative text of the shape
+)
+
+// FSP flags (grfPersistent) that mark shapes which are not drawn themselves.
+const (
+	fspPatriarch  = 0x0004 // Top-level group of a drawing
+	fspDeleted    = 0x0008 // Shape has been deleted
+	fspBackground = 0x0400 // Page background, which Word adds to every drawing
+)
+
+// OfficeArtRecord is a record of an Office Art (MS-ODRAW) record tree.
+type OfficeArtRecord struct {
+	Version  uint8  // recVer; 0xF marks a container
+	Instance uint16 // recInstance, whose meaning depends on the record type
+	Type     uint16 // recType
+	Data     []byte // Record body; the child records of a container
+}
+
+// IsContainer reports whether the record holds child records.
+func (r *OfficeArtRecord) IsContainer() bool {
+	return r.Version == 0xF
+}
+
+// ParseOfficeArtRecords parses the consecutive records in data. Bytes after
+// the last complete record are ignored.
+func ParseOfficeArtRecords(data []byte) ([]OfficeArtRecord, error) {
+	var records []OfficeArtRecord
+	offset := 0
+	for offset+officeArtHeaderSize <= len(data) {
+		verInst := binary.LittleEndian.Uint16(data[offset:])
+		recType := binary.LittleEndian.Uint16(data[offset+2:])
+		recLen := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += officeArtHeaderSize
+
+		if uint64(offset)+uint64(recLen) > uint64(len(data)) {
+			return records, fmt.Errorf("officeart: record 0x%04X at %d extends past the data", recType, offset-officeArtHeaderSize)
+		}
+		records = append(records, OfficeArtRecord{
+			Version:  uint8(verInst & 0x000F),
+			Instance: verInst >> 4,
+			Type:     recType,
+			Data:     data[offset : offset+int(recLen)],
+		})
+		offset += int(recLen)
+	}
+	return records, nil
+}
+
+// FindShapeContainers returns the shape containers in a record tree, in
+// document order, descending into every container.
+func FindShapeContainers(data []byte) ([]OfficeArtRecord, error) {
+	records, err := ParseOfficeArtRecords(data)
+	var shapes []OfficeArtRecord
+	for _, record := range records {
+		if record.Type == OfficeArtSpContainer {
+			shapes = append(shapes, record)
+			continue
+		}
+		if record.IsContainer() {
+			children, childErr := FindShapeContainers(record.Data)
+			shapes = append(shapes, children...)
+			if err == nil {
+				err = childErr
+			}
+		}
+	}
+	return shapes, err
+}
+
+// ShapeProperties holds the identity and the properties of a shape.
+type ShapeProperties struct {
+	ID         uint32            // Shape ID (spid)
+	ShapeType  uint16            // MSOSPT shape type, such as 75 for a picture frame
+	Flags      uint32            // FSP grfPersistent flags
+	Properties map[uint16]uint32 // Simple property values by property ID
+	Complex    map[uint16][]byte // Complex property data by property ID
+}
+
+// IsDrawn reports whether the shape is drawn, as opposed to the patriarch of
+// a drawing, the page background or a deleted shape.
+func (sp *ShapeProperties) IsDrawn() bool {
+	return sp.Flags&(fspPatriarch|fspDeleted|fspBackground) == 0
+}
+
+// StringProperty returns a complex property holding a UTF-16 string, such as
+// ShapePropDescription. Returns "" if the property is not set.
+func (sp *ShapeProperties) StringProperty(pid uint16) string {
+	data := sp.Complex[pid]
+	u16s := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		u := binary.LittleEndian.Uint16(data[i:])
+		if u == 0 {
+			break
+		}
+		u16s = append(u16s, u)
+	}
+	return string(utf16.Decode(u16s))
+}
+
+// ParseShapeContainer parses the FSP and property tables of a shape container.
+// Properties from the tertiary table override those of the primary one.
+func ParseShapeContainer(container *OfficeArtRecord) (*ShapeProperties, error) {
+	records, err := ParseOfficeArtRecords(container.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	shape := &ShapeProperties{
+		Properties: make(map[uint16]uint32),
+		Complex:    make(map[uint16][]byte),
+	}
+	for _, record := range records {
+		switch record.Type {
+		case OfficeArtFSP:
+			if len(record.Data) < 8 {
+				return nil, fmt.Errorf("officeart: FSP too short (%d bytes)", len(record.Data))
+			}
+			shape.ShapeType = record.Instance
+			shape.ID = binary.LittleEndian.Uint32(record.Data[0:4])
+			shape.Flags = binary.LittleEndian.Uint32(record.Data[4:8])
+		case OfficeArtFOPT, OfficeArtTertiaryFOPT:
+			if err := parseFOPT(&record, shape); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return shape, nil
+}
+
+// parseFOPT parses a property table into shape. The table holds one 6-byte
+// entry per property, given by the record instance, followed by the data of
+// the complex properties in entry order.
+func parseFOPT(record *OfficeArtRecord, shape *ShapeProperties) error {
+	count := int(record.Instance)
+	if count*6 > len(record.Data) {
+		return fmt.Errorf("officeart: property table too short for %d properties", count)
+	}
+
+	complexOffset := count * 6
+	for i := 0; i < count; i++ {
+		opid := binary.LittleEndian.Uint16(record.Data[i*6:])
+		op := binary.LittleEndian.Uint32(record.Data[i*6+2:])
+		pid := opid & 0x3FFF
+
+		if opid&0x8000 == 0 {
+			shape.Properties[pid] = op
+			continue
+		}
+		if uint64(complexOffset)+uint64(op) > uint64(len(record.Data)) {
+			return fmt.Errorf("officeart: complex data of property 0x%04X extends past the table", pid)
+		}
+		shape.Complex[pid] = record.Data[complexOffset : complexOffset+int(op)]
+		complexOffset += int(op)
+	}
+	return nil
+}
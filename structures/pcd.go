@@ -30,19 +30,20 @@ func ParsePCD(data []byte) (*PCD, error) {
 	// Next 4 bytes contain the file character position
 	fc := binary.LittleEndian.Uint32(data[2:6])
 
-	// Check if this is Unicode text
-	pcd.IsUnicode = (fc & 0x40000000) != 0
+	// Bit 30 (fCompressed) marks 8-bit ANSI text; pieces without it are
+	// UTF-16LE
+	pcd.IsUnicode = (fc & 0x40000000) == 0
 
-	// Clear the Unicode flag to get the actual file position
+	// Clear the compression flag to get the file position
 	pcd.FC = fc & 0x3FFFFFFF
 
 	return pcd, nil
 }
 
 // GetActualFC returns the actual file position for reading text.
-// For Unicode text, the position needs to be divided by 2.
+// For compressed ANSI text, the position needs to be divided by 2.
 func (pcd *PCD) GetActualFC() uint32 {
-	if pcd.IsUnicode {
+	if !pcd.IsUnicode {
 		return pcd.FC / 2
 	}
 	return pcd.FC
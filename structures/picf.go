@@ -0,0 +1,49 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// PICF is the header of a picture or embedded object stored in the Data
+// stream. Only the fields describing the display size are decoded.
+type PICF struct {
+	Lcb      uint32 // Size of the picture data including this header
+	CbHeader uint16 // Size of this header, normally 0x44
+	Mm       int16  // Metafile mapping mode, or MM_SHAPE (0x0064) for shapes
+	DxaGoal  int16  // Initial width in twips before scaling
+	DyaGoal  int16  // Initial height in twips before scaling
+	Mx       uint16 // Horizontal scaling in thousandths
+	My       uint16 // Vertical scaling in thousandths
+}
+
+// picfMinSize is the number of PICF bytes read by ParsePICF.
+const picfMinSize = 36
+
+// ParsePICF parses a PICF from the start of data.
+func ParsePICF(data []byte) (*PICF, error) {
+	if len(data) < picfMinSize {
+		return nil, fmt.Errorf("picf: data too short (%d bytes)", len(data))
+	}
+
+	picf := &PICF{
+		Lcb:      binary.LittleEndian.Uint32(data[0:4]),
+		CbHeader: binary.LittleEndian.Uint16(data[4:6]),
+		Mm:       int16(binary.LittleEndian.Uint16(data[6:8])),
+		DxaGoal:  int16(binary.LittleEndian.Uint16(data[28:30])),
+		DyaGoal:  int16(binary.LittleEndian.Uint16(data[30:32])),
+		Mx:       binary.LittleEndian.Uint16(data[32:34]),
+		My:       binary.LittleEndian.Uint16(data[34:36]),
+	}
+	if picf.CbHeader < picfMinSize || picf.Lcb < uint32(picf.CbHeader) {
+		return nil, fmt.Errorf("picf: invalid header size %d for lcb %d", picf.CbHeader, picf.Lcb)
+	}
+	return picf, nil
+}
+
+// DisplaySize returns the displayed width and height in twips, after scaling.
+func (p *PICF) DisplaySize() (width, height int32) {
+	width = int32(p.DxaGoal) * int32(p.Mx) / 1000
+	height = int32(p.DyaGoal) * int32(p.My) / 1000
+	return width, height
+}
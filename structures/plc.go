@@ -8,6 +8,10 @@ import (
 // PLC (Plex) is a common structure in .doc files. It is an array of
 // Character Positions (CPs) followed by an array of data elements.
 // The number of CPs is always one more than the number of data elements.
+//
+// A PLC may hold a single CP and no data elements. Such an empty PLC is valid:
+// Count returns 0 and callers must iterate up to Count rather than assume a
+// first element exists.
 type PLC struct {
 	CPs      []CP
 	Data     [][]byte // Generic representation of data elements
@@ -15,7 +19,8 @@ type PLC struct {
 }
 
 // ParsePLC parses a PLC structure from raw bytes.
-// dataSize specifies the size of each data element in bytes.
+// dataSize specifies the size of each data element in bytes. Four bytes of data
+// hold a single CP and parse as an empty PLC.
 func ParsePLC(data []byte, dataSize int) (*PLC, error) {
 	if len(data) < 4 {
 		return nil, fmt.Errorf("plc: data too short, need at least 4 bytes")
@@ -11,6 +11,64 @@ type SEPX struct {
 	Length uint16 // Length of the SEPX data
 }
 
+// PageNumberFormat identifies how page numbers are displayed in a section.
+type PageNumberFormat uint8
+
+const (
+	PageNumberArabic      PageNumberFormat = 0 // 1, 2, 3
+	PageNumberUpperRoman  PageNumberFormat = 1 // I, II, III
+	PageNumberLowerRoman  PageNumberFormat = 2 // i, ii, iii
+	PageNumberUpperLetter PageNumberFormat = 3 // A, B, C
+	PageNumberLowerLetter PageNumberFormat = 4 // a, b, c
+)
+
+// String returns a human-readable name for the page number format.
+func (f PageNumberFormat) String() string {
+	switch f {
+	case PageNumberArabic:
+		return "Arabic"
+	case PageNumberUpperRoman:
+		return "Upper Roman"
+	case PageNumberLowerRoman:
+		return "Lower Roman"
+	case PageNumberUpperLetter:
+		return "Upper Letter"
+	case PageNumberLowerLetter:
+		return "Lower Letter"
+	default:
+		return fmt.Sprintf("Unknown (%d)", uint8(f))
+	}
+}
+
+// SectionBreak identifies where a section starts, as stored in sprmSBkc.
+type SectionBreak uint8
+
+const (
+	BreakContinuous SectionBreak = 0 // On the same page as the previous section
+	BreakNewColumn  SectionBreak = 1 // In the next column
+	BreakNewPage    SectionBreak = 2 // On the next page
+	BreakEvenPage   SectionBreak = 3 // On the next even-numbered page
+	BreakOddPage    SectionBreak = 4 // On the next odd-numbered page
+)
+
+// String returns a human-readable name for the section break.
+func (b SectionBreak) String() string {
+	switch b {
+	case BreakContinuous:
+		return "Continuous"
+	case BreakNewColumn:
+		return "New Column"
+	case BreakNewPage:
+		return "New Page"
+	case BreakEvenPage:
+		return "Even Page"
+	case BreakOddPage:
+		return "Odd Page"
+	default:
+		return fmt.Sprintf("Unknown (%d)", uint8(b))
+	}
+}
+
 // SEP (Section Properties) contains parsed section formatting information.
 type SEP struct {
 	// Page setup
@@ -24,17 +82,24 @@ type SEP struct {
 	DyaHdrBottom uint16 // Header bottom margin in twips
 
 	// Page orientation and layout
-	FLandscape  bool   // True if landscape orientation
-	FContinuous bool   // True if continuous section break
-	FTitlePage  bool   // True if different first page
-	FPgnRestart bool   // True if restart page numbering
-	PgnStart    uint16 // Starting page number
+	FLandscape  bool             // True if landscape orientation
+	Bkc         SectionBreak     // Where the section starts
+	FContinuous bool             // True if continuous section break
+	FTitlePage  bool             // True if different first page
+	FPgnRestart bool             // True if restart page numbering
+	PgnStart    uint16           // Starting page number
+	NfcPgn      PageNumberFormat // Page number format
 
 	// Column layout
 	CcolM1        uint16 // Number of columns minus 1
 	FEvenlySpaced bool   // True if columns are evenly spaced
 	DxaColumns    uint16 // Space between columns in twips
 
+	// Columns that are not evenly spaced have their own width and the space
+	// after them, indexed by column. Both are nil for evenly spaced columns.
+	ColumnWidths   []uint32 // Width of each column in twips
+	ColumnSpacings []uint32 // Space after each column in twips
+
 	// Line numbering
 	Lnc    uint8  // Line number count
 	DxaLnn uint16 // Distance from text to line numbers
@@ -44,6 +109,24 @@ type SEP struct {
 	GrpfIhdt uint8 // Header/footer flags
 }
 
+// SED (Section Descriptor) is an entry of the section table (PlcfSed).
+type SED struct {
+	FcSepx uint32 // Offset of the SEPX in the WordDocument stream, 0xFFFFFFFF if none
+}
+
+// ParseSED parses a 12-byte SED structure.
+func ParseSED(data []byte) (*SED, error) {
+	if len(data) != 12 {
+		return nil, fmt.Errorf("sed: invalid data size %d, expected 12", len(data))
+	}
+	return &SED{FcSepx: binary.LittleEndian.Uint32(data[2:6])}, nil
+}
+
+// HasSEPX returns true if the section has properties other than the defaults.
+func (sed *SED) HasSEPX() bool {
+	return sed.FcSepx != 0xFFFFFFFF
+}
+
 // ParseSEPX parses a SEPX structure from raw data.
 func ParseSEPX(data []byte) (*SEPX, error) {
 	if len(data) < 2 {
@@ -68,58 +151,156 @@ func ParseSEPX(data []byte) (*SEPX, error) {
 }
 
 // ParseSEP parses a SEP structure from SEPX data.
+//
+// The SEPX data is a list of section sprms, which are applied on top of the
+// default section properties.
 func (sepx *SEPX) ParseSEP() (*SEP, error) {
-	if len(sepx.Data) < 48 { // Minimum size for basic SEP fields
-		return nil, fmt.Errorf("sepx: data too short for SEP structure")
-	}
-
-	sep := &SEP{}
+	sep := DefaultSEP()
 	data := sepx.Data
 
-	// Parse basic page layout fields
-	sep.XaPage = binary.LittleEndian.Uint16(data[0:2])
-	sep.YaPage = binary.LittleEndian.Uint16(data[2:4])
-	sep.DxaLeft = binary.LittleEndian.Uint16(data[4:6])
-	sep.DxaRight = binary.LittleEndian.Uint16(data[6:8])
-	sep.DyaTop = binary.LittleEndian.Uint16(data[8:10])
-	sep.DyaBottom = binary.LittleEndian.Uint16(data[10:12])
-	sep.DyaHdrTop = binary.LittleEndian.Uint16(data[12:14])
-	sep.DyaHdrBottom = binary.LittleEndian.Uint16(data[14:16])
-
-	// Parse flags from various positions
-	if len(data) > 16 {
-		flags := binary.LittleEndian.Uint16(data[16:18])
-		sep.FLandscape = (flags & 0x0001) != 0
-		sep.FContinuous = (flags & 0x0002) != 0
-		sep.FTitlePage = (flags & 0x0004) != 0
-		sep.FPgnRestart = (flags & 0x0008) != 0
-	}
+	offset := 0
+	for offset+2 <= len(data) {
+		sprm := binary.LittleEndian.Uint16(data[offset:])
+		offset += 2
+
+		size := SprmOperandSize(sprm, data[offset:])
+		if size < 0 || offset+size > len(data) {
+			return nil, fmt.Errorf("sepx: truncated operand for sprm 0x%04X", sprm)
+		}
+		operand := data[offset : offset+size]
+		offset += size
 
-	if len(data) > 18 {
-		sep.PgnStart = binary.LittleEndian.Uint16(data[18:20])
+		switch sprm {
+		case 0x3005: // sprmSFEvenlySpaced
+			sep.FEvenlySpaced = operand[0] != 0
+		case 0x3009: // sprmSBkc: section break type
+			sep.Bkc = SectionBreak(operand[0])
+			sep.FContinuous = sep.Bkc == BreakContinuous
+		case 0x300A: // sprmSFTitlePage
+			sep.FTitlePage = operand[0] != 0
+		case 0x500B: // sprmSCcolumns
+			sep.CcolM1 = binary.LittleEndian.Uint16(operand)
+		case 0x900C: // sprmSDxaColumns
+			sep.DxaColumns = binary.LittleEndian.Uint16(operand)
+		case 0xF203: // sprmSDxaColWidth: column index and width
+			sep.ColumnWidths = setColumnValue(sep.ColumnWidths, operand)
+		case 0xF204: // sprmSDxaColSpacing: column index and space after it
+			sep.ColumnSpacings = setColumnValue(sep.ColumnSpacings, operand)
+		case 0x300E: // sprmSNfcPgn: page number format
+			sep.NfcPgn = PageNumberFormat(operand[0])
+		case 0x3011: // sprmSFPgnRestart
+			sep.FPgnRestart = operand[0] != 0
+		case 0x3013: // sprmSLnc
+			sep.Lnc = operand[0]
+		case 0x9016: // sprmSDxaLnn
+			sep.DxaLnn = binary.LittleEndian.Uint16(operand)
+		case 0xB017: // sprmSDyaHdrTop
+			sep.DyaHdrTop = binary.LittleEndian.Uint16(operand)
+		case 0xB018: // sprmSDyaHdrBottom
+			sep.DyaHdrBottom = binary.LittleEndian.Uint16(operand)
+		case 0x501B: // sprmSLnnMin
+			sep.LnnMin = binary.LittleEndian.Uint16(operand)
+		case 0x501C: // sprmSPgnStart97
+			sep.PgnStart = binary.LittleEndian.Uint16(operand)
+		case 0x301D: // sprmSBOrientation
+			sep.FLandscape = operand[0] == 2
+		case 0xB01F: // sprmSXaPage
+			sep.XaPage = binary.LittleEndian.Uint16(operand)
+		case 0xB020: // sprmSYaPage
+			sep.YaPage = binary.LittleEndian.Uint16(operand)
+		case 0xB021: // sprmSDxaLeft
+			sep.DxaLeft = binary.LittleEndian.Uint16(operand)
+		case 0xB022: // sprmSDxaRight
+			sep.DxaRight = binary.LittleEndian.Uint16(operand)
+		case 0x9023: // sprmSDyaTop
+			sep.DyaTop = binary.LittleEndian.Uint16(operand)
+		case 0x9024: // sprmSDyaBottom
+			sep.DyaBottom = binary.LittleEndian.Uint16(operand)
+		}
 	}
 
-	// Parse column information
-	if len(data) > 20 {
-		sep.CcolM1 = binary.LittleEndian.Uint16(data[20:22])
-		colFlags := binary.LittleEndian.Uint16(data[22:24])
-		sep.FEvenlySpaced = (colFlags & 0x0001) != 0
-		sep.DxaColumns = binary.LittleEndian.Uint16(data[24:26])
+	return sep, nil
+}
+
+// setColumnValue stores the twips value of a column width or spacing operand,
+// a column index followed by the value, growing values to hold the column.
+func setColumnValue(values []uint32, operand []byte) []uint32 {
+	column := int(operand[0])
+	for len(values) <= column {
+		values = append(values, 0)
 	}
+	values[column] = uint32(binary.LittleEndian.Uint16(operand[1:]))
+	return values
+}
 
-	// Parse line numbering
-	if len(data) > 26 {
-		sep.Lnc = data[26]
-		sep.DxaLnn = binary.LittleEndian.Uint16(data[27:29])
-		sep.LnnMin = binary.LittleEndian.Uint16(data[29:31])
+// DefaultSEP returns the section properties used when a section has no
+// SEPX: a portrait US Letter page with Word's default margins.
+func DefaultSEP() *SEP {
+	return &SEP{
+		XaPage:        12240,
+		YaPage:        15840,
+		DxaLeft:       1800,
+		DxaRight:      1800,
+		DyaTop:        1440,
+		DyaBottom:     1440,
+		DyaHdrTop:     720,
+		DyaHdrBottom:  720,
+		Bkc:           BreakNewPage,
+		PgnStart:      1,
+		FEvenlySpaced: true,
+		DxaColumns:    720,
 	}
+}
 
-	// Parse header/footer information
-	if len(data) > 31 {
-		sep.GrpfIhdt = data[31]
+// SprmOperandSize returns the size in bytes of the operand of sprm, whose
+// operand data starts at operand. The size is given by the sprm's spra field,
+// except for variable-length operands, which start with their length.
+// sprmTDefTable is the exception, with a 2-byte length one more than the
+// size of the rest of the operand. Returns -1 if a variable-length operand
+// is missing its length.
+func SprmOperandSize(sprm uint16, operand []byte) int {
+	if sprm == sprmTDefTable {
+		if len(operand) < 2 {
+			return -1
+		}
+		return 1 + int(binary.LittleEndian.Uint16(operand))
+	}
+	switch sprm >> 13 {
+	case 0, 1:
+		return 1
+	case 2, 4, 5:
+		return 2
+	case 3:
+		return 4
+	case 7:
+		return 3
+	default: // 6: variable length
+		if len(operand) < 1 {
+			return -1
+		}
+		return 1 + int(operand[0])
 	}
+}
 
-	return sep, nil
+// FindSprm returns the operand of the last occurrence of sprm in grpprl.
+// Returns false if the sprm is not present or the grpprl is truncated.
+func FindSprm(grpprl []byte, sprm uint16) ([]byte, bool) {
+	var found []byte
+	offset := 0
+	for offset+2 <= len(grpprl) {
+		current := binary.LittleEndian.Uint16(grpprl[offset:])
+		offset += 2
+
+		size := SprmOperandSize(current, grpprl[offset:])
+		if size < 0 || offset+size > len(grpprl) {
+			return nil, false
+		}
+		if current == sprm {
+			found = grpprl[offset : offset+size]
+		}
+		offset += size
+	}
+	return found, found != nil
 }
 
 // IsLandscape returns true if the section uses landscape orientation.
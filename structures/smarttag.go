@@ -0,0 +1,147 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// Sizes of the data elements of the smart tag bookmark PLCs.
+const (
+	FBKFDSize = 6 // PlcfBkfFactoid: ibkl, bkc and cDepth
+	FBKLDSize = 4 // PlcfBklFactoid: ibkf and cDepth
+)
+
+// FactoidType describes a kind of smart tag known to the document: the tag
+// name and the namespace URI of the recognizer that produced it.
+type FactoidType struct {
+	ID          uint32 // Identifier referenced by PropertyBag.TypeID
+	URI         string // Namespace URI
+	Tag         string // Tag name within the namespace
+	DownloadURL string // Where the recognizer can be obtained, may be empty
+}
+
+// PropertyBag holds the properties of one smart tag. Each property is a pair
+// of indices into the string table of the SmartTagData.
+type PropertyBag struct {
+	TypeID     uint16      // Identifier of the FactoidType of the tag
+	Properties [][2]uint32 // Key and value indices into SmartTagData.Strings
+}
+
+// SmartTagData is the smart tag information stored at fcFactoidData: the
+// known smart tag types, a shared string table and one property bag per
+// smart tag, in the order of the smart tag bookmarks.
+type SmartTagData struct {
+	Types   []FactoidType
+	Strings []string
+	Bags    []PropertyBag
+}
+
+// ParseSmartTagData parses a SmartTagData structure.
+func ParseSmartTagData(data []byte) (*SmartTagData, error) {
+	r := &smartTagReader{data: data}
+	std := &SmartTagData{}
+
+	// PropertyBagStore: the factoid types, a header and the string table
+	typeCount := r.uint32()
+	for i := uint32(0); i < typeCount && r.err == nil; i++ {
+		r.uint32() // cbFactoid
+		std.Types = append(std.Types, FactoidType{
+			ID:          r.uint32(),
+			URI:         r.pbString(),
+			Tag:         r.pbString(),
+			DownloadURL: r.pbString(),
+		})
+	}
+	r.uint16() // cbHdr
+	r.uint16() // sVer
+	r.uint32() // cfactoid
+	stringCount := r.uint32()
+	for i := uint32(0); i < stringCount && r.err == nil; i++ {
+		std.Strings = append(std.Strings, r.pbString())
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("smarttagdata: property bag store: %w", r.err)
+	}
+
+	// The property bags fill the rest of the structure
+	for r.offset < len(data) {
+		bag := PropertyBag{TypeID: r.uint16()}
+		propCount := r.uint16()
+		r.uint16() // cbUnknown
+		for i := uint16(0); i < propCount && r.err == nil; i++ {
+			bag.Properties = append(bag.Properties, [2]uint32{r.uint32(), r.uint32()})
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("smarttagdata: property bag %d: %w", len(std.Bags), r.err)
+		}
+		std.Bags = append(std.Bags, bag)
+	}
+	return std, nil
+}
+
+// Type returns the factoid type with the given identifier, or nil if there
+// is none.
+func (std *SmartTagData) Type(id uint32) *FactoidType {
+	for i := range std.Types {
+		if std.Types[i].ID == id {
+			return &std.Types[i]
+		}
+	}
+	return nil
+}
+
+// smartTagReader reads the little-endian fields of a SmartTagData. After the
+// first read past the end of data, err is set and every read returns zero.
+type smartTagReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+// next returns the next n bytes, or nil if there are not enough left.
+func (r *smartTagReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.offset+n > len(r.data) {
+		r.err = fmt.Errorf("not enough data at offset %d", r.offset)
+		return nil
+	}
+	b := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *smartTagReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *smartTagReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// pbString reads a PBString: a 15-bit character count with fAnsiString in the
+// high bit, followed by the characters as bytes or UTF-16 units.
+func (r *smartTagReader) pbString() string {
+	header := r.uint16()
+	cch := int(header & 0x7FFF)
+	if header&0x8000 != 0 {
+		return string(r.next(cch))
+	}
+	b := r.next(cch * 2)
+	if b == nil {
+		return ""
+	}
+	u16s := make([]uint16, cch)
+	for i := range u16s {
+		u16s[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u16s))
+}
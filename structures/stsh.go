@@ -0,0 +1,161 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// StyleType is the kind of a style (stk).
+type StyleType uint8
+
+const (
+	StyleTypeParagraph StyleType = 1 // Paragraph style
+	StyleTypeCharacter StyleType = 2 // Character style
+	StyleTypeTable     StyleType = 3 // Table style
+	StyleTypeNumbering StyleType = 4 // Numbering style
+)
+
+// IstdNormal is the style index of the Normal paragraph style.
+const IstdNormal = 0
+
+// StiUser is the sti of user-defined styles.
+const StiUser = 0x0FFE
+
+// istdNil marks a missing base or next style.
+const istdNil = 0x0FFF
+
+// STD (Style Definition) describes one style of the style sheet.
+type STD struct {
+	Sti      uint16    // Built-in style identifier, StiUser for user-defined styles
+	Type     StyleType // Paragraph, character, table or numbering style
+	IstdBase uint16    // Index of the style this one is based on, 0x0FFF if none
+	IstdNext uint16    // Index of the style applied to the next paragraph
+	Name     string    // Style name
+
+	// ParagraphGrpprl holds the paragraph sprms of paragraph and table
+	// styles. CharacterGrpprl holds the character sprms of paragraph,
+	// character and table styles.
+	ParagraphGrpprl []byte
+	CharacterGrpprl []byte
+}
+
+// HasBase reports whether the style is based on another style.
+func (std *STD) HasBase() bool {
+	return std.IstdBase != istdNil
+}
+
+// STSH (Style Sheet) holds the style definitions of a document. The index of
+// a style in Styles is its istd; empty slots are nil.
+type STSH struct {
+	Styles []*STD
+}
+
+// Style returns the style with the given index, or nil if the slot is empty
+// or out of range.
+func (s *STSH) Style(istd uint16) *STD {
+	if int(istd) >= len(s.Styles) {
+		return nil
+	}
+	return s.Styles[istd]
+}
+
+// ParseSTSH parses a style sheet from the table stream.
+func ParseSTSH(data []byte) (*STSH, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("stsh: data too short")
+	}
+	cbStshi := int(binary.LittleEndian.Uint16(data))
+	if cbStshi < 4 || 2+cbStshi > len(data) {
+		return nil, fmt.Errorf("stsh: invalid STSHI size %d", cbStshi)
+	}
+	cstd := int(binary.LittleEndian.Uint16(data[2:]))
+	cbSTDBase := int(binary.LittleEndian.Uint16(data[4:]))
+	if cbSTDBase < 10 {
+		return nil, fmt.Errorf("stsh: invalid STD base size %d", cbSTDBase)
+	}
+
+	stsh := &STSH{Styles: make([]*STD, 0, cstd)}
+	offset := 2 + cbStshi
+	for istd := 0; istd < cstd; istd++ {
+		if offset+2 > len(data) {
+			return nil, fmt.Errorf("stsh: not enough data for style %d", istd)
+		}
+		cbStd := int(binary.LittleEndian.Uint16(data[offset:]))
+		offset += 2
+		if offset+cbStd > len(data) {
+			return nil, fmt.Errorf("stsh: not enough data for style %d", istd)
+		}
+
+		var std *STD
+		if cbStd > 0 {
+			var err error
+			if std, err = parseSTD(data[offset:offset+cbStd], cbSTDBase); err != nil {
+				return nil, fmt.Errorf("stsh: style %d: %w", istd, err)
+			}
+		}
+		stsh.Styles = append(stsh.Styles, std)
+		offset += cbStd
+	}
+	return stsh, nil
+}
+
+// parseSTD parses a style definition whose fixed part is cbSTDBase bytes.
+func parseSTD(data []byte, cbSTDBase int) (*STD, error) {
+	if len(data) < cbSTDBase+2 {
+		return nil, fmt.Errorf("definition too short")
+	}
+	std := &STD{
+		Sti:      binary.LittleEndian.Uint16(data[0:]) & 0x0FFF,
+		Type:     StyleType(binary.LittleEndian.Uint16(data[2:]) & 0x000F),
+		IstdBase: binary.LittleEndian.Uint16(data[2:]) >> 4,
+		IstdNext: binary.LittleEndian.Uint16(data[4:]) >> 4,
+	}
+	cupx := int(binary.LittleEndian.Uint16(data[4:]) & 0x000F)
+
+	// The name is a length-prefixed, null-terminated UTF-16 string
+	offset := cbSTDBase
+	cch := int(binary.LittleEndian.Uint16(data[offset:]))
+	offset += 2
+	if offset+cch*2 > len(data) {
+		return nil, fmt.Errorf("name too long")
+	}
+	name := make([]uint16, cch)
+	for i := range name {
+		name[i] = binary.LittleEndian.Uint16(data[offset+i*2:])
+	}
+	std.Name = string(utf16.Decode(name))
+	offset += (cch + 1) * 2
+
+	// Each UPX is length-prefixed and padded to an even length
+	var upxs [][]byte
+	for i := 0; i < cupx && offset+2 <= len(data); i++ {
+		cbUpx := int(binary.LittleEndian.Uint16(data[offset:]))
+		offset += 2
+		if offset+cbUpx > len(data) {
+			return nil, fmt.Errorf("UPX %d too long", i)
+		}
+		upxs = append(upxs, data[offset:offset+cbUpx])
+		offset += cbUpx + cbUpx%2
+	}
+
+	switch std.Type {
+	case StyleTypeParagraph, StyleTypeTable:
+		// A UpxPapx starts with the style index; a table style's first UPX
+		// holds table properties and is skipped
+		if std.Type == StyleTypeTable && len(upxs) > 0 {
+			upxs = upxs[1:]
+		}
+		if len(upxs) > 0 && len(upxs[0]) >= 2 {
+			std.ParagraphGrpprl = upxs[0][2:]
+		}
+		if len(upxs) > 1 {
+			std.CharacterGrpprl = upxs[1]
+		}
+	case StyleTypeCharacter:
+		if len(upxs) > 0 {
+			std.CharacterGrpprl = upxs[0]
+		}
+	}
+	return std, nil
+}
@@ -0,0 +1,127 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// STTB (String Table) is a list of strings, each optionally followed by a
+// fixed amount of extra data. It is used for font names, bookmark names,
+// revision authors and similar lists stored in the table stream.
+type STTB struct {
+	Strings []string // The strings in the table
+	Extra   [][]byte // Extra data for each string (nil if cbExtra is 0)
+	Unicode bool     // True if the strings are stored as UTF-16LE
+}
+
+// ParseSTTB parses a STTB structure from raw data.
+func ParseSTTB(data []byte) (*STTB, error) {
+	sttb, _, err := parseSTTB(data)
+	return sttb, err
+}
+
+// parseSTTB parses a STTB at the start of data and returns it together with
+// the number of bytes it occupies.
+func parseSTTB(data []byte) (*STTB, int, error) {
+	if len(data) < 6 {
+		return nil, 0, fmt.Errorf("sttb: data too short, need at least 6 bytes")
+	}
+
+	sttb := &STTB{}
+	offset := 0
+
+	// An fExtend value of 0xFFFF marks UTF-16 strings
+	if binary.LittleEndian.Uint16(data[0:2]) == 0xFFFF {
+		sttb.Unicode = true
+		offset = 2
+	}
+
+	if len(data) < offset+4 {
+		return nil, 0, fmt.Errorf("sttb: data too short for header")
+	}
+	count := int(binary.LittleEndian.Uint16(data[offset:]))
+	cbExtra := int(binary.LittleEndian.Uint16(data[offset+2:]))
+	offset += 4
+
+	sttb.Strings = make([]string, 0, count)
+	sttb.Extra = make([][]byte, 0, count)
+
+	for i := 0; i < count; i++ {
+		var str string
+		if sttb.Unicode {
+			if offset+2 > len(data) {
+				return nil, 0, fmt.Errorf("sttb: not enough data for length of string %d", i)
+			}
+			cch := int(binary.LittleEndian.Uint16(data[offset:]))
+			offset += 2
+			if offset+cch*2 > len(data) {
+				return nil, 0, fmt.Errorf("sttb: not enough data for string %d", i)
+			}
+			u16s := make([]uint16, cch)
+			for j := range u16s {
+				u16s[j] = binary.LittleEndian.Uint16(data[offset+j*2:])
+			}
+			str = string(utf16.Decode(u16s))
+			offset += cch * 2
+		} else {
+			if offset+1 > len(data) {
+				return nil, 0, fmt.Errorf("sttb: not enough data for length of string %d", i)
+			}
+			cch := int(data[offset])
+			offset++
+			if offset+cch > len(data) {
+				return nil, 0, fmt.Errorf("sttb: not enough data for string %d", i)
+			}
+			str = string(data[offset : offset+cch])
+			offset += cch
+		}
+
+		var extra []byte
+		if cbExtra > 0 {
+			if offset+cbExtra > len(data) {
+				return nil, 0, fmt.Errorf("sttb: not enough data for extra data of string %d", i)
+			}
+			extra = make([]byte, cbExtra)
+			copy(extra, data[offset:offset+cbExtra])
+			offset += cbExtra
+		}
+
+		sttb.Strings = append(sttb.Strings, str)
+		sttb.Extra = append(sttb.Extra, extra)
+	}
+
+	return sttb, offset, nil
+}
+
+// Count returns the number of strings in the table.
+func (sttb *STTB) Count() int {
+	return len(sttb.Strings)
+}
+
+// StwUser holds the document variables set through the Variables collection
+// in VBA. Names[i] is the name of the variable whose value is Values[i].
+type StwUser struct {
+	Names  []string
+	Values []string
+}
+
+// ParseStwUser parses the StwUser structure: a STTB of variable names
+// followed by one null-terminated Xstz value per name.
+func ParseStwUser(data []byte) (*StwUser, error) {
+	names, offset, err := parseSTTB(data)
+	if err != nil {
+		return nil, fmt.Errorf("stwuser: %w", err)
+	}
+
+	values := make([]string, 0, names.Count())
+	for i := 0; i < names.Count(); i++ {
+		var value string
+		value, offset, err = parseXstz(data, offset)
+		if err != nil {
+			return nil, fmt.Errorf("stwuser: value of variable %d: %w", i, err)
+		}
+		values = append(values, value)
+	}
+	return &StwUser{Names: names.Strings, Values: values}, nil
+}
@@ -0,0 +1,215 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Table sprms, stored in the PAPX of the end-of-row mark of each table row.
+const (
+	sprmTDefTable       = 0xD608 // Cell edges and TC80s of the row
+	sprmTDefTableShd80  = 0xD609 // SHD80 of each cell
+	sprmTDefTableShd3rd = 0xD60C // Shd of cells 44 to 62
+	sprmTDefTableShd    = 0xD612 // Shd of cells 0 to 21
+	sprmTDefTableShd2nd = 0xD616 // Shd of cells 22 to 43
+)
+
+// Sizes of the cell structures of the table sprms.
+const (
+	tc80Size  = 20
+	shdSize   = 10
+	shd80Size = 2
+)
+
+// COLORREF is a color as stored in the table stream: the red, green and blue
+// bytes followed by 0xFF for the automatic color.
+type COLORREF uint32
+
+// colorRefAuto is the automatic color.
+const colorRefAuto COLORREF = 0xFF000000
+
+// RGB returns the red, green and blue components of the color.
+func (c COLORREF) RGB() (r, g, b uint8) {
+	return uint8(c), uint8(c >> 8), uint8(c >> 16)
+}
+
+// IsAuto reports whether the color is the automatic color.
+func (c COLORREF) IsAuto() bool {
+	return c>>24 == 0xFF
+}
+
+// icoColors maps the ico palette indices of the older structures to
+// COLORREF values. Index 0 is the automatic color.
+var icoColors = []COLORREF{
+	colorRefAuto,
+	0x000000, 0xFF0000, 0xFFFF00, 0x00FF00, 0xFF00FF, 0x0000FF, 0x00FFFF, 0xFFFFFF,
+	0x800000, 0x808000, 0x008000, 0x800080, 0x000080, 0x008080, 0x808080, 0xC0C0C0,
+}
+
+// icoColor converts an ico palette index to a COLORREF. Out-of-range values
+// are treated as automatic.
+func icoColor(ico uint8) COLORREF {
+	if int(ico) < len(icoColors) {
+		return icoColors[ico]
+	}
+	return colorRefAuto
+}
+
+// BRC describes one border of a table cell.
+type BRC struct {
+	Width  uint8    // Line width in eighth-points
+	Type   uint8    // Border type (brcType), such as 1 for a single line
+	Color  COLORREF // Line color
+	Space  uint8    // Distance from the text in points
+	Shadow bool     // The border has a shadow
+}
+
+// SHD describes the shading of a table cell.
+type SHD struct {
+	Fore    COLORREF // Foreground color of the pattern
+	Back    COLORREF // Background color
+	Pattern uint16   // Fill pattern (ipat), such as 0 for clear and 1 for solid
+}
+
+// TC holds the properties of one cell of a table row.
+type TC struct {
+	Width       int16 // Width in twips, from the cell edges
+	FirstMerged bool  // First cell of a horizontally merged range
+	Merged      bool  // Cell is merged into the preceding cell
+	VertMerge   bool  // Cell is part of a vertically merged range
+	VertRestart bool  // First cell of a vertically merged range
+	VertAlign   uint8 // Vertical alignment: 0 top, 1 center, 2 bottom
+
+	// Borders of the cell, nil where the cell has none
+	Top, Left, Bottom, Right *BRC
+
+	// Shading of the cell, nil if the cell is not shaded
+	Shading *SHD
+}
+
+// TAP holds the table properties of a row: the positions of the cell edges
+// and the properties of each cell.
+type TAP struct {
+	CellEdges []int16 // Cell boundaries in twips, one more than there are cells
+	Cells     []*TC
+}
+
+// ParseTAP parses the table properties of a row from the sprms of the PAPX
+// of its end-of-row mark. Returns nil if the sprms do not define the row's
+// cells.
+func ParseTAP(grpprl []byte) (*TAP, error) {
+	operand, ok := FindSprm(grpprl, sprmTDefTable)
+	if !ok {
+		return nil, nil
+	}
+	tap, err := parseTDefTable(operand)
+	if err != nil {
+		return nil, err
+	}
+
+	// Word 2000 and later write a Shd for each cell; older versions, and
+	// later ones for compatibility, an SHD80
+	if operand, ok := FindSprm(grpprl, sprmTDefTableShd80); ok {
+		for i := 0; i < len(tap.Cells) && 1+(i+1)*shd80Size <= len(operand); i++ {
+			tap.Cells[i].Shading = parseSHD80(binary.LittleEndian.Uint16(operand[1+i*shd80Size:]))
+		}
+	}
+	for n, sprm := range []uint16{sprmTDefTableShd, sprmTDefTableShd2nd, sprmTDefTableShd3rd} {
+		operand, ok := FindSprm(grpprl, sprm)
+		if !ok {
+			continue
+		}
+		for i := 0; 1+(i+1)*shdSize <= len(operand); i++ {
+			cell := n*22 + i
+			if cell >= len(tap.Cells) {
+				break
+			}
+			tap.Cells[cell].Shading = parseSHD(operand[1+i*shdSize:])
+		}
+	}
+	return tap, nil
+}
+
+// parseTDefTable parses the operand of sprmTDefTable: its size, the number
+// of cells, the cell edges and a TC80 for each cell. Cells without a TC80
+// keep the default properties.
+func parseTDefTable(operand []byte) (*TAP, error) {
+	if len(operand) < 3 {
+		return nil, fmt.Errorf("tdeftable: operand too short")
+	}
+	count := int(operand[2])
+	if count > 63 {
+		return nil, fmt.Errorf("tdeftable: invalid cell count %d", count)
+	}
+	offset := 3 // cb and NumberOfColumns
+	if offset+(count+1)*2 > len(operand) {
+		return nil, fmt.Errorf("tdeftable: not enough data for %d cell edges", count+1)
+	}
+
+	tap := &TAP{CellEdges: make([]int16, count+1), Cells: make([]*TC, count)}
+	for i := range tap.CellEdges {
+		tap.CellEdges[i] = int16(binary.LittleEndian.Uint16(operand[offset+i*2:]))
+	}
+	offset += (count + 1) * 2
+
+	for i := range tap.Cells {
+		tc := &TC{Width: tap.CellEdges[i+1] - tap.CellEdges[i]}
+		if data := operand[min(offset+i*tc80Size, len(operand)):]; len(data) >= tc80Size {
+			tcgrf := binary.LittleEndian.Uint16(data)
+			tc.FirstMerged = tcgrf&0x0001 != 0
+			tc.Merged = tcgrf&0x0002 != 0
+			tc.VertMerge = tcgrf&0x0020 != 0
+			tc.VertRestart = tcgrf&0x0040 != 0
+			tc.VertAlign = uint8(tcgrf >> 7 & 0x03)
+			tc.Top = parseBRC80(data[4:])
+			tc.Left = parseBRC80(data[8:])
+			tc.Bottom = parseBRC80(data[12:])
+			tc.Right = parseBRC80(data[16:])
+		}
+		tap.Cells[i] = tc
+	}
+	return tap, nil
+}
+
+// parseBRC80 parses a 4-byte BRC80, returning nil for a missing border.
+func parseBRC80(data []byte) *BRC {
+	if binary.LittleEndian.Uint32(data) == 0xFFFFFFFF || data[1] == 0 {
+		return nil
+	}
+	return &BRC{
+		Width:  data[0],
+		Type:   data[1],
+		Color:  icoColor(data[2]),
+		Space:  data[3] & 0x1F,
+		Shadow: data[3]&0x20 != 0,
+	}
+}
+
+// parseSHD parses a 10-byte Shd, returning nil for the nil shading and for
+// clear shading over the automatic background, which Word writes for cells
+// without shading.
+func parseSHD(data []byte) *SHD {
+	shd := &SHD{
+		Fore:    COLORREF(binary.LittleEndian.Uint32(data)),
+		Back:    COLORREF(binary.LittleEndian.Uint32(data[4:])),
+		Pattern: binary.LittleEndian.Uint16(data[8:]),
+	}
+	if shd.Pattern == 0xFFFF || (shd.Pattern == 0 && shd.Back.IsAuto()) {
+		return nil
+	}
+	return shd
+}
+
+// parseSHD80 parses an SHD80, which packs the foreground and background ico
+// and the pattern into 16 bits.
+func parseSHD80(value uint16) *SHD {
+	shd := &SHD{
+		Fore:    icoColor(uint8(value & 0x1F)),
+		Back:    icoColor(uint8(value >> 5 & 0x1F)),
+		Pattern: value >> 10,
+	}
+	if shd.Pattern == 0 && shd.Back.IsAuto() {
+		return nil
+	}
+	return shd
+}
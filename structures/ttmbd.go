@@ -0,0 +1,48 @@
+package structures
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ttmbdSize is the size of the TTMBD stored as the extra data of each entry
+// of the SttbTtmbd.
+const ttmbdSize = 8
+
+// TTMBD describes a TrueType font embedded in the document.
+type TTMBD struct {
+	Name   string // Name of the font, as in the font table
+	Bold   bool   // The embedded face is bold
+	Italic bool   // The embedded face is italic
+	Subset bool   // Only the characters used by the document are embedded
+	FcFont uint32 // Offset of the font data in the Data stream
+}
+
+// ParseSttbTtmbd parses the embedded font table stored at fcSttbttmbd: an
+// extended STTB of font names whose extra data is a TTMBD holding the style
+// bits (bold 0x0001, italic 0x0002), the flags (subset 0x0001) and the
+// offset of the font data.
+func ParseSttbTtmbd(data []byte) ([]TTMBD, error) {
+	sttb, err := ParseSTTB(data)
+	if err != nil {
+		return nil, fmt.Errorf("sttbttmbd: %w", err)
+	}
+
+	fonts := make([]TTMBD, sttb.Count())
+	for i, name := range sttb.Strings {
+		extra := sttb.Extra[i]
+		if len(extra) < ttmbdSize {
+			return nil, fmt.Errorf("sttbttmbd: font %d has %d bytes of TTMBD, need %d", i, len(extra), ttmbdSize)
+		}
+		style := binary.LittleEndian.Uint16(extra)
+		flags := binary.LittleEndian.Uint16(extra[2:])
+		fonts[i] = TTMBD{
+			Name:   name,
+			Bold:   style&0x0001 != 0,
+			Italic: style&0x0002 != 0,
+			Subset: flags&0x0001 != 0,
+			FcFont: binary.LittleEndian.Uint32(extra[4:]),
+		}
+	}
+	return fonts, nil
+}
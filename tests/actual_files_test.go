@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/TalentFormula/msdoc/pkg"
@@ -44,3 +45,20 @@ func TestActualDocFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestSampleText(t *testing.T) {
+	doc, err := msdoc.Open("testdata/sample-2.doc")
+	if err != nil {
+		t.Fatalf("Failed to open document: %v", err)
+	}
+	defer doc.Close()
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	want := "This is a sample document to test the .doc file format implementation in go\r"
+	if !strings.HasPrefix(text, want) {
+		t.Errorf("Expected the text to start with %q, got %q", want, text)
+	}
+}
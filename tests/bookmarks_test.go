@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+// buildBookmarks encodes bookmark names, a PlcfBkf whose FBKFs give the
+// index of each start's end, and a PlcfBkl of end CPs. The returned offsets
+// and sizes follow the FIB order of the three structures.
+func buildBookmarks(table []byte, names []string, starts []uint32, ibkls []int16, ends []uint32, last uint32) ([]byte, [6]uint32) {
+	var fcLcb [6]uint32
+
+	sttb := buildSTTB(names...)
+	fcLcb[0], fcLcb[1] = uint32(len(table)), uint32(len(sttb))
+	table = append(table, sttb...)
+
+	var bkf []byte
+	for _, cp := range append(starts, last) {
+		bkf = binary.LittleEndian.AppendUint32(bkf, cp)
+	}
+	for _, ibkl := range ibkls {
+		bkf = binary.LittleEndian.AppendUint16(bkf, uint16(ibkl))
+		bkf = binary.LittleEndian.AppendUint16(bkf, 0) // bkc
+	}
+	fcLcb[2], fcLcb[3] = uint32(len(table)), uint32(len(bkf))
+	table = append(table, bkf...)
+
+	var bkl []byte
+	for _, cp := range append(ends, last) {
+		bkl = binary.LittleEndian.AppendUint32(bkl, cp)
+	}
+	fcLcb[4], fcLcb[5] = uint32(len(table)), uint32(len(bkl))
+	table = append(table, bkl...)
+	return table, fcLcb
+}
+
+func TestBookmarks(t *testing.T) {
+	text := "Dear Alice Smith, welcome\r"
+
+	// "Name" lies inside "Greeting" and ends first, so the ends are stored in
+	// the opposite order of the starts; "Cursor" is an insertion point
+	table, fcLcb := buildBookmarks(nil,
+		[]string{"Greeting", "Name", "Cursor"},
+		[]uint32{0, 5, 25}, []int16{1, 0, 2},
+		[]uint32{16, 17, 25}, 27)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   table,
+		fcLcb: map[int]uint32{
+			42: fcLcb[0], 43: fcLcb[1],
+			44: fcLcb[2], 45: fcLcb[3],
+			46: fcLcb[4], 47: fcLcb[5],
+		},
+	})
+
+	bookmarks, err := doc.Bookmarks()
+	if err != nil {
+		t.Fatalf("Bookmarks failed: %v", err)
+	}
+	expected := []msdoc.Bookmark{
+		{Name: "Greeting", StartCP: 0, EndCP: 17},
+		{Name: "Name", StartCP: 5, EndCP: 16},
+		{Name: "Cursor", StartCP: 25, EndCP: 25},
+	}
+	if len(bookmarks) != len(expected) {
+		t.Fatalf("Expected %d bookmarks, got %d: %+v", len(expected), len(bookmarks), bookmarks)
+	}
+	for i, want := range expected {
+		if bookmarks[i] != want {
+			t.Errorf("Bookmark %d: expected %+v, got %+v", i, want, bookmarks[i])
+		}
+	}
+}
+
+func TestBookmarksOverlapping(t *testing.T) {
+	text := "The quick brown fox jumps over\r"
+
+	// "First" and "Second" overlap, and "Inner" lies inside "Second" and ends
+	// before both. Pairing starts with ends by position would give "First"
+	// the end of "Inner".
+	table, fcLcb := buildBookmarks(nil,
+		[]string{"First", "Second", "Inner"},
+		[]uint32{0, 4, 10}, []int16{1, 2, 0},
+		[]uint32{15, 19, 25}, 31)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   table,
+		fcLcb: map[int]uint32{
+			42: fcLcb[0], 43: fcLcb[1],
+			44: fcLcb[2], 45: fcLcb[3],
+			46: fcLcb[4], 47: fcLcb[5],
+		},
+	})
+
+	bookmarks, err := doc.Bookmarks()
+	if err != nil {
+		t.Fatalf("Bookmarks failed: %v", err)
+	}
+	expected := []msdoc.Bookmark{
+		{Name: "First", StartCP: 0, EndCP: 19},
+		{Name: "Second", StartCP: 4, EndCP: 25},
+		{Name: "Inner", StartCP: 10, EndCP: 15},
+	}
+	if len(bookmarks) != len(expected) {
+		t.Fatalf("Expected %d bookmarks, got %d: %+v", len(expected), len(bookmarks), bookmarks)
+	}
+	for i, want := range expected {
+		if bookmarks[i] != want {
+			t.Errorf("Bookmark %d: expected %+v, got %+v", i, want, bookmarks[i])
+		}
+	}
+
+	// The overlapping bookmarks share "quick brown fox"
+	units := []rune(text)
+	if got := string(units[bookmarks[0].StartCP:bookmarks[0].EndCP]); got != "The quick brown fox" {
+		t.Errorf("Expected First to cover %q, got %q", "The quick brown fox", got)
+	}
+	if got := string(units[bookmarks[1].StartCP:bookmarks[1].EndCP]); got != "quick brown fox jumps" {
+		t.Errorf("Expected Second to cover %q, got %q", "quick brown fox jumps", got)
+	}
+}
+
+func TestBookmarksNone(t *testing.T) {
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{{text: "No bookmarks\r"}}})
+
+	bookmarks, err := doc.Bookmarks()
+	if err != nil {
+		t.Fatalf("Bookmarks failed: %v", err)
+	}
+	if bookmarks != nil {
+		t.Errorf("Expected no bookmarks, got %+v", bookmarks)
+	}
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+// buildATRD encodes an ATRD with the given initials and author index.
+func buildATRD(initials string, author int16) []byte {
+	atrd := make([]byte, 30)
+	units := utf16.Encode([]rune(initials))
+	binary.LittleEndian.PutUint16(atrd[0:], uint16(len(units)))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(atrd[2+i*2:], u)
+	}
+	binary.LittleEndian.PutUint16(atrd[20:], uint16(author))
+	binary.LittleEndian.PutUint32(atrd[26:], 0xFFFFFFFF) // No bookmark
+	return atrd
+}
+
+func TestCommentAuthors(t *testing.T) {
+	main := "Hello\x05 world\x05\r"
+	annotations := "\x05First note\r\x05Second note\r\r"
+
+	// Comment references at CPs 5 and 12, the first by Bob and the second by Alice
+	var table []byte
+	refOffset := uint32(len(table))
+	for _, cp := range []uint32{5, 12, 14} {
+		table = binary.LittleEndian.AppendUint32(table, cp)
+	}
+	table = append(table, buildATRD("BJ", 1)...)
+	table = append(table, buildATRD("AS", 0)...)
+	refSize := uint32(len(table)) - refOffset
+
+	txtOffset := uint32(len(table))
+	for _, cp := range []uint32{0, 12, 25, 26} {
+		table = binary.LittleEndian.AppendUint32(table, cp)
+	}
+	txtSize := uint32(len(table)) - txtOffset
+
+	ownersOffset := uint32(len(table))
+	for _, name := range []string{"Alice Smith", "Bob Jones"} {
+		units := utf16.Encode([]rune(name))
+		table = binary.LittleEndian.AppendUint16(table, uint16(len(units)))
+		for _, u := range units {
+			table = binary.LittleEndian.AppendUint16(table, u)
+		}
+	}
+	ownersSize := uint32(len(table)) - ownersOffset
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: main + annotations, unicode: true}},
+		ccpText: uint32(len(main)),
+		ccpAtn:  uint32(len(annotations)),
+		table:   table,
+		fcLcb: map[int]uint32{
+			8: refOffset, 9: refSize,
+			10: txtOffset, 11: txtSize,
+			72: ownersOffset, 73: ownersSize,
+		},
+	})
+
+	comments, err := doc.Comments()
+	if err != nil {
+		t.Fatalf("Comments failed: %v", err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("Expected 2 comments, got %d", len(comments))
+	}
+
+	expected := []struct {
+		author, initials, text string
+		cp                     uint32
+	}{
+		{"Bob Jones", "BJ", "First note", 5},
+		{"Alice Smith", "AS", "Second note", 12},
+	}
+	for i, want := range expected {
+		got := comments[i]
+		if got.Author != want.author || got.Initials != want.initials {
+			t.Errorf("Comment %d: expected author %q (%s), got %q (%s)", i, want.author, want.initials, got.Author, got.Initials)
+		}
+		if got.Text != want.text || got.CP != want.cp {
+			t.Errorf("Comment %d: expected %q at CP %d, got %q at CP %d", i, want.text, want.cp, got.Text, got.CP)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/crypto"
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+// buildCryptoAPIHeader creates an RC4 CryptoAPI encryption header whose verifier
+// was encrypted with the given password.
+func buildCryptoAPIHeader(t *testing.T, password string, salt []byte, keySize uint32) []byte {
+	return buildEncryptionHeader(t, password, salt, 0x6801, keySize)
+}
+
+// buildEncryptionHeader creates a CryptoAPI encryption header for the given
+// algorithm whose verifier was encrypted with the given password, laid out
+// as the version, flags, EncryptionHeader and EncryptionVerifier.
+func buildEncryptionHeader(t *testing.T, password string, salt []byte, algID uint32, keySize uint32) []byte {
+	t.Helper()
+
+	providerName := utf16.Encode([]rune("Microsoft Enhanced Cryptographic Provider v1.0\x00"))
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint16(4))                      // Major version
+	binary.Write(&buf, binary.LittleEndian, uint16(2))                      // Minor version
+	binary.Write(&buf, binary.LittleEndian, uint32(0x04))                   // Flags (fCryptoAPI)
+	binary.Write(&buf, binary.LittleEndian, uint32(32+len(providerName)*2)) // Header size
+	binary.Write(&buf, binary.LittleEndian, uint32(0x04))                   // Header flags
+	binary.Write(&buf, binary.LittleEndian, uint32(0))                      // SizeExtra
+	binary.Write(&buf, binary.LittleEndian, algID)                          // Algorithm
+	binary.Write(&buf, binary.LittleEndian, uint32(0x8004))                 // CALG_SHA1
+	binary.Write(&buf, binary.LittleEndian, keySize)                        // Key size in bits
+	binary.Write(&buf, binary.LittleEndian, uint32(1))                      // PROV_RSA_FULL
+	buf.Write(make([]byte, 8))                                              // Reserved
+	binary.Write(&buf, binary.LittleEndian, providerName)                   // CSPName
+	binary.Write(&buf, binary.LittleEndian, uint32(len(salt)))              // Salt size
+	buf.Write(salt)
+
+	key, err := crypto.GenerateCryptoAPIKey(password, salt, 0, keySize)
+	if err != nil {
+		t.Fatalf("GenerateCryptoAPIKey failed: %v", err)
+	}
+	rc4, err := crypto.NewRC4(key)
+	if err != nil {
+		t.Fatalf("NewRC4 failed: %v", err)
+	}
+
+	verifier := []byte("0123456789abcdef")
+	verifierHash := sha1.Sum(verifier)
+	buf.Write(rc4.Decrypt(verifier))
+	binary.Write(&buf, binary.LittleEndian, uint32(sha1.Size)) // Verifier hash size
+	buf.Write(rc4.Decrypt(verifierHash[:]))
+	if algID != crypto.AlgIDRC4 {
+		buf.Write(make([]byte, 32-sha1.Size)) // AES pads the hash to a block
+	}
+
+	return buf.Bytes()
+}
+
+func TestCryptoAPIKeyDerivation(t *testing.T) {
+	salt := make([]byte, 16)
+	for i := range salt {
+		salt[i] = byte(i)
+	}
+
+	// 128-bit key for block 0
+	key, err := crypto.GenerateCryptoAPIKey("password", salt, 0, 128)
+	if err != nil {
+		t.Fatalf("GenerateCryptoAPIKey failed: %v", err)
+	}
+	if got := hex.EncodeToString(key); got != "c25141865f8d11622c27586270440db4" {
+		t.Errorf("Unexpected 128-bit key %s", got)
+	}
+
+	// 40-bit key for block 1 is zero-padded to 128 bits
+	key, err = crypto.GenerateCryptoAPIKey("password", salt, 1, 40)
+	if err != nil {
+		t.Fatalf("GenerateCryptoAPIKey failed: %v", err)
+	}
+	if got := hex.EncodeToString(key); got != "a614652b010000000000000000000000" {
+		t.Errorf("Unexpected 40-bit key %s", got)
+	}
+}
+
+func TestStreamDecryptorBlocks(t *testing.T) {
+	salt := []byte("fedcba9876543210")
+	header, err := crypto.ParseEncryptionHeader(buildCryptoAPIHeader(t, "secret", salt, 128))
+	if err != nil {
+		t.Fatalf("ParseEncryptionHeader failed: %v", err)
+	}
+	decryptor, err := header.NewStreamDecryptor("secret")
+	if err != nil {
+		t.Fatalf("NewStreamDecryptor failed: %v", err)
+	}
+
+	// Each 512-byte block restarts the keystream with the key of its number
+	plain := bytes.Repeat([]byte("0123456789"), 120)
+	var want []byte
+	for block := uint32(0); int(block)*crypto.BlockSize < len(plain); block++ {
+		key, err := crypto.GenerateCryptoAPIKey("secret", salt, block, 128)
+		if err != nil {
+			t.Fatalf("GenerateCryptoAPIKey failed: %v", err)
+		}
+		rc4, err := crypto.NewRC4(key)
+		if err != nil {
+			t.Fatalf("NewRC4 failed: %v", err)
+		}
+		end := min(int(block+1)*crypto.BlockSize, len(plain))
+		want = append(want, rc4.Decrypt(plain[int(block)*crypto.BlockSize:end])...)
+	}
+
+	got, err := decryptor.DecryptAt(plain, 0)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("Expected the stream to be encrypted block by block (err: %v)", err)
+	}
+
+	// Ranges starting inside a block and crossing into the next decrypt on
+	// their own
+	part, err := decryptor.DecryptAt(want[500:530], 500)
+	if err != nil || !bytes.Equal(part, plain[500:530]) {
+		t.Errorf("Expected %q, got %q (err: %v)", plain[500:530], part, err)
+	}
+}
+
+func TestCryptoAPIPasswordValidation(t *testing.T) {
+	salt := []byte("fedcba9876543210")
+	header, err := crypto.ParseEncryptionHeader(buildCryptoAPIHeader(t, "secret", salt, 128))
+	if err != nil {
+		t.Fatalf("ParseEncryptionHeader failed: %v", err)
+	}
+
+	if !header.UsesSHA1() {
+		t.Fatal("Expected SHA-1 key derivation")
+	}
+	if len(header.VerifierHash) != sha1.Size {
+		t.Errorf("Expected %d byte verifier hash, got %d", sha1.Size, len(header.VerifierHash))
+	}
+
+	valid, err := header.ValidatePassword("secret")
+	if err != nil {
+		t.Fatalf("ValidatePassword failed: %v", err)
+	}
+	if !valid {
+		t.Error("Expected correct password to validate")
+	}
+
+	valid, err = header.ValidatePassword("wrong")
+	if err != nil {
+		t.Fatalf("ValidatePassword failed: %v", err)
+	}
+	if valid {
+		t.Error("Expected incorrect password to be rejected")
+	}
+
+	if _, err := header.CreateDecryptionCipher("secret"); err != nil {
+		t.Errorf("CreateDecryptionCipher failed: %v", err)
+	}
+}
+
+func TestRC4PasswordValidation(t *testing.T) {
+	// RC4 (version 1.1) headers hold the version, salt, verifier and the MD5
+	// of the verifier, encrypted with the block 0 key
+	salt := []byte("fedcba9876543210")
+	h0 := md5.Sum([]byte{'s', 0, 'e', 0, 'c', 0, 'r', 0, 'e', 0, 't', 0})
+	var intermediate []byte
+	for i := 0; i < 16; i++ {
+		intermediate = append(append(intermediate, h0[:5]...), salt...)
+	}
+	h1 := md5.Sum(intermediate)
+	key := md5.Sum(append(h1[:5], 0, 0, 0, 0))
+	rc4, err := crypto.NewRC4(key[:])
+	if err != nil {
+		t.Fatalf("NewRC4 failed: %v", err)
+	}
+	verifier := []byte("0123456789abcdef")
+	verifierHash := md5.Sum(verifier)
+	data := append([]byte{1, 0, 1, 0}, salt...)
+	data = append(data, rc4.Decrypt(append(verifier, verifierHash[:]...))...)
+
+	header, err := crypto.ParseEncryptionHeader(data)
+	if err != nil {
+		t.Fatalf("ParseEncryptionHeader failed: %v", err)
+	}
+	if header.UsesSHA1() || !header.IsRC4Encryption() || header.Size() != 52 {
+		t.Errorf("Expected a 52-byte RC4 header with MD5 keys, got %d bytes", header.Size())
+	}
+	if valid, err := header.ValidatePassword("secret"); err != nil || !valid {
+		t.Errorf("Expected the correct password to validate (err: %v)", err)
+	}
+	if valid, err := header.ValidatePassword("wrong"); err != nil || valid {
+		t.Errorf("Expected an incorrect password to be rejected (err: %v)", err)
+	}
+}
+
+func TestUnsupportedEncryptionError(t *testing.T) {
+	salt := []byte("fedcba9876543210")
+	mock := &mockDoc{
+		pieces:    []mockPiece{{text: "Secret text"}},
+		flags1:    0x0100, // fEncrypted
+		encHeader: buildEncryptionHeader(t, "secret", salt, 0x660E, 128),
+	}
+
+	_, err := msdoc.OpenWithPassword(mock.writeFile(t), "secret")
+	if err == nil {
+		t.Fatal("Expected opening an AES-encrypted document to fail")
+	}
+	if !errors.Is(err, crypto.ErrUnsupportedEncryption) {
+		t.Fatalf("Expected ErrUnsupportedEncryption, got: %v", err)
+	}
+
+	var unsupported *crypto.UnsupportedEncryptionError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected *UnsupportedEncryptionError, got %T", err)
+	}
+	if unsupported.AlgID != crypto.AlgIDAES128 {
+		t.Errorf("Expected AlgID 0x%04X, got 0x%04X", crypto.AlgIDAES128, unsupported.AlgID)
+	}
+	if !unsupported.IsAES() {
+		t.Error("Expected the algorithm to be reported as AES")
+	}
+}
+
+func TestOpenWithPasswordFunc(t *testing.T) {
+	salt := []byte("fedcba9876543210")
+	encrypted := &mockDoc{
+		pieces:      []mockPiece{{text: "Secret text"}},
+		flags1:      0x0100, // fEncrypted
+		encHeader:   buildCryptoAPIHeader(t, "secret", salt, 128),
+		encPassword: "secret",
+	}
+
+	calls := 0
+	doc, err := msdoc.OpenWithPasswordFunc(encrypted.writeFile(t), func() (string, error) {
+		calls++
+		return "secret", nil
+	})
+	if err != nil {
+		t.Fatalf("OpenWithPasswordFunc failed: %v", err)
+	}
+	defer doc.Close()
+
+	if calls != 1 {
+		t.Errorf("Expected the password callback to be called once, got %d", calls)
+	}
+	if !doc.IsEncrypted() {
+		t.Error("Expected the document to be reported as encrypted")
+	}
+
+	// Unencrypted documents never ask for a password
+	plain := &mockDoc{pieces: []mockPiece{{text: "Plain text"}}}
+	doc, err = msdoc.OpenWithPasswordFunc(plain.writeFile(t), func() (string, error) {
+		return "", errors.New("password callback should not be called")
+	})
+	if err != nil {
+		t.Fatalf("OpenWithPasswordFunc failed for an unencrypted document: %v", err)
+	}
+	doc.Close()
+}
+
+func TestTableStreamOffsets(t *testing.T) {
+	salt := []byte("fedcba9876543210")
+
+	// The DOP and CLX are found through the FIB in both documents, whose
+	// offsets count the encryption header in the encrypted one
+	dop := make([]byte, 84)
+	binary.LittleEndian.PutUint16(dop[10:], 1080) // dxaTab
+	pieces := []mockPiece{{text: "First piece "}, {text: "second piece", unicode: true}}
+
+	docs := map[string]*msdoc.Document{
+		"unencrypted": openMock(t, &mockDoc{
+			pieces: pieces,
+			table:  dop,
+			fcLcb:  map[int]uint32{62: 0, 63: uint32(len(dop))},
+		}),
+	}
+	encrypted := &mockDoc{
+		pieces:      pieces,
+		flags1:      0x0100, // fEncrypted
+		table:       dop,
+		fcLcb:       map[int]uint32{62: 0, 63: uint32(len(dop))},
+		encHeader:   buildCryptoAPIHeader(t, "secret", salt, 128),
+		encPassword: "secret",
+	}
+	doc, err := msdoc.OpenWithPassword(encrypted.writeFile(t), "secret")
+	if err != nil {
+		t.Fatalf("OpenWithPassword failed: %v", err)
+	}
+	defer doc.Close()
+	docs["encrypted"] = doc
+
+	for name, doc := range docs {
+		tab, err := doc.DefaultTabStop()
+		if err != nil {
+			t.Fatalf("%s: DefaultTabStop failed: %v", name, err)
+		}
+		if tab != 1080 {
+			t.Errorf("%s: expected default tab stop of 1080 twips, got %d", name, tab)
+		}
+
+		got, err := doc.Pieces()
+		if err != nil {
+			t.Fatalf("%s: Pieces failed: %v", name, err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("%s: expected 2 pieces, got %d", name, len(got))
+		}
+		if got[0].StartCP != 0 || got[0].EndCP != 12 || got[1].StartCP != 12 || got[1].EndCP != 24 {
+			t.Errorf("%s: unexpected piece ranges %+v", name, got)
+		}
+		if got[0].IsUnicode || !got[1].IsUnicode {
+			t.Errorf("%s: unexpected piece encodings %+v", name, got)
+		}
+	}
+}
+
+func TestEncryptedFormattedText(t *testing.T) {
+	salt := []byte("fedcba9876543210")
+	encrypted := &mockDoc{
+		pieces:      []mockPiece{{text: "First piece "}, {text: "second piece ", unicode: true}, {text: "third piece\r"}},
+		flags1:      0x0100, // fEncrypted
+		encHeader:   buildCryptoAPIHeader(t, "secret", salt, 128),
+		encPassword: "secret",
+	}
+	doc, err := msdoc.OpenWithPassword(encrypted.writeFile(t), "secret")
+	if err != nil {
+		t.Fatalf("OpenWithPassword failed: %v", err)
+	}
+	defer doc.Close()
+
+	// Each run is decrypted at its own offset, so repeated and out of order
+	// reads give the same text
+	want := []string{"First piece ", "second piece ", "third piece\r"}
+	for attempt := 0; attempt < 2; attempt++ {
+		runs, err := doc.GetFormattedText()
+		if err != nil {
+			t.Fatalf("GetFormattedText failed: %v", err)
+		}
+		if len(runs) != len(want) {
+			t.Fatalf("Expected %d runs, got %d", len(want), len(runs))
+		}
+		for i, run := range runs {
+			if run.Text != want[i] {
+				t.Errorf("Attempt %d: expected run %d to be %q, got %q", attempt+1, i, want[i], run.Text)
+			}
+		}
+		if runs[1].StartPos != 12 || runs[2].StartPos != 25 {
+			t.Errorf("Unexpected run positions %d and %d", runs[1].StartPos, runs[2].StartPos)
+		}
+	}
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if text != strings.Join(want, "") {
+		t.Errorf("Expected text %q, got %q", strings.Join(want, ""), text)
+	}
+}
+
+func TestEncryptionInfo(t *testing.T) {
+	salt := []byte("fedcba9876543210")
+	encrypted := &mockDoc{
+		pieces:      []mockPiece{{text: "Audited\r"}},
+		flags1:      0x0100, // fEncrypted
+		encHeader:   buildCryptoAPIHeader(t, "secret", salt, 128),
+		encPassword: "secret",
+	}
+	doc, err := msdoc.OpenWithPassword(encrypted.writeFile(t), "secret")
+	if err != nil {
+		t.Fatalf("OpenWithPassword failed: %v", err)
+	}
+	defer doc.Close()
+
+	info, err := doc.EncryptionInfo()
+	if err != nil {
+		t.Fatalf("EncryptionInfo failed: %v", err)
+	}
+	if !info.IsRC4Encryption() || info.AlgID != crypto.AlgIDRC4 {
+		t.Errorf("Expected RC4, got algorithm 0x%04X", info.AlgID)
+	}
+	if !info.UsesSHA1() || info.KeySize != 128 || info.ProviderType != 1 {
+		t.Errorf("Expected a 128-bit SHA-1 CryptoAPI key from PROV_RSA_FULL, got hash 0x%04X, %d bits, provider type %d",
+			info.AlgHashID, info.KeySize, info.ProviderType)
+	}
+	if !bytes.Equal(info.Salt, salt) {
+		t.Errorf("Expected salt %q, got %q", salt, info.Salt)
+	}
+
+	plain := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Plain\r"}}})
+	if info, err := plain.EncryptionInfo(); info != nil || !errors.Is(err, msdoc.ErrNotEncrypted) {
+		t.Errorf("Expected ErrNotEncrypted, got %v (%v)", info, err)
+	}
+}
+
+func TestEncryptedSample(t *testing.T) {
+	// sample-2 encrypted with 128-bit RC4 CryptoAPI by
+	// testdata/encrypt_rc4_cryptoapi.py, which follows the specifications
+	// independently of this package
+	const path = "testdata/sample-2-rc4-cryptoapi.doc"
+	if _, err := msdoc.OpenWithPassword(path, "wrong"); err == nil {
+		t.Error("Expected an incorrect password to be rejected")
+	}
+
+	doc, err := msdoc.OpenWithPassword(path, "password")
+	if err != nil {
+		t.Fatalf("OpenWithPassword failed: %v", err)
+	}
+	defer doc.Close()
+	plain, err := msdoc.Open("testdata/sample-2.doc")
+	if err != nil {
+		t.Fatalf("Failed to open sample: %v", err)
+	}
+	defer plain.Close()
+
+	info, err := doc.EncryptionInfo()
+	if err != nil {
+		t.Fatalf("EncryptionInfo failed: %v", err)
+	}
+	if info.Version != 4 || info.MinorVersion != 2 || info.KeySize != 128 || !info.UsesSHA1() {
+		t.Errorf("Expected a 128-bit SHA-1 CryptoAPI header version 4.2, got %d.%d with %d bits", info.Version, info.MinorVersion, info.KeySize)
+	}
+	if info.ProviderName != "Microsoft Enhanced Cryptographic Provider v1.0" {
+		t.Errorf("Unexpected provider %q", info.ProviderName)
+	}
+
+	// Everything read through the FIB, which is only partly stored
+	// unencrypted, matches the unencrypted document
+	want, _ := plain.Text()
+	if text, err := doc.Text(); err != nil || text != want {
+		t.Errorf("Expected text %q, got %q (err: %v)", want, text, err)
+	}
+	wantParagraphs, _ := plain.Paragraphs()
+	paragraphs, err := doc.Paragraphs()
+	if err != nil || len(paragraphs) != len(wantParagraphs) {
+		t.Fatalf("Expected %d paragraphs, got %d (err: %v)", len(wantParagraphs), len(paragraphs), err)
+	}
+	for i, para := range paragraphs {
+		if para.Text != wantParagraphs[i].Text || para.StyleName != wantParagraphs[i].StyleName {
+			t.Errorf("Expected paragraph %d to be %q in %q, got %q in %q", i,
+				wantParagraphs[i].Text, wantParagraphs[i].StyleName, para.Text, para.StyleName)
+		}
+	}
+	wantTab, _ := plain.DefaultTabStop()
+	if tab, err := doc.DefaultTabStop(); err != nil || tab != wantTab {
+		t.Errorf("Expected a default tab stop of %d, got %d (err: %v)", wantTab, tab, err)
+	}
+	wantRuns, _ := plain.GetFormattedText()
+	runs, err := doc.GetFormattedText()
+	if err != nil || len(runs) != len(wantRuns) {
+		t.Fatalf("Expected %d formatted runs, got %d (err: %v)", len(wantRuns), len(runs), err)
+	}
+	for i, run := range runs {
+		if run.Text != wantRuns[i].Text {
+			t.Errorf("Expected run %d to be %q, got %q", i, wantRuns[i].Text, run.Text)
+		}
+	}
+}
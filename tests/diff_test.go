@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+func TestDiffText(t *testing.T) {
+	a := openMock(t, &mockDoc{pieces: []mockPiece{{text: "The quick brown fox jumps\rover the lazy dog.\r"}}})
+	b := openMock(t, &mockDoc{pieces: []mockPiece{{text: "The quick red fox jumps\rover the dog.\r", unicode: true}}})
+
+	ops, err := msdoc.DiffText(a, b)
+	if err != nil {
+		t.Fatalf("DiffText failed: %v", err)
+	}
+	expected := []msdoc.DiffOp{
+		{Kind: msdoc.DiffEqual, Text: "The quick "},
+		{Kind: msdoc.DiffDelete, Text: "brown"},
+		{Kind: msdoc.DiffInsert, Text: "red"},
+		{Kind: msdoc.DiffEqual, Text: " fox jumps\nover the"},
+		{Kind: msdoc.DiffDelete, Text: " lazy"},
+		{Kind: msdoc.DiffEqual, Text: " dog."},
+	}
+	if !slices.Equal(ops, expected) {
+		t.Errorf("Expected ops %+v, got %+v", expected, ops)
+	}
+
+	// The ops rebuild the normalized text of both documents
+	var textA, textB strings.Builder
+	for _, op := range ops {
+		if op.Kind != msdoc.DiffInsert {
+			textA.WriteString(op.Text)
+		}
+		if op.Kind != msdoc.DiffDelete {
+			textB.WriteString(op.Text)
+		}
+	}
+	if textA.String() != "The quick brown fox jumps\nover the lazy dog." || textB.String() != "The quick red fox jumps\nover the dog." {
+		t.Errorf("Ops rebuild %q and %q", textA.String(), textB.String())
+	}
+}
+
+func TestDiffTextIdentical(t *testing.T) {
+	a := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Same text\r"}}})
+	b := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Same ", unicode: true}, {text: "text\r\r"}}})
+
+	ops, err := msdoc.DiffText(a, b)
+	if err != nil {
+		t.Fatalf("DiffText failed: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Kind != msdoc.DiffEqual || ops[0].Text != "Same text" {
+		t.Errorf("Expected a single equal op, got %+v", ops)
+	}
+}
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/fib"
+	"github.com/TalentFormula/msdoc/ole2"
+	"github.com/TalentFormula/msdoc/pkg"
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+func TestAppendParagraphSaveInPlace(t *testing.T) {
+	mock := &mockDoc{
+		pieces:  []mockPiece{{text: "First paragraph\r", unicode: true}},
+		streams: []mockStream{{name: "Extra", data: []byte("untouched")}},
+	}
+	path := mock.writeFile(t)
+
+	doc, err := msdoc.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open mock document: %v", err)
+	}
+	defer doc.Close()
+
+	if err := doc.AppendParagraph("Audit: reviewed"); err != nil {
+		t.Fatalf("AppendParagraph failed: %v", err)
+	}
+	if err := doc.SaveInPlace(); err != nil {
+		t.Fatalf("SaveInPlace failed: %v", err)
+	}
+
+	want := "First paragraph\rAudit: reviewed\r"
+	if text, err := doc.Text(); err != nil || text != want {
+		t.Errorf("Expected reloaded text %q, got %q (err: %v)", want, text, err)
+	}
+
+	reopened, err := msdoc.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen document: %v", err)
+	}
+	defer reopened.Close()
+	if text, err := reopened.Text(); err != nil || text != want {
+		t.Errorf("Expected reopened text %q, got %q (err: %v)", want, text, err)
+	}
+
+	// Streams other than WordDocument and the table stream are copied as-is
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read saved document: %v", err)
+	}
+	oleReader, err := ole2.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	extra, err := oleReader.ReadStream("Extra")
+	if err != nil || string(extra) != "untouched" {
+		t.Errorf("Expected the Extra stream to be preserved, got %q (err: %v)", extra, err)
+	}
+}
+
+func TestAppendParagraphBeforeSubdocuments(t *testing.T) {
+	// The main document is followed by header text in the same piece
+	mock := &mockDoc{
+		pieces:  []mockPiece{{text: "Body\rHeader\r", unicode: true}},
+		ccpText: 5,
+	}
+	path := mock.writeFile(t)
+
+	doc, err := msdoc.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open mock document: %v", err)
+	}
+	defer doc.Close()
+
+	doc.AppendParagraph("Footer")
+	if err := doc.SaveInPlace(); err != nil {
+		t.Fatalf("SaveInPlace failed: %v", err)
+	}
+
+	want := "Body\rFooter\rHeader\r"
+	if text, err := doc.Text(); err != nil || text != want {
+		t.Errorf("Expected text %q, got %q (err: %v)", want, text, err)
+	}
+
+	pieces, err := doc.Pieces()
+	if err != nil {
+		t.Fatalf("Pieces failed: %v", err)
+	}
+	if len(pieces) != 3 {
+		t.Fatalf("Expected the piece to be split around the new text, got %d pieces", len(pieces))
+	}
+	if pieces[1].StartCP != 5 || pieces[1].EndCP != 12 {
+		t.Errorf("Expected the new piece at CP [5, 12), got [%d, %d)", pieces[1].StartCP, pieces[1].EndCP)
+	}
+}
+
+func TestAppendParagraphSample(t *testing.T) {
+	// sample-3 has storages for an embedded object and custom XML data
+	original, err := os.ReadFile("testdata/sample-3.doc")
+	if err != nil {
+		t.Fatalf("Failed to read sample: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "sample-3.doc")
+	if err := os.WriteFile(path, original, 0o644); err != nil {
+		t.Fatalf("Failed to copy sample: %v", err)
+	}
+
+	doc, err := msdoc.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open sample: %v", err)
+	}
+	defer doc.Close()
+	before, err := doc.Paragraphs()
+	if err != nil {
+		t.Fatalf("Paragraphs failed: %v", err)
+	}
+
+	doc.AppendParagraph("Audit: reviewed")
+	doc.AppendParagraph("Second line")
+	if err := doc.SaveInPlace(); err != nil {
+		t.Fatalf("SaveInPlace failed: %v", err)
+	}
+
+	after, err := doc.Paragraphs()
+	if err != nil {
+		t.Fatalf("Paragraphs failed after saving: %v", err)
+	}
+	if len(after) != len(before)+2 {
+		t.Fatalf("Expected %d paragraphs, got %d", len(before)+2, len(after))
+	}
+	for i, want := range []string{"Audit: reviewed", "Second line"} {
+		para := after[len(before)+i]
+		if para.Text != want || para.StyleName != "Normal" {
+			t.Errorf("Expected paragraph %q in the Normal style, got %q in %q", want, para.Text, para.StyleName)
+		}
+	}
+
+	// The last section grows to include the new text
+	sections, err := doc.Sections()
+	if err != nil || len(sections) == 0 {
+		t.Fatalf("Sections failed: %v", err)
+	}
+	if end := sections[len(sections)-1].EndCP; end < after[len(after)-1].EndCP {
+		t.Errorf("Expected the last section to end at or after CP %d, got %d", after[len(after)-1].EndCP, end)
+	}
+	for _, issue := range doc.Validate() {
+		if issue.Severity != msdoc.SeverityInfo {
+			t.Errorf("Unexpected validation issue after saving: %v", issue)
+		}
+	}
+
+	// The new text is covered by the last FKP of each bin table, with one
+	// PAPX entry per paragraph
+	pieces, err := doc.Pieces()
+	if err != nil {
+		t.Fatalf("Pieces failed: %v", err)
+	}
+	var textEnd uint32
+	for _, piece := range pieces {
+		textEnd = max(textEnd, piece.FileOffset+piece.ByteLength)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read saved document: %v", err)
+	}
+	savedReader, err := ole2.NewReader(bytes.NewReader(saved))
+	if err != nil {
+		t.Fatalf("NewReader failed on the saved document: %v", err)
+	}
+	word, _ := savedReader.ReadStream("WordDocument")
+	table, _ := savedReader.ReadStream("1Table")
+	savedFIB, err := fib.ParseFIB(word)
+	if err != nil {
+		t.Fatalf("ParseFIB failed on the saved document: %v", err)
+	}
+	binTables := []struct {
+		fc, lcb uint32
+		fkpType structures.FKPType
+		entries int
+	}{
+		{savedFIB.RgFcLcb.FcPlcfbteChpx, savedFIB.RgFcLcb.LcbPlcfbteChpx, structures.FKPTypeCHP, 1},
+		{savedFIB.RgFcLcb.FcPlcfbtePapx, savedFIB.RgFcLcb.LcbPlcfbtePapx, structures.FKPTypePAP, 2},
+	}
+	for _, bt := range binTables {
+		plc := table[bt.fc : bt.fc+bt.lcb]
+		n := (len(plc) - 4) / 8
+		if end := binary.LittleEndian.Uint32(plc[n*4:]); end != textEnd {
+			t.Errorf("Expected the bin table to end at FC %d, got %d", textEnd, end)
+		}
+		pn := binary.LittleEndian.Uint32(plc[(2*n)*4:])
+		fkp, err := structures.ParseFKP(word[pn*structures.FKPSize:(pn+1)*structures.FKPSize], bt.fkpType)
+		if err != nil {
+			t.Errorf("Failed to parse the FKP for the new text: %v", err)
+		} else if fkp.EntryCount != bt.entries {
+			t.Errorf("Expected an FKP with %d entries for the new text, got %d", bt.entries, fkp.EntryCount)
+		}
+	}
+
+	// Storages are copied with their streams and CLSIDs
+	originalReader, err := ole2.NewReader(bytes.NewReader(original))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	for _, storage := range originalReader.ListStorages() {
+		want, _ := originalReader.StreamCLSID(storage)
+		if got, err := savedReader.StreamCLSID(storage); err != nil || got != want {
+			t.Errorf("Expected storage %q with CLSID %x, got %x (err: %v)", storage, want, got, err)
+		}
+	}
+	for _, name := range originalReader.ListStreams() {
+		if strings.Contains(name, "/") {
+			want, _ := originalReader.ReadStream(name)
+			if got, err := savedReader.ReadStream(name); err != nil || !bytes.Equal(got, want) {
+				t.Errorf("Expected stream %q to be copied unchanged (err: %v)", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+// buildSttbTtmbd encodes an embedded font table entry per name, with the
+// given style bits, flags and font data offset.
+func buildSttbTtmbd(names []string, styles, flags []uint16, fcFonts []uint32) []byte {
+	data := []byte{0xFF, 0xFF}
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(names)))
+	data = binary.LittleEndian.AppendUint16(data, 8) // cbExtra
+	for i, name := range names {
+		units := utf16.Encode([]rune(name))
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(units)))
+		for _, u := range units {
+			data = binary.LittleEndian.AppendUint16(data, u)
+		}
+		data = binary.LittleEndian.AppendUint16(data, styles[i])
+		data = binary.LittleEndian.AppendUint16(data, flags[i])
+		data = binary.LittleEndian.AppendUint32(data, fcFonts[i])
+	}
+	return data
+}
+
+func TestEmbeddedFonts(t *testing.T) {
+	// A subsetted regular face with its data, and a bold italic face whose
+	// data lies past the end of the Data stream
+	font := []byte("\x00\x01\x00\x00glyf data")
+	dataStream := append(make([]byte, 0x20), binary.LittleEndian.AppendUint32(nil, uint32(len(font)))...)
+	dataStream = append(dataStream, font...)
+	sttb := buildSttbTtmbd(
+		[]string{"Corporate Sans", "Corporate Serif"},
+		[]uint16{0x0000, 0x0003},
+		[]uint16{0x0001, 0x0000},
+		[]uint32{0x20, 0x1000},
+	)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: "Branded\r"}},
+		table:   sttb,
+		fcLcb:   map[int]uint32{122: 0, 123: uint32(len(sttb))},
+		streams: []mockStream{{name: "Data", data: dataStream}},
+	})
+
+	fonts, err := doc.EmbeddedFonts()
+	if err != nil {
+		t.Fatalf("EmbeddedFonts failed: %v", err)
+	}
+	if len(fonts) != 2 {
+		t.Fatalf("Expected 2 embedded fonts, got %d", len(fonts))
+	}
+	if f := fonts[0]; f.Name != "Corporate Sans" || !f.Subset || f.Bold || f.Italic || !bytes.Equal(f.Data, font) {
+		t.Errorf("Unexpected first font %+v", f)
+	}
+	if f := fonts[1]; f.Name != "Corporate Serif" || f.Subset || !f.Bold || !f.Italic || f.Data != nil {
+		t.Errorf("Unexpected second font %+v", f)
+	}
+
+	// Documents without the table embed no fonts
+	plain := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Plain\r"}}})
+	if fonts, err := plain.EmbeddedFonts(); err != nil || fonts != nil {
+		t.Errorf("Expected no embedded fonts, got %v (err: %v)", fonts, err)
+	}
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"encoding/binary"
 	"github.com/TalentFormula/msdoc/fib"
+	"strings"
 	"testing"
 )
 
@@ -10,7 +11,7 @@ func TestParseFIB(t *testing.T) {
 	// Create a mock FIB structure. Size must be large enough to contain
 	// all the parts up to the cbRgFcLcb field.
 	blobSizeInBytes := 93 * 8
-	fibRgLwSize := 76                                                 // CbMac(4) + reserved(4) + CcpText(4) + CcpFtn(4) + CcpHdd(4) + reserved(4) + CcpAtn(4) + CcpEdn(4) + CcpTxbx(4) + CcpHdrTxbx(4) + remaining[44] = 76 bytes
+	fibRgLwSize := 88                                                 // CbMac(4) + reserved(8) + CcpText(4) + CcpFtn(4) + CcpHdd(4) + reserved(4) + CcpAtn(4) + CcpEdn(4) + CcpTxbx(4) + CcpHdrTxbx(4) + remaining[44] = 88 bytes
 	fibBytes := make([]byte, 32+2+28+2+fibRgLwSize+2+blobSizeInBytes) // Base + counts + blobs
 
 	// --- Populate FibBase (first 32 bytes) ---
@@ -70,3 +71,54 @@ func TestParseFIB(t *testing.T) {
 		t.Errorf("Expected parsed LcbClx %d, got %d", lcbClx, parsedFIB.RgFcLcb.LcbClx)
 	}
 }
+
+func TestParseFIBInvalidCbRgFcLcb(t *testing.T) {
+	fibBytes := make([]byte, 32+2+28+2+88+2+93*8)
+	binary.LittleEndian.PutUint16(fibBytes[0:], 0xA5EC)
+	binary.LittleEndian.PutUint16(fibBytes[2:], 0x00C1)
+	binary.LittleEndian.PutUint16(fibBytes[32:], 14)
+	binary.LittleEndian.PutUint16(fibBytes[62:], 22)
+	binary.LittleEndian.PutUint16(fibBytes[152:], 0xFFFF) // Corrupt cbRgFcLcb
+
+	_, err := fib.ParseFIB(fibBytes)
+	if err == nil {
+		t.Fatal("Expected ParseFIB to reject an out-of-range cbRgFcLcb")
+	}
+	if !strings.Contains(err.Error(), "invalid cbRgFcLcb") {
+		t.Errorf("Expected an invalid cbRgFcLcb error, got: %v", err)
+	}
+
+	// The largest count for Word 97 is still accepted
+	binary.LittleEndian.PutUint16(fibBytes[152:], 0x5D)
+	if _, err := fib.ParseFIB(fibBytes); err != nil {
+		t.Errorf("ParseFIB failed for a valid cbRgFcLcb: %v", err)
+	}
+}
+
+func TestParseFIBCbRgFcLcbVersion(t *testing.T) {
+	// A Word 2000 sized FibRgFcLcb followed by room for FibRgCswNew
+	fibBytes := make([]byte, 32+2+28+2+88+2+0x6C*8+4)
+	binary.LittleEndian.PutUint16(fibBytes[0:], 0xA5EC)
+	binary.LittleEndian.PutUint16(fibBytes[2:], 0x00C1)
+	binary.LittleEndian.PutUint16(fibBytes[32:], 14)
+	binary.LittleEndian.PutUint16(fibBytes[62:], 22)
+	binary.LittleEndian.PutUint16(fibBytes[152:], 0x6C)
+
+	// Without FibRgCswNew the FIB is a Word 97 one, limited to 0x5D pairs
+	_, err := fib.ParseFIB(fibBytes)
+	if err == nil || !strings.Contains(err.Error(), "invalid cbRgFcLcb 0x6C for nFib 0x00C1") {
+		t.Errorf("Expected a Word 97 FIB with 0x6C pairs to be rejected, got: %v", err)
+	}
+
+	// With FibRgCswNew naming Word 2000 the count is valid
+	cswNew := 154 + 0x6C*8
+	binary.LittleEndian.PutUint16(fibBytes[cswNew:], 2)
+	binary.LittleEndian.PutUint16(fibBytes[cswNew+2:], 0x00D9)
+	parsed, err := fib.ParseFIB(fibBytes)
+	if err != nil {
+		t.Fatalf("ParseFIB failed for a Word 2000 FIB: %v", err)
+	}
+	if parsed.CswNew != 2 || parsed.NFib() != 0x00D9 {
+		t.Errorf("Expected cswNew 2 and nFib 0x00D9, got %d and 0x%04X", parsed.CswNew, parsed.NFib())
+	}
+}
@@ -0,0 +1,254 @@
+package tests
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+// buildFieldPLC encodes a field PLC with one field whose begin, separator and
+// end characters are at the given CPs, relative to the subdocument start.
+func buildFieldPLC(begin, separator, end, last uint32, fieldType byte) []byte {
+	var data []byte
+	for _, cp := range []uint32{begin, separator, end, last} {
+		data = binary.LittleEndian.AppendUint32(data, cp)
+	}
+	return append(data, 0x13, fieldType, 0x14, 0xFF, 0x15, 0x80)
+}
+
+func TestFootnoteHyperlink(t *testing.T) {
+	main := "Main text\r"
+	footnote := "\x13 HYPERLINK \"http://example.com\" \x14link\x15\r"
+	separator := uint32(strings.IndexByte(footnote, 0x14))
+	end := uint32(strings.IndexByte(footnote, 0x15))
+	plc := buildFieldPLC(0, separator, end, end+1, 0x58)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: main + footnote, unicode: true}},
+		ccpText: uint32(len(main)),
+		ccpFtn:  uint32(len(footnote)),
+		table:   plc,
+		fcLcb:   map[int]uint32{36: 0, 37: uint32(len(plc))}, // PlcffldFtn
+	})
+
+	if fields, err := doc.Fields(msdoc.SubdocumentMain); err != nil || len(fields) != 0 {
+		t.Errorf("Expected no main document fields, got %d (err: %v)", len(fields), err)
+	}
+
+	fields, err := doc.Fields(msdoc.SubdocumentFootnote)
+	if err != nil {
+		t.Fatalf("Fields failed: %v", err)
+	}
+	if len(fields) != 1 {
+		t.Fatalf("Expected 1 footnote field, got %d", len(fields))
+	}
+	if uint32(fields[0].Start) != 10 || uint32(fields[0].End) != 10+separator {
+		t.Errorf("Expected field at CP [10, %d), got [%d, %d)", 10+separator, fields[0].Start, fields[0].End)
+	}
+	if fields[0].FieldType != 0x58 {
+		t.Errorf("Expected a HYPERLINK field (0x58), got type 0x%02X", fields[0].FieldType)
+	}
+
+	markdown, err := doc.MarkdownText()
+	if err != nil {
+		t.Fatalf("MarkdownText failed: %v", err)
+	}
+	if markdown != "Main text\n\n" {
+		t.Errorf("Expected only the main document text, got %q", markdown)
+	}
+}
+
+func TestIsMailMergeMain(t *testing.T) {
+	// A letter with two merge fields
+	text := "Dear \x13 MERGEFIELD FirstName \x14«FirstName»\x15 \x13 MERGEFIELD \"Last Name\" \x14«Last Name»\x15,\r"
+	var plc []byte
+	var flds []byte
+	for i, u := range utf16.Encode([]rune(text)) {
+		switch u {
+		case 0x13:
+			flds = append(flds, 0x13, 0x3B) // MERGEFIELD
+		case 0x14:
+			flds = append(flds, 0x14, 0xFF)
+		case 0x15:
+			flds = append(flds, 0x15, 0x80)
+		default:
+			continue
+		}
+		plc = binary.LittleEndian.AppendUint32(plc, uint32(i))
+	}
+	plc = binary.LittleEndian.AppendUint32(plc, uint32(len(utf16.Encode([]rune(text)))))
+	plc = append(plc, flds...)
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: text, unicode: true}},
+		table:  plc,
+		fcLcb:  map[int]uint32{32: 0, 33: uint32(len(plc))}, // PlcffldMom
+	})
+
+	names, err := doc.MergeFields()
+	if err != nil {
+		t.Fatalf("MergeFields failed: %v", err)
+	}
+	if len(names) != 2 || names[0] != "FirstName" || names[1] != "Last Name" {
+		t.Errorf("Expected merge fields [FirstName \"Last Name\"], got %q", names)
+	}
+	if !doc.IsMailMergeMain() {
+		t.Error("Expected a document with merge fields to be a mail merge main document")
+	}
+
+	// The DOP flag alone also marks a main document
+	dop := make([]byte, 500)
+	dop[0] = 0x04 // fPMHMainDoc
+	doc = openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Dear customer,\r", unicode: true}},
+		table:  dop,
+		fcLcb:  map[int]uint32{62: 0, 63: uint32(len(dop))},
+	})
+	if !doc.IsMailMergeMain() {
+		t.Error("Expected a document with fPMHMainDoc to be a mail merge main document")
+	}
+
+	// A normal document is not
+	doc = openMock(t, &mockDoc{pieces: []mockPiece{{text: "Dear customer,\r", unicode: true}}})
+	if doc.IsMailMergeMain() {
+		t.Error("Expected a normal document not to be a mail merge main document")
+	}
+}
+
+func TestVisibleHyperlinks(t *testing.T) {
+	text := "See \x13 HYPERLINK \"http://visible.example\" \\o \"Tip\" \x14Visible\x15 " +
+		"\x13 HYPERLINK \"http://hidden.example\" \x14Hidden\x15 " +
+		"\x13 HYPERLINK \"http://empty.example\" \x14\x15\r"
+	units := utf16.Encode([]rune(text))
+	fc := func(cp uint32) uint32 { return mockTextOffset + cp*2 }
+
+	var plc, flds []byte
+	for i, u := range units {
+		switch u {
+		case 0x13:
+			flds = append(flds, 0x13, 0x58) // HYPERLINK
+		case 0x14:
+			flds = append(flds, 0x14, 0xFF)
+		case 0x15:
+			flds = append(flds, 0x15, 0x80)
+		default:
+			continue
+		}
+		plc = binary.LittleEndian.AppendUint32(plc, uint32(i))
+	}
+	plc = binary.LittleEndian.AppendUint32(plc, uint32(len(units)))
+	plc = append(plc, flds...)
+
+	// CHPX FKP: the result of the second link is hidden text
+	hiddenStart := uint32(strings.Index(text, "Hidden"))
+	hiddenEnd := hiddenStart + uint32(len("Hidden"))
+	fkp := make([]byte, 512)
+	for i, cp := range []uint32{0, hiddenStart, hiddenEnd, uint32(len(units))} {
+		binary.LittleEndian.PutUint32(fkp[i*4:], fc(cp))
+	}
+	fkp[16+1] = 0x80                            // Hidden run CHPX at byte offset 0x100
+	copy(fkp[0x100:], []byte{3, 0x3C, 0x08, 1}) // sprmCFVanish
+	fkp[511] = 3
+
+	bte := binary.LittleEndian.AppendUint32(nil, fc(0))
+	bte = binary.LittleEndian.AppendUint32(bte, fc(uint32(len(units))))
+	bte = binary.LittleEndian.AppendUint32(bte, mockFirstPage)
+	table := append(bte, plc...)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text, unicode: true}},
+		ccpText: uint32(len(units)),
+		table:   table,
+		fcLcb: map[int]uint32{
+			24: 0, 25: uint32(len(bte)),
+			32: uint32(len(bte)), 33: uint32(len(plc)), // PlcffldMom
+		},
+		pages: [][]byte{fkp},
+	})
+
+	all, err := doc.Hyperlinks()
+	if err != nil {
+		t.Fatalf("Hyperlinks failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 hyperlinks, got %d", len(all))
+	}
+	for i, want := range []string{"http://visible.example", "http://hidden.example", "http://empty.example"} {
+		if all[i].URL != want {
+			t.Errorf("Hyperlink %d: expected URL %q, got %q", i, want, all[i].URL)
+		}
+	}
+	if all[0].DisplayText != "Visible" || all[0].Start != 4 {
+		t.Errorf("Expected the first link to show %q at CP 4, got %q at CP %d", "Visible", all[0].DisplayText, all[0].Start)
+	}
+
+	visible, err := doc.VisibleHyperlinks()
+	if err != nil {
+		t.Fatalf("VisibleHyperlinks failed: %v", err)
+	}
+	if len(visible) != 1 || visible[0].URL != "http://visible.example" {
+		t.Errorf("Expected only the visible link, got %+v", visible)
+	}
+}
+
+func TestMarkdownText(t *testing.T) {
+	text := "Visit \x13 HYPERLINK \"http://example.com\" \\o \"Tip\" \x14Example\x15 or see " +
+		"\x13 HYPERLINK \\l \"Section1\" \x14Section \x13 PAGEREF Section1 \x14" + "2\x15\x15.\r"
+	units := utf16.Encode([]rune(text))
+
+	var plc, flds []byte
+	for i, u := range units {
+		switch u {
+		case 0x13:
+			flds = append(flds, 0x13, 0x58)
+		case 0x14:
+			flds = append(flds, 0x14, 0xFF)
+		case 0x15:
+			flds = append(flds, 0x15, 0x80)
+		default:
+			continue
+		}
+		plc = binary.LittleEndian.AppendUint32(plc, uint32(i))
+	}
+	plc = binary.LittleEndian.AppendUint32(plc, uint32(len(units)))
+	plc = append(plc, flds...)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text, unicode: true}},
+		ccpText: uint32(len(units)),
+		table:   plc,
+		fcLcb:   map[int]uint32{32: 0, 33: uint32(len(plc))}, // PlcffldMom
+	})
+
+	markdown, err := doc.MarkdownText()
+	if err != nil {
+		t.Fatalf("MarkdownText failed: %v", err)
+	}
+	want := "Visit [Example](http://example.com) or see [Section 2](#Section1).\n\n"
+	if markdown != want {
+		t.Errorf("Expected %q, got %q", want, markdown)
+	}
+}
+
+func TestMarkdownTextSample(t *testing.T) {
+	doc, err := msdoc.Open("testdata/sample-3.doc")
+	if err != nil {
+		t.Fatalf("Failed to open document: %v", err)
+	}
+	defer doc.Close()
+
+	markdown, err := doc.MarkdownText()
+	if err != nil {
+		t.Fatalf("MarkdownText failed: %v", err)
+	}
+	want := "implementation in go\n\nFor more information, [click here](https://github.com/TalentFormula/msdoc)\n\n"
+	if !strings.Contains(markdown, want) {
+		t.Errorf("Expected the markdown text to contain %q, got %q", want, markdown)
+	}
+	if strings.Contains(markdown, "Hey") {
+		t.Errorf("Expected the comment text to be left out, got %q", markdown)
+	}
+}
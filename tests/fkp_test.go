@@ -38,13 +38,14 @@ func TestCHPXFKPParsing(t *testing.T) {
 	// Create a mock CHPX FKP with 2 entries
 	fkpData := make([]byte, 512)
 
-	// Entry 1: FC=100, offset=200
+	// Run boundaries: FC 100, 200 and the end of the last run at 300
 	binary.LittleEndian.PutUint32(fkpData[0:], 100)
-	fkpData[4] = 200
+	binary.LittleEndian.PutUint32(fkpData[4:], 200)
+	binary.LittleEndian.PutUint32(fkpData[8:], 300)
 
-	// Entry 2: FC=200, offset=210
-	binary.LittleEndian.PutUint32(fkpData[5:], 200)
-	fkpData[9] = 210
+	// Word offsets of the CHPXs: 100 and 105 words (bytes 200 and 210)
+	fkpData[12] = 100
+	fkpData[13] = 105
 
 	// Add formatting data at offset 200 (length=8)
 	fkpData[200] = 8 // Length byte
@@ -105,17 +106,20 @@ func TestCHPXFKPParsing(t *testing.T) {
 }
 
 func TestPAPXFKPParsing(t *testing.T) {
-	// Create a mock PAPX FKP with 1 entry
+	// Create a mock PAPX FKP with 1 paragraph from FC 300 to 400
 	fkpData := make([]byte, 512)
-
-	// Entry 1: FC=300, offset=220
 	binary.LittleEndian.PutUint32(fkpData[0:], 300)
-	binary.LittleEndian.PutUint16(fkpData[4:], 220)
+	binary.LittleEndian.PutUint32(fkpData[4:], 400)
+
+	// The BxPap after the FCs stores the PAPX offset in words
+	fkpData[8] = 110
 
-	// Add formatting data at offset 220 (length=3 words = 6 bytes)
-	fkpData[220] = 3 // Length in words
+	// Add formatting data at offset 220. A zero count byte is followed by
+	// the length in words (3 words = 6 bytes).
+	fkpData[220] = 0
+	fkpData[221] = 3
 	for i := 0; i < 6; i++ {
-		fkpData[221+i] = byte(i + 20) // Test data
+		fkpData[222+i] = byte(i + 20) // Test data
 	}
 
 	// Set entry count
@@ -156,17 +160,12 @@ func TestFKPFindEntryForFC(t *testing.T) {
 	// Create a mock CHPX FKP with multiple entries
 	fkpData := make([]byte, 512)
 
-	// Entry 1: FC=100
+	// Runs start at FC 100, 200 and 300; the last run ends at 400.
+	// The offset bytes at 16-18 are zero, so there is no formatting data.
 	binary.LittleEndian.PutUint32(fkpData[0:], 100)
-	fkpData[4] = 0 // No formatting data
-
-	// Entry 2: FC=200
-	binary.LittleEndian.PutUint32(fkpData[5:], 200)
-	fkpData[9] = 0 // No formatting data
-
-	// Entry 3: FC=300
-	binary.LittleEndian.PutUint32(fkpData[10:], 300)
-	fkpData[14] = 0 // No formatting data
+	binary.LittleEndian.PutUint32(fkpData[4:], 200)
+	binary.LittleEndian.PutUint32(fkpData[8:], 300)
+	binary.LittleEndian.PutUint32(fkpData[12:], 400)
 
 	// Set entry count
 	fkpData[511] = 3
@@ -218,9 +217,131 @@ func TestFKPInvalidData(t *testing.T) {
 
 	// Test with too many entries for available space
 	fkpData := make([]byte, 512)
-	fkpData[511] = 200 // 200 * 5 = 1000 bytes > 511 available
+	fkpData[511] = 200 // 201 * 4 + 200 = 1004 bytes > 511 available
 	_, err = structures.ParseFKP(fkpData, structures.FKPTypeCHP)
 	if err == nil {
 		t.Error("Expected error for too many entries")
 	}
 }
+
+func TestCHPXFKPRealPage(t *testing.T) {
+	// A CHPX FKP page as written by Word: three runs, the middle one bold
+	// (sprmCFBold) and the others with default properties. The CHPX is
+	// stored at the end of the page, at word offset 0xFD (byte 0x1FA).
+	fkpData := make([]byte, 512)
+	binary.LittleEndian.PutUint32(fkpData[0:], 0x0600)
+	binary.LittleEndian.PutUint32(fkpData[4:], 0x0606)
+	binary.LittleEndian.PutUint32(fkpData[8:], 0x060A)
+	binary.LittleEndian.PutUint32(fkpData[12:], 0x0614)
+	fkpData[16] = 0x00
+	fkpData[17] = 0xFD
+	fkpData[18] = 0x00
+	copy(fkpData[0x1FA:], []byte{0x03, 0x35, 0x08, 0x01})
+	fkpData[511] = 3
+
+	fkp, err := structures.ParseFKP(fkpData, structures.FKPTypeCHP)
+	if err != nil {
+		t.Fatalf("ParseFKP failed: %v", err)
+	}
+	if len(fkp.Entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(fkp.Entries))
+	}
+
+	bold := fkp.FindEntryForFC(0x0608)
+	if bold == nil || bold.FC != 0x0606 {
+		t.Fatalf("Expected the run starting at 0x0606, got %+v", bold)
+	}
+	if bold.Offset != 0x1FA {
+		t.Errorf("Expected CHPX at byte offset 0x1FA, got 0x%X", bold.Offset)
+	}
+	want := []byte{0x35, 0x08, 0x01}
+	if string(bold.Data) != string(want) {
+		t.Errorf("Expected CHPX sprms % X, got % X", want, bold.Data)
+	}
+
+	for _, i := range []int{0, 2} {
+		if entry := fkp.Entries[i]; entry.Offset != 0 || entry.Data != nil {
+			t.Errorf("Entry %d: expected default properties, got offset %d", i, entry.Offset)
+		}
+	}
+
+	// A CHPX offset that points into the run boundaries is rejected
+	fkpData[17] = 0x02
+	if _, err := structures.ParseFKP(fkpData, structures.FKPTypeCHP); err == nil {
+		t.Error("Expected an error for a CHPX offset inside the FC array")
+	}
+
+	// So is a CHPX whose length runs past the end of the page
+	fkpData[17] = 0xFF
+	fkpData[510] = 4
+	if _, err := structures.ParseFKP(fkpData, structures.FKPTypeCHP); err == nil {
+		t.Error("Expected an error for a CHPX extending past the page")
+	}
+}
+
+func TestDocumentFKPs(t *testing.T) {
+	text := "First\rSecond\r"
+	mid := uint32(mockTextOffset + len("First\r"))
+	end := uint32(mockTextOffset + len(text))
+
+	// Two CHPX pages with one run each, and one PAPX page for both paragraphs
+	chpxPage := func(from, to uint32) []byte {
+		page := make([]byte, 512)
+		binary.LittleEndian.PutUint32(page[0:], from)
+		binary.LittleEndian.PutUint32(page[4:], to)
+		page[511] = 1
+		return page
+	}
+	papxPage := buildPAPXPage([]uint32{mockTextOffset, mid, end}, [][]byte{nil, nil})
+
+	var chpxBte []byte
+	for _, v := range []uint32{mockTextOffset, mid, end, mockFirstPage, mockFirstPage + 1} {
+		chpxBte = binary.LittleEndian.AppendUint32(chpxBte, v)
+	}
+	var papxBte []byte
+	for _, v := range []uint32{mockTextOffset, end, mockFirstPage + 2} {
+		papxBte = binary.LittleEndian.AppendUint32(papxBte, v)
+	}
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   append(chpxBte, papxBte...),
+		fcLcb: map[int]uint32{
+			24: 0, 25: uint32(len(chpxBte)),
+			26: uint32(len(chpxBte)), 27: uint32(len(papxBte)),
+		},
+		pages: [][]byte{chpxPage(mockTextOffset, mid), chpxPage(mid, end), papxPage},
+	})
+
+	chpx, err := doc.CharacterFKPs()
+	if err != nil {
+		t.Fatalf("CharacterFKPs failed: %v", err)
+	}
+	plc, err := structures.ParsePLC(chpxBte, 4)
+	if err != nil {
+		t.Fatalf("ParsePLC failed: %v", err)
+	}
+	if len(chpx) != plc.Count() {
+		t.Fatalf("Expected %d CHPX FKPs, one per BTE, got %d", plc.Count(), len(chpx))
+	}
+	for i, from := range []uint32{mockTextOffset, mid} {
+		if chpx[i].Type != structures.FKPTypeCHP || chpx[i].Entries[0].FC != from {
+			t.Errorf("CHPX FKP %d: expected a CHP page starting at FC %d, got type %d starting at %d", i, from, chpx[i].Type, chpx[i].Entries[0].FC)
+		}
+	}
+
+	papx, err := doc.ParagraphFKPs()
+	if err != nil {
+		t.Fatalf("ParagraphFKPs failed: %v", err)
+	}
+	if len(papx) != 1 || papx[0].Type != structures.FKPTypePAP || papx[0].EntryCount != 2 {
+		t.Errorf("Expected one PAP page with 2 entries, got %+v", papx)
+	}
+
+	// A document without bin tables has no FKPs
+	plain := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Plain\r"}}})
+	if fkps, err := plain.CharacterFKPs(); err != nil || fkps != nil {
+		t.Errorf("Expected no CHPX FKPs, got %d (err: %v)", len(fkps), err)
+	}
+}
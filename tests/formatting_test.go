@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/TalentFormula/msdoc/formatting"
+	"github.com/TalentFormula/msdoc/pkg"
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+func TestHighlightColor(t *testing.T) {
+	fe := formatting.NewFormattingExtractor()
+
+	// Red text (sprmCIco) with a yellow highlight (sprmCHighlight)
+	chpx := []byte{0x42, 0x2A, 0x06, 0x0C, 0x2A, 0x07}
+	props, err := fe.ParseCharacterProperties(chpx)
+	if err != nil {
+		t.Fatalf("ParseCharacterProperties failed: %v", err)
+	}
+
+	yellow := formatting.Color{Red: 255, Green: 255, Blue: 0}
+	if props.HighlightColor != yellow {
+		t.Errorf("Expected yellow highlight, got %+v", props.HighlightColor)
+	}
+	red := formatting.Color{Red: 255, Green: 0, Blue: 0}
+	if props.Color != red {
+		t.Errorf("Expected red text color, got %+v", props.Color)
+	}
+
+	// Text without the highlight sprm is not highlighted
+	props, err = fe.ParseCharacterProperties([]byte{0x5C, 0x08, 0x01})
+	if err != nil {
+		t.Fatalf("ParseCharacterProperties failed: %v", err)
+	}
+	if !props.HighlightColor.Auto {
+		t.Errorf("Expected no highlight, got %+v", props.HighlightColor)
+	}
+}
+
+func TestCharacterPropertiesSkipUnknownSprms(t *testing.T) {
+	fe := formatting.NewFormattingExtractor()
+
+	// Unknown sprms with 4-byte, variable-length, 2-byte and 3-byte operands
+	// between double underline, red text and a yellow highlight
+	chpx := []byte{
+		0x15, 0x68, 0x01, 0x02, 0x03, 0x04, // sprmCRsidProp, 4 bytes
+		0x3E, 0x2A, 0x03, // sprmCKul: double
+		0x47, 0xCA, 0x03, 0x0C, 0x2A, 0x07, // Variable length, looks like sprmCHighlight
+		0x42, 0x2A, 0x06, // sprmCIco: red
+		0x61, 0x4A, 0x42, 0x2A, // 2 bytes, looks like sprmCIco
+		0x99, 0xE0, 0x01, 0x02, 0x03, // 3 bytes
+		0x0C, 0x2A, 0x07, // sprmCHighlight: yellow
+	}
+	props, err := fe.ParseCharacterProperties(chpx)
+	if err != nil {
+		t.Fatalf("ParseCharacterProperties failed: %v", err)
+	}
+	if props.Underline != formatting.UnderlineDouble {
+		t.Errorf("Expected a double underline, got %v", props.Underline)
+	}
+	if red := (formatting.Color{Red: 255}); props.Color != red {
+		t.Errorf("Expected red text color, got %+v", props.Color)
+	}
+	if yellow := (formatting.Color{Red: 255, Green: 255}); props.HighlightColor != yellow {
+		t.Errorf("Expected yellow highlight, got %+v", props.HighlightColor)
+	}
+
+	// A truncated sprm ends parsing without losing the sprms before it
+	props, err = fe.ParseCharacterProperties([]byte{0x42, 0x2A, 0x06, 0x15, 0x68, 0x01})
+	if err != nil {
+		t.Fatalf("ParseCharacterProperties failed: %v", err)
+	}
+	if red := (formatting.Color{Red: 255}); props.Color != red {
+		t.Errorf("Expected red text color before the truncated sprm, got %+v", props.Color)
+	}
+}
+
+func TestParagraphPropertiesSkipUnknownSprms(t *testing.T) {
+	fe := formatting.NewFormattingExtractor()
+
+	// Unknown sprms with 4-byte, variable-length and 2-byte operands around
+	// centered alignment, a left indent and sprmPFBiDi
+	papx := []byte{
+		0x12, 0x64, 0x01, 0x02, 0x03, 0x04, // sprmPDyaLine, 4 bytes
+		0x05, 0x24, 0x01, // sprmPJc80: centered
+		0x47, 0xC6, 0x02, 0x05, 0x24, // Variable length, looks like sprmPJc80
+		0x0E, 0x84, 0xD0, 0x02, // sprmPDxaLeft80: 720 twips
+		0x0A, 0x46, 0x00, 0x00, // 2 bytes
+		0x41, 0x24, 0x01, // sprmPFBiDi
+	}
+	props, err := fe.ParseParagraphProperties(papx)
+	if err != nil {
+		t.Fatalf("ParseParagraphProperties failed: %v", err)
+	}
+	if props.Alignment != formatting.AlignCenter {
+		t.Errorf("Expected centered alignment, got %v", props.Alignment)
+	}
+	if props.LeftIndent != 720 {
+		t.Errorf("Expected a left indent of 720, got %d", props.LeftIndent)
+	}
+	if !props.BiDi {
+		t.Error("Expected sprmPFBiDi after the unknown sprms to be read")
+	}
+
+	// A truncated sprm ends parsing without losing the sprms before it
+	props, err = fe.ParseParagraphProperties([]byte{0x41, 0x24, 0x01, 0x12, 0x64, 0x01})
+	if err != nil {
+		t.Fatalf("ParseParagraphProperties failed: %v", err)
+	}
+	if !props.BiDi {
+		t.Error("Expected sprmPFBiDi before the truncated sprm to be read")
+	}
+}
+
+func TestFonts(t *testing.T) {
+	fontTable := buildSttbfFfn(
+		mockFont{name: "Times New Roman", flags: 0x16, charset: 0}, // Roman, TrueType, variable pitch
+		mockFont{name: "Arial", altName: "Helvetica", flags: 0x26}, // Swiss, TrueType, variable pitch
+		mockFont{name: "Courier New", flags: 0x35},                 // Modern, TrueType, fixed pitch
+	)
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Fonts"}},
+		table:  fontTable,
+		fcLcb:  map[int]uint32{30: 0, 31: uint32(len(fontTable))},
+	})
+
+	fonts, err := doc.Fonts()
+	if err != nil {
+		t.Fatalf("Fonts failed: %v", err)
+	}
+	if len(fonts) != 3 {
+		t.Fatalf("Expected 3 fonts, got %d", len(fonts))
+	}
+
+	var times *msdoc.Font
+	for _, font := range fonts {
+		if font.Name == "Times New Roman" {
+			times = font
+		}
+	}
+	if times == nil {
+		t.Fatal("Expected Times New Roman in the font table")
+	}
+	if times.Family != structures.FontFamilyRoman || times.Pitch != structures.FontPitchVariable || !times.TrueType {
+		t.Errorf("Unexpected Times New Roman properties: %+v", times)
+	}
+
+	if fonts[1].AltName != "Helvetica" {
+		t.Errorf("Expected alternate name Helvetica, got %q", fonts[1].AltName)
+	}
+	if fonts[2].Family != structures.FontFamilyModern || fonts[2].Pitch != structures.FontPitchFixed {
+		t.Errorf("Unexpected Courier New properties: %+v", fonts[2])
+	}
+}
+
+func TestNoProofAndLanguage(t *testing.T) {
+	fe := formatting.NewFormattingExtractor()
+
+	// A code snippet marked no-proof, in English (US)
+	chpx := []byte{0x75, 0x08, 0x01, 0x73, 0x48, 0x09, 0x04}
+	props, err := fe.ParseCharacterProperties(chpx)
+	if err != nil {
+		t.Fatalf("ParseCharacterProperties failed: %v", err)
+	}
+	if !props.NoProof {
+		t.Error("Expected the run to be marked no-proof")
+	}
+	if props.Language != 0x0409 {
+		t.Errorf("Expected language 0x0409, got 0x%04X", props.Language)
+	}
+
+	// Prose in German is proofed
+	props, err = fe.ParseCharacterProperties([]byte{0x6D, 0x48, 0x07, 0x04})
+	if err != nil {
+		t.Fatalf("ParseCharacterProperties failed: %v", err)
+	}
+	if props.NoProof {
+		t.Error("Expected the run to be proofed")
+	}
+	if props.Language != 0x0407 {
+		t.Errorf("Expected language 0x0407, got 0x%04X", props.Language)
+	}
+}
+
+func TestShadingEffectiveColor(t *testing.T) {
+	red := formatting.Color{Red: 0xFF}
+	blue := formatting.Color{Blue: 0xFF}
+	tests := []struct {
+		name     string
+		shading  formatting.Shading
+		expected formatting.Color
+	}{
+		{"25% pattern", formatting.Shading{Pattern: formatting.ShadingPct25, ForeColor: red, BackColor: blue}, formatting.Color{Red: 0x40, Blue: 0xBF}},
+		{"25% over auto", formatting.Shading{Pattern: formatting.ShadingPct25, ForeColor: formatting.Color{Auto: true}, BackColor: formatting.Color{Auto: true}}, formatting.Color{Red: 0xBF, Green: 0xBF, Blue: 0xBF}},
+		{"percentage overrides pattern", formatting.Shading{Pattern: formatting.ShadingPct25, ForeColor: red, BackColor: blue, Percentage: 50}, formatting.Color{Red: 0x80, Blue: 0x80}},
+		{"solid", formatting.Shading{Pattern: formatting.ShadingSolid, ForeColor: red, BackColor: blue}, red},
+		{"clear", formatting.Shading{Pattern: formatting.ShadingClear, ForeColor: red, BackColor: formatting.Color{Auto: true}}, formatting.Color{Auto: true}},
+	}
+	for _, tt := range tests {
+		if got := tt.shading.EffectiveColor(); got != tt.expected {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.expected, got)
+		}
+	}
+}
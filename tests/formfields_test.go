@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+// appendXstz appends s as a null-terminated Xst.
+func appendXstz(data []byte, s string) []byte {
+	units := utf16.Encode([]rune(s))
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(units)))
+	for _, u := range units {
+		data = binary.LittleEndian.AppendUint16(data, u)
+	}
+	return append(data, 0, 0)
+}
+
+// buildDropDownFFData encodes the Data stream record of a drop-down form
+// field: the size, the 0x44-byte header and the FFDATA.
+func buildDropDownFFData(name string, selected uint16, bits uint16, help string, entries ...string) []byte {
+	ffData := binary.LittleEndian.AppendUint32(nil, 0xFFFFFFFF)
+	ffData = binary.LittleEndian.AppendUint16(ffData, 2|selected<<2|bits) // iType drop-down, iRes
+	ffData = binary.LittleEndian.AppendUint16(ffData, 0)                  // cch
+	ffData = binary.LittleEndian.AppendUint16(ffData, 20)                 // hps
+	ffData = appendXstz(ffData, name)
+	ffData = binary.LittleEndian.AppendUint16(ffData, 0) // wDef
+	ffData = appendXstz(ffData, "")                      // xstzTextFormat
+	ffData = appendXstz(ffData, help)                    // xstzHelpText
+	for i := 0; i < 3; i++ {
+		ffData = appendXstz(ffData, "") // Status text, entry and exit macros
+	}
+	ffData = append(ffData, buildSTTB(entries...)...)
+
+	record := binary.LittleEndian.AppendUint32(nil, uint32(0x44+len(ffData)))
+	record = binary.LittleEndian.AppendUint16(record, 0x44)
+	record = append(record, make([]byte, 0x44-6)...)
+	return append(record, ffData...)
+}
+
+func TestFormFieldDropDown(t *testing.T) {
+	text := "Color: \x13 FORMDROPDOWN \x14\x15\r"
+	begin := uint32(strings.IndexByte(text, 0x13))
+	separator := uint32(strings.IndexByte(text, 0x14))
+	end := uint32(strings.IndexByte(text, 0x15))
+	fc := func(cp uint32) uint32 { return mockTextOffset + cp*2 }
+
+	// Green is selected and fields are recalculated on exit (fRecalc)
+	data := buildDropDownFFData("Color", 1, 0x4000, "Pick a color", "Red", "Green", "Blue")
+
+	// CHPX FKP: the field begin character points at the FFDATA
+	fkp := make([]byte, 512)
+	for i, cp := range []uint32{0, begin, begin + 1, uint32(len(text))} {
+		binary.LittleEndian.PutUint32(fkp[i*4:], fc(cp))
+	}
+	fkp[16+1] = 0x80 // CHPX at byte offset 0x100
+	chpx := []byte{
+		0x55, 0x08, 0x01, // sprmCFSpec
+		0x06, 0x08, 0x01, // sprmCFData
+		0x03, 0x6A, 0x00, 0x00, 0x00, 0x00, // sprmCPicLocation: 0
+	}
+	fkp[0x100] = byte(len(chpx))
+	copy(fkp[0x101:], chpx)
+	fkp[511] = 3
+
+	table := binary.LittleEndian.AppendUint32(nil, fc(0))
+	table = binary.LittleEndian.AppendUint32(table, fc(uint32(len(text))))
+	table = binary.LittleEndian.AppendUint32(table, mockFirstPage)
+	bteSize := uint32(len(table))
+	plc := buildFieldPLC(begin, separator, end, end+1, 0x53)
+	table = append(table, plc...)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text, unicode: true}},
+		ccpText: uint32(len(text)),
+		table:   table,
+		fcLcb: map[int]uint32{
+			24: 0, 25: bteSize,
+			32: bteSize, 33: uint32(len(plc)), // PlcffldMom
+		},
+		pages:   [][]byte{fkp},
+		streams: []mockStream{{name: "Data", data: data}},
+	})
+
+	formFields, err := doc.FormFields()
+	if err != nil {
+		t.Fatalf("FormFields failed: %v", err)
+	}
+	if len(formFields) != 1 {
+		t.Fatalf("Expected 1 form field, got %d", len(formFields))
+	}
+
+	field := formFields[0]
+	if field.Type != msdoc.FormFieldDropDown {
+		t.Errorf("Expected a drop-down field, got %v", field.Type)
+	}
+	if field.Name != "Color" || field.CP != begin {
+		t.Errorf("Expected field Color at CP %d, got %q at %d", begin, field.Name, field.CP)
+	}
+	if strings.Join(field.Options, ",") != "Red,Green,Blue" {
+		t.Errorf("Expected options Red, Green, Blue, got %q", field.Options)
+	}
+	if field.Selected != 1 || field.Result != "Green" {
+		t.Errorf("Expected Green to be selected, got %d (%q)", field.Selected, field.Result)
+	}
+	if !field.Enabled || !field.CalcOnExit {
+		t.Errorf("Expected an enabled field calculated on exit, got enabled %v, calc on exit %v", field.Enabled, field.CalcOnExit)
+	}
+	if field.MaxLength != 0 || field.HelpText != "Pick a color" {
+		t.Errorf("Unexpected max length %d or help text %q", field.MaxLength, field.HelpText)
+	}
+}
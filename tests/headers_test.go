@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"encoding/binary"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+func TestHeadersFooters(t *testing.T) {
+	main := "Body\r"
+	separators := []string{"\x03\r", "\x04\r", "", "\x03\r", "\x04\r", ""}
+	section1 := []string{"", "Odd header\r", "", "Page footer\r", "", ""}
+	section2 := []string{"Even header\r", "", "", "", "Title page\r", "First footer\r"}
+
+	var hdd []byte
+	var cps []uint32
+	for _, story := range slices.Concat(separators, section1, section2) {
+		cps = append(cps, uint32(len(hdd)))
+		hdd = append(hdd, story...)
+	}
+	cps = append(cps, uint32(len(hdd)))
+	hdd = append(hdd, '\r') // Guard paragraph mark
+	cps = append(cps, uint32(len(hdd)))
+
+	var table []byte
+	for _, cp := range cps {
+		table = binary.LittleEndian.AppendUint32(table, cp)
+	}
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: main + string(hdd), unicode: true}},
+		ccpText: uint32(len(main)),
+		ccpHdd:  uint32(len(hdd)),
+		table:   table,
+		fcLcb:   map[int]uint32{22: 0, 23: uint32(len(table))},
+	})
+
+	stories, err := doc.HeadersFooters()
+	if err != nil {
+		t.Fatalf("HeadersFooters failed: %v", err)
+	}
+	var expected []string
+	for _, story := range slices.Concat(section1, section2) {
+		expected = append(expected, strings.TrimSuffix(story, "\r"))
+	}
+	if !slices.Equal(stories, expected) {
+		t.Fatalf("Expected stories %q, got %q", expected, stories)
+	}
+	if got := stories[msdoc.StoriesPerSection+msdoc.HeaderFirst]; got != "Title page" {
+		t.Errorf("Expected first page header of section 2 %q, got %q", "Title page", got)
+	}
+	if got := stories[msdoc.FooterOdd]; got != "Page footer" {
+		t.Errorf("Expected odd footer of section 1 %q, got %q", "Page footer", got)
+	}
+}
+
+func TestHeadersFootersNone(t *testing.T) {
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Body\r"}}})
+	stories, err := doc.HeadersFooters()
+	if err != nil || stories != nil {
+		t.Errorf("Expected no stories, got %q (%v)", stories, err)
+	}
+}
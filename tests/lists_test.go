@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+// buildLVL encodes a list level without sprms starting at start, with the
+// given number format and number text.
+func buildLVL(start int32, nfc byte, numberText string) []byte {
+	lvlf := make([]byte, 28)
+	binary.LittleEndian.PutUint32(lvlf, uint32(start))
+	lvlf[4] = nfc
+	units := utf16.Encode([]rune(numberText))
+	data := binary.LittleEndian.AppendUint16(lvlf, uint16(len(units)))
+	for _, u := range units {
+		data = binary.LittleEndian.AppendUint16(data, u)
+	}
+	return data
+}
+
+// buildPlfLst encodes a PlfLst with one nine-level decimal list definition
+// followed by its LVLs.
+func buildPlfLst(lsid int32) (plfLst, levels []byte) {
+	lstf := make([]byte, 28)
+	binary.LittleEndian.PutUint32(lstf, uint32(lsid))
+	for i := 0; i < 9; i++ {
+		binary.LittleEndian.PutUint16(lstf[8+i*2:], 0x0FFF) // No linked style
+	}
+	plfLst = append(binary.LittleEndian.AppendUint16(nil, 1), lstf...)
+
+	for i := 0; i < 9; i++ {
+		levels = append(levels, buildLVL(1, 0, string(rune(i))+".")...)
+	}
+	return plfLst, levels
+}
+
+// listPAPX encodes a PAPX placing a paragraph in list instance ilfo at
+// level ilvl.
+func listPAPX(ilfo uint16, ilvl byte) []byte {
+	papx := []byte{0, 0, 0x0B, 0x46} // istd 0, sprmPIlfo
+	papx = binary.LittleEndian.AppendUint16(papx, ilfo)
+	return append(papx, 0x0A, 0x26, ilvl) // sprmPIlvl
+}
+
+func TestListRestartOverride(t *testing.T) {
+	text := "First\rSecond\rRestarted\r"
+	fc := func(cp uint32) uint32 { return mockTextOffset + cp*2 }
+	const lsid = 0x1234
+
+	// Two instances of the same list; the second restarts level 0 at 5
+	plfLst, levels := buildPlfLst(lsid)
+	plfLfo := binary.LittleEndian.AppendUint32(nil, 2)
+	for _, clfolvl := range []byte{0, 1} {
+		lfo := make([]byte, 16)
+		binary.LittleEndian.PutUint32(lfo, lsid)
+		lfo[12] = clfolvl
+		plfLfo = append(plfLfo, lfo...)
+	}
+	plfLfo = binary.LittleEndian.AppendUint32(plfLfo, 0)    // LFOData of the first instance: cp
+	plfLfo = binary.LittleEndian.AppendUint32(plfLfo, 0)    // LFOData of the second instance: cp
+	plfLfo = binary.LittleEndian.AppendUint32(plfLfo, 5)    // iStartAt
+	plfLfo = binary.LittleEndian.AppendUint32(plfLfo, 0x10) // iLvl 0, fStartAt
+
+	page := buildPAPXPage(
+		[]uint32{fc(0), fc(6), fc(13), fc(23)},
+		[][]byte{listPAPX(1, 0), listPAPX(1, 0), listPAPX(2, 0)},
+	)
+	bte := binary.LittleEndian.AppendUint32(nil, fc(0))
+	bte = binary.LittleEndian.AppendUint32(bte, fc(23))
+	bte = binary.LittleEndian.AppendUint32(bte, mockFirstPage)
+
+	lstOffset := uint32(len(bte))
+	lfoOffset := lstOffset + uint32(len(plfLst)+len(levels))
+	table := append(append(append(append([]byte(nil), bte...), plfLst...), levels...), plfLfo...)
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: text, unicode: true}},
+		table:  table,
+		fcLcb: map[int]uint32{
+			26: 0, 27: uint32(len(bte)),
+			146: lstOffset, 147: uint32(len(plfLst)),
+			148: lfoOffset, 149: uint32(len(plfLfo)),
+		},
+		pages: [][]byte{page},
+	})
+
+	lists, err := doc.Lists()
+	if err != nil {
+		t.Fatalf("Lists failed: %v", err)
+	}
+	if len(lists) != 2 {
+		t.Fatalf("Expected 2 list instances, got %d", len(lists))
+	}
+	for i, want := range []int{1, 5} {
+		if len(lists[i].Levels) != 9 {
+			t.Fatalf("List %d: expected 9 levels, got %d", i, len(lists[i].Levels))
+		}
+		if lists[i].ID != lsid {
+			t.Errorf("List %d: expected lsid 0x%X, got 0x%X", i, lsid, lists[i].ID)
+		}
+		if got := lists[i].Levels[0].Start; got != want {
+			t.Errorf("List %d: expected level 0 to start at %d, got %d", i, want, got)
+		}
+		if got := lists[i].Levels[1].Start; got != 1 {
+			t.Errorf("List %d: expected level 1 to start at 1, got %d", i, got)
+		}
+	}
+	if lists[1].Levels[0].NumberText != "\x00." {
+		t.Errorf("Expected the number text to be kept, got %q", lists[1].Levels[0].NumberText)
+	}
+
+	paragraphs, err := doc.Paragraphs()
+	if err != nil {
+		t.Fatalf("Paragraphs failed: %v", err)
+	}
+	if len(paragraphs) != 3 {
+		t.Fatalf("Expected 3 paragraphs, got %d", len(paragraphs))
+	}
+	for i, want := range []int{1, 1, 2} {
+		if paragraphs[i].ListInstance != want || paragraphs[i].ListLevel != 0 {
+			t.Errorf("Paragraph %d: expected list %d level 0, got list %d level %d", i, want, paragraphs[i].ListInstance, paragraphs[i].ListLevel)
+		}
+	}
+}
+
+func TestListsNone(t *testing.T) {
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{{text: "No lists\r"}}})
+
+	lists, err := doc.Lists()
+	if err != nil {
+		t.Fatalf("Lists failed: %v", err)
+	}
+	if lists != nil {
+		t.Errorf("Expected no lists, got %v", lists)
+	}
+}
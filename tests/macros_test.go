@@ -0,0 +1,298 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/macros"
+	"github.com/TalentFormula/msdoc/ole2"
+)
+
+// appendDirRecord appends a dir stream record of the given type.
+func appendDirRecord(dir []byte, recordType uint16, data []byte) []byte {
+	dir = binary.LittleEndian.AppendUint16(dir, recordType)
+	dir = binary.LittleEndian.AppendUint32(dir, uint32(len(data)))
+	return append(dir, data...)
+}
+
+// utf16Record encodes s as the UTF-16LE data of a Unicode dir stream record.
+func utf16Record(s string) []byte {
+	var data []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		data = binary.LittleEndian.AppendUint16(data, u)
+	}
+	return data
+}
+
+// appendModuleRecords appends the records of a module whose compressed code
+// starts at offset in its stream: a procedural module if procedural is set,
+// otherwise a document, class or form module.
+func appendModuleRecords(dir []byte, name, stream string, offset uint32, procedural bool) []byte {
+	dir = appendDirRecord(dir, 0x0019, []byte(name))
+	dir = appendDirRecord(dir, 0x0047, utf16Record(name))
+	dir = appendDirRecord(dir, 0x001A, []byte(stream))
+	dir = appendDirRecord(dir, 0x0032, utf16Record(stream))
+	dir = appendDirRecord(dir, 0x001C, nil)
+	dir = appendDirRecord(dir, 0x0048, nil)
+	dir = appendDirRecord(dir, 0x0031, binary.LittleEndian.AppendUint32(nil, offset))
+	dir = appendDirRecord(dir, 0x001E, make([]byte, 4))
+	dir = appendDirRecord(dir, 0x002C, []byte{0xFF, 0xFF})
+	if procedural {
+		dir = appendDirRecord(dir, 0x0021, nil)
+	} else {
+		dir = appendDirRecord(dir, 0x0022, nil)
+	}
+	dir = appendDirRecord(dir, 0x002B, nil)
+	return dir
+}
+
+// openVBAProject stores a VBA project in the Macros storage the way Word
+// does: the compressed dir stream and the module streams in Macros/VBA, and
+// the PROJECT stream, if given, in Macros.
+func openVBAProject(t *testing.T, dir []byte, modules map[string][]byte, project string) *macros.MacroExtractor {
+	t.Helper()
+	w := ole2.NewWriter()
+	w.AddStream("Macros/VBA/_VBA_PROJECT", []byte{0xCC, 0x61, 0xFF, 0xFF, 0x00, 0x00, 0x00})
+	w.AddStream("Macros/VBA/dir", compressLiterals(dir))
+	for name, data := range modules {
+		w.AddStream("Macros/VBA/"+name, data)
+	}
+	if project != "" {
+		w.AddStream("Macros/PROJECT", []byte(project))
+	}
+	var buf bytes.Buffer
+	if err := w.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	reader, err := ole2.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	return macros.NewMacroExtractor(reader)
+}
+
+// compressLiterals encodes data of up to 3640 bytes as an MS-OVBA compressed
+// container with a single chunk holding only literal tokens.
+func compressLiterals(data []byte) []byte {
+	var tokens []byte
+	for len(data) > 0 {
+		n := min(len(data), 8)
+		tokens = append(append(tokens, 0x00), data[:n]...)
+		data = data[n:]
+	}
+	container := binary.LittleEndian.AppendUint16([]byte{0x01}, 0xB000|uint16(len(tokens)+2-3))
+	return append(container, tokens...)
+}
+
+// extractModule stores module as the compressed code of Module1 in a VBA
+// project and returns the code extracted from it.
+func extractModule(t *testing.T, module []byte) (string, error) {
+	t.Helper()
+	dir := appendModuleRecords(nil, "Module1", "Module1", 0, true)
+	return openVBAProject(t, dir, map[string][]byte{"Module1": module}, "").ExtractModuleCode("Module1")
+}
+
+func TestVBADecompression(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		compressed []byte
+		want       string
+	}{
+		{
+			// The examples of the MS-OVBA specification
+			name: "no compression",
+			compressed: []byte{
+				0x01, 0x19, 0xB0, 0x00, 0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x00, 0x69, 0x6A,
+				0x6B, 0x6C, 0x6D, 0x6E, 0x6F, 0x70, 0x00, 0x71, 0x72, 0x73, 0x74, 0x75, 0x76, 0x2E,
+			},
+			want: "abcdefghijklmnopqrstuv.",
+		},
+		{
+			name: "normal compression",
+			compressed: []byte{
+				0x01, 0x2F, 0xB0, 0x00, 0x23, 0x61, 0x61, 0x61, 0x62, 0x63, 0x64, 0x65, 0x82, 0x66, 0x00,
+				0x70, 0x61, 0x67, 0x68, 0x69, 0x6A, 0x01, 0x38, 0x08, 0x61, 0x6B, 0x6C, 0x00, 0x30, 0x6D,
+				0x6E, 0x6F, 0x70, 0x06, 0x71, 0x02, 0x70, 0x04, 0x10, 0x72, 0x73, 0x74, 0x75, 0x76, 0x10,
+				0x77, 0x78, 0x79, 0x7A, 0x00, 0x3C,
+			},
+			want: "#aaabcdefaaaaghijaaaaaklaaamnopqaaaaaaaaaaaarstuvwxyzaaa",
+		},
+		{
+			name:       "maximum compression",
+			compressed: []byte{0x01, 0x03, 0xB0, 0x02, 0x61, 0x45, 0x00},
+			want:       strings.Repeat("a", 73),
+		},
+		{
+			// An uncompressed chunk holds 4096 literal bytes
+			name:       "raw chunk",
+			compressed: append([]byte{0x01, 0xFF, 0x3F}, bytes.Repeat([]byte("x"), 4096)...),
+			want:       strings.Repeat("x", 4096),
+		},
+		{
+			// Copy tokens in a second chunk count from its start: with two
+			// bytes decompressed the token 0x1000 copies three bytes from
+			// two bytes back
+			name: "two chunks",
+			compressed: append(append([]byte{0x01, 0xFF, 0x3F}, bytes.Repeat([]byte("x"), 4096)...),
+				0x04, 0xB0, 0x04, 0x61, 0x62, 0x00, 0x10),
+			want: strings.Repeat("x", 4096) + "ababa",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := extractModule(t, tc.compressed)
+			if err != nil {
+				t.Fatalf("ExtractModuleCode failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	// A chunk header without the chunk signature is rejected
+	if _, err := extractModule(t, []byte{0x01, 0x19, 0x00, 0x00, 0x61}); err == nil {
+		t.Error("Expected an error for an invalid chunk signature")
+	}
+}
+
+func TestVBACodeFor(t *testing.T) {
+	code := map[string]string{
+		"Module1": "Sub First()\r\nEnd Sub\r\n",
+		"Module2": "Function Second()\r\nEnd Function\r\n",
+	}
+	// Module2 is stored after 4 bytes of performance cache
+	module2 := append([]byte{0xAA, 0xBB, 0xCC, 0xDD}, compressLiterals([]byte(code["Module2"]))...)
+
+	dir := appendDirRecord(nil, 0x0004, []byte("Project"))
+	dir = appendModuleRecords(dir, "Module1", "Module1", 0, true)
+	dir = appendModuleRecords(dir, "Module2", "Module2", 4, true)
+	dir = appendDirRecord(dir, 0x0010, nil)
+	extractor := openVBAProject(t, dir, map[string][]byte{
+		"Module1": compressLiterals([]byte(code["Module1"])),
+		"Module2": module2,
+	}, "")
+	project, err := extractor.ExtractProject()
+	if err != nil {
+		t.Fatalf("ExtractProject failed: %v", err)
+	}
+	for name, want := range code {
+		got, err := extractor.ExtractModuleCode(name)
+		if err != nil {
+			t.Fatalf("ExtractModuleCode(%s) failed: %v", name, err)
+		}
+		full, _ := project.GetModuleCode(name)
+		if got != full || got != want {
+			t.Errorf("Expected %s code %q, got %q (full project: %q)", name, want, got, full)
+		}
+	}
+
+	if _, err := extractor.ExtractModuleCode("Missing"); err == nil {
+		t.Error("Expected an error for a missing module")
+	}
+}
+
+// TestVBADirStream builds the dir stream of a project as Word writes it,
+// with the records the extractor skips, since the test documents hold no
+// macros.
+func TestVBADirStream(t *testing.T) {
+	const wordLibid = `*\G{00020905-0000-0000-C000-000000000046}#8.7#0#C:\Program Files\Word.olb#Microsoft Word Object Library`
+
+	dir := appendDirRecord(nil, 0x0001, binary.LittleEndian.AppendUint32(nil, 1)) // PROJECTSYSKIND: Win32
+	dir = appendDirRecord(dir, 0x0002, binary.LittleEndian.AppendUint32(nil, 0x0409))
+	dir = appendDirRecord(dir, 0x0014, binary.LittleEndian.AppendUint32(nil, 0x0409))
+	dir = appendDirRecord(dir, 0x0003, binary.LittleEndian.AppendUint16(nil, 1252))
+	dir = appendDirRecord(dir, 0x0004, []byte("Project"))
+	dir = appendDirRecord(dir, 0x0005, []byte("Caf? macros"))
+	dir = appendDirRecord(dir, 0x0040, utf16Record("Café macros"))
+	dir = appendDirRecord(dir, 0x0006, []byte("help.chm"))
+	dir = appendDirRecord(dir, 0x003D, []byte("help.chm"))
+	dir = appendDirRecord(dir, 0x0007, make([]byte, 4))
+	dir = appendDirRecord(dir, 0x0008, make([]byte, 4))
+	dir = appendDirRecord(dir, 0x000C, nil)
+	dir = appendDirRecord(dir, 0x003C, nil)
+	// PROJECTVERSION declares 4 bytes but holds 6
+	dir = binary.LittleEndian.AppendUint16(dir, 0x0009)
+	dir = binary.LittleEndian.AppendUint32(dir, 4)
+	dir = append(dir, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00)
+
+	// A registered reference
+	dir = appendDirRecord(dir, 0x0016, []byte("Word"))
+	dir = appendDirRecord(dir, 0x003E, utf16Record("Word"))
+	registered := binary.LittleEndian.AppendUint32(nil, uint32(len(wordLibid)))
+	registered = append(append(registered, wordLibid...), make([]byte, 6)...)
+	dir = appendDirRecord(dir, 0x000D, registered)
+
+	// A control reference, preceded by the libid it was created from and
+	// followed by the extended libid of its type library
+	const formsLibid = `*\G{0D452EE1-E08F-101A-852E-02608C4D0BB4}#2.0#0#C:\Windows\system32\FM20.DLL#Microsoft Forms 2.0 Object Library`
+	const twiddledLibid = `*\G{00000000-0000-0000-0000-000000000000}#0.0#0#C:\Temp\MSForms.TWD#Twiddled`
+	dir = appendDirRecord(dir, 0x0016, []byte("MSForms"))
+	dir = appendDirRecord(dir, 0x003E, utf16Record("MSForms"))
+	dir = appendDirRecord(dir, 0x0033, []byte(formsLibid))
+	twiddled := binary.LittleEndian.AppendUint32(nil, uint32(len(twiddledLibid)))
+	twiddled = append(append(twiddled, twiddledLibid...), make([]byte, 6)...)
+	dir = appendDirRecord(dir, 0x002F, twiddled)
+	extended := binary.LittleEndian.AppendUint32(nil, uint32(len(twiddledLibid)))
+	extended = append(append(extended, twiddledLibid...), make([]byte, 26)...)
+	dir = appendDirRecord(dir, 0x0030, extended)
+
+	dir = appendDirRecord(dir, 0x000F, binary.LittleEndian.AppendUint16(nil, 2))
+	dir = appendDirRecord(dir, 0x0013, []byte{0xFF, 0xFF})
+	dir = appendModuleRecords(dir, "ThisDocument", "ThisDocument", 3, false)
+	dir = appendModuleRecords(dir, "Modul€1", "Module1", 0, true)
+	dir = appendDirRecord(dir, 0x0010, nil)
+
+	code := "Attribute VB_Name = \"Module1\"\r\nSub Hello()\r\nEnd Sub\r\n"
+	extractor := openVBAProject(t, dir, map[string][]byte{
+		"ThisDocument": append([]byte{1, 2, 3}, compressLiterals([]byte("Attribute VB_Name = \"ThisDocument\"\r\n"))...),
+		"Module1":      compressLiterals([]byte(code)),
+	}, "ID=\"{00000000-0000-0000-0000-000000000000}\"\r\nDocument=ThisDocument/&H00000000\r\nModule=Modul€1\r\nName=\"Project\"\r\n")
+
+	project, err := extractor.ExtractProject()
+	if err != nil {
+		t.Fatalf("ExtractProject failed: %v", err)
+	}
+	if project.Name != "Project" || project.Description != "Café macros" || project.HelpFile != "help.chm" {
+		t.Errorf("Unexpected project information: name %q, description %q, help file %q", project.Name, project.Description, project.HelpFile)
+	}
+
+	if len(project.References) != 2 {
+		t.Fatalf("Expected 2 references, got %d", len(project.References))
+	}
+	ref := project.References[0]
+	if ref.Name != "Word" || ref.GUID != "{00020905-0000-0000-C000-000000000046}" || ref.Version != "8.7" ||
+		ref.Path != `C:\Program Files\Word.olb` || ref.Description != "Microsoft Word Object Library" {
+		t.Errorf("Unexpected reference %+v", *ref)
+	}
+	control := project.References[1]
+	if control.Name != "MSForms" || control.GUID != "{0D452EE1-E08F-101A-852E-02608C4D0BB4}" || control.Version != "2.0" ||
+		control.Path != `C:\Windows\system32\FM20.DLL` || control.Description != "Microsoft Forms 2.0 Object Library" {
+		t.Errorf("Unexpected control reference %+v", *control)
+	}
+
+	if len(project.Modules) != 2 {
+		t.Fatalf("Expected 2 modules, got %v", project.GetAllModuleNames())
+	}
+	if doc := project.Modules["ThisDocument"]; doc == nil || doc.Type != macros.ModuleDocument || doc.Offset != 3 {
+		t.Errorf("Unexpected ThisDocument module %+v", doc)
+	}
+	module := project.Modules["Modul€1"]
+	if module == nil {
+		t.Fatal("Expected the module named by its Unicode record")
+	}
+	if module.Type != macros.ModuleStandard || module.StreamName != "Module1" || !module.Compressed ||
+		module.Code != code || module.Size != uint32(len(code)) {
+		t.Errorf("Unexpected Module1 module %+v", module)
+	}
+
+	// A record running past the end of the dir stream is rejected
+	truncated := appendDirRecord(nil, 0x0004, []byte("Project"))
+	truncated = binary.LittleEndian.AppendUint16(truncated, 0x0019)
+	truncated = binary.LittleEndian.AppendUint32(truncated, 100)
+	if _, err := openVBAProject(t, truncated, nil, "").ExtractProject(); err == nil {
+		t.Error("Expected an error for a truncated dir stream")
+	}
+}
@@ -1,10 +1,13 @@
 package tests
 
 import (
+	"bytes"
+	"encoding/binary"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/TalentFormula/msdoc/metadata"
 	"github.com/TalentFormula/msdoc/ole2"
 	"github.com/TalentFormula/msdoc/pkg"
 )
@@ -37,7 +40,7 @@ func TestMetadataExtraction(t *testing.T) {
 		{
 			filename:              "testdata/sample-3.doc",
 			expectedTitle:         "The Third Title",
-			expectedAuthor:        "",
+			expectedAuthor:        "Advik B; Someone",
 			expectedSubject:       "TalentSort",
 			expectedKeywords:      "tag1",
 			expectedComments:      "Yayy",
@@ -59,7 +62,7 @@ func TestMetadataExtraction(t *testing.T) {
 			expectedCompany:       "",
 			expectedManager:       "",
 			expectedContentStatus: "",
-			expectedContentType:   "",
+			expectedContentType:   "application/msword",
 			expectedCategory:      "",
 		},
 	}
@@ -324,3 +327,110 @@ func TestSample4DocStreams(t *testing.T) {
 		}
 	}
 }
+
+// mockProperty is a property stored in a mock property section. The value
+// holds the type, padding and value bytes.
+type mockProperty struct {
+	id    uint32
+	value []byte
+}
+
+// buildPropertySection encodes a property section.
+func buildPropertySection(props ...mockProperty) []byte {
+	header := 8 + len(props)*8
+	var values []byte
+	section := binary.LittleEndian.AppendUint32(nil, 0) // Size, set below
+	section = binary.LittleEndian.AppendUint32(section, uint32(len(props)))
+	for _, p := range props {
+		section = binary.LittleEndian.AppendUint32(section, p.id)
+		section = binary.LittleEndian.AppendUint32(section, uint32(header+len(values)))
+		values = append(values, p.value...)
+		for len(values)%4 != 0 {
+			values = append(values, 0)
+		}
+	}
+	section = append(section, values...)
+	binary.LittleEndian.PutUint32(section, uint32(len(section)))
+	return section
+}
+
+// buildPropertySetStream encodes a property set stream with the given sections.
+func buildPropertySetStream(fmtids [][16]byte, sections ...[]byte) []byte {
+	data := binary.LittleEndian.AppendUint16(nil, 0xFFFE)
+	data = append(data, make([]byte, 22)...) // Version, system ID and CLSID
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(sections)))
+	offset := len(data) + len(sections)*20
+	for i, section := range sections {
+		data = append(data, fmtids[i][:]...)
+		data = binary.LittleEndian.AppendUint32(data, uint32(offset))
+		offset += len(section)
+	}
+	for _, section := range sections {
+		data = append(data, section...)
+	}
+	return data
+}
+
+// ansiProperty encodes a VT_LPSTR property value.
+func ansiProperty(s string) []byte {
+	value := binary.LittleEndian.AppendUint32([]byte{0x1E, 0, 0, 0}, uint32(len(s)+1))
+	return append(append(value, s...), 0)
+}
+
+func TestCustomPropertiesRaw(t *testing.T) {
+	docSummary := [16]byte{0x02, 0xD5, 0xCD, 0xD5, 0x9C, 0x2E, 0x1B, 0x10, 0x93, 0x97, 0x08, 0x00, 0x2B, 0x2C, 0xF9, 0xAE}
+	userDefined := [16]byte{0x05, 0xD5, 0xCD, 0xD5, 0x9C, 0x2E, 0x1B, 0x10, 0x93, 0x97, 0x08, 0x00, 0x2B, 0x2C, 0xF9, 0xAE}
+
+	// Dictionary naming custom properties 2 and 3
+	dictionary := binary.LittleEndian.AppendUint32(nil, 2)
+	for _, entry := range []struct {
+		id   uint32
+		name string
+	}{{2, "Project"}, {3, "Budget"}} {
+		dictionary = binary.LittleEndian.AppendUint32(dictionary, entry.id)
+		dictionary = binary.LittleEndian.AppendUint32(dictionary, uint32(len(entry.name)+1))
+		dictionary = append(append(dictionary, entry.name...), 0)
+	}
+
+	// VT_CY is not decoded, so its eight value bytes must be kept as-is
+	currency := []byte{0x06, 0, 0, 0, 0x10, 0x27, 0, 0, 0, 0, 0, 0}
+
+	stream := buildPropertySetStream(
+		[][16]byte{docSummary, userDefined},
+		buildPropertySection(mockProperty{0x0F, ansiProperty("TalentFormula")}), // PIDCompany
+		buildPropertySection(
+			mockProperty{0, dictionary},
+			mockProperty{1, []byte{0x02, 0, 0, 0, 0xE4, 0x04}}, // Code page 1252
+			mockProperty{2, ansiProperty("Apollo")},
+			mockProperty{3, currency},
+		),
+	)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: "Custom properties"}},
+		streams: []mockStream{{name: "\x05DocumentSummaryInformation", data: stream}},
+	})
+	props := doc.Metadata()
+
+	if props.Company != "TalentFormula" {
+		t.Errorf("Expected company %q, got %q", "TalentFormula", props.Company)
+	}
+	if props.Category != "" {
+		t.Errorf("Expected custom property 2 not to be read as the category, got %q", props.Category)
+	}
+
+	if project := props.CustomProperties["Project"]; project != "Apollo" {
+		t.Errorf("Expected custom property Project to be %q, got %v", "Apollo", project)
+	}
+
+	raw, ok := props.CustomProperties["Budget"].(metadata.RawProperty)
+	if !ok {
+		t.Fatalf("Expected custom property Budget to be a RawProperty, got %T", props.CustomProperties["Budget"])
+	}
+	if raw.Type != metadata.PropertyTypeCurrency {
+		t.Errorf("Expected type VT_CY, got 0x%04X", raw.Type)
+	}
+	if !bytes.Equal(raw.Bytes, currency[4:]) {
+		t.Errorf("Expected raw bytes %x, got %x", currency[4:], raw.Bytes)
+	}
+}
@@ -0,0 +1,408 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/crypto"
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+// mockStream is a named stream stored in a mock compound file.
+type mockStream struct {
+	name string
+	data []byte
+}
+
+// buildCompoundFile assembles a minimal OLE2 compound file containing the given
+// streams. Every stream is stored in regular 512-byte sectors; the FAT occupies
+// sector 0 and the directory is placed after the stream data.
+func buildCompoundFile(streams []mockStream) []byte {
+	const sectorSize = 512
+
+	sectorsFor := func(n int) int {
+		if n == 0 {
+			return 0
+		}
+		return (n + sectorSize - 1) / sectorSize
+	}
+
+	// Lay out stream data starting at sector 1
+	starts := make([]int, len(streams))
+	next := 1
+	for i, s := range streams {
+		starts[i] = next
+		next += sectorsFor(len(s.data))
+	}
+	dirStart := next
+	numDirSectors := sectorsFor((len(streams) + 1) * 128)
+
+	// Header
+	header := make([]byte, sectorSize)
+	binary.LittleEndian.PutUint64(header[0:], 0xE11AB1A1E011CFD0)
+	binary.LittleEndian.PutUint16(header[24:], 0x003E)
+	binary.LittleEndian.PutUint16(header[26:], 0x0003)
+	binary.LittleEndian.PutUint16(header[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(header[30:], 0x0009)
+	binary.LittleEndian.PutUint16(header[32:], 0x0006)
+	binary.LittleEndian.PutUint32(header[44:], 1) // One FAT sector
+	binary.LittleEndian.PutUint32(header[48:], uint32(dirStart))
+	binary.LittleEndian.PutUint32(header[56:], 4096)
+	binary.LittleEndian.PutUint32(header[60:], 0xFFFFFFFE)
+	binary.LittleEndian.PutUint32(header[68:], 0xFFFFFFFE)
+	for i := 76; i < sectorSize; i += 4 {
+		binary.LittleEndian.PutUint32(header[i:], 0xFFFFFFFF)
+	}
+	binary.LittleEndian.PutUint32(header[76:], 0) // FAT is in sector 0
+
+	// FAT
+	fat := make([]byte, sectorSize)
+	for i := 0; i < sectorSize; i += 4 {
+		binary.LittleEndian.PutUint32(fat[i:], 0xFFFFFFFF)
+	}
+	binary.LittleEndian.PutUint32(fat[0:], 0xFFFFFFFD)
+	chain := func(start, count int) {
+		for i := 0; i < count; i++ {
+			nextSector := uint32(start + i + 1)
+			if i == count-1 {
+				nextSector = 0xFFFFFFFE
+			}
+			binary.LittleEndian.PutUint32(fat[(start+i)*4:], nextSector)
+		}
+	}
+	for i, s := range streams {
+		chain(starts[i], sectorsFor(len(s.data)))
+	}
+	chain(dirStart, numDirSectors)
+
+	// Directory
+	dir := make([]byte, numDirSectors*sectorSize)
+	writeEntry := func(index int, name string, objectType byte, child int32, start int, size int) {
+		entry := dir[index*128 : (index+1)*128]
+		nameUnits := utf16.Encode([]rune(name + "\x00"))
+		for i, u := range nameUnits {
+			binary.LittleEndian.PutUint16(entry[i*2:], u)
+		}
+		binary.LittleEndian.PutUint16(entry[64:], uint16(len(nameUnits)*2))
+		entry[66] = objectType
+		entry[67] = 1 // Black
+		binary.LittleEndian.PutUint32(entry[68:], 0xFFFFFFFF)
+		binary.LittleEndian.PutUint32(entry[72:], 0xFFFFFFFF)
+		binary.LittleEndian.PutUint32(entry[76:], uint32(child))
+		binary.LittleEndian.PutUint32(entry[116:], uint32(start))
+		binary.LittleEndian.PutUint64(entry[120:], uint64(size))
+	}
+	writeEntry(0, "Root Entry", 5, 1, -2, 0)
+	for i, s := range streams {
+		writeEntry(i+1, s.name, 2, -1, starts[i], len(s.data))
+		// Chain the streams as right siblings of one another
+		if i+1 < len(streams) {
+			binary.LittleEndian.PutUint32(dir[(i+1)*128+72:], uint32(i+2))
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.Write(fat)
+	for _, s := range streams {
+		padded := make([]byte, sectorsFor(len(s.data))*sectorSize)
+		copy(padded, s.data)
+		buf.Write(padded)
+	}
+	buf.Write(dir)
+	return buf.Bytes()
+}
+
+// mockPiece is a run of text stored in a mock document's piece table.
+type mockPiece struct {
+	text      string
+	unicode   bool
+	bigEndian bool // Store the Unicode text byte-swapped
+}
+
+// mockDoc describes a minimal Word document built for tests. The FIB is laid
+// out the way fib.ParseFIB reads it, the text of each piece is stored in the
+// WordDocument stream after the FIB, and the CLX is stored in the 1Table stream
+// unless clxInWord is set.
+type mockDoc struct {
+	pieces      []mockPiece    // Text pieces in CP order
+	flags1      uint16         // Extra FibBase flags (fWhichTblStm is always set)
+	nFib        uint16         // FibBase nFib, Word 97 (0x00C1) if not set
+	lid         uint16         // FibBase lid, zero if not set
+	ccpText     uint32         // FibRgLw ccpText, zero if not set
+	ccpFtn      uint32         // FibRgLw ccpFtn, zero if not set
+	ccpHdd      uint32         // FibRgLw ccpHdd, zero if not set
+	ccpAtn      uint32         // FibRgLw ccpAtn, zero if not set
+	ccpEdn      uint32         // FibRgLw ccpEdn, zero if not set
+	fcLcb       map[int]uint32 // Additional FibRgFcLcb values by uint32 index
+	fcLcbPairs  int            // FibRgFcLcb fc/lcb pairs, 93 (FibRgFcLcb97) if not set; more overlap word
+	table       []byte         // Table stream data placed before the CLX
+	word        []byte         // WordDocument data placed at mockWordDataOffset, after the FIB
+	streams     []mockStream   // Additional streams
+	pages       [][]byte       // 512-byte pages placed in WordDocument from page mockFirstPage on
+	padding     string         // Appended to the WordDocument and table stream names
+	clxInWord   bool           // Store the CLX at the end of the WordDocument stream
+	prc         []byte         // Prc entries written at the start of the CLX, before the Pcdt
+	encHeader   []byte         // Encryption header placed at the start of the table stream
+	encPassword string         // Password encrypting the table stream after encHeader and the piece text with encHeader
+	textAt      map[int]uint32 // Filled in by build: WordDocument offset of each piece
+}
+
+const (
+	mockFibSize        = 32 + 2 + 28 + 2 + 88 + 2 + 93*8 + 2
+	mockWordDataOffset = mockFibSize
+	mockTextOffset     = 1280 // Leaves room for a FibRgFcLcb2002 of 136 pairs
+	mockFirstPage      = 4    // Page number of the first of mockDoc.pages, after the text
+)
+
+// build returns the bytes of the compound file for the mock document.
+func (m *mockDoc) build() []byte {
+	// WordDocument stream: FIB followed by the piece text
+	word := make([]byte, mockTextOffset)
+	binary.LittleEndian.PutUint16(word[0:], 0xA5EC)
+	nFib := m.nFib
+	if nFib == 0 {
+		nFib = 0x00C1
+	}
+	binary.LittleEndian.PutUint16(word[2:], nFib)
+	binary.LittleEndian.PutUint16(word[6:], m.lid)
+	binary.LittleEndian.PutUint16(word[10:], 0x0200|m.flags1)
+	binary.LittleEndian.PutUint16(word[32:], 14)
+	binary.LittleEndian.PutUint16(word[62:], 22)
+	binary.LittleEndian.PutUint32(word[76:], m.ccpText)
+	binary.LittleEndian.PutUint32(word[80:], m.ccpFtn)
+	binary.LittleEndian.PutUint32(word[84:], m.ccpHdd)
+	binary.LittleEndian.PutUint32(word[92:], m.ccpAtn)
+	binary.LittleEndian.PutUint32(word[96:], m.ccpEdn)
+	copy(word[mockWordDataOffset:mockTextOffset], m.word)
+	fcLcbPairs := m.fcLcbPairs
+	if fcLcbPairs == 0 {
+		fcLcbPairs = 93
+	}
+	binary.LittleEndian.PutUint16(word[152:], uint16(fcLcbPairs))
+
+	m.textAt = make(map[int]uint32)
+	var pcds [][]byte
+	var cps []uint32
+	cp := uint32(0)
+	for i, p := range m.pieces {
+		offset := uint32(len(word))
+		m.textAt[i] = offset
+
+		fc := offset
+		charCount := uint32(0)
+		if p.unicode {
+			for _, u := range utf16.Encode([]rune(p.text)) {
+				if p.bigEndian {
+					word = binary.BigEndian.AppendUint16(word, u)
+				} else {
+					word = binary.LittleEndian.AppendUint16(word, u)
+				}
+				charCount++
+			}
+		} else {
+			word = append(word, p.text...)
+			charCount = uint32(len(p.text))
+			// Compressed ANSI pieces store twice the stream offset with bit 30 set
+			fc = (offset * 2) | 0x40000000
+		}
+
+		pcd := make([]byte, 8)
+		binary.LittleEndian.PutUint32(pcd[2:], fc)
+		pcds = append(pcds, pcd)
+		cps = append(cps, cp)
+		cp += charCount
+	}
+	cps = append(cps, cp)
+
+	if len(m.pages) > 0 {
+		if len(word) > mockFirstPage*512 {
+			panic("mock document text overlaps its pages")
+		}
+		word = append(word, make([]byte, mockFirstPage*512-len(word))...)
+		for _, page := range m.pages {
+			word = append(word, page...)
+		}
+	}
+
+	// CLX: any Prcs, then the PlcPcd marker and size followed by the piece
+	// table
+	clx := append([]byte(nil), m.prc...)
+	clx = append(clx, 0x02)
+	clx = binary.LittleEndian.AppendUint32(clx, uint32(len(cps)*4+len(pcds)*8))
+	for _, c := range cps {
+		clx = binary.LittleEndian.AppendUint32(clx, c)
+	}
+	for _, pcd := range pcds {
+		clx = append(clx, pcd...)
+	}
+	clxSize := uint32(len(clx))
+
+	// Table stream: any encryption header, caller data and the CLX. FIB
+	// offsets into the table stream count the encryption header, so the
+	// fcs in m.fcLcb, which are relative to m.table, are moved past it
+	table := append(append([]byte(nil), m.encHeader...), m.table...)
+	clxOffset := uint32(len(table))
+	if m.clxInWord {
+		clxOffset = uint32(len(word))
+		word = append(word, clx...)
+	} else {
+		table = append(table, clx...)
+	}
+
+	blob := word[154 : 154+fcLcbPairs*8]
+	binary.LittleEndian.PutUint32(blob[66*4:], clxOffset)
+	binary.LittleEndian.PutUint32(blob[67*4:], clxSize)
+	for index, value := range m.fcLcb {
+		if index%2 == 0 {
+			value += uint32(len(m.encHeader))
+		}
+		binary.LittleEndian.PutUint32(blob[index*4:], value)
+	}
+
+	// Both streams are encrypted block by block from their start, except
+	// for the first 68 bytes of the FIB and the encryption header, which
+	// are stored unencrypted
+	if encryptor := m.encryptor(); encryptor != nil {
+		for _, stream := range []struct {
+			data  []byte
+			plain int
+		}{{word, 68}, {table, len(m.encHeader)}} {
+			encrypted, err := encryptor.DecryptAt(stream.data, 0)
+			if err != nil {
+				panic(err)
+			}
+			copy(stream.data[stream.plain:], encrypted[stream.plain:])
+		}
+	}
+
+	streams := []mockStream{
+		{name: "WordDocument" + m.padding, data: word},
+		{name: "1Table" + m.padding, data: table},
+	}
+	streams = append(streams, m.streams...)
+	return buildCompoundFile(streams)
+}
+
+// encryptor returns the cipher encrypting the streams of the mock document,
+// or nil if it has no encPassword. RC4 is symmetric, so decrypting encrypts.
+func (m *mockDoc) encryptor() *crypto.StreamDecryptor {
+	if m.encPassword == "" {
+		return nil
+	}
+	header, err := crypto.ParseEncryptionHeader(m.encHeader)
+	if err != nil {
+		panic(err)
+	}
+	encryptor, err := header.NewStreamDecryptor(m.encPassword)
+	if err != nil {
+		panic(err)
+	}
+	return encryptor
+}
+
+// writeFile writes the mock document to a temporary file and returns its path.
+func (m *mockDoc) writeFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mock.doc")
+	if err := os.WriteFile(path, m.build(), 0o644); err != nil {
+		t.Fatalf("Failed to write mock document: %v", err)
+	}
+	return path
+}
+
+// openMock opens a mock document, closing it when the test finishes.
+func openMock(t *testing.T, mock *mockDoc) *msdoc.Document {
+	t.Helper()
+	doc, err := msdoc.Open(mock.writeFile(t))
+	if err != nil {
+		t.Fatalf("Failed to open mock document: %v", err)
+	}
+	t.Cleanup(func() { doc.Close() })
+	return doc
+}
+
+// buildSTTB encodes strings as a Unicode STTB with no extra data.
+func buildSTTB(strs ...string) []byte {
+	data := []byte{0xFF, 0xFF}
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(strs)))
+	data = binary.LittleEndian.AppendUint16(data, 0) // cbExtra
+	for _, s := range strs {
+		units := utf16.Encode([]rune(s))
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(units)))
+		for _, u := range units {
+			data = binary.LittleEndian.AppendUint16(data, u)
+		}
+	}
+	return data
+}
+
+// mockObject is an object stored in a mock ObjectPool stream.
+type mockObject struct {
+	objType uint16 // 0x0002 for OLE objects, 0x0003 for images
+	header  []byte // Type-specific header (class name or image format)
+	payload []byte
+}
+
+// buildObjectPool encodes objects in the layout read by objects.ObjectPool.
+func buildObjectPool(objs ...mockObject) []byte {
+	var data []byte
+	for _, obj := range objs {
+		data = binary.LittleEndian.AppendUint32(data, 0x00000501)
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(obj.header)+len(obj.payload)))
+		data = binary.LittleEndian.AppendUint16(data, obj.objType)
+		data = binary.LittleEndian.AppendUint16(data, 0)
+		data = append(data, obj.header...)
+		data = append(data, obj.payload...)
+	}
+	return data
+}
+
+// oleObjectHeader returns the header of an OLE object with the given class name.
+func oleObjectHeader(className string) []byte {
+	header := make([]byte, 12)
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(className)))
+	return append(header, className...)
+}
+
+// imageObjectHeader returns the header of an image with the given format code.
+func imageObjectHeader(format uint32) []byte {
+	header := make([]byte, 12)
+	binary.LittleEndian.PutUint32(header[0:], format)
+	return header
+}
+
+// mockFont is a font stored in a mock SttbfFfn.
+type mockFont struct {
+	name    string
+	altName string
+	flags   byte // prq, fTrueType and ff bits
+	charset byte
+}
+
+// buildSttbfFfn encodes fonts as a font table.
+func buildSttbfFfn(fonts ...mockFont) []byte {
+	data := binary.LittleEndian.AppendUint16(nil, uint16(len(fonts)))
+	data = binary.LittleEndian.AppendUint16(data, 0)
+	for _, f := range fonts {
+		ffn := make([]byte, 39)
+		ffn[0] = f.flags
+		binary.LittleEndian.PutUint16(ffn[1:], 400)
+		ffn[3] = f.charset
+		names := utf16.Encode([]rune(f.name + "\x00"))
+		if f.altName != "" {
+			ffn[4] = byte(len(names))
+			names = append(names, utf16.Encode([]rune(f.altName+"\x00"))...)
+		}
+		for _, u := range names {
+			ffn = binary.LittleEndian.AppendUint16(ffn, u)
+		}
+		data = append(data, byte(len(ffn)))
+		data = append(data, ffn...)
+	}
+	return data
+}
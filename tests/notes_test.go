@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// buildNotePLCs encodes a note reference PLC with the given reference CPs and
+// FRD values and a note text PLC with the given CPs, returning both and their
+// offsets in the table.
+func buildNotePLCs(table []byte, refCPs []uint32, frds []int16, textCPs []uint32) ([]byte, [4]uint32) {
+	var loc [4]uint32
+	loc[0] = uint32(len(table))
+	for _, cp := range refCPs {
+		table = binary.LittleEndian.AppendUint32(table, cp)
+	}
+	for _, frd := range frds {
+		table = binary.LittleEndian.AppendUint16(table, uint16(frd))
+	}
+	loc[1] = uint32(len(table)) - loc[0]
+	loc[2] = uint32(len(table))
+	for _, cp := range textCPs {
+		table = binary.LittleEndian.AppendUint32(table, cp)
+	}
+	loc[3] = uint32(len(table)) - loc[2]
+	return table, loc
+}
+
+func TestFootnotesAndEndnotes(t *testing.T) {
+	main := "One\x02 two\x02 three*\r"
+	footnotes := "\x02First note\r\x02Second note\r\r"
+	endnotes := "*Custom mark\r\r"
+
+	table, ftn := buildNotePLCs(nil, []uint32{3, 8, 20}, []int16{1, 2}, []uint32{0, 12, 25, 26})
+	table, edn := buildNotePLCs(table, []uint32{15, 20}, []int16{0}, []uint32{0, 13, 14})
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: main + footnotes + endnotes, unicode: true}},
+		ccpText: uint32(len(main)),
+		ccpFtn:  uint32(len(footnotes)),
+		ccpEdn:  uint32(len(endnotes)),
+		table:   table,
+		fcLcb: map[int]uint32{
+			4: ftn[0], 5: ftn[1], 6: ftn[2], 7: ftn[3],
+			92: edn[0], 93: edn[1], 94: edn[2], 95: edn[3],
+		},
+	})
+
+	notes, err := doc.Footnotes()
+	if err != nil {
+		t.Fatalf("Footnotes failed: %v", err)
+	}
+	if len(notes) != 2 {
+		t.Fatalf("Expected 2 footnotes, got %d", len(notes))
+	}
+	for i, want := range []struct {
+		text string
+		cp   uint32
+	}{{"First note", 3}, {"Second note", 8}} {
+		if got := notes[i]; got.Text != want.text || got.CP != want.cp || !got.AutoNumbered {
+			t.Errorf("Footnote %d: expected numbered %q at CP %d, got %+v", i, want.text, want.cp, got)
+		}
+		if ref := main[notes[i].CP]; ref != 0x02 {
+			t.Errorf("Footnote %d: expected the reference mark at CP %d, found %q", i, notes[i].CP, ref)
+		}
+	}
+
+	endnoteList, err := doc.Endnotes()
+	if err != nil {
+		t.Fatalf("Endnotes failed: %v", err)
+	}
+	if len(endnoteList) != 1 {
+		t.Fatalf("Expected 1 endnote, got %d", len(endnoteList))
+	}
+	if got := endnoteList[0]; got.Text != "*Custom mark" || got.CP != 15 || got.AutoNumbered {
+		t.Errorf("Expected custom-marked endnote at CP 15, got %+v", got)
+	}
+}
+
+func TestFootnotesNone(t *testing.T) {
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Body\r"}}})
+	if notes, err := doc.Footnotes(); err != nil || notes != nil {
+		t.Errorf("Expected no footnotes, got %+v (%v)", notes, err)
+	}
+	if notes, err := doc.Endnotes(); err != nil || notes != nil {
+		t.Errorf("Expected no endnotes, got %+v (%v)", notes, err)
+	}
+}
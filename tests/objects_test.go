@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/objects"
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+func TestExtractAllObjects(t *testing.T) {
+	sheet := []byte("spreadsheet data")
+	png := []byte("\x89PNG\r\n\x1a\nimage data")
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Objects"}},
+		streams: []mockStream{{
+			name: "ObjectPool",
+			data: buildObjectPool(
+				mockObject{objType: 0x0002, header: oleObjectHeader("Excel.Sheet.8"), payload: sheet},
+				mockObject{objType: 0x0003, header: imageObjectHeader(0x8000), payload: png},
+			),
+		}},
+	})
+
+	dir := filepath.Join(t.TempDir(), "objects")
+	paths, err := doc.ExtractAllObjects(dir)
+	if err != nil {
+		t.Fatalf("ExtractAllObjects failed: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("Expected 2 extracted files, got %d", len(paths))
+	}
+
+	expected := []struct {
+		ext  string
+		data []byte
+	}{
+		{".xls", sheet},
+		{".png", png},
+	}
+	for i, want := range expected {
+		if filepath.Dir(paths[i]) != dir {
+			t.Errorf("Expected %s to be written to %s", paths[i], dir)
+		}
+		if ext := filepath.Ext(paths[i]); ext != want.ext {
+			t.Errorf("Expected extension %s for %s, got %s", want.ext, paths[i], ext)
+		}
+		data, err := os.ReadFile(paths[i])
+		if err != nil {
+			t.Fatalf("Extracted file missing: %v", err)
+		}
+		if !bytes.Equal(data, want.data) {
+			t.Errorf("Unexpected contents in %s: %q", paths[i], data)
+		}
+	}
+}
+
+func TestObjectDisplaySize(t *testing.T) {
+	text := "Sales: \x01\r"
+	anchorFC := uint32(mockTextOffset + strings.IndexByte(text, 0x01))
+	textEnd := uint32(mockTextOffset + len(text))
+
+	// CHPX FKP with three runs; only the anchor character has a CHPX, which
+	// points at the picture header in the Data stream
+	fkp := make([]byte, 512)
+	for i, fc := range []uint32{mockTextOffset, anchorFC, anchorFC + 1, textEnd} {
+		binary.LittleEndian.PutUint32(fkp[i*4:], fc)
+	}
+	fkp[16+1] = 0x40 // Anchor CHPX at byte offset 0x80
+	chpx := []byte{
+		0x09,
+		0x55, 0x08, 0x01, // sprmCFSpec
+		0x03, 0x6A, 0x10, 0x00, 0x00, 0x00, // sprmCPicLocation: 0x10
+	}
+	copy(fkp[0x80:], chpx)
+	fkp[511] = 3
+
+	// PlcBteChpx covering the text with the FKP page
+	var bte []byte
+	bte = binary.LittleEndian.AppendUint32(bte, mockTextOffset)
+	bte = binary.LittleEndian.AppendUint32(bte, textEnd)
+	bte = binary.LittleEndian.AppendUint32(bte, mockFirstPage)
+
+	// PICF: a 4" x 2" chart scaled to 50% width
+	picf := make([]byte, 0x44)
+	binary.LittleEndian.PutUint32(picf[0:], 0x44)
+	binary.LittleEndian.PutUint16(picf[4:], 0x44)
+	binary.LittleEndian.PutUint16(picf[6:], 0x64)
+	binary.LittleEndian.PutUint16(picf[28:], 5760)
+	binary.LittleEndian.PutUint16(picf[30:], 2880)
+	binary.LittleEndian.PutUint16(picf[32:], 500)
+	binary.LittleEndian.PutUint16(picf[34:], 1000)
+	data := append(make([]byte, 0x10), picf...)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   bte,
+		fcLcb:   map[int]uint32{24: 0, 25: uint32(len(bte))},
+		pages:   [][]byte{fkp},
+		streams: []mockStream{
+			{name: "Data", data: data},
+			{name: "ObjectPool", data: buildObjectPool(mockObject{objType: 0x0005, payload: []byte("chart data")})},
+		},
+	})
+
+	objs, err := doc.GetEmbeddedObjects()
+	if err != nil {
+		t.Fatalf("GetEmbeddedObjects failed: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("Expected 1 object, got %d", len(objs))
+	}
+	for _, obj := range objs {
+		if obj.Type != objects.ObjectTypeChart {
+			t.Errorf("Expected a chart, got type %d", obj.Type)
+		}
+		if obj.DisplayWidthTwips != 2880 || obj.DisplayHeightTwips != 2880 {
+			t.Errorf("Expected display size 2880x2880 twips, got %dx%d", obj.DisplayWidthTwips, obj.DisplayHeightTwips)
+		}
+	}
+}
+
+func TestForEachObject(t *testing.T) {
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Objects"}},
+		streams: []mockStream{{
+			name: "ObjectPool",
+			data: buildObjectPool(
+				mockObject{objType: 0x0002, header: oleObjectHeader("Excel.Sheet.8"), payload: []byte("sheet")},
+				mockObject{objType: 0x0003, header: imageObjectHeader(0x8000), payload: []byte("\x89PNG\r\n\x1a\n")},
+				mockObject{objType: 0x0002, header: oleObjectHeader("Word.Document.8"), payload: []byte("doc")},
+			),
+		}},
+	})
+
+	var positions []uint32
+	err := doc.ForEachObject(func(obj *msdoc.EmbeddedObject) error {
+		positions = append(positions, obj.Position)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachObject failed: %v", err)
+	}
+
+	all, err := doc.GetEmbeddedObjects()
+	if err != nil {
+		t.Fatalf("GetEmbeddedObjects failed: %v", err)
+	}
+	if len(positions) != len(all) || len(positions) != 3 {
+		t.Fatalf("Expected 3 objects from both, got %d from ForEachObject and %d from GetEmbeddedObjects", len(positions), len(all))
+	}
+	for i, position := range positions {
+		if _, ok := all[position]; !ok {
+			t.Errorf("Object at position %d not returned by GetEmbeddedObjects", position)
+		}
+		if i > 0 && position <= positions[i-1] {
+			t.Errorf("Expected objects in document order, got positions %v", positions)
+		}
+	}
+
+	// An error from the callback stops the iteration
+	stop := errors.New("stop")
+	count := 0
+	err = doc.ForEachObject(func(obj *msdoc.EmbeddedObject) error {
+		count++
+		return stop
+	})
+	if !errors.Is(err, stop) || count != 1 {
+		t.Errorf("Expected iteration to stop after 1 object with the callback error, got %d objects and %v", count, err)
+	}
+}
+
+func TestObjectAnchorPositions(t *testing.T) {
+	text := "Sheet \x01 and chart \x01\r"
+	first := strings.IndexByte(text, 0x01)
+	second := strings.LastIndexByte(text, 0x01)
+	textEnd := uint32(mockTextOffset + len(text))
+
+	// Both anchors are special characters; the text between them is not
+	fkp := make([]byte, 512)
+	fcs := []uint32{mockTextOffset, uint32(mockTextOffset + first), uint32(mockTextOffset + first + 1), uint32(mockTextOffset + second), uint32(mockTextOffset + second + 1), textEnd}
+	for i, fc := range fcs {
+		binary.LittleEndian.PutUint32(fkp[i*4:], fc)
+	}
+	rgb := len(fcs) * 4
+	fkp[rgb+1] = 0x40 // Anchors share the CHPX at byte offset 0x80
+	fkp[rgb+3] = 0x40
+	copy(fkp[0x80:], []byte{0x03, 0x55, 0x08, 0x01}) // sprmCFSpec
+	fkp[511] = byte(len(fcs) - 1)
+
+	var bte []byte
+	for _, v := range []uint32{mockTextOffset, textEnd, mockFirstPage} {
+		bte = binary.LittleEndian.AppendUint32(bte, v)
+	}
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   bte,
+		fcLcb:   map[int]uint32{24: 0, 25: uint32(len(bte))},
+		pages:   [][]byte{fkp},
+		streams: []mockStream{{
+			name: "ObjectPool",
+			data: buildObjectPool(
+				mockObject{objType: 0x0002, header: oleObjectHeader("Excel.Sheet.8"), payload: []byte("sheet")},
+				mockObject{objType: 0x0005, payload: []byte("chart data")},
+			),
+		}},
+	})
+
+	objs, err := doc.GetEmbeddedObjects()
+	if err != nil {
+		t.Fatalf("GetEmbeddedObjects failed: %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("Expected 2 objects, got %d", len(objs))
+	}
+	if obj := objs[uint32(first)]; obj == nil || obj.ClassName != "Excel.Sheet.8" {
+		t.Errorf("Expected the worksheet at CP %d, got %+v", first, obj)
+	}
+	if obj := objs[uint32(second)]; obj == nil || obj.Type != objects.ObjectTypeChart {
+		t.Errorf("Expected the chart at CP %d, got %+v", second, obj)
+	}
+	if obj, err := doc.GetEmbeddedObject(uint32(second)); err != nil || obj.Position != uint32(second) {
+		t.Errorf("Expected GetEmbeddedObject to find the chart by its CP, got %+v (err: %v)", obj, err)
+	}
+
+	// Loading again and iterating report the same positions
+	if objs, err := doc.GetEmbeddedObjects(); err != nil || len(objs) != 2 || objs[uint32(first)] == nil {
+		t.Errorf("Expected the same objects when loaded again, got %d (err: %v)", len(objs), err)
+	}
+	var positions []uint32
+	err = doc.ForEachObject(func(obj *msdoc.EmbeddedObject) error {
+		positions = append(positions, obj.Position)
+		return nil
+	})
+	if err != nil || len(positions) != 2 || positions[0] != uint32(first) || positions[1] != uint32(second) {
+		t.Errorf("Expected ForEachObject positions [%d %d], got %v (err: %v)", first, second, positions, err)
+	}
+}
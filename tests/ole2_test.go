@@ -3,7 +3,13 @@ package tests
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 	"unicode/utf16"
 
@@ -165,3 +171,627 @@ func TestOLE2RealWordDocs(t *testing.T) {
 		}
 	}
 }
+
+func TestOLE2ReaderManyDirectoryEntries(t *testing.T) {
+	// 220 streams need 56 directory sectors
+	const streamCount = 220
+	w := ole2.NewWriter()
+	for i := 0; i < streamCount; i++ {
+		w.AddStream(fmt.Sprintf("Stream%03d", i), []byte(fmt.Sprintf("data %d", i)))
+	}
+	var buf bytes.Buffer
+	if err := w.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+
+	reader, err := ole2.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	if got := len(reader.ListStreams()); got != streamCount {
+		t.Fatalf("Expected %d streams, got %d", streamCount, got)
+	}
+	for _, i := range []int{0, 150, streamCount - 1} {
+		data, err := reader.ReadStream(fmt.Sprintf("Stream%03d", i))
+		if err != nil {
+			t.Fatalf("ReadStream failed for stream %d: %v", i, err)
+		}
+		if want := fmt.Sprintf("data %d", i); string(data) != want {
+			t.Errorf("Expected %q, got %q", want, data)
+		}
+	}
+}
+
+func TestOLE2StreamCLSID(t *testing.T) {
+	data := buildCompoundFile([]mockStream{
+		{name: "WordDocument", data: []byte("word")},
+		{name: "ObjectPool"},
+		{name: "_1234567890"},
+		{name: "\x01CompObj", data: []byte("compobj")},
+	})
+
+	// Turn ObjectPool and _1234567890 into nested storages:
+	// Root -> WordDocument, ObjectPool -> _1234567890 -> \x01CompObj
+	dirOffset := (int(binary.LittleEndian.Uint32(data[48:])) + 1) * 512
+	entry := func(i int) []byte { return data[dirOffset+i*128 : dirOffset+(i+1)*128] }
+	excelCLSID := [16]byte{0x20, 0x08, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}
+	for _, i := range []int{2, 3} {
+		entry(i)[66] = 1                                          // Storage
+		binary.LittleEndian.PutUint32(entry(i)[72:], 0xFFFFFFFF)  // No right sibling
+		binary.LittleEndian.PutUint32(entry(i)[76:], uint32(i+1)) // Child
+		binary.LittleEndian.PutUint32(entry(i)[116:], 0xFFFFFFFE) // No data
+		binary.LittleEndian.PutUint64(entry(i)[120:], 0)
+	}
+	copy(entry(3)[80:96], excelCLSID[:])
+
+	reader, err := ole2.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	clsid, err := reader.StreamCLSID("ObjectPool/_1234567890")
+	if err != nil {
+		t.Fatalf("StreamCLSID failed: %v", err)
+	}
+	if clsid != excelCLSID {
+		t.Errorf("Expected CLSID % X, got % X", excelCLSID, clsid)
+	}
+
+	// Streams normally have a zero CLSID
+	clsid, err = reader.StreamCLSID("ObjectPool/_1234567890/\x01CompObj")
+	if err != nil {
+		t.Fatalf("StreamCLSID failed for a stream: %v", err)
+	}
+	if clsid != ([16]byte{}) {
+		t.Errorf("Expected a zero CLSID, got % X", clsid)
+	}
+
+	if _, err := reader.StreamCLSID("_1234567890"); err == nil {
+		t.Error("Expected an error for an entry that is not in the root storage")
+	}
+}
+
+func TestOLE2ReaderSizeLimits(t *testing.T) {
+	data := buildCompoundFile([]mockStream{
+		{name: "Small", data: bytes.Repeat([]byte{1}, 100)},
+		{name: "Large", data: bytes.Repeat([]byte{2}, 2000)},
+	})
+
+	reader, err := ole2.NewReaderWithOptions(bytes.NewReader(data), ole2.ReaderOptions{MaxStreamSize: 1024})
+	if err != nil {
+		t.Fatalf("NewReaderWithOptions failed: %v", err)
+	}
+	if _, err := reader.ReadStream("Large"); !errors.Is(err, ole2.ErrSizeLimit) {
+		t.Errorf("Expected ErrSizeLimit for an oversized stream, got %v", err)
+	}
+	if small, err := reader.ReadStream("Small"); err != nil || len(small) != 100 {
+		t.Errorf("Expected the small stream to be read, got %d bytes (%v)", len(small), err)
+	}
+
+	// The total limit counts every stream read
+	reader, err = ole2.NewReaderWithOptions(bytes.NewReader(data), ole2.ReaderOptions{MaxTotalSize: 250})
+	if err != nil {
+		t.Fatalf("NewReaderWithOptions failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := reader.ReadStream("Small"); err != nil {
+			t.Fatalf("Read %d of the small stream failed: %v", i+1, err)
+		}
+	}
+	if _, err := reader.ReadStream("Small"); !errors.Is(err, ole2.ErrSizeLimit) {
+		t.Errorf("Expected ErrSizeLimit once the total is exceeded, got %v", err)
+	}
+
+	// Without options there is no limit
+	reader, err = ole2.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if large, err := reader.ReadStream("Large"); err != nil || len(large) != 2000 {
+		t.Errorf("Expected the large stream to be read, got %d bytes (%v)", len(large), err)
+	}
+}
+
+// recordingReaderAt records the offsets read from the underlying reader.
+type recordingReaderAt struct {
+	r       io.ReaderAt
+	offsets []int64
+}
+
+func (r *recordingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.offsets = append(r.offsets, off)
+	return r.r.ReadAt(p, off)
+}
+
+func TestOLE2ReaderLazy(t *testing.T) {
+	data := buildCompoundFile([]mockStream{{name: "Stream", data: []byte("lazy data")}})
+
+	// Opening lazily only reads the header
+	recorder := &recordingReaderAt{r: bytes.NewReader(data)}
+	reader, err := ole2.NewReaderLazy(recorder)
+	if err != nil {
+		t.Fatalf("NewReaderLazy failed: %v", err)
+	}
+	if !slices.Equal(recorder.offsets, []int64{0}) {
+		t.Errorf("Expected only the header to be read, got reads at %v", recorder.offsets)
+	}
+
+	// The first lookup loads the FAT and the directory
+	if got := reader.ListStreams(); !slices.Equal(got, []string{"Stream"}) {
+		t.Errorf("Expected [Stream], got %v", got)
+	}
+	if stream, err := reader.ReadStream("Stream"); err != nil || string(stream) != "lazy data" {
+		t.Errorf("Expected %q, got %q (%v)", "lazy data", stream, err)
+	}
+
+	// An eager reader reads the directory while opening
+	recorder = &recordingReaderAt{r: bytes.NewReader(data)}
+	if _, err := ole2.NewReader(recorder); err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if len(recorder.offsets) < 3 {
+		t.Errorf("Expected the FAT and directory to be read, got reads at %v", recorder.offsets)
+	}
+
+	// A damaged directory is reported by the lookups of a lazy reader
+	damaged := slices.Clone(data)
+	binary.LittleEndian.PutUint32(damaged[48:], 0x7FFFFF00) // Directory beyond the end of the file
+	if _, err := ole2.NewReader(bytes.NewReader(damaged)); err == nil {
+		t.Error("Expected NewReader to fail for a missing directory")
+	}
+	if reader, err = ole2.NewReaderLazy(bytes.NewReader(damaged)); err != nil {
+		t.Fatalf("NewReaderLazy failed: %v", err)
+	}
+	if _, err := reader.ReadStream("Stream"); err == nil {
+		t.Error("Expected ReadStream to fail for a missing directory")
+	}
+	if _, err := ole2.NewReaderLazy(bytes.NewReader(make([]byte, 512))); err == nil {
+		t.Error("Expected NewReaderLazy to check the signature")
+	}
+}
+
+func TestOLE2WriterDeterministic(t *testing.T) {
+	streams := []mockStream{
+		{name: "Data", data: []byte("data")},
+		{name: "\x05SummaryInformation", data: []byte("summary")},
+		{name: "ObjectPool/_1/\x01Ole", data: []byte("ole")},
+		{name: "1Table", data: []byte("table")},
+		{name: "\x05DocumentSummaryInformation", data: []byte("document summary")},
+		{name: "WordDocument", data: []byte("word")},
+	}
+	write := func(order []int) []byte {
+		w := ole2.NewWriter()
+		for _, i := range order {
+			w.AddStream(streams[i].name, streams[i].data)
+		}
+		var buf bytes.Buffer
+		if err := w.WriteTo(&buf); err != nil {
+			t.Fatalf("WriteTo failed: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	first := write([]int{0, 1, 2, 3, 4, 5})
+	for _, order := range [][]int{{0, 1, 2, 3, 4, 5}, {5, 4, 3, 2, 1, 0}, {2, 5, 0, 3, 1, 4}} {
+		if !bytes.Equal(write(order), first) {
+			t.Fatalf("Expected identical output for insertion order %v", order)
+		}
+	}
+
+	// The data sectors follow the header and the single FAT sector in the
+	// order WordDocument, table, property sets, then the rest by name
+	for i, want := range []string{"word", "table", "document summary", "summary", "data", "ole"} {
+		offset := (2 + i) * 512
+		if got := string(first[offset : offset+len(want)]); got != want {
+			t.Errorf("Sector %d: expected %q, got %q", 1+i, want, got)
+		}
+	}
+}
+
+func TestOLE2LongStreamNames(t *testing.T) {
+	name31 := "ABCDEFGHIJKLMNOPQRSTUVWXYZ01234"      // The longest valid name
+	astral := strings.Repeat("x", 29) + "\U0001F4C4" // 31 UTF-16 units
+
+	w := ole2.NewWriter()
+	w.AddStream(name31, []byte("thirty-one"))
+	w.AddStream(astral, []byte("astral"))
+	var buf bytes.Buffer
+	if err := w.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	reader, err := ole2.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	for name, want := range map[string]string{name31: "thirty-one", astral: "astral"} {
+		if data, err := reader.ReadStream(name); err != nil || string(data) != want {
+			t.Errorf("ReadStream(%q) = %q, %v; want %q", name, data, err, want)
+		}
+	}
+	if names := reader.ListStreams(); !slices.Contains(names, name31) || !slices.Contains(names, astral) {
+		t.Errorf("Expected both names in full, got %q", names)
+	}
+
+	// A name needing 32 units does not fit next to its terminator
+	w.AddStream(astral+"y", []byte("too long"))
+	if err := w.WriteTo(io.Discard); err == nil {
+		t.Error("Expected an error for a name of 32 UTF-16 units")
+	}
+
+	// Some producers fill all 32 units and leave out the terminator
+	name32 := name31 + "5"
+	reader, err = ole2.NewReader(bytes.NewReader(buildCompoundFile([]mockStream{{name: name32, data: []byte("full")}})))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if data, err := reader.ReadStream(name32); err != nil || string(data) != "full" {
+		t.Errorf("ReadStream(%q) = %q, %v; want %q", name32, data, err, "full")
+	}
+}
+
+// TestOLE2MiniStream reads a small stream from the mini stream. Its mini
+// sectors are out of order, so it only reads correctly by following the mini
+// FAT, and its starting mini sector, read as a regular sector, holds the
+// mini stream itself.
+func TestOLE2MiniStream(t *testing.T) {
+	const sectorSize = 512
+	small := bytes.Repeat([]byte("mini"), 25) // 100 bytes: mini sectors 2 then 5
+	large := bytes.Repeat([]byte("L"), 4096)  // At the cutoff: regular sectors 4-11
+
+	newSector := func() []byte { return make([]byte, sectorSize) }
+	header, fat, miniFAT, miniStream, dir := newSector(), newSector(), newSector(), newSector(), newSector()
+
+	binary.LittleEndian.PutUint64(header[0:], 0xE11AB1A1E011CFD0)
+	binary.LittleEndian.PutUint16(header[24:], 0x003E)
+	binary.LittleEndian.PutUint16(header[26:], 0x0003)
+	binary.LittleEndian.PutUint16(header[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(header[30:], 0x0009)
+	binary.LittleEndian.PutUint16(header[32:], 0x0006)
+	binary.LittleEndian.PutUint32(header[44:], 1) // One FAT sector
+	binary.LittleEndian.PutUint32(header[48:], 3) // Directory
+	binary.LittleEndian.PutUint32(header[56:], 4096)
+	binary.LittleEndian.PutUint32(header[60:], 1) // Mini FAT
+	binary.LittleEndian.PutUint32(header[64:], 1)
+	binary.LittleEndian.PutUint32(header[68:], 0xFFFFFFFE)
+	for i := 76; i < sectorSize; i += 4 {
+		binary.LittleEndian.PutUint32(header[i:], 0xFFFFFFFF)
+	}
+	binary.LittleEndian.PutUint32(header[76:], 0)
+
+	for i := 0; i < sectorSize; i += 4 {
+		binary.LittleEndian.PutUint32(fat[i:], 0xFFFFFFFF)
+		binary.LittleEndian.PutUint32(miniFAT[i:], 0xFFFFFFFF)
+	}
+	binary.LittleEndian.PutUint32(fat[0:], 0xFFFFFFFD)
+	for _, s := range []int{1, 2, 3, 11} {
+		binary.LittleEndian.PutUint32(fat[s*4:], 0xFFFFFFFE)
+	}
+	for s := 4; s < 11; s++ {
+		binary.LittleEndian.PutUint32(fat[s*4:], uint32(s+1))
+	}
+	binary.LittleEndian.PutUint32(miniFAT[2*4:], 5)
+	binary.LittleEndian.PutUint32(miniFAT[5*4:], 0xFFFFFFFE)
+
+	for i := range miniStream {
+		miniStream[i] = 0xEE
+	}
+	copy(miniStream[2*64:], small[:64])
+	copy(miniStream[5*64:], small[64:])
+
+	writeEntry := func(index int, name string, objectType byte, child, right, start int32, size int) {
+		entry := dir[index*128:]
+		for i, u := range strToUtf16(name) {
+			binary.LittleEndian.PutUint16(entry[i*2:], u)
+		}
+		binary.LittleEndian.PutUint16(entry[64:], uint16(len(strToUtf16(name))*2))
+		entry[66] = objectType
+		entry[67] = 1
+		binary.LittleEndian.PutUint32(entry[68:], 0xFFFFFFFF)
+		binary.LittleEndian.PutUint32(entry[72:], uint32(right))
+		binary.LittleEndian.PutUint32(entry[76:], uint32(child))
+		binary.LittleEndian.PutUint32(entry[116:], uint32(start))
+		binary.LittleEndian.PutUint64(entry[120:], uint64(size))
+	}
+	writeEntry(0, "Root Entry", 5, 1, -1, 2, sectorSize)
+	writeEntry(1, "Small", 2, -1, 2, 2, len(small))
+	writeEntry(2, "Large", 2, -1, -1, 4, len(large))
+
+	var file []byte
+	for _, s := range [][]byte{header, fat, miniFAT, miniStream, dir, large} {
+		file = append(file, s...)
+	}
+	reader, err := ole2.NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	for name, want := range map[string][]byte{"Small": small, "Large": large} {
+		got, err := reader.ReadStream(name)
+		if err != nil {
+			t.Fatalf("ReadStream(%s) failed: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Expected %s to hold %d bytes of its data, got %q", name, len(want), got)
+		}
+	}
+
+	// A mini FAT chain leaving the mini stream is an error
+	binary.LittleEndian.PutUint32(file[2*sectorSize+2*4:], 100)
+	if reader, err = ole2.NewReader(bytes.NewReader(file)); err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if _, err := reader.ReadStream("Small"); err == nil {
+		t.Error("Expected an error for a mini sector outside the mini stream")
+	}
+}
+
+// describeTree lists the entries below e as indented "name (storage)" or
+// "name (size)" lines, sorting the children of each storage by name.
+func describeTree(e *ole2.Entry, indent string) []string {
+	children := slices.Clone(e.Children)
+	slices.SortFunc(children, func(a, b *ole2.Entry) int { return strings.Compare(a.Name, b.Name) })
+
+	var lines []string
+	for _, child := range children {
+		if child.IsStorage() {
+			lines = append(lines, fmt.Sprintf("%s%s (storage)", indent, child.Name))
+			lines = append(lines, describeTree(child, indent+"  ")...)
+		} else {
+			lines = append(lines, fmt.Sprintf("%s%s (%d)", indent, child.Name, child.Size))
+		}
+	}
+	return lines
+}
+
+func TestOLE2Tree(t *testing.T) {
+	w := ole2.NewWriter()
+	w.AddStream("WordDocument", make([]byte, 10))
+	w.AddStream("Macros/PROJECT", make([]byte, 3))
+	w.AddStream("Macros/VBA/dir", make([]byte, 4))
+	w.AddStream("Macros/VBA/Module1", []byte("Sub Main()"))
+	w.AddStream("ObjectPool/_1/\x01Ole10Native", make([]byte, 5))
+	var buf bytes.Buffer
+	if err := w.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	reader, err := ole2.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	root, err := reader.Tree()
+	if err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	if root.Type != ole2.EntryRoot || !root.IsStorage() {
+		t.Errorf("Expected the root storage, got type %d", root.Type)
+	}
+	want := []string{
+		"Macros (storage)",
+		"  PROJECT (3)",
+		"  VBA (storage)",
+		"    Module1 (10)",
+		"    dir (4)",
+		"ObjectPool (storage)",
+		"  _1 (storage)",
+		"    \x01Ole10Native (5)",
+		"WordDocument (10)",
+	}
+	if got := describeTree(root, ""); !slices.Equal(got, want) {
+		t.Errorf("Unexpected tree:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if data, err := reader.ReadStream("Macros/VBA/Module1"); err != nil || string(data) != "Sub Main()" {
+		t.Errorf("Expected the nested module stream, got %q (%v)", data, err)
+	}
+	if _, err := reader.ReadStream("Macros/VBA"); err == nil {
+		t.Error("Expected an error reading a storage as a stream")
+	}
+
+	// The directory of sample-4 lies beyond the FAT sectors listed in the
+	// header, so it is only found through the DIFAT sector chain
+	file, err := os.Open("testdata/sample-4.doc")
+	if err != nil {
+		t.Fatalf("Failed to open sample: %v", err)
+	}
+	defer file.Close()
+	if reader, err = ole2.NewReader(file); err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if root, err = reader.Tree(); err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	names := make(map[string]*ole2.Entry)
+	for _, child := range root.Children {
+		names[child.Name] = child
+	}
+	for _, name := range []string{"WordDocument", "1Table", "\x05SummaryInformation", "\x05DocumentSummaryInformation"} {
+		if entry := names[name]; entry == nil || entry.IsStorage() {
+			t.Errorf("Expected stream %q at the root", name)
+		}
+	}
+	if store := names["MsoDataStore"]; store == nil || !store.IsStorage() || len(store.Children) != 1 || len(store.Children[0].Children) != 2 {
+		t.Errorf("Expected MsoDataStore to hold one storage of two streams, got %+v", store)
+	}
+}
+
+// rawDirectoryEntry holds the fields of a directory entry that describe the
+// red-black tree of a storage's children.
+type rawDirectoryEntry struct {
+	name               string
+	color              byte
+	left, right, child uint32
+}
+
+// readRawDirectory reads the directory entries of a compound file written
+// with 512-byte sectors and a single FAT sector.
+func readRawDirectory(t *testing.T, file []byte) []rawDirectoryEntry {
+	t.Helper()
+	fat := file[(binary.LittleEndian.Uint32(file[76:])+1)*512:][:512]
+	var entries []rawDirectoryEntry
+	for sector := binary.LittleEndian.Uint32(file[48:]); sector < 0xFFFFFFFA; sector = binary.LittleEndian.Uint32(fat[sector*4:]) {
+		for offset := (sector + 1) * 512; offset < (sector+2)*512; offset += 128 {
+			raw := file[offset : offset+128]
+			units := make([]uint16, 32)
+			for i := range units {
+				units[i] = binary.LittleEndian.Uint16(raw[i*2:])
+			}
+			nameLength := int(binary.LittleEndian.Uint16(raw[64:]))
+			if nameLength < 2 {
+				nameLength = 2
+			}
+			entries = append(entries, rawDirectoryEntry{
+				name:  string(utf16.Decode(units[:nameLength/2-1])),
+				color: raw[67],
+				left:  binary.LittleEndian.Uint32(raw[68:]),
+				right: binary.LittleEndian.Uint32(raw[72:]),
+				child: binary.LittleEndian.Uint32(raw[76:]),
+			})
+		}
+	}
+	return entries
+}
+
+// compareCFBNames compares names as MS-CFB orders siblings: by length, then
+// by uppercase UTF-16 code units.
+func compareCFBNames(a, b string) int {
+	unitsA, unitsB := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	if len(unitsA) != len(unitsB) {
+		return len(unitsA) - len(unitsB)
+	}
+	return slices.Compare(
+		utf16.Encode([]rune(strings.ToUpper(string(utf16.Decode(unitsA))))),
+		utf16.Encode([]rune(strings.ToUpper(string(utf16.Decode(unitsB))))),
+	)
+}
+
+func TestOLE2WriterDirectoryOrder(t *testing.T) {
+	w := ole2.NewWriter()
+	for _, name := range []string{
+		"WordDocument", "1Table", "\x05SummaryInformation", "\x05DocumentSummaryInformation",
+		"Data", "b", "A", "ObjectPool/_2/\x01Ole", "ObjectPool/_10/\x01Ole",
+		"ObjectPool/_1/\x01Ole", "ObjectPool/_1/\x01CompObj", "ObjectPool/_1/\x03ObjInfo",
+		"Macros/VBA/dir", "Macros/VBA/_VBA_PROJECT", "Macros/VBA/Module1", "Macros/PROJECT",
+	} {
+		w.AddStream(name, []byte(name))
+	}
+	var buf bytes.Buffer
+	if err := w.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	entries := readRawDirectory(t, buf.Bytes())
+
+	// Each storage's children form a binary search tree in CFB order, with
+	// no red node under a red parent and the same black height on every path
+	var checkStorage func(path string, index uint32)
+	checkStorage = func(path string, index uint32) {
+		var names []string
+		var walk func(node uint32, parentRed bool) int
+		walk = func(node uint32, parentRed bool) int {
+			if node == 0xFFFFFFFF {
+				return 1
+			}
+			if int(node) >= len(entries) {
+				t.Fatalf("%s: sibling %d out of range", path, node)
+			}
+			entry := entries[node]
+			red := entry.color == 0
+			if red && parentRed {
+				t.Errorf("%s: red entry %q has a red parent", path, entry.name)
+			}
+			left := walk(entry.left, red)
+			names = append(names, entry.name)
+			if entry.child != 0xFFFFFFFF {
+				checkStorage(path+"/"+entry.name, entry.child)
+			}
+			if right := walk(entry.right, red); right != left {
+				t.Errorf("%s: black heights %d and %d below %q", path, left, right, entry.name)
+			}
+			if red {
+				return left
+			}
+			return left + 1
+		}
+		if root := entries[index]; root.color == 0 {
+			t.Errorf("%s: the tree root %q is red", path, root.name)
+		}
+		walk(index, false)
+		for i := 1; i < len(names); i++ {
+			if compareCFBNames(names[i-1], names[i]) >= 0 {
+				t.Errorf("%s: siblings out of order: %q", path, names)
+				break
+			}
+		}
+	}
+	checkStorage("", entries[0].child)
+
+	reader, err := ole2.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if got := len(reader.ListStreams()); got != 16 {
+		t.Errorf("Expected 16 streams, got %d", got)
+	}
+	for _, name := range []string{"A", "b", "ObjectPool/_10/\x01Ole", "Macros/VBA/_VBA_PROJECT", "\x05DocumentSummaryInformation"} {
+		if data, err := reader.ReadStream(name); err != nil || string(data) != name {
+			t.Errorf("ReadStream(%q) = %q, %v", name, data, err)
+		}
+	}
+}
+
+func TestOLE2StreamPaths(t *testing.T) {
+	streams := map[string]string{
+		"WordDocument":              "word",
+		"\x01CompObj":               "root compobj",
+		"Macros/VBA/dir":            "vba dir",
+		"Macros/VBA/Module1":        "module",
+		"ObjectPool/_1/dir":         "object dir",
+		"ObjectPool/_1/\x01CompObj": "object compobj",
+	}
+	w := ole2.NewWriter()
+	for name, data := range streams {
+		w.AddStream(name, []byte(data))
+	}
+	var buf bytes.Buffer
+	if err := w.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	reader, err := ole2.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	paths := reader.ListStreams()
+	slices.Sort(paths)
+	want := slices.Sorted(maps.Keys(streams))
+	if !slices.Equal(paths, want) {
+		t.Errorf("Expected stream paths %q, got %q", want, paths)
+	}
+	storages := reader.ListStorages()
+	slices.Sort(storages)
+	if want := []string{"Macros", "Macros/VBA", "ObjectPool", "ObjectPool/_1"}; !slices.Equal(storages, want) {
+		t.Errorf("Expected storage paths %q, got %q", want, storages)
+	}
+
+	// Every listed path reads its own stream
+	for _, path := range paths {
+		if data, err := reader.ReadStream(path); err != nil || string(data) != streams[path] {
+			t.Errorf("ReadStream(%q): expected %q, got %q (%v)", path, streams[path], data, err)
+		}
+	}
+
+	// Bare names resolve to the root storage first, then to a unique
+	// nested stream
+	for name, want := range map[string]string{"\x01CompObj": "root compobj", "Module1": "module"} {
+		if data, err := reader.ReadStream(name); err != nil || string(data) != want {
+			t.Errorf("ReadStream(%q): expected %q, got %q (%v)", name, want, data, err)
+		}
+	}
+	if _, err := reader.ReadStream("dir"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Expected an ambiguous name error for dir, got %v", err)
+	}
+	if _, err := reader.ReadStream("Macros/Module1"); err == nil {
+		t.Error("Expected an error for a path to a missing stream")
+	}
+}
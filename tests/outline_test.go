@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestOutlineMarkdown(t *testing.T) {
+	heading := func(name string, level byte) *mockStyle {
+		return &mockStyle{name: name, istdBase: 0, papx: []byte{0x40, 0x26, level}} // sprmPOutLvl
+	}
+	stsh := buildSTSH(
+		&mockStyle{name: "Normal", istdBase: 0x0FFF},
+		heading("Heading 1", 0),
+		heading("Heading 2", 1),
+		heading("Heading 3", 2),
+	)
+
+	paragraphs := []struct {
+		text string
+		istd byte
+	}{
+		{"Report\r", 1},
+		{"Some introduction.\r", 0},
+		{"Background\r", 2},
+		{"History\r", 3},
+		{"Details follow.\r", 0},
+		{"\r", 2}, // Empty headings are left out
+		{"Results\r", 2},
+	}
+	var text string
+	fcs := []uint32{mockTextOffset}
+	var papxs [][]byte
+	for _, para := range paragraphs {
+		text += para.text
+		fcs = append(fcs, uint32(mockTextOffset+len(text)))
+		papxs = append(papxs, []byte{para.istd, 0x00})
+	}
+	page := buildPAPXPage(fcs, papxs)
+	var bte []byte
+	for _, v := range []uint32{mockTextOffset, fcs[len(fcs)-1], mockFirstPage} {
+		bte = binary.LittleEndian.AppendUint32(bte, v)
+	}
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   append(stsh, bte...),
+		fcLcb: map[int]uint32{
+			2: 0, 3: uint32(len(stsh)),
+			26: uint32(len(stsh)), 27: uint32(len(bte)),
+		},
+		pages: [][]byte{page},
+	})
+
+	markdown, err := doc.OutlineMarkdown()
+	if err != nil {
+		t.Fatalf("OutlineMarkdown failed: %v", err)
+	}
+	want := "# Report\n## Background\n### History\n## Results\n"
+	if markdown != want {
+		t.Errorf("Expected outline\n%s\ngot\n%s", want, markdown)
+	}
+
+	// Documents without headings have an empty outline
+	plain := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Just text\r"}}})
+	if markdown, err := plain.OutlineMarkdown(); err != nil || markdown != "" {
+		t.Errorf("Expected an empty outline, got %q (err: %v)", markdown, err)
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// buildPAPXPage encodes a PAPX FKP page for paragraphs delimited by fcs,
+// storing papxs[i] (style index and sprms) for paragraph i, or default
+// properties when papxs[i] is nil.
+func buildPAPXPage(fcs []uint32, papxs [][]byte) []byte {
+	page := make([]byte, 512)
+	for i, fc := range fcs {
+		binary.LittleEndian.PutUint32(page[i*4:], fc)
+	}
+	bxStart := len(fcs) * 4
+
+	// PAPXs are stored from the end of the page, word aligned
+	end := 511
+	for i, papx := range papxs {
+		if papx == nil {
+			continue
+		}
+		var encoded []byte
+		if len(papx)%2 == 1 {
+			encoded = append([]byte{byte((len(papx) + 1) / 2)}, papx...)
+		} else {
+			encoded = append([]byte{0, byte(len(papx) / 2)}, papx...)
+		}
+		start := (end - len(encoded)) &^ 1
+		copy(page[start:], encoded)
+		page[bxStart+i*13] = byte(start / 2)
+		end = start
+	}
+	page[511] = byte(len(papxs))
+	return page
+}
+
+func TestParagraphTableFlags(t *testing.T) {
+	// Body paragraph, a one-row table with two cells, then another body paragraph
+	text := "Intro\rA1\x07B1\x07\x07After\r"
+	fc := func(cp uint32) uint32 { return mockTextOffset + cp*2 }
+
+	inCell := []byte{0, 0, 0x16, 0x24, 1}
+	rowEnd := []byte{0, 0, 0x16, 0x24, 1, 0x17, 0x24, 1}
+	page := buildPAPXPage(
+		[]uint32{fc(0), fc(6), fc(9), fc(12), fc(13), fc(19)},
+		[][]byte{nil, inCell, inCell, rowEnd, nil},
+	)
+
+	// Bin table: one FKP page covering the whole text
+	bte := binary.LittleEndian.AppendUint32(nil, fc(0))
+	bte = binary.LittleEndian.AppendUint32(bte, fc(19))
+	bte = binary.LittleEndian.AppendUint32(bte, mockFirstPage)
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: text, unicode: true}},
+		table:  bte,
+		fcLcb:  map[int]uint32{26: 0, 27: uint32(len(bte))},
+		pages:  [][]byte{page},
+	})
+
+	paragraphs, err := doc.Paragraphs()
+	if err != nil {
+		t.Fatalf("Paragraphs failed: %v", err)
+	}
+
+	expected := []struct {
+		text     string
+		startCP  uint32
+		endCP    uint32
+		inTable  bool
+		depth    int
+		isRowEnd bool
+	}{
+		{"Intro", 0, 6, false, 0, false},
+		{"A1", 6, 9, true, 1, false},
+		{"B1", 9, 12, true, 1, false},
+		{"", 12, 13, true, 1, true},
+		{"After", 13, 19, false, 0, false},
+	}
+	if len(paragraphs) != len(expected) {
+		t.Fatalf("Expected %d paragraphs, got %d: %+v", len(expected), len(paragraphs), paragraphs)
+	}
+	for i, want := range expected {
+		got := paragraphs[i]
+		if got.Text != want.text || got.StartCP != want.startCP || got.EndCP != want.endCP {
+			t.Errorf("Paragraph %d: expected %q at [%d, %d), got %q at [%d, %d)", i, want.text, want.startCP, want.endCP, got.Text, got.StartCP, got.EndCP)
+		}
+		if got.InTable != want.inTable || got.TableDepth != want.depth || got.IsRowEnd != want.isRowEnd {
+			t.Errorf("Paragraph %d: expected InTable=%v TableDepth=%d IsRowEnd=%v, got %v %d %v", i, want.inTable, want.depth, want.isRowEnd, got.InTable, got.TableDepth, got.IsRowEnd)
+		}
+	}
+}
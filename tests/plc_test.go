@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"testing"
 
+	"github.com/TalentFormula/msdoc/pkg"
 	"github.com/TalentFormula/msdoc/structures"
 )
 
@@ -82,6 +83,40 @@ func TestPLCInvalidData(t *testing.T) {
 	}
 }
 
+func TestPLCSingleCP(t *testing.T) {
+	// One CP and no data elements
+	plc, err := structures.ParsePLC([]byte{0x2A, 0, 0, 0}, 12)
+	if err != nil {
+		t.Fatalf("ParsePLC failed for a single-CP PLC: %v", err)
+	}
+	if plc.Count() != 0 || len(plc.CPs) != 1 || plc.CPs[0] != 42 {
+		t.Errorf("Expected no elements and CP 42, got %d elements and CPs %v", plc.Count(), plc.CPs)
+	}
+	if err := plc.Validate(); err != nil {
+		t.Errorf("Expected an empty PLC to be valid, got %v", err)
+	}
+	if _, _, err := plc.GetRange(0); err == nil {
+		t.Error("Expected an error for a range of an empty PLC")
+	}
+
+	// Documents whose field and comment PLCs are empty have neither
+	empty := binary.LittleEndian.AppendUint32(nil, 0)
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Nothing here\r"}},
+		table:  empty,
+		fcLcb: map[int]uint32{
+			8: 0, 9: 4, // PlcfandRef
+			32: 0, 33: 4, // PlcffldMom
+		},
+	})
+	if fields, err := doc.Fields(msdoc.SubdocumentMain); err != nil || fields != nil {
+		t.Errorf("Expected no fields, got %v (err: %v)", fields, err)
+	}
+	if comments, err := doc.Comments(); err != nil || comments != nil {
+		t.Errorf("Expected no comments, got %v (err: %v)", comments, err)
+	}
+}
+
 func TestPCDParsing(t *testing.T) {
 	// Create a mock PCD (8 bytes)
 	pcdData := make([]byte, 8)
@@ -90,8 +125,8 @@ func TestPCDParsing(t *testing.T) {
 	flags := uint16(0x0003) // fNoEncryption | fComplex
 	binary.LittleEndian.PutUint16(pcdData[0:], flags)
 
-	// Set FC (next 4 bytes) with Unicode flag
-	fc := uint32(0x40001000) // Unicode text at position 0x1000
+	// Set FC (next 4 bytes) with the compression flag
+	fc := uint32(0x40001000) // ANSI text at position 0x1000 / 2
 	binary.LittleEndian.PutUint32(pcdData[2:], fc)
 
 	// Parse PCD
@@ -107,8 +142,8 @@ func TestPCDParsing(t *testing.T) {
 	if !pcd.FComplex {
 		t.Error("Expected FComplex to be true")
 	}
-	if !pcd.IsUnicode {
-		t.Error("Expected IsUnicode to be true")
+	if pcd.IsUnicode {
+		t.Error("Expected IsUnicode to be false")
 	}
 
 	// Check FC
@@ -119,7 +154,7 @@ func TestPCDParsing(t *testing.T) {
 
 	// Check actual FC calculation
 	actualFC := pcd.GetActualFC()
-	expectedActualFC := uint32(0x1000 / 2) // Unicode FC is divided by 2
+	expectedActualFC := uint32(0x1000 / 2) // Compressed FC is divided by 2
 	if actualFC != expectedActualFC {
 		t.Errorf("Expected actual FC %d, got %d", expectedActualFC, actualFC)
 	}
@@ -139,13 +174,13 @@ func TestPlcPcdParsing(t *testing.T) {
 	// Write PCDs
 	// PCD 1: ANSI text
 	offset := 12
-	binary.LittleEndian.PutUint16(plcData[offset:], 0x0001)   // fNoEncryption
-	binary.LittleEndian.PutUint32(plcData[offset+2:], 0x2000) // FC (ANSI)
+	binary.LittleEndian.PutUint16(plcData[offset:], 0x0001)       // fNoEncryption
+	binary.LittleEndian.PutUint32(plcData[offset+2:], 0x40002000) // FC (ANSI)
 
 	// PCD 2: Unicode text
 	offset = 20
-	binary.LittleEndian.PutUint16(plcData[offset:], 0x0000)       // No flags
-	binary.LittleEndian.PutUint32(plcData[offset+2:], 0x40003000) // FC (Unicode)
+	binary.LittleEndian.PutUint16(plcData[offset:], 0x0000)   // No flags
+	binary.LittleEndian.PutUint32(plcData[offset+2:], 0x3000) // FC (Unicode)
 
 	// Parse PlcPcd
 	plcPcd, err := structures.ParsePlcPcd(plcData)
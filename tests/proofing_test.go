@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+// buildProofingPLC encodes a PlcfSpl or PlcfGram with the given CPs and states.
+func buildProofingPLC(cps []uint32, states []uint16) []byte {
+	var data []byte
+	for _, cp := range cps {
+		data = binary.LittleEndian.AppendUint32(data, cp)
+	}
+	for _, state := range states {
+		data = binary.LittleEndian.AppendUint16(data, state)
+	}
+	return data
+}
+
+func TestProofingErrors(t *testing.T) {
+	text := "The quikc fox are fast.\r"
+
+	// "quikc" is an unknown word; "fox are" is a grammar error
+	spl := buildProofingPLC([]uint32{0, 4, 9, 24}, []uint16{0x07, 0x0C, 0x07})
+	gram := buildProofingPLC([]uint32{0, 10, 17, 24}, []uint16{0x07, 0x0A, 0x07})
+	table := append(append([]byte(nil), spl...), gram...)
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: text}},
+		table:  table,
+		fcLcb: map[int]uint32{
+			110: 0, 111: uint32(len(spl)), // PlcfSpl
+			180: uint32(len(spl)), 181: uint32(len(gram)), // PlcfGram
+		},
+	})
+
+	ranges, err := doc.ProofingErrors()
+	if err != nil {
+		t.Fatalf("ProofingErrors failed: %v", err)
+	}
+
+	expected := []msdoc.ProofingRange{
+		{StartCP: 4, EndCP: 9, Kind: msdoc.ProofingSpelling},
+		{StartCP: 10, EndCP: 17, Kind: msdoc.ProofingGrammar},
+	}
+	if len(ranges) != len(expected) {
+		t.Fatalf("Expected %d proofing errors, got %v", len(expected), ranges)
+	}
+	for i, want := range expected {
+		if ranges[i] != want {
+			t.Errorf("Range %d: expected %+v, got %+v", i, want, ranges[i])
+		}
+	}
+	if word := text[ranges[0].StartCP:ranges[0].EndCP]; word != "quikc" {
+		t.Errorf("Expected the spelling error to cover %q, got %q", "quikc", word)
+	}
+
+	// Documents without proofing tables have no errors
+	doc = openMock(t, &mockDoc{pieces: []mockPiece{{text: text}}})
+	if ranges, err := doc.ProofingErrors(); err != nil || len(ranges) != 0 {
+		t.Errorf("Expected no proofing errors, got %v (err: %v)", ranges, err)
+	}
+}
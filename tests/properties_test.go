@@ -0,0 +1,338 @@
+package tests
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/pkg"
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+func TestHasTrackedChanges(t *testing.T) {
+	// DOP with fRMView and fRMPrint set, as Word for Mac writes by default
+	dop := make([]byte, 84)
+	dop[7] = 0x18
+
+	// Word lists the default author even without revisions
+	unknown := buildSTTB("Unknown")
+	plain := &mockDoc{
+		pieces: []mockPiece{{text: "No revisions"}},
+		table:  append(append([]byte(nil), dop...), unknown...),
+		fcLcb: map[int]uint32{
+			62: 0, 63: uint32(len(dop)),
+			102: uint32(len(dop)), 103: uint32(len(unknown)),
+		},
+	}
+	if openMock(t, plain).HasTrackedChanges() {
+		t.Error("Expected a document without revisions to report no tracked changes")
+	}
+
+	authors := buildSTTB("Reviewer")
+	withAuthors := &mockDoc{
+		pieces: []mockPiece{{text: "Edited text"}},
+		table:  append(append([]byte(nil), dop...), authors...),
+		fcLcb: map[int]uint32{
+			62: 0, 63: uint32(len(dop)),
+			102: uint32(len(dop)), 103: uint32(len(authors)),
+		},
+	}
+	if !openMock(t, withAuthors).HasTrackedChanges() {
+		t.Error("Expected a document with revision authors to report tracked changes")
+	}
+
+	tracking := append([]byte(nil), dop...)
+	tracking[5] |= 0x80 // fRevMarking
+	withTracking := &mockDoc{
+		pieces: []mockPiece{{text: "Tracking on"}},
+		table:  tracking,
+		fcLcb:  map[int]uint32{62: 0, 63: uint32(len(tracking))},
+	}
+	if !openMock(t, withTracking).HasTrackedChanges() {
+		t.Error("Expected a document with revision tracking on to report tracked changes")
+	}
+}
+
+func TestHasTrackedChangesSamples(t *testing.T) {
+	for _, name := range []string{"sample-1.doc", "sample-2.doc", "sample-3.doc", "sample-4.doc"} {
+		t.Run(name, func(t *testing.T) {
+			doc, err := msdoc.Open("testdata/" + name)
+			if err != nil {
+				t.Fatalf("Failed to open document: %v", err)
+			}
+			defer doc.Close()
+
+			if doc.HasTrackedChanges() {
+				t.Error("Expected a document without revisions to report no tracked changes")
+			}
+		})
+	}
+}
+
+func TestParseDOPFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		mask   byte
+		flag   func(*structures.DOP) bool
+	}{
+		{"fRevMarking", 5, 0x80, func(dop *structures.DOP) bool { return dop.FRevMarking }},
+		{"fLockAtn", 6, 0x10, func(dop *structures.DOP) bool { return dop.FLockAtn }},
+		{"fProtEnabled", 7, 0x02, func(dop *structures.DOP) bool { return dop.FProtEnabled }},
+		{"fRMView", 7, 0x08, func(dop *structures.DOP) bool { return dop.FRMView }},
+		{"fRMPrint", 7, 0x10, func(dop *structures.DOP) bool { return dop.FRMPrint }},
+		{"fLockRev", 7, 0x40, func(dop *structures.DOP) bool { return dop.FLockRev }},
+	}
+
+	for _, tt := range tests {
+		data := make([]byte, 84)
+		data[tt.offset] = tt.mask
+		dop, err := structures.ParseDOP(data)
+		if err != nil {
+			t.Fatalf("ParseDOP failed: %v", err)
+		}
+		for _, other := range tests {
+			if got := other.flag(dop); got != (other.name == tt.name) {
+				t.Errorf("With %s set, expected %s to be %v", tt.name, other.name, !got)
+			}
+		}
+	}
+
+	// fPagResults lies next to fLockAtn and sets none of the flags
+	data := make([]byte, 84)
+	data[6] = 0x08
+	dop, err := structures.ParseDOP(data)
+	if err != nil {
+		t.Fatalf("ParseDOP failed: %v", err)
+	}
+	for _, tt := range tests {
+		if tt.flag(dop) {
+			t.Errorf("Expected fPagResults not to set %s", tt.name)
+		}
+	}
+}
+
+func TestDOPStatistics(t *testing.T) {
+	// Word 2003 DOP with statistics in DopBase and Dop97
+	dopData := make([]byte, 616)
+	binary.LittleEndian.PutUint32(dopData[38:], 250)   // cWords
+	binary.LittleEndian.PutUint32(dopData[42:], 1200)  // cCh
+	binary.LittleEndian.PutUint16(dopData[46:], 3)     // cPg
+	binary.LittleEndian.PutUint32(dopData[48:], 12)    // cParas
+	binary.LittleEndian.PutUint32(dopData[56:], 40)    // cLines
+	binary.LittleEndian.PutUint32(dopData[426:], 1450) // cChWS
+
+	dop, err := structures.ParseDOP(dopData)
+	if err != nil {
+		t.Fatalf("ParseDOP failed: %v", err)
+	}
+	if dop.Version != structures.DOPVersion2003 {
+		t.Errorf("Expected a Dop2003, got version %d", dop.Version)
+	}
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Statistics"}},
+		table:  dopData,
+		fcLcb:  map[int]uint32{62: 0, 63: uint32(len(dopData))},
+	})
+	metadata := doc.Metadata()
+
+	if metadata.WordCount != dop.CWords {
+		t.Errorf("Expected word count %d, got %d", dop.CWords, metadata.WordCount)
+	}
+	if metadata.CharCount != dop.CCh {
+		t.Errorf("Expected character count %d, got %d", dop.CCh, metadata.CharCount)
+	}
+	if metadata.CharCountWithSpaces != dop.CChWS {
+		t.Errorf("Expected character count with spaces %d, got %d", dop.CChWS, metadata.CharCountWithSpaces)
+	}
+	if metadata.PageCount != int32(dop.CPg) {
+		t.Errorf("Expected page count %d, got %d", dop.CPg, metadata.PageCount)
+	}
+	if metadata.ParagraphCount != dop.CParas {
+		t.Errorf("Expected paragraph count %d, got %d", dop.CParas, metadata.ParagraphCount)
+	}
+	if metadata.LineCount != dop.CLines {
+		t.Errorf("Expected line count %d, got %d", dop.CLines, metadata.LineCount)
+	}
+}
+
+func TestProtection(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(dop []byte)
+		expected msdoc.ProtectionType
+		password bool
+	}{
+		{"none", func(dop []byte) {}, msdoc.ProtectionNone, false},
+		{"forms", func(dop []byte) {
+			dop[7] |= 0x02                                      // fProtEnabled
+			binary.LittleEndian.PutUint32(dop[78:], 0x1A2B3C4D) // lKeyProtDoc
+		}, msdoc.ProtectionForms, true},
+		{"tracked changes", func(dop []byte) { dop[7] |= 0x40 }, msdoc.ProtectionTrackedChanges, false},
+		{"comments", func(dop []byte) { dop[6] |= 0x10 }, msdoc.ProtectionComments, false},
+		{"read-only", func(dop []byte) {
+			dop[6] |= 0x10   // fLockAtn
+			dop[594] |= 0x01 // fTreatLockAtnAsReadOnly
+		}, msdoc.ProtectionReadOnly, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dop := make([]byte, 616)
+			tt.setup(dop)
+
+			doc := openMock(t, &mockDoc{
+				pieces: []mockPiece{{text: "Protected form"}},
+				table:  dop,
+				fcLcb:  map[int]uint32{62: 0, 63: uint32(len(dop))},
+			})
+			info, err := doc.Protection()
+			if err != nil {
+				t.Fatalf("Protection failed: %v", err)
+			}
+			if info.Type != tt.expected {
+				t.Errorf("Expected protection %v, got %v", tt.expected, info.Type)
+			}
+			if info.HasPassword != tt.password {
+				t.Errorf("Expected HasPassword %v, got %v", tt.password, info.HasPassword)
+			}
+		})
+	}
+}
+
+func TestProtectionNone(t *testing.T) {
+	// The DOP of sample-1 has fPagResults set, next to fLockAtn
+	doc, err := msdoc.Open("testdata/sample-1.doc")
+	if err != nil {
+		t.Fatalf("Failed to open document: %v", err)
+	}
+	defer doc.Close()
+
+	info, err := doc.Protection()
+	if err != nil {
+		t.Fatalf("Protection failed: %v", err)
+	}
+	if info.Type != msdoc.ProtectionNone || info.HasPassword {
+		t.Errorf("Expected an unprotected document, got %+v", info)
+	}
+}
+
+func TestViewState(t *testing.T) {
+	tests := []struct {
+		name     string
+		view     uint16 // wvkoSaved, pctWwdSaved and zkSaved bits
+		expected msdoc.ViewState
+	}{
+		{"print layout at 120%", 1 | 120<<3, msdoc.ViewState{View: msdoc.ViewPrint, ZoomPercent: 120, ZoomType: msdoc.ZoomPercent}},
+		{"web layout fit to width", 5 | 100<<3 | 2<<12, msdoc.ViewState{View: msdoc.ViewWeb, ZoomPercent: 100, ZoomType: msdoc.ZoomPageWidth}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dop := make([]byte, 500)
+			binary.LittleEndian.PutUint16(dop[82:], tt.view)
+			doc := openMock(t, &mockDoc{
+				pieces: []mockPiece{{text: "Zoomed"}},
+				table:  dop,
+				fcLcb:  map[int]uint32{62: 0, 63: uint32(len(dop))},
+			})
+
+			state, err := doc.ViewState()
+			if err != nil {
+				t.Fatalf("ViewState failed: %v", err)
+			}
+			if state == nil || *state != tt.expected {
+				t.Errorf("Expected view state %+v, got %+v", tt.expected, state)
+			}
+		})
+	}
+
+	// Documents without a DOP have no saved view
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{{text: "No DOP"}}})
+	if state, err := doc.ViewState(); err != nil || state != nil {
+		t.Errorf("Expected no view state, got %+v (err: %v)", state, err)
+	}
+}
+
+func TestCompatibilityOptions(t *testing.T) {
+	// Dop2000 with fNoLeading in Copts80 and fDontUseHTMLParagraphAutoSpacing
+	// in Copts. The stale DopBase copy of fNoTabForInd must be ignored.
+	dop := make([]byte, 544)
+	dop[8] = 0x01   // fNoTabForInd in DopBase
+	dop[510] = 0x02 // fNoSpaceRaiseLower
+	dop[512] = 0x08 // fNoLeading
+	dop[514] = 0x04 // fDontUseHTMLParagraphAutoSpacing
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Compatible"}},
+		table:  dop,
+		fcLcb:  map[int]uint32{62: 0, 63: uint32(len(dop))},
+	})
+
+	options, err := doc.CompatibilityOptions()
+	if err != nil {
+		t.Fatalf("CompatibilityOptions failed: %v", err)
+	}
+	for _, name := range []string{"fNoLeading", "fDontUseHTMLParagraphAutoSpacing", "fNoSpaceRaiseLower"} {
+		if !options[name] {
+			t.Errorf("Expected %s to be set", name)
+		}
+	}
+	for _, name := range []string{"fNoTabForInd", "fWPJust", "fSpLayoutLikeWW8"} {
+		if set, ok := options[name]; !ok || set {
+			t.Errorf("Expected %s to be present and clear, got %v (present: %v)", name, set, ok)
+		}
+	}
+
+	// A DopBase only holds the Copts60 options
+	base := make([]byte, 84)
+	base[9] = 0x10 // fLeaveBackslashAlone
+	doc = openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Old"}},
+		table:  base,
+		fcLcb:  map[int]uint32{62: 0, 63: uint32(len(base))},
+	})
+	options, err = doc.CompatibilityOptions()
+	if err != nil {
+		t.Fatalf("CompatibilityOptions failed: %v", err)
+	}
+	if !options["fLeaveBackslashAlone"] {
+		t.Error("Expected fLeaveBackslashAlone to be set")
+	}
+	if _, ok := options["fNoLeading"]; ok {
+		t.Error("Expected no Copts80 options in a DopBase")
+	}
+}
+
+func TestPageCount(t *testing.T) {
+	// Word stores the page count in the DOP on saving
+	dop := make([]byte, 616)
+	binary.LittleEndian.PutUint16(dop[46:], 3) // cPg
+	stored := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Three pages\r"}},
+		table:  dop,
+		fcLcb:  map[int]uint32{62: 0, 63: uint32(len(dop))},
+	})
+	pages, authoritative, err := stored.PageCount()
+	if err != nil {
+		t.Fatalf("PageCount failed: %v", err)
+	}
+	if pages != 3 || !authoritative {
+		t.Errorf("Expected a stored count of 3 pages, got %d (authoritative: %v)", pages, authoritative)
+	}
+
+	// Without one, 7000 characters fill three pages and the text after the
+	// page break a fourth
+	text := strings.Repeat("a", 7000) + "\fEnd\r"
+	estimated := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+	})
+	pages, authoritative, err = estimated.PageCount()
+	if err != nil {
+		t.Fatalf("PageCount failed: %v", err)
+	}
+	if pages != 4 || authoritative {
+		t.Errorf("Expected an estimate of 4 pages, got %d (authoritative: %v)", pages, authoritative)
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+// revisionCHPX encodes a CHPX marking a run as inserted or deleted by the
+// given author at the given DTTM.
+func revisionCHPX(deleted bool, author uint16, dttm uint32) []byte {
+	flag := []byte{0x01, 0x08, 0x01} // sprmCFRMarkIns
+	if deleted {
+		flag = []byte{0x00, 0x08, 0x01} // sprmCFRMarkDel
+	}
+	grpprl := append(flag, 0x04, 0x48) // sprmCIbstRMark
+	grpprl = binary.LittleEndian.AppendUint16(grpprl, author)
+	grpprl = append(grpprl, 0x05, 0x68) // sprmCDttmRMark
+	grpprl = binary.LittleEndian.AppendUint32(grpprl, dttm)
+	return append([]byte{byte(len(grpprl))}, grpprl...)
+}
+
+func TestRevisions(t *testing.T) {
+	text := "The quick brown fox\r"
+	fc := func(cp uint32) uint32 { return mockTextOffset + cp*2 }
+
+	// 2024-03-15 14:30 and 2024-03-16 09:05
+	inserted := uint32(30 | 14<<6 | 15<<11 | 3<<16 | 124<<20)
+	deleted := uint32(5 | 9<<6 | 16<<11 | 3<<16 | 124<<20)
+
+	// CHPX FKP: "quick " inserted by Alice, "brown " deleted by Bob
+	fkp := make([]byte, 512)
+	for i, cp := range []uint32{0, 4, 10, 16, 20} {
+		binary.LittleEndian.PutUint32(fkp[i*4:], fc(cp))
+	}
+	fkp[20+1] = 0x80 // Insertion CHPX at byte offset 0x100
+	fkp[20+2] = 0x90 // Deletion CHPX at byte offset 0x120
+	copy(fkp[0x100:], revisionCHPX(false, 0, inserted))
+	copy(fkp[0x120:], revisionCHPX(true, 1, deleted))
+	fkp[511] = 4
+
+	table := binary.LittleEndian.AppendUint32(nil, fc(0))
+	table = binary.LittleEndian.AppendUint32(table, fc(20))
+	table = binary.LittleEndian.AppendUint32(table, mockFirstPage)
+	bteSize := uint32(len(table))
+	authors := buildSTTB("Alice", "Bob")
+	table = append(table, authors...)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text, unicode: true}},
+		ccpText: uint32(len(text)),
+		table:   table,
+		fcLcb: map[int]uint32{
+			24: 0, 25: bteSize,
+			102: bteSize, 103: uint32(len(authors)),
+		},
+		pages: [][]byte{fkp},
+	})
+
+	revisions, err := doc.Revisions()
+	if err != nil {
+		t.Fatalf("Revisions failed: %v", err)
+	}
+
+	expected := []msdoc.Revision{
+		{
+			Type:    msdoc.RevisionInsertion,
+			Author:  "Alice",
+			Date:    time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC),
+			StartCP: 4,
+			EndCP:   10,
+			Text:    "quick ",
+		},
+		{
+			Type:    msdoc.RevisionDeletion,
+			Author:  "Bob",
+			Date:    time.Date(2024, time.March, 16, 9, 5, 0, 0, time.UTC),
+			StartCP: 10,
+			EndCP:   16,
+			Text:    "brown ",
+		},
+	}
+	if len(revisions) != len(expected) {
+		t.Fatalf("Expected %d revisions, got %d: %+v", len(expected), len(revisions), revisions)
+	}
+	for i, want := range expected {
+		got := revisions[i]
+		if got.Type != want.Type || got.Author != want.Author || !got.Date.Equal(want.Date) {
+			t.Errorf("Revision %d: expected %s by %s at %v, got %s by %s at %v", i, want.Type, want.Author, want.Date, got.Type, got.Author, got.Date)
+		}
+		if got.StartCP != want.StartCP || got.EndCP != want.EndCP || got.Text != want.Text {
+			t.Errorf("Revision %d: expected %q at [%d, %d), got %q at [%d, %d)", i, want.Text, want.StartCP, want.EndCP, got.Text, got.StartCP, got.EndCP)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// buildPlcfSed encodes a section table whose sections end at the given CPs.
+// Each section's SEPX offset is taken from fcSepx.
+func buildPlcfSed(cps []uint32, fcSepx []uint32) []byte {
+	var data []byte
+	for _, cp := range cps {
+		data = binary.LittleEndian.AppendUint32(data, cp)
+	}
+	for _, fc := range fcSepx {
+		sed := make([]byte, 12)
+		binary.LittleEndian.PutUint32(sed[2:], fc)
+		binary.LittleEndian.PutUint32(sed[8:], 0xFFFFFFFF) // fcMpr
+		data = append(data, sed...)
+	}
+	return data
+}
+
+func TestSectionPageNumbering(t *testing.T) {
+	// The second section restarts page numbering at i with lower roman numerals
+	sepx := []byte{
+		0x0A, 0x00, // cb
+		0x11, 0x30, 0x01, // sprmSFPgnRestart
+		0x0E, 0x30, 0x02, // sprmSNfcPgn: lower roman
+		0x1C, 0x50, 0x01, 0x00, // sprmSPgnStart97
+	}
+	plc := buildPlcfSed([]uint32{0, 6, 14}, []uint32{0xFFFFFFFF, mockWordDataOffset})
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Intro\rChapter\r"}},
+		word:   sepx,
+		table:  plc,
+		fcLcb:  map[int]uint32{12: 0, 13: uint32(len(plc))}, // PlcfSed
+	})
+
+	sections, err := doc.Sections()
+	if err != nil {
+		t.Fatalf("Sections failed: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(sections))
+	}
+
+	first := sections[0].Properties
+	if first.NfcPgn != structures.PageNumberArabic || first.FPgnRestart {
+		t.Errorf("Expected the first section to use continuous arabic numbering, got %v (restart %v)", first.NfcPgn, first.FPgnRestart)
+	}
+
+	second := sections[1]
+	if second.StartCP != 6 || second.EndCP != 14 {
+		t.Errorf("Expected the second section at CP [6, 14), got [%d, %d)", second.StartCP, second.EndCP)
+	}
+	if second.Properties.NfcPgn != structures.PageNumberLowerRoman {
+		t.Errorf("Expected lower roman page numbers, got %v", second.Properties.NfcPgn)
+	}
+	if !second.Properties.FPgnRestart || second.Properties.PgnStart != 1 {
+		t.Errorf("Expected numbering to restart at 1, got restart %v start %d", second.Properties.FPgnRestart, second.Properties.PgnStart)
+	}
+}
+
+func TestSectionsWithContent(t *testing.T) {
+	text := "Title page\r\x0CChapter one\rSome text\r"
+	plc := buildPlcfSed([]uint32{0, 12, uint32(len(text))}, []uint32{0xFFFFFFFF, 0xFFFFFFFF})
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text, unicode: true}},
+		ccpText: uint32(len(text)),
+		table:   plc,
+		fcLcb:   map[int]uint32{12: 0, 13: uint32(len(plc))}, // PlcfSed
+	})
+
+	contents, err := doc.SectionsWithContent()
+	if err != nil {
+		t.Fatalf("SectionsWithContent failed: %v", err)
+	}
+	if len(contents) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(contents))
+	}
+
+	expected := []struct {
+		text       string
+		paragraphs []string
+	}{
+		{"Title page\r\x0C", []string{"Title page", ""}},
+		{"Chapter one\rSome text\r", []string{"Chapter one", "Some text"}},
+	}
+	for i, want := range expected {
+		got := contents[i]
+		if got.Text != want.text {
+			t.Errorf("Section %d: expected text %q, got %q", i, want.text, got.Text)
+		}
+		if got.Section.Properties == nil {
+			t.Errorf("Section %d: expected section properties", i)
+		}
+		if len(got.Paragraphs) != len(want.paragraphs) {
+			t.Errorf("Section %d: expected %d paragraphs, got %+v", i, len(want.paragraphs), got.Paragraphs)
+			continue
+		}
+		for j, para := range got.Paragraphs {
+			if para.Text != want.paragraphs[j] {
+				t.Errorf("Section %d paragraph %d: expected %q, got %q", i, j, want.paragraphs[j], para.Text)
+			}
+		}
+	}
+}
+
+func TestSectionColumnWidths(t *testing.T) {
+	// Two columns: a 4" main column, 0.5" apart from a 1.5" sidebar
+	sepx := []byte{
+		0x16, 0x00, // cb
+		0x0B, 0x50, 0x01, 0x00, // sprmSCcolumns: 2 columns
+		0x05, 0x30, 0x00, // sprmSFEvenlySpaced: false
+		0x03, 0xF2, 0x00, 0x80, 0x16, // sprmSDxaColWidth: column 0, 5760
+		0x04, 0xF2, 0x00, 0xD0, 0x02, // sprmSDxaColSpacing: column 0, 720
+		0x03, 0xF2, 0x01, 0x70, 0x08, // sprmSDxaColWidth: column 1, 2160
+	}
+	plc := buildPlcfSed([]uint32{0, 8}, []uint32{mockWordDataOffset})
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Columns\r"}},
+		word:   sepx,
+		table:  plc,
+		fcLcb:  map[int]uint32{12: 0, 13: uint32(len(plc))}, // PlcfSed
+	})
+
+	sections, err := doc.Sections()
+	if err != nil {
+		t.Fatalf("Sections failed: %v", err)
+	}
+	if len(sections) != 1 {
+		t.Fatalf("Expected 1 section, got %d", len(sections))
+	}
+
+	sep := sections[0].Properties
+	if sep.GetColumnCount() != 2 || sep.FEvenlySpaced {
+		t.Errorf("Expected 2 unevenly spaced columns, got %d (evenly spaced %v)", sep.GetColumnCount(), sep.FEvenlySpaced)
+	}
+	if len(sep.ColumnWidths) != 2 || sep.ColumnWidths[0] != 5760 || sep.ColumnWidths[1] != 2160 {
+		t.Errorf("Expected column widths [5760 2160], got %v", sep.ColumnWidths)
+	}
+	if len(sep.ColumnSpacings) != 1 || sep.ColumnSpacings[0] != 720 {
+		t.Errorf("Expected a spacing of 720 after the first column, got %v", sep.ColumnSpacings)
+	}
+
+	// Evenly spaced columns have no per-column values
+	if even := structures.DefaultSEP(); even.ColumnWidths != nil || even.ColumnSpacings != nil {
+		t.Errorf("Expected no column widths for the default section, got %v and %v", even.ColumnWidths, even.ColumnSpacings)
+	}
+}
+
+func TestSectionBreakType(t *testing.T) {
+	// The second section starts on the next even page
+	sepx := []byte{
+		0x03, 0x00, // cb
+		0x09, 0x30, 0x03, // sprmSBkc: even page
+	}
+	plc := buildPlcfSed([]uint32{0, 6, 14}, []uint32{0xFFFFFFFF, mockWordDataOffset})
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Intro\rChapter\r"}},
+		word:   sepx,
+		table:  plc,
+		fcLcb:  map[int]uint32{12: 0, 13: uint32(len(plc))}, // PlcfSed
+	})
+
+	sections, err := doc.Sections()
+	if err != nil {
+		t.Fatalf("Sections failed: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(sections))
+	}
+	if got := sections[0].Properties.Bkc; got != structures.BreakNewPage {
+		t.Errorf("Expected the first section to start on a new page, got %v", got)
+	}
+	second := sections[1].Properties
+	if second.Bkc != structures.BreakEvenPage || second.FContinuous {
+		t.Errorf("Expected the second section to start on an even page, got %v (continuous %v)", second.Bkc, second.FContinuous)
+	}
+	if got := second.Bkc.String(); got != "Even Page" {
+		t.Errorf("Expected %q, got %q", "Even Page", got)
+	}
+}
@@ -0,0 +1,260 @@
+package tests
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+// officeArtRecord encodes an Office Art record with the given header fields.
+func officeArtRecord(ver uint8, inst uint16, recType uint16, data []byte) []byte {
+	record := binary.LittleEndian.AppendUint16(nil, uint16(ver)|inst<<4)
+	record = binary.LittleEndian.AppendUint16(record, recType)
+	record = binary.LittleEndian.AppendUint32(record, uint32(len(data)))
+	return append(record, data...)
+}
+
+// mockShape describes a shape container for Office Art test data.
+type mockShape struct {
+	id, flags   uint32
+	shapeType   uint16
+	blip        uint32 // pib property, omitted if zero
+	name, descr string // Complex string properties, omitted if empty
+}
+
+// encode returns the shape container with an FSP, a primary property table
+// holding the picture index and a tertiary table holding the strings.
+func (s mockShape) encode() []byte {
+	fsp := binary.LittleEndian.AppendUint32(nil, s.id)
+	fsp = binary.LittleEndian.AppendUint32(fsp, s.flags)
+	children := officeArtRecord(0x2, s.shapeType, 0xF00A, fsp)
+
+	if s.blip != 0 {
+		fopt := binary.LittleEndian.AppendUint16(nil, 0x4104) // pib, fBid
+		fopt = binary.LittleEndian.AppendUint32(fopt, s.blip)
+		children = append(children, officeArtRecord(0x3, 1, 0xF00B, fopt)...)
+	}
+
+	var table, complexData []byte
+	count := uint16(0)
+	for _, prop := range []struct {
+		pid   uint16
+		value string
+	}{{0x0380, s.name}, {0x0381, s.descr}} {
+		if prop.value == "" {
+			continue
+		}
+		var str []byte
+		for _, u := range utf16.Encode([]rune(prop.value + "\x00")) {
+			str = binary.LittleEndian.AppendUint16(str, u)
+		}
+		table = binary.LittleEndian.AppendUint16(table, prop.pid|0x8000)
+		table = binary.LittleEndian.AppendUint32(table, uint32(len(str)))
+		complexData = append(complexData, str...)
+		count++
+	}
+	if count > 0 {
+		children = append(children, officeArtRecord(0x3, count, 0xF122, append(table, complexData...))...)
+	}
+	return officeArtRecord(0xF, 0, 0xF004, children)
+}
+
+func TestShapesAltText(t *testing.T) {
+	text := "Logo below\r"
+
+	// Drawing with the patriarch group shape and one picture frame
+	group := officeArtRecord(0xF, 0, 0xF003, append(
+		mockShape{id: 1024, flags: 0x0005}.encode(),
+		mockShape{id: 1025, flags: 0x0A00, shapeType: 75, blip: 1, name: "Picture 1", descr: "Company logo"}.encode()...,
+	))
+	dggInfo := officeArtRecord(0xF, 0, 0xF000, nil)
+	dggInfo = append(dggInfo, 0) // Main document drawing
+	dggInfo = append(dggInfo, officeArtRecord(0xF, 1, 0xF002, group)...)
+
+	// The picture is anchored at CP 5
+	spa := make([]byte, 26)
+	binary.LittleEndian.PutUint32(spa, 1025)
+	plcSpa := binary.LittleEndian.AppendUint32(nil, 5)
+	plcSpa = binary.LittleEndian.AppendUint32(plcSpa, uint32(len(text)))
+	plcSpa = append(plcSpa, spa...)
+
+	table := append(append([]byte(nil), dggInfo...), plcSpa...)
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   table,
+		fcLcb: map[int]uint32{
+			100: 0, 101: uint32(len(dggInfo)),
+			80: uint32(len(dggInfo)), 81: uint32(len(plcSpa)),
+		},
+	})
+
+	shapes, err := doc.Shapes()
+	if err != nil {
+		t.Fatalf("Shapes failed: %v", err)
+	}
+	if len(shapes) != 1 {
+		t.Fatalf("Expected 1 shape, got %d: %+v", len(shapes), shapes)
+	}
+	shape := shapes[0]
+	if shape.ID != 1025 || shape.Type != 75 || !shape.IsPicture || shape.CP != 5 {
+		t.Errorf("Expected picture 1025 of type 75 at CP 5, got %+v", shape)
+	}
+	if shape.AltText != "Company logo" || shape.Name != "Picture 1" {
+		t.Errorf("Expected alt text %q and name %q, got %q and %q", "Company logo", "Picture 1", shape.AltText, shape.Name)
+	}
+}
+
+func TestImagesAltText(t *testing.T) {
+	text := "Chart: \x01\r"
+	anchorFC := uint32(mockTextOffset + 7)
+	textEnd := uint32(mockTextOffset + len(text))
+
+	// CHPX of the anchor character points at the PICF at Data offset 0
+	fkp := make([]byte, 512)
+	for i, fc := range []uint32{mockTextOffset, anchorFC, anchorFC + 1, textEnd} {
+		binary.LittleEndian.PutUint32(fkp[i*4:], fc)
+	}
+	fkp[16+1] = 0x40
+	copy(fkp[0x80:], []byte{
+		0x09,
+		0x55, 0x08, 0x01, // sprmCFSpec
+		0x03, 0x6A, 0x00, 0x00, 0x00, 0x00, // sprmCPicLocation: 0
+	})
+	fkp[511] = 3
+
+	bte := binary.LittleEndian.AppendUint32(nil, mockTextOffset)
+	bte = binary.LittleEndian.AppendUint32(bte, textEnd)
+	bte = binary.LittleEndian.AppendUint32(bte, mockFirstPage)
+
+	// PICF of a 2" x 1" picture followed by its shape container
+	shape := mockShape{id: 1026, flags: 0x0A00, shapeType: 75, blip: 1, descr: "Sales by region"}.encode()
+	picf := make([]byte, 0x44)
+	binary.LittleEndian.PutUint32(picf[0:], uint32(0x44+len(shape)))
+	binary.LittleEndian.PutUint16(picf[4:], 0x44)
+	binary.LittleEndian.PutUint16(picf[6:], 0x64)
+	binary.LittleEndian.PutUint16(picf[28:], 2880)
+	binary.LittleEndian.PutUint16(picf[30:], 1440)
+	binary.LittleEndian.PutUint16(picf[32:], 1000)
+	binary.LittleEndian.PutUint16(picf[34:], 1000)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   bte,
+		fcLcb:   map[int]uint32{24: 0, 25: uint32(len(bte))},
+		pages:   [][]byte{fkp},
+		streams: []mockStream{{name: "Data", data: append(picf, shape...)}},
+	})
+
+	images, err := doc.Images()
+	if err != nil {
+		t.Fatalf("Images failed: %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("Expected 1 image, got %d", len(images))
+	}
+	image := images[0]
+	if image.CP != 7 || image.DisplayWidthTwips != 2880 || image.DisplayHeightTwips != 1440 {
+		t.Errorf("Expected a 2880x1440 image at CP 7, got %+v", image)
+	}
+	if image.AltText != "Sales by region" {
+		t.Errorf("Expected alt text %q, got %q", "Sales by region", image.AltText)
+	}
+}
+
+func TestImageAnchorSpecialFlag(t *testing.T) {
+	// A literal 0x01 typed into the text, then a picture anchor and a shape
+	// anchor, both marked special
+	text := "A\x01B\x01C\x08\r"
+	fc := func(cp uint32) uint32 { return mockTextOffset + cp }
+
+	fkp := make([]byte, 512)
+	for i, cp := range []uint32{0, 3, 4, 5, 6, 7} {
+		binary.LittleEndian.PutUint32(fkp[i*4:], fc(cp))
+	}
+	fkp[24+1] = 0x40 // Picture anchor CHPX at byte offset 0x80
+	fkp[24+3] = 0x48 // Shape anchor CHPX at byte offset 0x90
+	copy(fkp[0x80:], []byte{
+		0x09,
+		0x55, 0x08, 0x01, // sprmCFSpec
+		0x03, 0x6A, 0x00, 0x00, 0x00, 0x00, // sprmCPicLocation: 0
+	})
+	copy(fkp[0x90:], []byte{0x03, 0x55, 0x08, 0x01}) // sprmCFSpec
+	fkp[511] = 5
+
+	bte := binary.LittleEndian.AppendUint32(nil, fc(0))
+	bte = binary.LittleEndian.AppendUint32(bte, fc(uint32(len(text))))
+	bte = binary.LittleEndian.AppendUint32(bte, mockFirstPage)
+
+	picf := make([]byte, 0x44)
+	binary.LittleEndian.PutUint32(picf[0:], 0x44)
+	binary.LittleEndian.PutUint16(picf[4:], 0x44)
+	binary.LittleEndian.PutUint16(picf[6:], 0x64)
+	binary.LittleEndian.PutUint16(picf[28:], 1440)
+	binary.LittleEndian.PutUint16(picf[30:], 1440)
+	binary.LittleEndian.PutUint16(picf[32:], 1000)
+	binary.LittleEndian.PutUint16(picf[34:], 1000)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   bte,
+		fcLcb:   map[int]uint32{24: 0, 25: uint32(len(bte))},
+		pages:   [][]byte{fkp},
+		streams: []mockStream{{name: "Data", data: picf}},
+	})
+
+	images, err := doc.Images()
+	if err != nil {
+		t.Fatalf("Images failed: %v", err)
+	}
+	if len(images) != 1 || images[0].CP != 3 {
+		t.Fatalf("Expected one image anchored at CP 3, got %+v", images)
+	}
+
+	plain, err := doc.TextWithOptions(msdoc.TextOptions{OmitObjectAnchors: true})
+	if err != nil {
+		t.Fatalf("TextWithOptions failed: %v", err)
+	}
+	if want := "A\x01BC\r"; plain != want {
+		t.Errorf("Expected %q without the anchors, got %q", want, plain)
+	}
+}
+
+func TestImagesSample(t *testing.T) {
+	doc, err := msdoc.Open("testdata/sample-4.doc")
+	if err != nil {
+		t.Fatalf("Failed to open document: %v", err)
+	}
+	defer doc.Close()
+
+	// The hyperlinks of the table of contents also have object anchors, but
+	// their locations hold hyperlink data rather than pictures
+	images, err := doc.Images()
+	if err != nil {
+		t.Fatalf("Images failed: %v", err)
+	}
+	if len(images) != 8 {
+		t.Fatalf("Expected 8 images, got %d", len(images))
+	}
+	first := images[0]
+	if first.CP != 6237 || first.Name != "Picture 3" {
+		t.Errorf("Expected Picture 3 at CP 6237, got %q at CP %d", first.Name, first.CP)
+	}
+	if !strings.HasPrefix(first.AltText, "Graphical user interface, text, application") {
+		t.Errorf("Expected the alt text of Picture 3, got %q", first.AltText)
+	}
+
+	// The only shape of the drawing is the page background
+	shapes, err := doc.Shapes()
+	if err != nil {
+		t.Fatalf("Shapes failed: %v", err)
+	}
+	if len(shapes) != 0 {
+		t.Errorf("Expected no floating shapes, got %+v", shapes)
+	}
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+// pbString encodes a PBString, as ANSI characters if ansi is set and as
+// UTF-16 otherwise.
+func pbString(s string, ansi bool) []byte {
+	if ansi {
+		data := binary.LittleEndian.AppendUint16(nil, uint16(len(s))|0x8000)
+		return append(data, s...)
+	}
+	units := utf16.Encode([]rune(s))
+	data := binary.LittleEndian.AppendUint16(nil, uint16(len(units)))
+	for _, u := range units {
+		data = binary.LittleEndian.AppendUint16(data, u)
+	}
+	return data
+}
+
+// buildBookmarkPLC encodes a smart tag bookmark PLC with one data element
+// per CP but the last.
+func buildBookmarkPLC(cps []uint32, elems ...[]byte) []byte {
+	var data []byte
+	for _, cp := range cps {
+		data = binary.LittleEndian.AppendUint32(data, cp)
+	}
+	for _, elem := range elems {
+		data = append(data, elem...)
+	}
+	return data
+}
+
+func TestSmartTags(t *testing.T) {
+	text := "Call Alice Smith today\r"
+	const namespace = "urn:schemas-microsoft-com:office:smarttags"
+
+	// One smart tag over "Alice Smith" (CPs 5 to 16)
+	bkf := buildBookmarkPLC([]uint32{5, 23}, []byte{0, 0, 0, 0, 1, 0}) // ibkl 0, cDepth 1
+	bkl := buildBookmarkPLC([]uint32{16, 23}, []byte{0, 0, 1, 0})      // ibkf 0, cDepth 1
+
+	// SmartTagData: the PersonName type, a string table and one property bag
+	factoidType := binary.LittleEndian.AppendUint32(nil, 1) // id
+	factoidType = append(factoidType, pbString(namespace, false)...)
+	factoidType = append(factoidType, pbString("PersonName", false)...)
+	factoidType = append(factoidType, pbString("", false)...)
+	tagData := binary.LittleEndian.AppendUint32(nil, 1) // cFactoidType
+	tagData = binary.LittleEndian.AppendUint32(tagData, uint32(len(factoidType)))
+	tagData = append(tagData, factoidType...)
+	tagData = append(tagData, 0x0C, 0x00, 0x00, 0x01)      // cbHdr, sVer
+	tagData = binary.LittleEndian.AppendUint32(tagData, 1) // cfactoid
+	tagData = binary.LittleEndian.AppendUint32(tagData, 2) // cste
+	tagData = append(tagData, pbString("FirstName", true)...)
+	tagData = append(tagData, pbString("Alice", true)...)
+	tagData = append(tagData, 1, 0, 1, 0, 0, 0) // id 1, cProp 1, cbUnknown 0
+	tagData = binary.LittleEndian.AppendUint32(tagData, 0)
+	tagData = binary.LittleEndian.AppendUint32(tagData, 1)
+
+	table := append(append(append([]byte(nil), bkf...), bkl...), tagData...)
+	doc := openMock(t, &mockDoc{
+		pieces:     []mockPiece{{text: text}},
+		nFib:       0x0101,
+		fcLcbPairs: 136,
+		fcLcb: map[int]uint32{
+			230: 0, 231: uint32(len(bkf)),
+			234: uint32(len(bkf)), 235: uint32(len(bkl)),
+			236: uint32(len(bkf) + len(bkl)), 237: uint32(len(tagData)),
+		},
+		table: table,
+	})
+
+	tags, err := doc.SmartTags()
+	if err != nil {
+		t.Fatalf("SmartTags failed: %v", err)
+	}
+	if len(tags) != 1 {
+		t.Fatalf("Expected 1 smart tag, got %d", len(tags))
+	}
+
+	tag := tags[0]
+	if tag.Type != "PersonName" || tag.Namespace != namespace {
+		t.Errorf("Expected PersonName in %s, got %q in %q", namespace, tag.Type, tag.Namespace)
+	}
+	if tag.StartCP != 5 || tag.EndCP != 16 {
+		t.Errorf("Expected CPs 5 to 16, got %d to %d", tag.StartCP, tag.EndCP)
+	}
+	if tag.Text != "Alice Smith" {
+		t.Errorf("Expected tagged text %q, got %q", "Alice Smith", tag.Text)
+	}
+	if tag.Properties["FirstName"] != "Alice" {
+		t.Errorf("Expected FirstName property Alice, got %v", tag.Properties)
+	}
+}
+
+func TestSmartTagsNone(t *testing.T) {
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{{text: "No tags\r"}}})
+
+	tags, err := doc.SmartTags()
+	if err != nil {
+		t.Fatalf("SmartTags failed: %v", err)
+	}
+	if tags != nil {
+		t.Errorf("Expected no smart tags, got %v", tags)
+	}
+}
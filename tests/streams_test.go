@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/pkg"
+	"github.com/TalentFormula/msdoc/streams"
+)
+
+func TestPaddedStreamNames(t *testing.T) {
+	mock := &mockDoc{
+		pieces:  []mockPiece{{text: "Padded names\r", unicode: true}},
+		padding: "  ",
+	}
+	path := mock.writeFile(t)
+
+	doc, err := msdoc.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open a document with padded stream names: %v", err)
+	}
+	defer doc.Close()
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if text != "Padded names\r" {
+		t.Errorf("Expected %q, got %q", "Padded names\r", text)
+	}
+
+	if err := doc.AppendParagraph("Appended"); err != nil {
+		t.Fatalf("AppendParagraph failed: %v", err)
+	}
+	if err := doc.SaveInPlace(); err != nil {
+		t.Fatalf("SaveInPlace failed: %v", err)
+	}
+	if text, err := doc.Text(); err != nil || text != "Padded names\rAppended\r" {
+		t.Errorf("Expected the appended text after saving, got %q (err: %v)", text, err)
+	}
+}
+
+func TestRawStreams(t *testing.T) {
+	dop := make([]byte, 84)
+	binary.LittleEndian.PutUint16(dop[10:], 720) // dxaTab
+	pieces := []mockPiece{{text: "Raw streams\r"}}
+
+	salt := []byte("0123456789abcdef")
+	encHeader := buildCryptoAPIHeader(t, "secret", salt, 128)
+	encrypted := &mockDoc{
+		pieces:      pieces,
+		flags1:      0x0100, // fEncrypted
+		table:       dop,
+		fcLcb:       map[int]uint32{62: 0, 63: uint32(len(dop))},
+		encHeader:   encHeader,
+		encPassword: "secret",
+	}
+	encryptedDoc, err := msdoc.OpenWithPassword(encrypted.writeFile(t), "secret")
+	if err != nil {
+		t.Fatalf("OpenWithPassword failed: %v", err)
+	}
+	defer encryptedDoc.Close()
+
+	docs := []struct {
+		name      string
+		doc       *msdoc.Document
+		dopOffset int
+	}{
+		{"unencrypted", openMock(t, &mockDoc{
+			pieces: pieces,
+			table:  dop,
+			fcLcb:  map[int]uint32{62: 0, 63: uint32(len(dop))},
+		}), 0},
+		{"encrypted", encryptedDoc, len(encHeader)},
+	}
+	for _, tc := range docs {
+		wordDoc, table, err := tc.doc.RawStreams()
+		if err != nil {
+			t.Fatalf("%s: RawStreams failed: %v", tc.name, err)
+		}
+		if len(wordDoc) < 2 || binary.LittleEndian.Uint16(wordDoc) != 0xA5EC {
+			t.Errorf("%s: expected the WordDocument stream to start with wIdent 0xA5EC", tc.name)
+		}
+		// The DOP is decrypted in place, after the unencrypted header
+		if len(table) < tc.dopOffset+len(dop) || binary.LittleEndian.Uint16(table[tc.dopOffset+10:]) != 720 {
+			t.Errorf("%s: expected the DOP at offset %d of the table stream", tc.name, tc.dopOffset)
+		}
+		if !bytes.Equal(table[:tc.dopOffset], encHeader[:tc.dopOffset]) {
+			t.Errorf("%s: expected the encryption header to be kept as stored", tc.name)
+		}
+	}
+}
+
+func TestTableStreamLocationOverflow(t *testing.T) {
+	// An offset near the top of the uint32 range wraps around when added to
+	// the size, which must not pass the bounds check
+	ts := streams.NewTableStream(make([]byte, 64), "1Table")
+	const fc, lcb = 0xFFFFFFF0, 0x20
+	if _, err := ts.GetDOP(fc, lcb); err == nil {
+		t.Error("Expected an error for a DOP past the end of the table stream")
+	}
+	if _, err := ts.GetRevisionAuthors(fc, lcb); err == nil {
+		t.Error("Expected an error for a revision author table past the end of the table stream")
+	}
+	if _, err := ts.GetUserVariables(fc, lcb); err == nil {
+		t.Error("Expected an error for a document variable table past the end of the table stream")
+	}
+}
@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/formatting"
+	msdoc "github.com/TalentFormula/msdoc/pkg"
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// mockStyle is a style definition written by buildSTSH. A nil style leaves
+// an empty slot in the style sheet.
+type mockStyle struct {
+	name     string
+	stk      uint16 // Style type, paragraph (1) if not set
+	istdBase uint16 // Base style index, 0x0FFF for none
+	papx     []byte // Paragraph sprms
+	chpx     []byte // Character sprms
+}
+
+// buildSTSH builds a style sheet whose styles have the Word 2000 STD layout.
+func buildSTSH(styles ...*mockStyle) []byte {
+	const cbSTDBase = 18
+
+	stshi := make([]byte, 18)
+	binary.LittleEndian.PutUint16(stshi[0:], uint16(len(styles)))
+	binary.LittleEndian.PutUint16(stshi[2:], cbSTDBase)
+	data := binary.LittleEndian.AppendUint16(nil, uint16(len(stshi)))
+	data = append(data, stshi...)
+
+	appendUPX := func(std, upx []byte) []byte {
+		std = binary.LittleEndian.AppendUint16(std, uint16(len(upx)))
+		std = append(std, upx...)
+		if len(upx)%2 == 1 {
+			std = append(std, 0)
+		}
+		return std
+	}
+
+	for _, style := range styles {
+		if style == nil {
+			data = binary.LittleEndian.AppendUint16(data, 0)
+			continue
+		}
+		stk := style.stk
+		if stk == 0 {
+			stk = 1
+		}
+		cupx := uint16(1)
+		if stk == 1 {
+			cupx = 2
+		}
+
+		std := make([]byte, cbSTDBase)
+		binary.LittleEndian.PutUint16(std[0:], 0x0FFE)
+		binary.LittleEndian.PutUint16(std[2:], stk|style.istdBase<<4)
+		binary.LittleEndian.PutUint16(std[4:], cupx)
+		name := utf16.Encode([]rune(style.name))
+		std = binary.LittleEndian.AppendUint16(std, uint16(len(name)))
+		for _, u := range name {
+			std = binary.LittleEndian.AppendUint16(std, u)
+		}
+		std = append(std, 0, 0)
+		if stk == 1 {
+			// UpxPapx starts with the style index
+			std = appendUPX(std, append([]byte{0, 0}, style.papx...))
+		}
+		std = appendUPX(std, style.chpx)
+
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(std)))
+		data = append(data, std...)
+	}
+	return data
+}
+
+func TestDefaultParagraphProperties(t *testing.T) {
+	// Normal: 8pt after, 1.08 line spacing and widow control, as in Word's
+	// default template, followed by a heading style that must not apply
+	normal := []byte{
+		0x31, 0x24, 0x01, // sprmPFWidowControl
+		0x14, 0xA4, 0xA0, 0x00, // sprmPDyaAfter 160
+		0x12, 0x64, 0x03, 0x01, 0x01, 0x00, // sprmPDyaLine 259, multiple
+	}
+	heading := []byte{0x61, 0x24, 0x01} // sprmPJc center
+	stsh := buildSTSH(
+		&mockStyle{name: "Normal", istdBase: 0x0FFF, papx: normal},
+		&mockStyle{name: "Heading 1", istdBase: 0, papx: heading},
+	)
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Styled text\r"}},
+		table:  stsh,
+		fcLcb:  map[int]uint32{2: 0, 3: uint32(len(stsh))},
+	})
+
+	props, err := doc.DefaultParagraphProperties()
+	if err != nil {
+		t.Fatalf("DefaultParagraphProperties failed: %v", err)
+	}
+	if props.Alignment != formatting.AlignLeft {
+		t.Errorf("Expected left alignment, got %v", props.Alignment)
+	}
+	if props.SpaceBefore != 0 || props.SpaceAfter != 160 {
+		t.Errorf("Expected spacing of 0 before and 160 after, got %d and %d", props.SpaceBefore, props.SpaceAfter)
+	}
+	if props.LineSpacing.Type != formatting.LineSpacingMultiple || props.LineSpacing.Value != 259 {
+		t.Errorf("Expected multiple line spacing of 259, got %+v", props.LineSpacing)
+	}
+	if !props.WidowControl {
+		t.Error("Expected widow control")
+	}
+	if props.StyleName != "Normal" {
+		t.Errorf("Expected style name %q, got %q", "Normal", props.StyleName)
+	}
+
+	// Documents without a style sheet use Word's built-in defaults
+	plain := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Plain\r"}}})
+	if props, err := plain.DefaultParagraphProperties(); err != nil {
+		t.Fatalf("DefaultParagraphProperties failed: %v", err)
+	} else if props.Alignment != formatting.AlignLeft || props.LineSpacing.Type != formatting.LineSpacingSingle || props.SpaceAfter != 0 {
+		t.Errorf("Unexpected built-in defaults %+v", props)
+	}
+}
+
+func TestParagraphStyleInheritance(t *testing.T) {
+	// Normal sets 11pt text; Heading 1 is based on it and adds bold 16pt
+	// text kept with the next paragraph
+	stsh := buildSTSH(
+		&mockStyle{name: "Normal", istdBase: 0x0FFF, chpx: []byte{0x43, 0x4A, 0x16, 0x00}},
+		&mockStyle{
+			name:     "Heading 1",
+			istdBase: 0,
+			papx:     []byte{0x06, 0x24, 0x01},                         // sprmPFKeepFollow
+			chpx:     []byte{0x35, 0x08, 0x01, 0x43, 0x4A, 0x20, 0x00}, // sprmCFBold, sprmCHps 32
+		},
+	)
+
+	text := "Title\rBody\r"
+	mid := uint32(mockTextOffset + len("Title\r"))
+	end := uint32(mockTextOffset + len(text))
+
+	// The heading's PAPX applies Heading 1 and centers it directly
+	page := buildPAPXPage([]uint32{mockTextOffset, mid, end}, [][]byte{
+		{0x01, 0x00, 0x61, 0x24, 0x01},
+		{0x00, 0x00},
+	})
+	var bte []byte
+	for _, v := range []uint32{mockTextOffset, end, mockFirstPage} {
+		bte = binary.LittleEndian.AppendUint32(bte, v)
+	}
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   append(stsh, bte...),
+		fcLcb: map[int]uint32{
+			2: 0, 3: uint32(len(stsh)),
+			26: uint32(len(stsh)), 27: uint32(len(bte)),
+		},
+		pages: [][]byte{page},
+	})
+
+	paragraphs, err := doc.Paragraphs()
+	if err != nil {
+		t.Fatalf("Paragraphs failed: %v", err)
+	}
+	if len(paragraphs) != 2 {
+		t.Fatalf("Expected 2 paragraphs, got %d", len(paragraphs))
+	}
+
+	heading := paragraphs[0]
+	if heading.StyleName != "Heading 1" || heading.Properties.StyleName != "Heading 1" {
+		t.Errorf("Expected the Heading 1 style, got %q and %q", heading.StyleName, heading.Properties.StyleName)
+	}
+	if !heading.CharacterProperties.Bold || heading.CharacterProperties.FontSize != 32 {
+		t.Errorf("Expected bold 16pt text from the style, got bold %v size %d", heading.CharacterProperties.Bold, heading.CharacterProperties.FontSize)
+	}
+	if !heading.Properties.KeepWithNext || heading.Properties.Alignment != formatting.AlignCenter {
+		t.Errorf("Expected a centered paragraph kept with the next, got %+v", heading.Properties)
+	}
+
+	body := paragraphs[1]
+	if body.CharacterProperties.Bold || body.CharacterProperties.FontSize != 22 {
+		t.Errorf("Expected regular 11pt text from Normal, got bold %v size %d", body.CharacterProperties.Bold, body.CharacterProperties.FontSize)
+	}
+	if body.Properties.KeepWithNext || body.Properties.Alignment != formatting.AlignLeft {
+		t.Errorf("Expected the Normal paragraph formatting, got %+v", body.Properties)
+	}
+}
+
+func TestStyles(t *testing.T) {
+	doc, err := msdoc.Open("testdata/sample-4.doc")
+	if err != nil {
+		t.Fatalf("Failed to open document: %v", err)
+	}
+	defer doc.Close()
+
+	styles, err := doc.Styles()
+	if err != nil {
+		t.Fatalf("Styles failed: %v", err)
+	}
+
+	var heading *msdoc.Style
+	for i := range styles {
+		if styles[i].Name == "Heading 1" {
+			heading = &styles[i]
+		}
+	}
+	if heading == nil {
+		t.Fatalf("Expected a Heading 1 style among %d styles", len(styles))
+	}
+	if heading.Index != 1 || heading.Type != structures.StyleTypeParagraph || !heading.BuiltIn {
+		t.Errorf("Expected built-in paragraph style 1, got %+v", *heading)
+	}
+	if heading.BasedOn != "Normal" || heading.Next != "Body Text" {
+		t.Errorf("Expected Heading 1 based on Normal and followed by Body Text, got %q and %q", heading.BasedOn, heading.Next)
+	}
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// tableCell describes a cell for buildTableSprms.
+type tableCell struct {
+	width      int16
+	tcgrf      uint16
+	brcTop     uint32 // BRC80 of the top border, 0xFFFFFFFF for none
+	shdFore    uint32 // COLORREF of the shading foreground
+	shdBack    uint32 // COLORREF of the shading background
+	shdPattern uint16
+}
+
+// buildTableSprms encodes sprmTDefTable and sprmTDefTableShd for a row with
+// the given cells.
+func buildTableSprms(cells []tableCell) []byte {
+	var def []byte
+	def = append(def, byte(len(cells)))
+	edge := int16(0)
+	def = binary.LittleEndian.AppendUint16(def, uint16(edge))
+	for _, cell := range cells {
+		edge += cell.width
+		def = binary.LittleEndian.AppendUint16(def, uint16(edge))
+	}
+	for _, cell := range cells {
+		def = binary.LittleEndian.AppendUint16(def, cell.tcgrf)
+		def = binary.LittleEndian.AppendUint16(def, uint16(cell.width))
+		def = binary.LittleEndian.AppendUint32(def, cell.brcTop)
+		for range 3 {
+			def = binary.LittleEndian.AppendUint32(def, 0xFFFFFFFF)
+		}
+	}
+
+	var grpprl []byte
+	grpprl = binary.LittleEndian.AppendUint16(grpprl, 0xD608) // sprmTDefTable
+	grpprl = binary.LittleEndian.AppendUint16(grpprl, uint16(len(def)+1))
+	grpprl = append(grpprl, def...)
+
+	grpprl = binary.LittleEndian.AppendUint16(grpprl, 0xD612) // sprmTDefTableShd
+	grpprl = append(grpprl, byte(len(cells)*10))
+	for _, cell := range cells {
+		grpprl = binary.LittleEndian.AppendUint32(grpprl, cell.shdFore)
+		grpprl = binary.LittleEndian.AppendUint32(grpprl, cell.shdBack)
+		grpprl = binary.LittleEndian.AppendUint16(grpprl, cell.shdPattern)
+	}
+	return grpprl
+}
+
+func TestParseTAP(t *testing.T) {
+	grpprl := buildTableSprms([]tableCell{
+		{width: 2880, brcTop: 0x00010104, shdFore: 0xFF000000, shdBack: 0xFF000000}, // Single black border, 4/8 pt wide
+		{width: 1440, tcgrf: 0x0080, brcTop: 0xFFFFFFFF, shdFore: 0x0000FF, shdBack: 0xFFFFFF, shdPattern: 5},
+	})
+	grpprl = append(grpprl, 0x16, 0x24, 1) // sprmPFInTable after the variable-length table sprms
+
+	if operand, ok := structures.FindSprm(grpprl, 0x2416); !ok || operand[0] != 1 {
+		t.Fatalf("Expected sprmPFInTable after sprmTDefTable, got %v (%v)", operand, ok)
+	}
+
+	tap, err := structures.ParseTAP(grpprl)
+	if err != nil {
+		t.Fatalf("ParseTAP failed: %v", err)
+	}
+	if tap == nil || len(tap.Cells) != 2 {
+		t.Fatalf("Expected 2 cells, got %+v", tap)
+	}
+	if len(tap.CellEdges) != 3 || tap.CellEdges[2] != 4320 {
+		t.Errorf("Expected cell edges ending at 4320, got %v", tap.CellEdges)
+	}
+
+	first, second := tap.Cells[0], tap.Cells[1]
+	if first.Width != 2880 || second.Width != 1440 {
+		t.Errorf("Expected widths 2880 and 1440, got %d and %d", first.Width, second.Width)
+	}
+	if first.Top == nil || first.Top.Type != 1 || first.Top.Width != 4 || first.Top.Color.IsAuto() {
+		t.Errorf("Expected a single black top border on the first cell, got %+v", first.Top)
+	}
+	if first.Left != nil || second.Top != nil {
+		t.Errorf("Expected no other borders, got %+v and %+v", first.Left, second.Top)
+	}
+	if first.Shading != nil {
+		t.Errorf("Expected the first cell to be unshaded, got %+v", first.Shading)
+	}
+	if second.Shading == nil || second.Shading.Pattern != 5 {
+		t.Fatalf("Expected 25%% shading on the second cell, got %+v", second.Shading)
+	}
+	if r, g, b := second.Shading.Fore.RGB(); r != 0xFF || g != 0 || b != 0 {
+		t.Errorf("Expected red shading foreground, got %02X%02X%02X", r, g, b)
+	}
+	if second.VertAlign != 1 {
+		t.Errorf("Expected the second cell to be centered vertically, got %d", second.VertAlign)
+	}
+
+	if tap, err := structures.ParseTAP([]byte{0x16, 0x24, 1}); tap != nil || err != nil {
+		t.Errorf("Expected no table properties without sprmTDefTable, got %+v (%v)", tap, err)
+	}
+}
+
+func TestTables(t *testing.T) {
+	// Body paragraph, a table with two rows of three cells, then another body
+	// paragraph. Cell B2 holds two paragraphs
+	text := "Intro\rA1\x07B1\x07C1\x07\x07A2\x07B2a\rB2b\x07C2\x07\x07After\r"
+	fc := func(cp uint32) uint32 { return mockTextOffset + cp*2 }
+
+	inCell := []byte{0, 0, 0x16, 0x24, 1}
+	rowEnd := func(cells []tableCell) []byte {
+		papx := []byte{0, 0, 0x16, 0x24, 1, 0x17, 0x24, 1}
+		return append(papx, buildTableSprms(cells)...)
+	}
+	plain := tableCell{width: 1440, brcTop: 0xFFFFFFFF, shdFore: 0xFF000000, shdBack: 0xFF000000}
+	shaded := tableCell{width: 2880, brcTop: 0xFFFFFFFF, shdFore: 0x0000FF, shdBack: 0xFFFFFF, shdPattern: 1}
+	page := buildPAPXPage(
+		[]uint32{fc(0), fc(6), fc(9), fc(12), fc(15), fc(16), fc(19), fc(23), fc(27), fc(30), fc(31), fc(37)},
+		[][]byte{
+			nil,
+			inCell, inCell, inCell, rowEnd([]tableCell{plain, plain, plain}),
+			inCell, inCell, inCell, inCell, rowEnd([]tableCell{plain, shaded, plain}),
+			nil,
+		},
+	)
+
+	bte := binary.LittleEndian.AppendUint32(nil, fc(0))
+	bte = binary.LittleEndian.AppendUint32(bte, fc(37))
+	bte = binary.LittleEndian.AppendUint32(bte, mockFirstPage)
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: text, unicode: true}},
+		table:  bte,
+		fcLcb:  map[int]uint32{26: 0, 27: uint32(len(bte))},
+		pages:  [][]byte{page},
+	})
+
+	tables, err := doc.Tables()
+	if err != nil {
+		t.Fatalf("Tables failed: %v", err)
+	}
+	if len(tables) != 1 {
+		t.Fatalf("Expected 1 table, got %d", len(tables))
+	}
+	table := tables[0]
+	if table.StartCP != 6 || table.EndCP != 31 {
+		t.Errorf("Expected the table to span CPs 6 to 31, got %d to %d", table.StartCP, table.EndCP)
+	}
+	if len(table.Rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(table.Rows))
+	}
+
+	expected := [][]string{{"A1", "B1", "C1"}, {"A2", "B2a\rB2b", "C2"}}
+	for i, row := range table.Rows {
+		if len(row.Cells) != 3 {
+			t.Fatalf("Row %d: expected 3 cells, got %d", i, len(row.Cells))
+		}
+		for j, cell := range row.Cells {
+			if cell.Text != expected[i][j] {
+				t.Errorf("Cell %d,%d: expected %q, got %q", i, j, expected[i][j], cell.Text)
+			}
+			var runText string
+			for _, run := range cell.Runs {
+				runText += run.Text
+			}
+			if runText != expected[i][j] {
+				t.Errorf("Cell %d,%d: expected runs holding %q, got %q", i, j, expected[i][j], runText)
+			}
+		}
+		if row.Properties == nil || len(row.Properties.CellEdges) != 4 {
+			t.Errorf("Row %d: expected 4 cell edges, got %+v", i, row.Properties)
+		}
+	}
+
+	b2 := table.Rows[1].Cells[1]
+	if b2.StartCP != 19 || b2.EndCP != 27 {
+		t.Errorf("Expected cell B2 to span CPs 19 to 27, got %d to %d", b2.StartCP, b2.EndCP)
+	}
+	if b2.Properties == nil || b2.Properties.Width != 2880 || b2.Properties.Shading == nil || b2.Properties.Shading.Pattern != 1 {
+		t.Errorf("Expected cell B2 to be 2880 twips wide with solid shading, got %+v", b2.Properties)
+	}
+	if a2 := table.Rows[1].Cells[0]; a2.Properties == nil || a2.Properties.Shading != nil {
+		t.Errorf("Expected cell A2 to be unshaded, got %+v", a2.Properties)
+	}
+}
+
+func TestTablesNone(t *testing.T) {
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Just text\r"}}})
+	tables, err := doc.Tables()
+	if err != nil || tables != nil {
+		t.Errorf("Expected no tables, got %+v (%v)", tables, err)
+	}
+}
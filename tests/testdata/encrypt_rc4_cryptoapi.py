@@ -0,0 +1,168 @@
+#!/usr/bin/env python3
+"""Encrypt a Word 97-2003 document with RC4 CryptoAPI encryption.
+
+This is how tests/testdata/sample-2-rc4-cryptoapi.doc was made from
+sample-2.doc. It follows MS-DOC 2.2.6.2 and MS-OFFCRYPTO 2.3.5 and shares no
+code with the Go package, so the fixture checks the reader against an
+independent reading of the specifications. It was not produced by Word.
+
+    python3 encrypt_rc4_cryptoapi.py sample-2.doc sample-2-rc4-cryptoapi.doc password
+
+The compound file is patched in place: the streams keep their sectors and the
+table stream, which grows by the size of the encryption header, gets new
+sectors at the end of the file. Only streams stored in regular sectors are
+supported, which covers the WordDocument, table and Data streams of the
+samples.
+"""
+
+import hashlib
+import struct
+import sys
+
+BLOCK_SIZE = 512
+PLAIN_FIB_SIZE = 68  # FibBase, csw, FibRgW, cslw and cbMac stay unencrypted
+SALT = bytes(range(0x10, 0x20))
+VERIFIER = bytes(range(0xA0, 0xB0))
+PROVIDER = "Microsoft Enhanced Cryptographic Provider v1.0"
+FREE_SECTOR, END_OF_CHAIN = 0xFFFFFFFF, 0xFFFFFFFE
+
+
+def rc4(key, data):
+    s = list(range(256))
+    j = 0
+    for i in range(256):
+        j = (j + s[i] + key[i % len(key)]) & 0xFF
+        s[i], s[j] = s[j], s[i]
+    out = bytearray()
+    i = j = 0
+    for byte in data:
+        i = (i + 1) & 0xFF
+        j = (j + s[i]) & 0xFF
+        s[i], s[j] = s[j], s[i]
+        out.append(byte ^ s[(s[i] + s[j]) & 0xFF])
+    return bytes(out)
+
+
+def block_key(password, block):
+    """MS-OFFCRYPTO 2.3.5.2 with a 128-bit key."""
+    h0 = hashlib.sha1(SALT + password.encode("utf-16-le")).digest()
+    return hashlib.sha1(h0 + struct.pack("<I", block)).digest()[:16]
+
+
+def encrypt_stream(password, data):
+    """Each 512-byte block is encrypted with the key of its block number."""
+    out = bytearray()
+    for start in range(0, len(data), BLOCK_SIZE):
+        out += rc4(block_key(password, start // BLOCK_SIZE), data[start:start + BLOCK_SIZE])
+    return bytes(out)
+
+
+def encryption_header(password):
+    """RC4 CryptoAPI Encryption Header, MS-OFFCRYPTO 2.3.5.1."""
+    csp = (PROVIDER + "\0").encode("utf-16-le")
+    header = struct.pack("<IIIIIIII", 0x04, 0, 0x6801, 0x8004, 128, 1, 0, 0) + csp
+    verifier_hash = hashlib.sha1(VERIFIER).digest()
+    # The verifier and its hash are encrypted with one block 0 keystream
+    encrypted = rc4(block_key(password, 0), VERIFIER + verifier_hash)
+    verifier = (struct.pack("<I", len(SALT)) + SALT + encrypted[:16] +
+                struct.pack("<I", len(verifier_hash)) + encrypted[16:])
+    return struct.pack("<HHII", 4, 2, 0x04, len(header)) + header + verifier
+
+
+class CompoundFile:
+    def __init__(self, data):
+        self.data = bytearray(data)
+        self.sector_size = 1 << struct.unpack_from("<H", data, 0x1E)[0]
+        fat_sectors = struct.unpack_from("<109I", data, 0x4C)[:struct.unpack_from("<I", data, 0x2C)[0]]
+        self.fat_sectors = list(fat_sectors)
+        self.fat = []
+        for sector in self.fat_sectors:
+            self.fat += struct.unpack_from("<%dI" % (self.sector_size // 4), data, self.offset(sector))
+        self.dir_offsets = [self.offset(s) + i for s in self.chain(struct.unpack_from("<I", data, 0x30)[0])
+                            for i in range(0, self.sector_size, 128)]
+
+    def offset(self, sector):
+        return (sector + 1) * self.sector_size
+
+    def chain(self, sector):
+        sectors = []
+        while sector < 0xFFFFFFFA:
+            sectors.append(sector)
+            sector = self.fat[sector]
+        return sectors
+
+    def entry(self, name):
+        for offset in self.dir_offsets:
+            length = struct.unpack_from("<H", self.data, offset + 64)[0]
+            if length and self.data[offset:offset + length - 2].decode("utf-16-le") == name:
+                return offset
+        return None
+
+    def read(self, name):
+        entry = self.entry(name)
+        start, size = struct.unpack_from("<II", self.data, entry + 116)
+        if size < struct.unpack_from("<I", self.data, 0x38)[0]:
+            raise ValueError("%s is stored in the mini stream" % name)
+        return b"".join(self.data[self.offset(s):self.offset(s) + self.sector_size]
+                        for s in self.chain(start))[:size]
+
+    def write(self, name, content):
+        entry = self.entry(name)
+        start = struct.unpack_from("<I", self.data, entry + 116)[0]
+        sectors = self.chain(start)
+        while len(sectors) * self.sector_size < len(content):
+            new = self.fat.index(FREE_SECTOR)
+            while len(self.data) < self.offset(new + 1):
+                self.data += bytes(self.sector_size)
+            self.fat[sectors[-1]], self.fat[new] = new, END_OF_CHAIN
+            sectors.append(new)
+        padded = content + bytes(len(sectors) * self.sector_size - len(content))
+        for i, sector in enumerate(sectors):
+            chunk = padded[i * self.sector_size:(i + 1) * self.sector_size]
+            self.data[self.offset(sector):self.offset(sector) + self.sector_size] = chunk
+        struct.pack_into("<I", self.data, entry + 120, len(content))
+
+    def save(self):
+        per_sector = self.sector_size // 4
+        for i, sector in enumerate(self.fat_sectors):
+            struct.pack_into("<%dI" % per_sector, self.data, self.offset(sector),
+                             *self.fat[i * per_sector:(i + 1) * per_sector])
+        return bytes(self.data)
+
+
+def main(source, target, password):
+    cfb = CompoundFile(open(source, "rb").read())
+    word = bytearray(cfb.read("WordDocument"))
+    flags = struct.unpack_from("<H", word, 10)[0]
+    table_name = "1Table" if flags & 0x0200 else "0Table"
+    table = cfb.read(table_name)
+    header = encryption_header(password)
+
+    # fEncrypted, no XOR obfuscation, and lKey is the size of the header
+    struct.pack_into("<H", word, 10, (flags | 0x0100) & ~0x8000)
+    struct.pack_into("<I", word, 14, len(header))
+
+    # The header is inserted at the start of the table stream, and FIB
+    # offsets into the table stream count it
+    csw = struct.unpack_from("<H", word, 32)[0]
+    cslw = struct.unpack_from("<H", word, 34 + csw * 2)[0]
+    rg_fc_lcb = 34 + csw * 2 + 2 + cslw * 4 + 2
+    pairs = struct.unpack_from("<H", word, rg_fc_lcb - 2)[0]
+    for pair in range(pairs):
+        fc, lcb = struct.unpack_from("<II", word, rg_fc_lcb + pair * 8)
+        if lcb:
+            struct.pack_into("<I", word, rg_fc_lcb + pair * 8, fc + len(header))
+    table = header + table
+
+    # Streams are encrypted from their start; the start of the FIB and the
+    # encryption header are then restored unencrypted
+    encrypted_word = encrypt_stream(password, bytes(word))
+    cfb.write("WordDocument", bytes(word[:PLAIN_FIB_SIZE]) + encrypted_word[PLAIN_FIB_SIZE:])
+    cfb.write(table_name, header + encrypt_stream(password, table)[len(header):])
+    if cfb.entry("Data") is not None:
+        cfb.write("Data", encrypt_stream(password, cfb.read("Data")))
+    open(target, "wb").write(cfb.save())
+
+
+if __name__ == "__main__":
+    main(*sys.argv[1:4])
@@ -0,0 +1,586 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/TalentFormula/msdoc/ole2"
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+func TestFormattedTextFileOffsets(t *testing.T) {
+	mock := &mockDoc{
+		pieces: []mockPiece{
+			{text: "Hello ", unicode: true},
+			{text: "World", unicode: false},
+		},
+	}
+	path := mock.writeFile(t)
+
+	doc, err := msdoc.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open mock document: %v", err)
+	}
+	defer doc.Close()
+
+	runs, err := doc.GetFormattedText()
+	if err != nil {
+		t.Fatalf("GetFormattedText failed: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("Expected 2 runs, got %d", len(runs))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read mock document: %v", err)
+	}
+	oleReader, err := ole2.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	wordStream, err := oleReader.ReadStream("WordDocument")
+	if err != nil {
+		t.Fatalf("Failed to read WordDocument stream: %v", err)
+	}
+
+	// The Unicode run covers UTF-16LE bytes
+	unicodeRun := runs[0]
+	if unicodeRun.FileOffset != mock.textAt[0] {
+		t.Errorf("Expected file offset %d, got %d", mock.textAt[0], unicodeRun.FileOffset)
+	}
+	if unicodeRun.ByteLength != 12 {
+		t.Errorf("Expected byte length 12, got %d", unicodeRun.ByteLength)
+	}
+	raw := wordStream[unicodeRun.FileOffset : unicodeRun.FileOffset+unicodeRun.ByteLength]
+	u16s := make([]uint16, len(raw)/2)
+	for i := range u16s {
+		u16s[i] = uint16(raw[i*2]) | uint16(raw[i*2+1])<<8
+	}
+	if got := string(utf16.Decode(u16s)); got != unicodeRun.Text {
+		t.Errorf("Bytes at run offset decode to %q, expected %q", got, unicodeRun.Text)
+	}
+
+	// The ANSI run covers one byte per character
+	ansiRun := runs[1]
+	if ansiRun.StartPos != 6 || ansiRun.EndPos != 11 {
+		t.Errorf("Expected CP range [6, 11), got [%d, %d)", ansiRun.StartPos, ansiRun.EndPos)
+	}
+	raw = wordStream[ansiRun.FileOffset : ansiRun.FileOffset+ansiRun.ByteLength]
+	if string(raw) != "World" {
+		t.Errorf("Bytes at run offset are %q, expected %q", raw, "World")
+	}
+}
+
+func TestPieces(t *testing.T) {
+	mock := &mockDoc{
+		pieces: []mockPiece{{text: "Hello World", unicode: true}},
+	}
+
+	doc, err := msdoc.Open(mock.writeFile(t))
+	if err != nil {
+		t.Fatalf("Failed to open mock document: %v", err)
+	}
+	defer doc.Close()
+
+	pieces, err := doc.Pieces()
+	if err != nil {
+		t.Fatalf("Pieces failed: %v", err)
+	}
+	if len(pieces) != 1 {
+		t.Fatalf("Expected 1 piece, got %d", len(pieces))
+	}
+
+	piece := pieces[0]
+	if !piece.IsUnicode {
+		t.Error("Expected a Unicode piece")
+	}
+	if piece.IsEncrypted {
+		t.Error("Expected an unencrypted piece")
+	}
+	if piece.StartCP != 0 || piece.EndCP != 11 {
+		t.Errorf("Expected CP range [0, 11), got [%d, %d)", piece.StartCP, piece.EndCP)
+	}
+	if piece.FileOffset != mock.textAt[0] {
+		t.Errorf("Expected file offset %d, got %d", mock.textAt[0], piece.FileOffset)
+	}
+	if piece.ByteLength != 22 {
+		t.Errorf("Expected byte length 22, got %d", piece.ByteLength)
+	}
+}
+
+func TestPieceEncoding(t *testing.T) {
+	mixed := []mockPiece{
+		{text: "Hello ", unicode: true},
+		{text: "World", unicode: false},
+	}
+
+	tests := []struct {
+		name     string
+		nFib     uint16
+		lid      uint16
+		expected []string
+	}{
+		{"Word 97", 0x00C1, 0x0411, []string{"UTF-16LE", "CP1252"}},
+		{"Word 6 Japanese", 0x0065, 0x0411, []string{"UTF-16LE", "CP932"}},
+		{"Word 6 Russian", 0x0065, 0x0419, []string{"UTF-16LE", "CP1251"}},
+		{"Word 6 English", 0x0065, 0x0409, []string{"UTF-16LE", "CP1252"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := openMock(t, &mockDoc{pieces: mixed, nFib: tt.nFib, lid: tt.lid})
+
+			pieces, err := doc.Pieces()
+			if err != nil {
+				t.Fatalf("Pieces failed: %v", err)
+			}
+			if len(pieces) != len(tt.expected) {
+				t.Fatalf("Expected %d pieces, got %d", len(tt.expected), len(pieces))
+			}
+			for i, piece := range pieces {
+				if piece.Encoding != tt.expected[i] {
+					t.Errorf("Piece %d: expected encoding %q, got %q", i, tt.expected[i], piece.Encoding)
+				}
+			}
+		})
+	}
+}
+
+// tableTextMock returns a mock document of the ASCII text with a paragraph
+// ending at each paragraph and cell mark. The cell marks at rowEnds are
+// marked as row end marks by their PAPX and the other cell marks as ending
+// table cells.
+func tableTextMock(text string, rowEnds ...uint32) *mockDoc {
+	fc := func(cp uint32) uint32 { return mockTextOffset + cp*2 }
+	inCell := []byte{0, 0, 0x16, 0x24, 1}
+	rowEnd := []byte{0, 0, 0x16, 0x24, 1, 0x17, 0x24, 1}
+
+	fcs := []uint32{fc(0)}
+	var papxs [][]byte
+	for cp := uint32(0); cp < uint32(len(text)); cp++ {
+		if text[cp] != '\r' && text[cp] != '\x07' && cp != uint32(len(text))-1 {
+			continue
+		}
+		fcs = append(fcs, fc(cp+1))
+		switch {
+		case slices.Contains(rowEnds, cp):
+			papxs = append(papxs, rowEnd)
+		case text[cp] == '\x07':
+			papxs = append(papxs, inCell)
+		default:
+			papxs = append(papxs, nil)
+		}
+	}
+	bte := binary.LittleEndian.AppendUint32(nil, fc(0))
+	bte = binary.LittleEndian.AppendUint32(bte, fc(uint32(len(text))))
+	bte = binary.LittleEndian.AppendUint32(bte, mockFirstPage)
+
+	return &mockDoc{
+		pieces: []mockPiece{{text: text, unicode: true}},
+		table:  bte,
+		fcLcb:  map[int]uint32{26: 0, 27: uint32(len(bte))},
+		pages:  [][]byte{buildPAPXPage(fcs, papxs)},
+	}
+}
+
+func TestTextWithTableSeparators(t *testing.T) {
+	// A 2x2 table: each cell ends with a cell mark and each row with a row
+	// end mark, followed by a regular paragraph.
+	doc := openMock(t, tableTextMock("A1\x07B1\x07\x07A2\x07B2\x07\x07After\r", 6, 13))
+
+	text, err := doc.TextWithOptions(msdoc.TextOptions{
+		TableCellSeparator: "\t",
+		TableRowSeparator:  "\n",
+	})
+	if err != nil {
+		t.Fatalf("TextWithOptions failed: %v", err)
+	}
+	if want := "A1\tB1\nA2\tB2\nAfter\r"; text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+
+	// Without separators the text matches Text()
+	plain, err := doc.TextWithOptions(msdoc.TextOptions{})
+	if err != nil {
+		t.Fatalf("TextWithOptions failed: %v", err)
+	}
+	raw, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if plain != raw {
+		t.Errorf("Expected %q, got %q", raw, plain)
+	}
+
+	// An empty last cell leaves three marks in a row, of which only the last
+	// one ends the row
+	doc = openMock(t, tableTextMock("a\x07\x07\x07b\x07c\x07\x07", 3, 8))
+	text, err = doc.TextWithOptions(msdoc.TextOptions{TableCellSeparator: "\t", TableRowSeparator: "\n"})
+	if err != nil {
+		t.Fatalf("TextWithOptions failed: %v", err)
+	}
+	if want := "a\t\nb\tc\n"; text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+	// The default separators keep the marks that are not replaced
+	if text, err = doc.TextWithOptions(msdoc.TextOptions{TableCellSeparator: "|"}); err != nil || text != "a|\x07\x07b|c\x07\x07" {
+		t.Errorf("Expected the row marks kept, got %q (%v)", text, err)
+	}
+
+}
+
+func TestTextWithBOM(t *testing.T) {
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Hello"}}})
+
+	text, err := doc.TextWithOptions(msdoc.TextOptions{EmitBOM: true})
+	if err != nil {
+		t.Fatalf("TextWithOptions failed: %v", err)
+	}
+	if want := "\xEF\xBB\xBFHello"; text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+
+	text, err = doc.TextWithOptions(msdoc.TextOptions{})
+	if err != nil {
+		t.Fatalf("TextWithOptions failed: %v", err)
+	}
+	if text != "Hello" {
+		t.Errorf("Expected no BOM by default, got %q", text)
+	}
+}
+
+func TestTextWithInfoPieceCoverage(t *testing.T) {
+	// The FIB records 20 characters but the piece table only covers 11
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: "Hello World", unicode: true}},
+		ccpText: 20,
+	})
+
+	text, info, err := doc.TextWithInfo()
+	if err != nil {
+		t.Fatalf("TextWithInfo failed: %v", err)
+	}
+	if text != "Hello World" {
+		t.Errorf("Expected text %q, got %q", "Hello World", text)
+	}
+	if info.PieceCount != 1 || info.LastCP != 11 || info.ExpectedCPs != 20 {
+		t.Errorf("Expected 1 piece ending at CP 11 of 20, got %d pieces ending at CP %d of %d", info.PieceCount, info.LastCP, info.ExpectedCPs)
+	}
+	if len(info.Warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %v", info.Warnings)
+	}
+
+	// Strict mode turns the warning into an error
+	if _, err := doc.TextWithOptions(msdoc.TextOptions{StrictPieceTable: true}); !errors.Is(err, msdoc.ErrPieceTableCoverage) {
+		t.Errorf("Expected ErrPieceTableCoverage in strict mode, got %v", err)
+	}
+	if _, err := doc.TextWithOptions(msdoc.TextOptions{}); err != nil {
+		t.Errorf("Expected no error without strict mode, got %v", err)
+	}
+
+	// A piece table matching the FIB produces no warnings
+	doc = openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: "Hello World", unicode: true}},
+		ccpText: 11,
+	})
+	if _, info, err = doc.TextWithInfo(); err != nil {
+		t.Fatalf("TextWithInfo failed: %v", err)
+	}
+	if len(info.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", info.Warnings)
+	}
+	if _, err := doc.TextWithOptions(msdoc.TextOptions{StrictPieceTable: true}); err != nil {
+		t.Errorf("Expected no error in strict mode, got %v", err)
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	hash := func(title, author string, paragraphs ...string) string {
+		t.Helper()
+		w := msdoc.NewDocumentWriter()
+		w.SetTitle(title)
+		w.SetAuthor(author)
+		for _, p := range paragraphs {
+			w.AddParagraph(p)
+		}
+		h, err := saveAndOpen(t, w).ContentHash()
+		if err != nil {
+			t.Fatalf("ContentHash failed: %v", err)
+		}
+		return h
+	}
+
+	first := hash("Draft", "Alice", "Quarterly report", "Revenue grew.")
+	if len(first) != 64 {
+		t.Errorf("Expected a hex SHA-256 hash, got %q", first)
+	}
+	if second := hash("Final", "Bob", "Quarterly report", "Revenue grew."); second != first {
+		t.Errorf("Expected identical text to hash the same, got %s and %s", first, second)
+	}
+	if changed := hash("Draft", "Alice", "Quarterly report", "Revenue fell."); changed == first {
+		t.Error("Expected different text to hash differently")
+	}
+}
+
+func TestTextManualLineBreak(t *testing.T) {
+	doc := openMock(t, tableTextMock("Jane Doe\v12 Main Street\vSpringfield\rA1\x07B1\x07\x07", 42))
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	expected := "Jane Doe\n12 Main Street\nSpringfield\rA1\x07B1\x07\x07"
+	if text != expected {
+		t.Errorf("Expected text %q, got %q", expected, text)
+	}
+
+	text, err = doc.TextWithOptions(msdoc.TextOptions{TableCellSeparator: "\t", TableRowSeparator: "\n"})
+	if err != nil {
+		t.Fatalf("TextWithOptions failed: %v", err)
+	}
+	expected = "Jane Doe\n12 Main Street\nSpringfield\rA1\tB1\n"
+	if text != expected {
+		t.Errorf("Expected text %q, got %q", expected, text)
+	}
+}
+
+func TestTextCLXInWordDocument(t *testing.T) {
+	doc := openMock(t, &mockDoc{
+		pieces:    []mockPiece{{text: "Stored the old way\r", unicode: true}},
+		clxInWord: true,
+	})
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if text != "Stored the old way\r" {
+		t.Errorf("Expected text %q, got %q", "Stored the old way\r", text)
+	}
+
+	pieces, err := doc.Pieces()
+	if err != nil {
+		t.Fatalf("Pieces failed: %v", err)
+	}
+	if len(pieces) != 1 || pieces[0].EndCP != 19 {
+		t.Errorf("Expected 1 piece ending at CP 19, got %+v", pieces)
+	}
+}
+
+func TestTextCLXWithPrc(t *testing.T) {
+	// A Prc holding sprmCFBold ahead of the piece table
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "After the Prc\r"}},
+		prc:    []byte{0x01, 0x03, 0x00, 0x35, 0x08, 0x01},
+	})
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if text != "After the Prc\r" {
+		t.Errorf("Expected text %q, got %q", "After the Prc\r", text)
+	}
+}
+
+func TestTextWithStyleNamePrefixes(t *testing.T) {
+	text := "Introduction\rBody text\rBackground\r"
+	fc := func(cp uint32) uint32 { return mockTextOffset + cp*2 }
+
+	// Both headings use Heading 1 (istd 1); the body paragraph has default
+	// properties and so the Normal style
+	heading := []byte{1, 0}
+	page := buildPAPXPage(
+		[]uint32{fc(0), fc(13), fc(23), fc(34)},
+		[][]byte{heading, nil, heading},
+	)
+	bte := binary.LittleEndian.AppendUint32(nil, fc(0))
+	bte = binary.LittleEndian.AppendUint32(bte, fc(34))
+	bte = binary.LittleEndian.AppendUint32(bte, mockFirstPage)
+
+	stsh := buildSTSH(
+		&mockStyle{name: "Normal", istdBase: 0x0FFF},
+		&mockStyle{name: "Heading 1", istdBase: 0},
+	)
+	table := append(append([]byte(nil), bte...), stsh...)
+
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: text, unicode: true}},
+		table:  table,
+		fcLcb: map[int]uint32{
+			2: uint32(len(bte)), 3: uint32(len(stsh)),
+			26: 0, 27: uint32(len(bte)),
+		},
+		pages: [][]byte{page},
+	})
+
+	got, err := doc.TextWithOptions(msdoc.TextOptions{PrefixStyleNames: true})
+	if err != nil {
+		t.Fatalf("TextWithOptions failed: %v", err)
+	}
+	want := "[Heading 1] Introduction\r[Normal] Body text\r[Heading 1] Background\r"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// Without the option the text is unchanged
+	plain, err := doc.TextWithOptions(msdoc.TextOptions{})
+	if err != nil {
+		t.Fatalf("TextWithOptions failed: %v", err)
+	}
+	if plain != text {
+		t.Errorf("Expected %q without prefixes, got %q", text, plain)
+	}
+}
+
+func TestTextByteSwappedPieces(t *testing.T) {
+	// A byte-swapped Latin piece between an ANSI and a little-endian piece
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{
+		{text: "Intro\r"},
+		{text: "Swapped text\r", unicode: true, bigEndian: true},
+		{text: "Straight text\r", unicode: true},
+	}})
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if want := "Intro\rSwapped text\rStraight text\r"; text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+
+	// Swapped text without zero bytes needs the override, unless it starts
+	// with a byte order mark
+	cjk := openMock(t, &mockDoc{pieces: []mockPiece{{text: "日本語\r", unicode: true, bigEndian: true}}})
+	if text, err := cjk.TextWithOptions(msdoc.TextOptions{Endianness: msdoc.EndiannessBig}); err != nil || text != "日本語\r" {
+		t.Errorf("Expected the big-endian text, got %q (err: %v)", text, err)
+	}
+	if text, err := cjk.Text(); err != nil || text == "日本語\r" {
+		t.Errorf("Expected the text decoded as little-endian, got %q (err: %v)", text, err)
+	}
+	bom := openMock(t, &mockDoc{pieces: []mockPiece{{text: "\uFEFF日本語\r", unicode: true, bigEndian: true}}})
+	if text, err := bom.Text(); err != nil || text != "\uFEFF日本語\r" {
+		t.Errorf("Expected the text after the byte order mark, got %q (err: %v)", text, err)
+	}
+
+	// Short little-endian pieces of characters with a zero low byte look
+	// swapped but are too short to tell
+	short := openMock(t, &mockDoc{pieces: []mockPiece{
+		{text: "\u4E00", unicode: true},
+		{text: "\u3000", unicode: true},
+		{text: "\uAC00\u4E00", unicode: true},
+		{text: "\r", unicode: true},
+	}})
+	if text, err := short.Text(); err != nil || text != "\u4E00\u3000\uAC00\u4E00\r" {
+		t.Errorf("Expected the short pieces as little-endian, got %q (err: %v)", text, err)
+	}
+}
+
+func TestTextCP1252Pieces(t *testing.T) {
+	// ANSI pieces hold CP-1252 bytes: a right single quote, an em dash and
+	// e with an acute accent, next to a Unicode piece
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{
+		{text: "don\x92t \x97 r\xe9sum\xe9 "},
+		{text: "€5\r", unicode: true},
+		{text: "\x80\x81\xa0\xff\r"},
+	}})
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	want := "don’t — résumé €5\r€\u0081 ÿ\r"
+	if text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+	if !utf8.ValidString(text) {
+		t.Error("Expected valid UTF-8")
+	}
+
+	// Every byte is one character, so the CPs of later text are unchanged
+	runs, err := doc.GetFormattedText()
+	if err != nil {
+		t.Fatalf("GetFormattedText failed: %v", err)
+	}
+	if len(runs) != 3 || runs[1].StartPos != 15 || runs[1].Text != "€5\r" {
+		t.Errorf("Unexpected runs %+v", runs)
+	}
+}
+
+func TestFormattedTextBoldRun(t *testing.T) {
+	text := "Plain bold text\r"
+	fc := func(cp uint32) uint32 { return mockTextOffset + cp }
+
+	// CHPX FKP: "bold" has sprmCFBold, the rest default properties
+	fkp := make([]byte, 512)
+	for i, cp := range []uint32{0, 6, 10, uint32(len(text))} {
+		binary.LittleEndian.PutUint32(fkp[i*4:], fc(cp))
+	}
+	fkp[16+1] = 0x80                            // Bold run CHPX at byte offset 0x100
+	copy(fkp[0x100:], []byte{3, 0x35, 0x08, 1}) // sprmCFBold
+	fkp[511] = 3
+
+	bte := binary.LittleEndian.AppendUint32(nil, fc(0))
+	bte = binary.LittleEndian.AppendUint32(bte, fc(uint32(len(text))))
+	bte = binary.LittleEndian.AppendUint32(bte, mockFirstPage)
+
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: uint32(len(text)),
+		table:   bte,
+		fcLcb:   map[int]uint32{24: 0, 25: uint32(len(bte))},
+		pages:   [][]byte{fkp},
+	})
+
+	runs, err := doc.GetFormattedText()
+	if err != nil {
+		t.Fatalf("GetFormattedText failed: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("Expected 3 runs, got %d", len(runs))
+	}
+	for i, want := range []struct {
+		text       string
+		start, end uint32
+		bold       bool
+	}{
+		{"Plain ", 0, 6, false},
+		{"bold", 6, 10, true},
+		{" text\r", 10, 16, false},
+	} {
+		run := runs[i]
+		if run.Text != want.text || run.StartPos != want.start || run.EndPos != want.end {
+			t.Errorf("Run %d: expected %q at [%d, %d), got %q at [%d, %d)", i, want.text, want.start, want.end, run.Text, run.StartPos, run.EndPos)
+		}
+		if run.CharProps == nil || run.CharProps.Bold != want.bold {
+			t.Errorf("Run %d: expected bold %v, got %+v", i, want.bold, run.CharProps)
+		}
+		if run.ParaProps == nil {
+			t.Errorf("Run %d: expected paragraph properties", i)
+		}
+	}
+	if runs[1].FileOffset != fc(6) || runs[1].ByteLength != 4 {
+		t.Errorf("Expected the bold run at offset %d with 4 bytes, got %d with %d", fc(6), runs[1].FileOffset, runs[1].ByteLength)
+	}
+}
+
+func TestAccessedStreams(t *testing.T) {
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: "Plain text\r"}},
+		streams: []mockStream{{name: "Data", data: []byte("unused")}},
+	})
+
+	if _, err := doc.Text(); err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	expected := []string{"1Table", "WordDocument"}
+	if got := doc.AccessedStreams(); !slices.Equal(got, expected) {
+		t.Errorf("Expected accessed streams %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/metadata"
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+// openThumbnailMock opens a mock document whose SummaryInformation stores
+// clipboardData as its VT_CF thumbnail.
+func openThumbnailMock(t *testing.T, clipboardData []byte) *msdoc.Document {
+	t.Helper()
+	summary := [16]byte{0xE0, 0x85, 0x9F, 0xF2, 0xF9, 0x4F, 0x68, 0x10, 0xAB, 0x91, 0x08, 0x00, 0x2B, 0x27, 0xB3, 0xD9}
+	value := binary.LittleEndian.AppendUint32([]byte{0x47, 0, 0, 0}, uint32(len(clipboardData)))
+	value = append(value, clipboardData...)
+	stream := buildPropertySetStream([][16]byte{summary}, buildPropertySection(mockProperty{0x11, value}))
+	return openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: "Preview"}},
+		streams: []mockStream{{name: "\x05SummaryInformation", data: stream}},
+	})
+}
+
+func TestThumbnailDIB(t *testing.T) {
+	// 2x2 24-bit bitmap, stored bottom-up with rows padded to 4 bytes
+	dib := binary.LittleEndian.AppendUint32(nil, 40)
+	dib = binary.LittleEndian.AppendUint32(dib, 2)        // Width
+	dib = binary.LittleEndian.AppendUint32(dib, 2)        // Height
+	dib = binary.LittleEndian.AppendUint16(dib, 1)        // Planes
+	dib = binary.LittleEndian.AppendUint16(dib, 24)       // Bit count
+	dib = append(dib, make([]byte, 24)...)                // BI_RGB, no palette
+	dib = append(dib, 0, 0, 0xFF, 0, 0xFF, 0, 0, 0)       // Bottom row: red, green
+	dib = append(dib, 0xFF, 0, 0, 0xFF, 0xFF, 0xFF, 0, 0) // Top row: blue, white
+
+	clipboard := binary.LittleEndian.AppendUint32(nil, 0xFFFFFFFF)
+	clipboard = binary.LittleEndian.AppendUint32(clipboard, metadata.CFDIB)
+	doc := openThumbnailMock(t, append(clipboard, dib...))
+
+	if format := doc.Metadata().ThumbnailClipboardFormat; format != metadata.CFDIB {
+		t.Errorf("Expected clipboard format CF_DIB, got %d", format)
+	}
+	thumbnail, err := doc.Thumbnail()
+	if err != nil {
+		t.Fatalf("Thumbnail failed: %v", err)
+	}
+	if thumbnail == nil || thumbnail.Kind != metadata.ThumbnailDIB || thumbnail.Image == nil {
+		t.Fatalf("Expected a decoded DIB thumbnail, got %+v", thumbnail)
+	}
+	if size := thumbnail.Image.Bounds().Size(); size != image.Pt(2, 2) {
+		t.Fatalf("Expected a 2x2 image, got %v", size)
+	}
+	expected := map[image.Point]color.RGBA{
+		{0, 0}: {0, 0, 0xFF, 0xFF},
+		{1, 0}: {0xFF, 0xFF, 0xFF, 0xFF},
+		{0, 1}: {0xFF, 0, 0, 0xFF},
+		{1, 1}: {0, 0xFF, 0, 0xFF},
+	}
+	for pt, want := range expected {
+		if got := color.RGBAModel.Convert(thumbnail.Image.At(pt.X, pt.Y)); got != want {
+			t.Errorf("Pixel %v: expected %v, got %v", pt, want, got)
+		}
+	}
+}
+
+func TestThumbnailPNG(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	src.Set(2, 0, color.RGBA{0x12, 0x34, 0x56, 0xFF})
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, src); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+
+	// Registered clipboard format named "PNG"
+	clipboard := binary.LittleEndian.AppendUint32(nil, 4)
+	clipboard = append(clipboard, "PNG\x00"...)
+	doc := openThumbnailMock(t, append(clipboard, encoded.Bytes()...))
+
+	thumbnail, err := doc.Thumbnail()
+	if err != nil {
+		t.Fatalf("Thumbnail failed: %v", err)
+	}
+	if thumbnail == nil || thumbnail.Kind != metadata.ThumbnailPNG || !bytes.Equal(thumbnail.Data, encoded.Bytes()) {
+		t.Fatalf("Expected the PNG thumbnail, got %+v", thumbnail)
+	}
+	if got := color.RGBAModel.Convert(thumbnail.Image.At(2, 0)); got != (color.RGBA{0x12, 0x34, 0x56, 0xFF}) {
+		t.Errorf("Unexpected pixel %v", got)
+	}
+
+	// Metafiles are returned without their METAFILEPICT header
+	wmf := []byte{0x01, 0x00, 0x09, 0x00}
+	clipboard = binary.LittleEndian.AppendUint32(nil, 0xFFFFFFFF)
+	clipboard = binary.LittleEndian.AppendUint32(clipboard, metadata.CFMetafilePict)
+	clipboard = append(clipboard, 8, 0, 0x10, 0, 0x10, 0, 0, 0) // MM_ANISOTROPIC, extents
+	thumbnail, err = metadata.ParseThumbnail(append(clipboard, wmf...))
+	if err != nil {
+		t.Fatalf("ParseThumbnail failed: %v", err)
+	}
+	if thumbnail.Kind != metadata.ThumbnailWMF || !bytes.Equal(thumbnail.Data, wmf) || thumbnail.Image != nil {
+		t.Errorf("Expected the bare WMF data, got %+v", thumbnail)
+	}
+
+	// Documents without a thumbnail have none
+	plain := openMock(t, &mockDoc{pieces: []mockPiece{{text: "No preview"}}})
+	if thumbnail, err := plain.Thumbnail(); err != nil || thumbnail != nil {
+		t.Errorf("Expected no thumbnail, got %+v (err: %v)", thumbnail, err)
+	}
+}
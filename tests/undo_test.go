@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+func TestUndoData(t *testing.T) {
+	undo := []byte{0x0C, 0x00, 0x01, 0x02, 0xDE, 0xAD, 0xBE, 0xEF}
+	table := append(make([]byte, 16), undo...)
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Edited\r"}},
+		table:  table,
+		fcLcb:  map[int]uint32{154: 16, 155: uint32(len(undo))},
+	})
+	if !doc.HasUndoData() {
+		t.Error("Expected undo data")
+	}
+	data, err := doc.UndoData()
+	if err != nil {
+		t.Fatalf("UndoData failed: %v", err)
+	}
+	if !bytes.Equal(data, undo) {
+		t.Errorf("Expected %x, got %x", undo, data)
+	}
+
+	// Undo data beyond the table stream is an error
+	doc = openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Edited\r"}},
+		table:  table,
+		fcLcb:  map[int]uint32{154: 16, 155: 0x1000},
+	})
+	if _, err := doc.UndoData(); err == nil {
+		t.Error("Expected an error for undo data outside the table stream")
+	}
+
+	// The sample documents were saved without undo data
+	sample, err := msdoc.Open("testdata/sample-1.doc")
+	if err != nil {
+		t.Fatalf("Failed to open sample: %v", err)
+	}
+	defer sample.Close()
+	if sample.HasUndoData() {
+		t.Error("Expected no undo data in the sample")
+	}
+	if data, err := sample.UndoData(); data != nil || err != nil {
+		t.Errorf("Expected no undo data, got %d bytes (%v)", len(data), err)
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+// issuesOf returns the issues with at least the given severity.
+func issuesOf(issues []msdoc.Issue, min msdoc.Severity) []msdoc.Issue {
+	var found []msdoc.Issue
+	for _, issue := range issues {
+		if issue.Severity >= min {
+			found = append(found, issue)
+		}
+	}
+	return found
+}
+
+func TestValidateHealthy(t *testing.T) {
+	w := msdoc.NewDocumentWriter()
+	w.SetTitle("Healthy")
+	w.AddParagraph("A healthy document")
+	if issues := issuesOf(saveAndOpen(t, w).Validate(), msdoc.SeverityWarning); len(issues) != 0 {
+		t.Errorf("Expected no issues for a written document, got %+v", issues)
+	}
+
+	doc, err := msdoc.Open("testdata/sample-1.doc")
+	if err != nil {
+		t.Fatalf("Failed to open sample-1.doc: %v", err)
+	}
+	defer doc.Close()
+	if issues := issuesOf(doc.Validate(), msdoc.SeverityWarning); len(issues) != 0 {
+		t.Errorf("Expected no issues for sample-1.doc, got %+v", issues)
+	}
+}
+
+func TestValidateCorrupted(t *testing.T) {
+	// The FIB counts more text than the piece table holds, the DOP lies past
+	// the end of the table stream and the SummaryInformation is not a
+	// property set
+	text := "Short\r"
+	doc := openMock(t, &mockDoc{
+		pieces:  []mockPiece{{text: text}},
+		ccpText: 100,
+		table:   make([]byte, 16),
+		fcLcb:   map[int]uint32{62: 8, 63: 500}, // Dop
+		streams: []mockStream{{name: "\x05SummaryInformation", data: []byte(strings.Repeat("junk", 16))}},
+	})
+
+	issues := doc.Validate()
+	for _, want := range []struct {
+		severity msdoc.Severity
+		check    string
+		message  string
+	}{
+		{msdoc.SeverityWarning, "piece-table", "text is truncated"},
+		{msdoc.SeverityError, "table-stream", "Dop at offset 8 with size 500"},
+		{msdoc.SeverityError, "property-set", "SummaryInformation: invalid byte order mark"},
+	} {
+		found := false
+		for _, issue := range issues {
+			if issue.Severity == want.severity && issue.Check == want.check && strings.Contains(issue.Message, want.message) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Expected a %v %s issue containing %q, got %+v", want.severity, want.check, want.message, issues)
+		}
+	}
+	if len(issues) != 3 {
+		t.Errorf("Expected exactly 3 issues, got %+v", issues)
+	}
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+// buildStwUser encodes document variables as a StwUser: a STTB of names
+// followed by a null-terminated Xstz value per name.
+func buildStwUser(names, values []string) []byte {
+	data := buildSTTB(names...)
+	for _, value := range values {
+		units := utf16.Encode([]rune(value))
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(units)))
+		for _, u := range units {
+			data = binary.LittleEndian.AppendUint16(data, u)
+		}
+		data = binary.LittleEndian.AppendUint16(data, 0) // chTerm
+	}
+	return data
+}
+
+func TestVariables(t *testing.T) {
+	stwUser := buildStwUser([]string{"ClientName", "ContractID"}, []string{"Contoso Ltd", "C-2024-017"})
+	doc := openMock(t, &mockDoc{
+		pieces: []mockPiece{{text: "Dear client\r"}},
+		table:  stwUser,
+		fcLcb:  map[int]uint32{120: 0, 121: uint32(len(stwUser))},
+	})
+
+	vars, err := doc.Variables()
+	if err != nil {
+		t.Fatalf("Variables failed: %v", err)
+	}
+	if len(vars) != 2 {
+		t.Fatalf("Expected 2 variables, got %d: %v", len(vars), vars)
+	}
+	if vars["ClientName"] != "Contoso Ltd" {
+		t.Errorf("Expected ClientName %q, got %q", "Contoso Ltd", vars["ClientName"])
+	}
+	if vars["ContractID"] != "C-2024-017" {
+		t.Errorf("Expected ContractID %q, got %q", "C-2024-017", vars["ContractID"])
+	}
+}
+
+func TestVariablesNone(t *testing.T) {
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{{text: "No variables\r"}}})
+
+	vars, err := doc.Variables()
+	if err != nil {
+		t.Fatalf("Variables failed: %v", err)
+	}
+	if len(vars) != 0 {
+		t.Errorf("Expected no variables, got %v", vars)
+	}
+}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TalentFormula/msdoc/pkg"
+)
+
+func TestOpenUnsupportedVariants(t *testing.T) {
+	// Word for Macintosh 5.1 header: wIdent 0xFE37 and nFib 0x23, big-endian
+	mac := append([]byte{0xFE, 0x37, 0x00, 0x23}, make([]byte, 508)...)
+
+	// A compound file whose FIB is byte-swapped
+	swapped := append([]byte{0xA5, 0xEC, 0x00, 0xC1}, make([]byte, 508)...)
+
+	for _, tc := range []struct {
+		name    string
+		data    []byte
+		variant string
+	}{
+		{"Mac Word 5", mac, "Word for Macintosh 5"},
+		{"Pocket Word", []byte(`{\pwi Pocket Word document}`), "Pocket Word"},
+		{"big-endian FIB", buildCompoundFile([]mockStream{{name: "WordDocument", data: swapped}}), "big-endian FIB"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "variant.doc")
+			if err := os.WriteFile(path, tc.data, 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+			_, err := msdoc.Open(path)
+			if !errors.Is(err, msdoc.ErrUnsupportedVariant) {
+				t.Fatalf("Expected ErrUnsupportedVariant, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.variant) {
+				t.Errorf("Expected the error to name %q, got %v", tc.variant, err)
+			}
+		})
+	}
+
+	// Word 98 and later for Macintosh use the Windows format
+	doc := openMock(t, &mockDoc{pieces: []mockPiece{{text: "Written on a Mac\r"}}})
+	if text, err := doc.Text(); err != nil || text != "Written on a Mac\r" {
+		t.Errorf("Expected the text of a little-endian document, got %q (err: %v)", text, err)
+	}
+}
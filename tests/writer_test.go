@@ -0,0 +1,393 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/TalentFormula/msdoc/formatting"
+	"github.com/TalentFormula/msdoc/pkg"
+	"github.com/TalentFormula/msdoc/structures"
+)
+
+// saveAndOpen saves the writer's document to a temporary file and reopens it.
+func saveAndOpen(t *testing.T, w *msdoc.DocumentWriter) *msdoc.Document {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "written.doc")
+	if err := w.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	doc, err := msdoc.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open written document: %v", err)
+	}
+	t.Cleanup(func() { doc.Close() })
+	return doc
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	w := msdoc.NewDocumentWriter()
+	w.AddParagraph("First paragraph")
+	w.AddParagraph("Second paragraph")
+
+	doc := saveAndOpen(t, w)
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	for _, want := range []string{"First paragraph", "Second paragraph"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected written text to contain %q, got %q", want, text)
+		}
+	}
+}
+
+func TestWriterReadOnlyRecommended(t *testing.T) {
+	for _, recommended := range []bool{true, false} {
+		w := msdoc.NewDocumentWriter()
+		w.AddParagraph("Distributed template")
+		w.SetReadOnlyRecommended(recommended)
+
+		doc := saveAndOpen(t, w)
+
+		if got := doc.Metadata().ReadOnlyRecommended; got != recommended {
+			t.Errorf("Expected ReadOnlyRecommended %v, got %v", recommended, got)
+		}
+
+		text, err := doc.Text()
+		if err != nil {
+			t.Fatalf("Text failed: %v", err)
+		}
+		if !strings.Contains(text, "Distributed template") {
+			t.Errorf("Expected written text to round-trip, got %q", text)
+		}
+	}
+}
+
+func TestWriterPageAndSectionBreaks(t *testing.T) {
+	w := msdoc.NewDocumentWriter()
+	w.AddParagraph("Page one")
+	w.InsertPageBreak()
+	w.AddParagraph("Page two")
+	w.InsertSectionBreak()
+	w.AddParagraph("Second section")
+
+	doc := saveAndOpen(t, w)
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	expected := "Page one\r\x0CPage two\r\x0CSecond section\r"
+	if text != expected {
+		t.Errorf("Expected text %q, got %q", expected, text)
+	}
+
+	sections, err := doc.Sections()
+	if err != nil {
+		t.Fatalf("Sections failed: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(sections))
+	}
+
+	// The first section ends just past the section mark
+	sectionMark := uint32(strings.LastIndex(expected, "\x0C"))
+	if sections[0].StartCP != 0 || sections[0].EndCP != sectionMark+1 {
+		t.Errorf("Section 0: expected CP range [0, %d), got [%d, %d)", sectionMark+1, sections[0].StartCP, sections[0].EndCP)
+	}
+	if sections[1].StartCP != sectionMark+1 || sections[1].EndCP != uint32(len(expected)) {
+		t.Errorf("Section 1: expected CP range [%d, %d), got [%d, %d)", sectionMark+1, len(expected), sections[1].StartCP, sections[1].EndCP)
+	}
+	for i, section := range sections {
+		if section.Properties.NfcPgn != structures.PageNumberArabic {
+			t.Errorf("Section %d: expected default page numbering, got %v", i, section.Properties.NfcPgn)
+		}
+	}
+}
+
+func TestWriterDocumentProperties(t *testing.T) {
+	w := msdoc.NewDocumentWriter()
+	w.AddParagraph("Narrow margins")
+	w.InsertSectionBreak()
+	w.AddParagraph("Same page setup")
+	w.SetPageSetup(msdoc.PageSetup{
+		Width:        11906, // A4
+		Height:       16838,
+		MarginLeft:   720,
+		MarginRight:  720,
+		MarginTop:    1080,
+		MarginBottom: 1080,
+	})
+
+	doc := saveAndOpen(t, w)
+
+	tab, err := doc.DefaultTabStop()
+	if err != nil {
+		t.Fatalf("DefaultTabStop failed: %v", err)
+	}
+	if tab != 720 {
+		t.Errorf("Expected default tab stop of 720 twips, got %d", tab)
+	}
+
+	sections, err := doc.Sections()
+	if err != nil {
+		t.Fatalf("Sections failed: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(sections))
+	}
+	for i, section := range sections {
+		sep := section.Properties
+		if width, height := sep.GetPageDimensions(); width != 11906 || height != 16838 {
+			t.Errorf("Section %d: expected A4 page, got %dx%d", i, width, height)
+		}
+		if left, right, top, bottom := sep.GetMargins(); left != 720 || right != 720 || top != 1080 || bottom != 1080 {
+			t.Errorf("Section %d: unexpected margins %d, %d, %d, %d", i, left, right, top, bottom)
+		}
+	}
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if text != "Narrow margins\r\x0CSame page setup\r" {
+		t.Errorf("Unexpected text %q", text)
+	}
+
+	// A custom tab stop interval is written to the DOP
+	w = msdoc.NewDocumentWriter()
+	w.AddParagraph("Wide tabs")
+	w.SetDefaultTabStop(1440)
+	if tab, err := saveAndOpen(t, w).DefaultTabStop(); err != nil || tab != 1440 {
+		t.Errorf("Expected default tab stop of 1440 twips, got %d (%v)", tab, err)
+	}
+}
+
+func TestWriterDocumentSummaryProperties(t *testing.T) {
+	w := msdoc.NewDocumentWriter()
+	w.AddParagraph("Budget")
+	w.SetTitle("Annual budget")
+	w.SetCompany("Contoso")
+	w.SetManager("Jane Smith")
+	w.SetCategory("Finance")
+	w.SetContentStatus("Draft")
+
+	meta := saveAndOpen(t, w).Metadata()
+	if meta == nil {
+		t.Fatal("Expected metadata")
+	}
+	if meta.Manager != "Jane Smith" {
+		t.Errorf("Expected manager %q, got %q", "Jane Smith", meta.Manager)
+	}
+	if meta.Category != "Finance" {
+		t.Errorf("Expected category %q, got %q", "Finance", meta.Category)
+	}
+	if meta.ContentStatus != "Draft" {
+		t.Errorf("Expected content status %q, got %q", "Draft", meta.ContentStatus)
+	}
+	if meta.Company != "Contoso" {
+		t.Errorf("Expected company %q, got %q", "Contoso", meta.Company)
+	}
+	if meta.Title != "Annual budget" {
+		t.Errorf("Expected title %q, got %q", "Annual budget", meta.Title)
+	}
+}
+
+func TestWriterEmbeddedFile(t *testing.T) {
+	pdf := []byte("%PDF-1.4\nattachment\n%%EOF")
+
+	w := msdoc.NewDocumentWriter()
+	w.AddText("See the attached report: ")
+	w.AddEmbeddedFile(pdf, "report.pdf")
+	w.AddParagraph("")
+
+	doc := saveAndOpen(t, w)
+
+	objs, err := doc.GetEmbeddedObjects()
+	if err != nil {
+		t.Fatalf("GetEmbeddedObjects failed: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("Expected 1 embedded object, got %d", len(objs))
+	}
+	for _, obj := range objs {
+		if obj.ClassName != "Package" {
+			t.Errorf("Expected class Package, got %q", obj.ClassName)
+		}
+		if obj.Name != "report.pdf" {
+			t.Errorf("Expected name %q, got %q", "report.pdf", obj.Name)
+		}
+		if !bytes.Equal(obj.Data, pdf) {
+			t.Errorf("Expected the embedded file to round-trip, got %q", obj.Data)
+		}
+		if ext := obj.FileExtension(); ext != ".pdf" {
+			t.Errorf("Expected extension .pdf, got %s", ext)
+		}
+	}
+
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	if text != "See the attached report: \x01\r" {
+		t.Errorf("Expected the object anchor in the text, got %q", text)
+	}
+}
+
+func TestWriterRightToLeft(t *testing.T) {
+	w := msdoc.NewDocumentWriter()
+	w.AddParagraph("Left to right")
+	w.AddFormattedParagraph("مرحبا بالعالم",
+		&formatting.CharacterProperties{BiDi: true},
+		&formatting.ParagraphProperties{BiDi: true})
+	w.AddParagraph("שלום")
+
+	doc := saveAndOpen(t, w)
+
+	paragraphs, err := doc.Paragraphs()
+	if err != nil {
+		t.Fatalf("Paragraphs failed: %v", err)
+	}
+	if len(paragraphs) != 3 {
+		t.Fatalf("Expected 3 paragraphs, got %d", len(paragraphs))
+	}
+	if paragraphs[1].Text != "مرحبا بالعالم" {
+		t.Errorf("Expected the Arabic text to round-trip, got %q", paragraphs[1].Text)
+	}
+	for i, want := range []bool{false, true, false} {
+		if paragraphs[i].BiDi != want {
+			t.Errorf("Paragraph %d: expected BiDi %v, got %v", i, want, paragraphs[i].BiDi)
+		}
+	}
+}
+
+func TestWriterTableOfContents(t *testing.T) {
+	w := msdoc.NewDocumentWriter()
+	w.AddTableOfContents()
+	w.AddParagraph("Introduction")
+
+	doc := saveAndOpen(t, w)
+
+	fields, err := doc.Fields(msdoc.SubdocumentMain)
+	if err != nil {
+		t.Fatalf("Fields failed: %v", err)
+	}
+	text, err := doc.Text()
+	if err != nil {
+		t.Fatalf("Text failed: %v", err)
+	}
+	units := utf16.Encode([]rune(text))
+
+	var codes []string
+	for _, field := range fields {
+		// The field code lies between the begin character and the separator
+		if int(field.Start) < int(field.End) && int(field.End) <= len(units) {
+			codes = append(codes, strings.TrimSpace(string(utf16.Decode(units[field.Start+1:field.End]))))
+		}
+	}
+	if len(codes) != 1 || codes[0] != `TOC \o "1-3"` {
+		t.Errorf("Expected a single TOC field, got codes %q", codes)
+	}
+	if len(fields) != 1 || fields[0].FieldType != 0x0D {
+		t.Errorf("Expected a field of type TOC (0x0D), got %+v", fields)
+	}
+	if !strings.Contains(text, "Introduction") {
+		t.Errorf("Expected the text after the table of contents, got %q", text)
+	}
+}
+
+func TestWriterSaveFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "existing.doc")
+
+	w := msdoc.NewDocumentWriter()
+	w.AddParagraph("Original content")
+	if err := w.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read saved document: %v", err)
+	}
+
+	// More text than a compound file with only header FAT sectors can
+	// address makes writing the document fail
+	big := msdoc.NewDocumentWriter()
+	big.AddParagraph(strings.Repeat("x", 8<<20))
+	if err := big.Save(path); err == nil {
+		t.Fatal("Expected Save to fail for an oversized document")
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read document after the failed save: %v", err)
+	}
+	if !bytes.Equal(after, original) {
+		t.Error("Expected the failed save to leave the existing document untouched")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, got %d entries", len(entries))
+	}
+}
+
+func TestWriterCustomProperties(t *testing.T) {
+	reviewed := time.Date(2024, 5, 17, 9, 30, 0, 0, time.UTC)
+
+	w := msdoc.NewDocumentWriter()
+	w.AddParagraph("Proposal")
+	w.SetCompany("Contoso")
+	w.SetCustomProperty("Project Code", "PRJ-2291")
+	w.SetCustomProperty("Revision", 3)
+	w.SetCustomProperty("Budget", 12500.75)
+	w.SetCustomProperty("Approved", true)
+	w.SetCustomProperty("Reviewed", reviewed)
+	w.SetCustomProperty("Revision", 4) // Replaces the earlier value
+
+	doc := saveAndOpen(t, w)
+	meta := doc.Metadata()
+	if meta == nil {
+		t.Fatal("Expected metadata")
+	}
+	if meta.Company != "Contoso" {
+		t.Errorf("Expected company %q next to the custom properties, got %q", "Contoso", meta.Company)
+	}
+
+	custom := meta.CustomProperties
+	if len(custom) != 5 {
+		t.Errorf("Expected 5 custom properties, got %v", custom)
+	}
+	if code := custom["Project Code"]; code != "PRJ-2291" {
+		t.Errorf("Expected Project Code %q, got %v", "PRJ-2291", code)
+	}
+	if revision := custom["Revision"]; revision != int32(4) {
+		t.Errorf("Expected Revision 4, got %v (%T)", revision, revision)
+	}
+	if budget := custom["Budget"]; budget != 12500.75 {
+		t.Errorf("Expected Budget 12500.75, got %v (%T)", budget, budget)
+	}
+	if approved := custom["Approved"]; approved != true {
+		t.Errorf("Expected Approved to be true, got %v", approved)
+	}
+	if date, ok := custom["Reviewed"].(time.Time); !ok || !date.Equal(reviewed) {
+		t.Errorf("Expected Reviewed %v, got %v", reviewed, custom["Reviewed"])
+	}
+
+	// Values of other types fail the save
+	w = msdoc.NewDocumentWriter()
+	w.AddParagraph("Invalid")
+	w.SetCustomProperty("Tags", []string{"a", "b"})
+	if err := w.Save(filepath.Join(t.TempDir(), "invalid.doc")); err == nil {
+		t.Error("Expected Save to fail for an unsupported custom property type")
+	}
+}
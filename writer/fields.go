@@ -0,0 +1,76 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Characters delimiting the field code and result of a field.
+const (
+	fieldBeginChar     = "\x13"
+	fieldSeparatorChar = "\x14"
+	fieldEndChar       = "\x15"
+)
+
+// Field descriptor (FLD) values.
+const (
+	fltTOC    = 0x0D // Field type of TOC fields
+	fldHasSep = 0x80 // The field ended by this end character has a separator
+)
+
+// tocPlaceholder is the result of a table of contents until Word updates it.
+const tocPlaceholder = "Press F9 to update the table of contents."
+
+// AddTableOfContents adds a table of contents of the headings at outline
+// levels 1 to 3 as a paragraph of its own. The document only stores the TOC
+// field with a placeholder result; Word builds the entries when the field is
+// updated, for example by selecting it and pressing F9.
+func (dw *DocumentWriter) AddTableOfContents() {
+	dw.addField(fltTOC, ` TOC \o "1-3" `, tocPlaceholder)
+	dw.AddParagraph("")
+}
+
+// addField adds a field of type flt with the given field code and result.
+// Each field character is a section of its own so that the field table and
+// character properties can locate it.
+func (dw *DocumentWriter) addField(flt byte, code, result string) {
+	dw.text = append(dw.text,
+		TextSection{Text: fieldBeginChar, FLD: []byte{fieldBeginChar[0], flt}},
+		TextSection{Text: code},
+		TextSection{Text: fieldSeparatorChar, FLD: []byte{fieldSeparatorChar[0], 0xFF}},
+		TextSection{Text: result},
+		TextSection{Text: fieldEndChar, FLD: []byte{fieldEndChar[0], fldHasSep}},
+	)
+}
+
+// fieldCharSprms returns the character sprms of a field character, which is
+// a special character.
+func fieldCharSprms() []byte {
+	var grpprl bytes.Buffer
+	binary.Write(&grpprl, binary.LittleEndian, uint16(sprmCFSpec))
+	grpprl.WriteByte(1)
+	return grpprl.Bytes()
+}
+
+// buildFieldTable builds the PlcfFldMom locating the field characters of the
+// main document, each with its FLD. Returns nil if there are no fields.
+func (dw *DocumentWriter) buildFieldTable() []byte {
+	var cps []uint32
+	var flds []byte
+	for i, section := range dw.text {
+		if section.FLD == nil || i >= len(dw.pieceTable.pieces) {
+			continue
+		}
+		cps = append(cps, dw.pieceTable.pieces[i].StartCP)
+		flds = append(flds, section.FLD...)
+	}
+	if len(cps) == 0 {
+		return nil
+	}
+	cps = append(cps, dw.fibBuilder.fib.FibRgLw.CcpText)
+
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, cps)
+	buffer.Write(flds)
+	return buffer.Bytes()
+}
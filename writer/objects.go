@@ -0,0 +1,123 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/TalentFormula/msdoc/ole2"
+)
+
+// embeddedFile is a file embedded in the document as an OLE package.
+type embeddedFile struct {
+	id          uint32 // Object identifier, which names its ObjectPool storage
+	data        []byte
+	displayName string
+}
+
+// Character sprms marking an object anchor character.
+const (
+	sprmCFSpec       = 0x0855 // The character is a special character
+	sprmCFObj        = 0x0856 // The special character anchors an embedded object
+	sprmCPicLocation = 0x6A03 // Identifier of the embedded object
+)
+
+// objectAnchorChar marks the position of an embedded object in the text.
+const objectAnchorChar = "\x01"
+
+// clsidPackage is the CLSID of OLE packages, {0003000C-0000-0000-C000-000000000046}.
+var clsidPackage = [16]byte{
+	0x0C, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46,
+}
+
+// AddEmbeddedFile embeds a file, such as an attached PDF, as an OLE package
+// at the current position. The display name is shown for the object and is
+// used as the file name when the object is extracted.
+func (dw *DocumentWriter) AddEmbeddedFile(data []byte, displayName string) {
+	id := uint32(len(dw.objects) + 1)
+	dw.objects = append(dw.objects, embeddedFile{id: id, data: data, displayName: displayName})
+	dw.text = append(dw.text, TextSection{
+		Text:     objectAnchorChar,
+		ObjectID: id,
+	})
+}
+
+// objectAnchorSprms returns the character sprms of the anchor character of
+// the embedded object id.
+func objectAnchorSprms(id uint32) []byte {
+	var grpprl bytes.Buffer
+	binary.Write(&grpprl, binary.LittleEndian, uint16(sprmCFSpec))
+	grpprl.WriteByte(1)
+	binary.Write(&grpprl, binary.LittleEndian, uint16(sprmCFObj))
+	grpprl.WriteByte(1)
+	binary.Write(&grpprl, binary.LittleEndian, uint16(sprmCPicLocation))
+	binary.Write(&grpprl, binary.LittleEndian, id)
+	return grpprl.Bytes()
+}
+
+// addObjectStreams adds the ObjectPool storage of each embedded file to the
+// compound document.
+func (dw *DocumentWriter) addObjectStreams(oleWriter *ole2.Writer) {
+	for _, obj := range dw.objects {
+		storage := fmt.Sprintf("ObjectPool/_%d", obj.id)
+		oleWriter.SetCLSID(storage, clsidPackage)
+		oleWriter.AddStream(storage+"/\x01CompObj", buildPackageCompObj())
+		oleWriter.AddStream(storage+"/\x03ObjInfo", buildPackageObjInfo())
+		oleWriter.AddStream(storage+"/\x01Ole10Native", buildOle10Native(obj))
+	}
+}
+
+// buildOle10Native builds the Ole10Native stream of an OLE package: the size
+// of the native data, then the package's label, source path, temporary path
+// and the file itself.
+func buildOle10Native(obj embeddedFile) []byte {
+	var native bytes.Buffer
+	binary.Write(&native, binary.LittleEndian, uint16(2)) // Embedded file
+	native.WriteString(obj.displayName + "\x00")          // Label
+	native.WriteString(obj.displayName + "\x00")          // Source path
+	binary.Write(&native, binary.LittleEndian, uint32(0x00030000))
+	binary.Write(&native, binary.LittleEndian, uint32(len(obj.displayName)+1))
+	native.WriteString(obj.displayName + "\x00") // Temporary path
+	binary.Write(&native, binary.LittleEndian, uint32(len(obj.data)))
+	native.Write(obj.data)
+
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, uint32(native.Len()))
+	buffer.Write(native.Bytes())
+	return buffer.Bytes()
+}
+
+// buildPackageCompObj builds the CompObj stream naming the Package class.
+func buildPackageCompObj() []byte {
+	lengthPrefixed := func(buffer *bytes.Buffer, s string) {
+		binary.Write(buffer, binary.LittleEndian, uint32(len(s)+1))
+		buffer.WriteString(s + "\x00")
+	}
+
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, uint32(0xFFFE0001)) // Reserved
+	binary.Write(&buffer, binary.LittleEndian, uint32(0x00000A03)) // Version
+	binary.Write(&buffer, binary.LittleEndian, uint32(0xFFFFFFFF)) // Reserved
+	buffer.Write(clsidPackage[:])
+	lengthPrefixed(&buffer, "Package")                             // User type
+	binary.Write(&buffer, binary.LittleEndian, uint32(0))          // No clipboard format
+	lengthPrefixed(&buffer, "Package")                             // ProgID
+	binary.Write(&buffer, binary.LittleEndian, uint32(0x71B239F4)) // Unicode marker
+	binary.Write(&buffer, binary.LittleEndian, [3]uint32{})        // Empty Unicode strings
+	return buffer.Bytes()
+}
+
+// buildPackageObjInfo builds the ObjInfo stream of an OLE 1.0 object shown as
+// an icon.
+func buildPackageObjInfo() []byte {
+	const (
+		fIcon          = 0x0020
+		fIsOle1        = 0x0040
+		cfMetafilePict = 0x0003
+	)
+	info := make([]byte, 4)
+	binary.LittleEndian.PutUint16(info[0:], fIcon|fIsOle1)
+	binary.LittleEndian.PutUint16(info[2:], cfMetafilePict)
+	return info
+}
@@ -9,11 +9,15 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"path/filepath"
 	"time"
+	"unicode/utf16"
 
 	"github.com/TalentFormula/msdoc/fib"
 	"github.com/TalentFormula/msdoc/formatting"
+	"github.com/TalentFormula/msdoc/metadata"
 	"github.com/TalentFormula/msdoc/ole2"
 )
 
@@ -25,24 +29,55 @@ type DocumentWriter struct {
 	fibBuilder *FIBBuilder
 	pieceTable *PieceTableBuilder
 	formatting *FormattingBuilder
+
+	pageSetup      *PageSetup // Page size and margins of every section, nil for defaults
+	defaultTabStop uint16     // Default tab stop interval in twips
+
+	objects   []embeddedFile // Files embedded by AddEmbeddedFile
+	chpxPages []byte         // CHPX FKP pages, filled in by buildCHPXTable
+	papxPages []byte         // PAPX FKP pages, filled in by buildPAPXTable
+
+	sectionEnds []uint32 // CPs just past each section mark, filled in by buildDocument
+
+	customProperties []customProperty // User-defined properties, in the order first set
+}
+
+// customProperty is a user-defined property set by SetCustomProperty.
+type customProperty struct {
+	name  string
+	value interface{}
+}
+
+// PageSetup holds the page size and margins of a section, in twips.
+type PageSetup struct {
+	Width        uint16
+	Height       uint16
+	MarginLeft   uint16
+	MarginRight  uint16
+	MarginTop    uint16
+	MarginBottom uint16
 }
 
+// defaultTabStop is Word's default tab stop interval (half an inch) in twips.
+const defaultTabStop = 720
+
 // DocumentInfo holds document-level information.
 type DocumentInfo struct {
-	Title       string
-	Author      string
-	Subject     string
-	Keywords    string
-	Comments    string
-	Company     string
-	Manager     string
-	Category    string
-	Template    string
-	Application string
-	Version     string
-	Language    int32
-	Created     time.Time
-	Modified    time.Time
+	Title         string
+	Author        string
+	Subject       string
+	Keywords      string
+	Comments      string
+	Company       string
+	Manager       string
+	Category      string
+	ContentStatus string
+	Template      string
+	Application   string
+	Version       string
+	Language      int32
+	Created       time.Time
+	Modified      time.Time
 }
 
 // TextSection represents a section of text with formatting.
@@ -51,6 +86,9 @@ type TextSection struct {
 	CharProps *formatting.CharacterProperties
 	ParaProps *formatting.ParagraphProperties
 	IsNewPara bool
+	IsSection bool   // Ends the current section
+	ObjectID  uint32 // Embedded object anchored by the text, zero if none
+	FLD       []byte // Field descriptor of a field character, nil for other text
 }
 
 // FIBBuilder handles File Information Block construction.
@@ -101,10 +139,11 @@ func NewDocumentWriter() *DocumentWriter {
 			Application: "msdoc library",
 			Language:    0x0409, // English (US)
 		},
-		text:       make([]TextSection, 0),
-		fibBuilder: NewFIBBuilder(),
-		pieceTable: NewPieceTableBuilder(),
-		formatting: NewFormattingBuilder(),
+		text:           make([]TextSection, 0),
+		defaultTabStop: defaultTabStop,
+		fibBuilder:     NewFIBBuilder(),
+		pieceTable:     NewPieceTableBuilder(),
+		formatting:     NewFormattingBuilder(),
 	}
 }
 
@@ -138,6 +177,54 @@ func (dw *DocumentWriter) SetCompany(company string) {
 	dw.metadata.Company = company
 }
 
+// SetManager sets the name of the author's manager.
+func (dw *DocumentWriter) SetManager(manager string) {
+	dw.metadata.Manager = manager
+}
+
+// SetCategory sets the document category.
+func (dw *DocumentWriter) SetCategory(category string) {
+	dw.metadata.Category = category
+}
+
+// SetContentStatus sets the document status, such as "Draft" or "Final".
+func (dw *DocumentWriter) SetContentStatus(status string) {
+	dw.metadata.ContentStatus = status
+}
+
+// SetCustomProperty sets a user-defined document property, shown on the
+// Custom tab of Word's document properties. The value may be a string, an
+// integer, a float, a bool or a time.Time; Save fails for other types.
+// Setting a property again replaces its value.
+func (dw *DocumentWriter) SetCustomProperty(name string, value interface{}) {
+	for i := range dw.customProperties {
+		if dw.customProperties[i].name == name {
+			dw.customProperties[i].value = value
+			return
+		}
+	}
+	dw.customProperties = append(dw.customProperties, customProperty{name, value})
+}
+
+// SetReadOnlyRecommended sets whether Word should suggest opening the document
+// read-only. This is commonly used for distributed templates.
+func (dw *DocumentWriter) SetReadOnlyRecommended(recommended bool) {
+	dw.fibBuilder.SetReadOnlyRecommended(recommended)
+}
+
+// SetPageSetup sets the page size and margins used by every section.
+// Without a page setup, sections use Word's defaults (US Letter with 1"
+// top and bottom and 1.25" side margins).
+func (dw *DocumentWriter) SetPageSetup(setup PageSetup) {
+	dw.pageSetup = &setup
+}
+
+// SetDefaultTabStop sets the interval in twips between the default tab
+// stops. The default is 720 twips (half an inch).
+func (dw *DocumentWriter) SetDefaultTabStop(twips uint16) {
+	dw.defaultTabStop = twips
+}
+
 // AddText adds plain text to the document.
 func (dw *DocumentWriter) AddText(text string) {
 	dw.AddFormattedText(text, nil, nil)
@@ -172,33 +259,58 @@ func (dw *DocumentWriter) AddFormattedParagraph(text string, charProps *formatti
 
 // InsertPageBreak inserts a page break.
 func (dw *DocumentWriter) InsertPageBreak() {
-	dw.AddText("\f") // Form feed character for page break
+	dw.AddText("\x0C") // Page break character
 }
 
-// InsertSectionBreak inserts a section break.
+// InsertSectionBreak ends the current section and starts a new one on the
+// next page. The new section uses the default section properties.
 func (dw *DocumentWriter) InsertSectionBreak() {
-	dw.AddText("\r") // Section break marker
+	dw.text = append(dw.text, TextSection{
+		Text:      "\x0C", // Section mark, which also ends the paragraph
+		IsNewPara: true,
+		IsSection: true,
+	})
 }
 
 // Save saves the document to the specified filename.
+//
+// The document is written to a temporary file in the same directory, which
+// replaces filename only once it is complete. If saving fails, an existing
+// file at filename is left untouched.
 func (dw *DocumentWriter) Save(filename string) error {
 	// Build the document structure
 	if err := dw.buildDocument(); err != nil {
 		return fmt.Errorf("failed to build document: %w", err)
 	}
 
-	// Create output file
-	file, err := os.Create(filename)
+	// Create the temporary file, keeping the permissions of a file being
+	// replaced
+	file, err := os.CreateTemp(filepath.Dir(filename), ".msdoc-*.tmp")
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
-	defer file.Close()
+	defer os.Remove(file.Name())
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := file.Chmod(mode); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to create file: %w", err)
+	}
 
 	// Write OLE2 compound document
 	if err := dw.writeOLE2Document(file); err != nil {
+		file.Close()
 		return fmt.Errorf("failed to write OLE2 document: %w", err)
 	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
 
+	if err := os.Rename(file.Name(), filename); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
 	return nil
 }
 
@@ -206,6 +318,7 @@ func (dw *DocumentWriter) Save(filename string) error {
 func (dw *DocumentWriter) buildDocument() error {
 	// Build piece table from text sections
 	currentCP := uint32(0)
+	dw.sectionEnds = dw.sectionEnds[:0]
 	for _, section := range dw.text {
 		piece := PieceDescriptor{
 			StartCP:    currentCP,
@@ -213,6 +326,10 @@ func (dw *DocumentWriter) buildDocument() error {
 			FileOffset: uint32(dw.pieceTable.text.Len()),
 			IsUnicode:  dw.needsUnicode(section.Text),
 		}
+		if piece.IsUnicode {
+			// Unicode pieces hold one CP per UTF-16 code unit
+			piece.EndCP = currentCP + uint32(len(utf16.Encode([]rune(section.Text))))
+		}
 
 		// Add text to buffer
 		if piece.IsUnicode {
@@ -240,6 +357,10 @@ func (dw *DocumentWriter) buildDocument() error {
 			})
 		}
 
+		if section.IsSection {
+			dw.sectionEnds = append(dw.sectionEnds, piece.EndCP)
+		}
+
 		currentCP = piece.EndCP
 	}
 
@@ -263,11 +384,9 @@ func (dw *DocumentWriter) needsUnicode(text string) bool {
 
 // addUnicodeText adds Unicode text to the buffer.
 func (dw *DocumentWriter) addUnicodeText(text string) {
-	for _, r := range text {
-		// Write as UTF-16LE
-		// It is safe to ignore the error from binary.Write here because bytes.Buffer.Write never returns an error.
-		binary.Write(&dw.pieceTable.text, binary.LittleEndian, uint16(r))
-	}
+	// Write as UTF-16LE
+	// It is safe to ignore the error from binary.Write here because bytes.Buffer.Write never returns an error.
+	binary.Write(&dw.pieceTable.text, binary.LittleEndian, utf16.Encode([]rune(text)))
 }
 
 // writeOLE2Document writes the complete OLE2 compound document.
@@ -275,6 +394,14 @@ func (dw *DocumentWriter) writeOLE2Document(writer io.Writer) error {
 	// Create OLE2 writer
 	oleWriter := ole2.NewWriter()
 
+	// Write Table stream (1Table for newer documents). This is built first
+	// so the FIB can record where the piece table ended up.
+	tableStream, err := dw.buildTableStream()
+	if err != nil {
+		return fmt.Errorf("failed to build Table stream: %w", err)
+	}
+	oleWriter.AddStream("1Table", tableStream)
+
 	// Write WordDocument stream
 	wordDocStream, err := dw.buildWordDocumentStream()
 	if err != nil {
@@ -282,13 +409,6 @@ func (dw *DocumentWriter) writeOLE2Document(writer io.Writer) error {
 	}
 	oleWriter.AddStream("WordDocument", wordDocStream)
 
-	// Write Table stream (1Table for newer documents)
-	tableStream, err := dw.buildTableStream()
-	if err != nil {
-		return fmt.Errorf("failed to build Table stream: %w", err)
-	}
-	oleWriter.AddStream("1Table", tableStream)
-
 	// Write SummaryInformation stream
 	summaryStream, err := dw.buildSummaryInformationStream()
 	if err != nil {
@@ -303,6 +423,9 @@ func (dw *DocumentWriter) writeOLE2Document(writer io.Writer) error {
 	}
 	oleWriter.AddStream("\x05DocumentSummaryInformation", docSummaryStream)
 
+	// Write the storages of embedded objects
+	dw.addObjectStreams(oleWriter)
+
 	// Write the compound document
 	return oleWriter.WriteTo(writer)
 }
@@ -321,6 +444,16 @@ func (dw *DocumentWriter) buildWordDocumentStream() ([]byte, error) {
 	// Write text content
 	buffer.Write(dw.pieceTable.text.Bytes())
 
+	// Write the section properties shared by every section
+	buffer.Write(dw.buildSEPX())
+
+	// Write the CHPX and then the PAPX FKPs, which start on a page boundary
+	if len(dw.chpxPages)+len(dw.papxPages) > 0 {
+		buffer.Write(make([]byte, fkpPageStart(buffer.Len())-buffer.Len()))
+		buffer.Write(dw.chpxPages)
+		buffer.Write(dw.papxPages)
+	}
+
 	return buffer.Bytes(), nil
 }
 
@@ -333,14 +466,44 @@ func (dw *DocumentWriter) buildTableStream() ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to build CLX: %w", err)
 	}
+	dw.fibBuilder.SetClx(uint32(buffer.Len()), uint32(len(clxData)))
 	buffer.Write(clxData)
 
-	// Write formatting tables
-	formattingData, err := dw.buildFormattingTables()
+	// Write section table
+	sedData := dw.buildSectionTable()
+	dw.fibBuilder.SetPlcfSed(uint32(buffer.Len()), uint32(len(sedData)))
+	buffer.Write(sedData)
+
+	// Write main document field table
+	if fldData := dw.buildFieldTable(); fldData != nil {
+		dw.fibBuilder.SetPlcffldMom(uint32(buffer.Len()), uint32(len(fldData)))
+		buffer.Write(fldData)
+	}
+
+	// Write document properties
+	dopData := dw.buildDOP()
+	dw.fibBuilder.SetDop(uint32(buffer.Len()), uint32(len(dopData)))
+	buffer.Write(dopData)
+
+	// Write character formatting bin table
+	chpxData, err := dw.buildCHPXTable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build CHPX table: %w", err)
+	}
+	if chpxData != nil {
+		dw.fibBuilder.SetPlcfbteChpx(uint32(buffer.Len()), uint32(len(chpxData)))
+		buffer.Write(chpxData)
+	}
+
+	// Write paragraph formatting bin table
+	papxData, err := dw.buildPAPXTable()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build formatting tables: %w", err)
+		return nil, fmt.Errorf("failed to build PAPX table: %w", err)
+	}
+	if papxData != nil {
+		dw.fibBuilder.SetPlcfbtePapx(uint32(buffer.Len()), uint32(len(papxData)))
+		buffer.Write(papxData)
 	}
-	buffer.Write(formattingData)
 
 	return buffer.Bytes(), nil
 }
@@ -349,14 +512,15 @@ func (dw *DocumentWriter) buildTableStream() ([]byte, error) {
 func (dw *DocumentWriter) buildCLX() ([]byte, error) {
 	var buffer bytes.Buffer
 
-	// CLX marker
-	buffer.WriteByte(0x02)
-
 	// Build PLC of piece descriptors
 	plcData, err := dw.buildPiecePLC()
 	if err != nil {
 		return nil, err
 	}
+
+	// Pcdt: the PlcPcd marker and size, then the PlcPcd
+	buffer.WriteByte(0x02)
+	binary.Write(&buffer, binary.LittleEndian, uint32(len(plcData)))
 	buffer.Write(plcData)
 
 	return buffer.Bytes(), nil
@@ -374,6 +538,8 @@ func (dw *DocumentWriter) buildPiecePLC() ([]byte, error) {
 	if len(dw.pieceTable.pieces) > 0 {
 		lastPiece := dw.pieceTable.pieces[len(dw.pieceTable.pieces)-1]
 		binary.Write(&buffer, binary.LittleEndian, lastPiece.EndCP)
+	} else {
+		binary.Write(&buffer, binary.LittleEndian, uint32(0))
 	}
 
 	// Write PCD array
@@ -394,103 +560,608 @@ func (dw *DocumentWriter) buildPCD(piece PieceDescriptor) ([]byte, error) {
 
 	// PCD structure (8 bytes)
 	flags := uint16(0x0001) // fNoEncryption
-	if piece.IsUnicode {
-		flags |= 0x0040 // fCompressed = 0 for Unicode
+
+	// The text follows the FIB in the WordDocument stream. Compressed ANSI
+	// pieces store twice the offset with bit 30 set, as structures.ParsePCD
+	// expects.
+	fc := fibSize + piece.FileOffset
+	if !piece.IsUnicode {
+		fc = (fc * 2) | 0x40000000
 	}
 
 	binary.Write(&buffer, binary.LittleEndian, flags)
-	binary.Write(&buffer, binary.LittleEndian, piece.FileOffset)
+	binary.Write(&buffer, binary.LittleEndian, fc)
 	binary.Write(&buffer, binary.LittleEndian, uint16(0)) // Prm (property modifier)
 
 	return buffer.Bytes(), nil
 }
 
-// buildFormattingTables constructs character and paragraph formatting tables.
-func (dw *DocumentWriter) buildFormattingTables() ([]byte, error) {
+// buildSectionTable builds the PlcfSed describing where each section ends.
+// Every section shares the SEPX written after the text, if there is one.
+func (dw *DocumentWriter) buildSectionTable() []byte {
 	var buffer bytes.Buffer
 
-	// Build character formatting (CHPX FKP)
-	chpxData, err := dw.buildCHPXTable()
-	if err != nil {
-		return nil, fmt.Errorf("failed to build CHPX table: %w", err)
+	// Write CP array: the start of the document, then the end of each section
+	textLength := dw.fibBuilder.fib.FibRgLw.CcpText
+	cps := []uint32{0}
+	for _, end := range dw.sectionEnds {
+		if end < textLength {
+			cps = append(cps, end)
+		}
 	}
-	buffer.Write(chpxData)
+	cps = append(cps, textLength)
+	binary.Write(&buffer, binary.LittleEndian, cps)
 
-	// Build paragraph formatting (PAPX FKP)
-	papxData, err := dw.buildPAPXTable()
-	if err != nil {
-		return nil, fmt.Errorf("failed to build PAPX table: %w", err)
+	// The SEPX follows the text in the WordDocument stream
+	fcSepx := uint32(0xFFFFFFFF) // No SEPX
+	if dw.pageSetup != nil {
+		fcSepx = fibSize + uint32(dw.pieceTable.text.Len())
 	}
-	buffer.Write(papxData)
 
-	return buffer.Bytes(), nil
+	// Write SED array (12 bytes each)
+	for i := 1; i < len(cps); i++ {
+		binary.Write(&buffer, binary.LittleEndian, uint16(0)) // fn
+		binary.Write(&buffer, binary.LittleEndian, fcSepx)
+		binary.Write(&buffer, binary.LittleEndian, uint16(0))          // fnMpr
+		binary.Write(&buffer, binary.LittleEndian, uint32(0xFFFFFFFF)) // fcMpr
+	}
+
+	return buffer.Bytes()
 }
 
-// buildCHPXTable builds the character formatting table.
+// buildSEPX builds the section properties for the page setup.
+// Returns nil if no page setup was set.
+func (dw *DocumentWriter) buildSEPX() []byte {
+	if dw.pageSetup == nil {
+		return nil
+	}
+
+	var grpprl bytes.Buffer
+	sprms := []struct {
+		sprm    uint16
+		operand uint16
+	}{
+		{0xB01F, dw.pageSetup.Width},        // sprmSXaPage
+		{0xB020, dw.pageSetup.Height},       // sprmSYaPage
+		{0xB021, dw.pageSetup.MarginLeft},   // sprmSDxaLeft
+		{0xB022, dw.pageSetup.MarginRight},  // sprmSDxaRight
+		{0x9023, dw.pageSetup.MarginTop},    // sprmSDyaTop
+		{0x9024, dw.pageSetup.MarginBottom}, // sprmSDyaBottom
+	}
+	for _, s := range sprms {
+		binary.Write(&grpprl, binary.LittleEndian, s.sprm)
+		binary.Write(&grpprl, binary.LittleEndian, s.operand)
+	}
+
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, uint16(grpprl.Len())) // cb
+	buffer.Write(grpprl.Bytes())
+	return buffer.Bytes()
+}
+
+// dopSize is the size of the Dop97 written by buildDOP.
+const dopSize = 500
+
+// buildDOP builds the document properties. Only the fields that differ from
+// zero in a new Word document are set; page margins are stored per section
+// in the SEPX, as the DOP has no margin fields.
+func (dw *DocumentWriter) buildDOP() []byte {
+	dop := make([]byte, dopSize)
+	dop[0] = 0x02                                              // fWidowControl
+	binary.LittleEndian.PutUint16(dop[10:], dw.defaultTabStop) // dxaTab
+	return dop
+}
+
+// fkpRun is a run of text with character or paragraph properties in the
+// WordDocument stream.
+type fkpRun struct {
+	fc     uint32 // Offset of the first character
+	end    uint32 // Offset just past the last character
+	grpprl []byte // Character sprms, or the style index and paragraph sprms
+}
+
+// Layout of the FKPs written by buildCHPXTable and buildPAPXTable.
+const (
+	fkpPageSize        = 512
+	maxRunsPerPage     = 16 // Formatted runs per CHPX FKP, leaving room for default runs and CHPXs
+	maxParaRunsPerPage = 8  // Formatted runs per PAPX FKP, whose BxPaps take 13 bytes each
+	bxPapSize          = 13
+)
+
+// Sprms written for character and paragraph properties.
+const (
+	sprmCFBiDi = 0x085A // Right-to-left run
+	sprmPFBiDi = 0x2441 // Right-to-left paragraph
+)
+
+// fkpPageStart returns the offset of the first FKP page at or after offset.
+func fkpPageStart(offset int) int {
+	return (offset + fkpPageSize - 1) / fkpPageSize * fkpPageSize
+}
+
+// buildCHPXTable builds the character formatting bin table (PlcBteChpx) and
+// the CHPX FKP pages it points to, which are kept in dw.chpxPages for the
+// WordDocument stream. Text between formatted runs has default properties.
+// Returns nil if no text has character properties.
 func (dw *DocumentWriter) buildCHPXTable() ([]byte, error) {
-	// This would build FKP structures for character properties
-	// For now, return empty data (default formatting)
-	return make([]byte, 512), nil // Standard FKP page size
+	dw.chpxPages = nil
+	runs := dw.characterRuns()
+	if len(runs) == 0 {
+		return nil, nil
+	}
+
+	// The pages follow the text and SEPX in the WordDocument stream
+	textEnd := fibSize + dw.pieceTable.text.Len()
+	firstPage := fkpPageStart(textEnd+len(dw.buildSEPX())) / fkpPageSize
+
+	table, pages, err := dw.buildBinTable(runs, maxRunsPerPage, firstPage, buildCHPXFKP)
+	if err != nil {
+		return nil, err
+	}
+	dw.chpxPages = pages
+	return table, nil
 }
 
-// buildPAPXTable builds the paragraph formatting table.
+// buildPAPXTable builds the paragraph formatting bin table (PlcBtePapx) and
+// the PAPX FKP pages it points to, which are kept in dw.papxPages for the
+// WordDocument stream after the CHPX FKPs. Paragraphs without properties
+// use the Normal style. Returns nil if no paragraph has properties.
 func (dw *DocumentWriter) buildPAPXTable() ([]byte, error) {
-	// This would build FKP structures for paragraph properties
-	// For now, return empty data (default formatting)
-	return make([]byte, 512), nil // Standard FKP page size
+	dw.papxPages = nil
+	runs := dw.paragraphRuns()
+	if len(runs) == 0 {
+		return nil, nil
+	}
+
+	textEnd := fibSize + dw.pieceTable.text.Len()
+	firstPage := fkpPageStart(textEnd+len(dw.buildSEPX()))/fkpPageSize + len(dw.chpxPages)/fkpPageSize
+
+	table, pages, err := dw.buildBinTable(runs, maxParaRunsPerPage, firstPage, buildPAPXFKP)
+	if err != nil {
+		return nil, err
+	}
+	dw.papxPages = pages
+	return table, nil
+}
+
+// buildBinTable splits runs into FKP pages of at most perPage runs covering
+// the whole text, numbered from firstPage, and returns the bin table that
+// maps text ranges to them together with the pages.
+func (dw *DocumentWriter) buildBinTable(runs []fkpRun, perPage, firstPage int, buildFKP func(runs []fkpRun, start, end uint32) ([]byte, error)) (table, pages []byte, err error) {
+	textStart := uint32(fibSize)
+	textEnd := textStart + uint32(dw.pieceTable.text.Len())
+
+	var fcs, pns []uint32
+	start := textStart
+	for i := 0; i < len(runs); i += perPage {
+		pageRuns := runs[i:min(i+perPage, len(runs))]
+		end := textEnd
+		if i+perPage < len(runs) {
+			end = runs[i+perPage].fc
+		}
+
+		page, err := buildFKP(pageRuns, start, end)
+		if err != nil {
+			return nil, nil, err
+		}
+		fcs = append(fcs, start)
+		pns = append(pns, uint32(firstPage+len(pns)))
+		pages = append(pages, page...)
+		start = end
+	}
+	fcs = append(fcs, textEnd)
+
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, fcs)
+	binary.Write(&buffer, binary.LittleEndian, pns)
+	return buffer.Bytes(), pages, nil
+}
+
+// characterRuns returns the runs of text with character properties, in
+// stream order.
+func (dw *DocumentWriter) characterRuns() []fkpRun {
+	var runs []fkpRun
+	for i, section := range dw.text {
+		if i >= len(dw.pieceTable.pieces) {
+			break
+		}
+		grpprl := characterSprms(section.CharProps)
+		if section.ObjectID != 0 {
+			grpprl = append(grpprl, objectAnchorSprms(section.ObjectID)...)
+		}
+		if section.FLD != nil {
+			grpprl = append(grpprl, fieldCharSprms()...)
+		}
+		if len(grpprl) == 0 {
+			continue
+		}
+		runs = append(runs, dw.pieceRun(i, grpprl))
+	}
+	return runs
+}
+
+// paragraphRuns returns the runs of text ending with a paragraph mark whose
+// paragraph has properties, in stream order.
+func (dw *DocumentWriter) paragraphRuns() []fkpRun {
+	var runs []fkpRun
+	for i, section := range dw.text {
+		if i >= len(dw.pieceTable.pieces) {
+			break
+		}
+		if !section.IsNewPara {
+			continue
+		}
+		sprms := paragraphSprms(section.ParaProps)
+		if len(sprms) == 0 {
+			continue
+		}
+		grpprl := append([]byte{0, 0}, sprms...) // istd 0, the Normal style
+		runs = append(runs, dw.pieceRun(i, grpprl))
+	}
+	return runs
+}
+
+// pieceRun returns a run covering the text of piece i.
+func (dw *DocumentWriter) pieceRun(i int, grpprl []byte) fkpRun {
+	piece := dw.pieceTable.pieces[i]
+	fc := fibSize + piece.FileOffset
+	length := piece.EndCP - piece.StartCP
+	if piece.IsUnicode {
+		length *= 2
+	}
+	return fkpRun{fc: fc, end: fc + length, grpprl: grpprl}
+}
+
+// characterSprms returns the character sprms for the properties the writer
+// supports, or nil if props is nil or sets none of them.
+func characterSprms(props *formatting.CharacterProperties) []byte {
+	if props == nil {
+		return nil
+	}
+	var grpprl bytes.Buffer
+	if props.BiDi {
+		binary.Write(&grpprl, binary.LittleEndian, uint16(sprmCFBiDi))
+		grpprl.WriteByte(1)
+	}
+	return grpprl.Bytes()
+}
+
+// paragraphSprms returns the paragraph sprms for the properties the writer
+// supports, or nil if props is nil or sets none of them.
+func paragraphSprms(props *formatting.ParagraphProperties) []byte {
+	if props == nil {
+		return nil
+	}
+	var grpprl bytes.Buffer
+	if props.BiDi {
+		binary.Write(&grpprl, binary.LittleEndian, uint16(sprmPFBiDi))
+		grpprl.WriteByte(1)
+	}
+	return grpprl.Bytes()
+}
+
+// fkpEntries returns the FCs delimiting the entries of an FKP covering
+// [start, end) with the given runs, and the properties of each entry. Text
+// between the runs gets entries with nil properties.
+func fkpEntries(runs []fkpRun, start, end uint32) ([]uint32, [][]byte) {
+	var fcs []uint32
+	var grpprls [][]byte
+	current := start
+	for _, run := range runs {
+		if current < run.fc {
+			fcs = append(fcs, current)
+			grpprls = append(grpprls, nil)
+		}
+		fcs = append(fcs, run.fc)
+		grpprls = append(grpprls, run.grpprl)
+		current = run.end
+	}
+	if current < end {
+		fcs = append(fcs, current)
+		grpprls = append(grpprls, nil)
+	}
+	return append(fcs, end), grpprls
+}
+
+// buildCHPXFKP builds a CHPX FKP covering [start, end) with the given runs.
+// The CHPXs are stored from the end of the page backwards.
+func buildCHPXFKP(runs []fkpRun, start, end uint32) ([]byte, error) {
+	fcs, grpprls := fkpEntries(runs, start, end)
+
+	crun := len(grpprls)
+	page := make([]byte, fkpPageSize)
+	for i, fc := range fcs {
+		binary.LittleEndian.PutUint32(page[i*4:], fc)
+	}
+	offsetsStart := len(fcs) * 4
+	free := fkpPageSize - 1 // The last byte is crun
+	for i, grpprl := range grpprls {
+		if grpprl == nil {
+			continue // Offset zero means default properties
+		}
+		free = (free - 1 - len(grpprl)) &^ 1 // CHPXs start on a word boundary
+		if free < offsetsStart+crun {
+			return nil, fmt.Errorf("character properties do not fit in an FKP")
+		}
+		page[free] = byte(len(grpprl))
+		copy(page[free+1:], grpprl)
+		page[offsetsStart+i] = byte(free / 2)
+	}
+	page[fkpPageSize-1] = byte(crun)
+	return page, nil
+}
+
+// buildPAPXFKP builds a PAPX FKP covering [start, end) with the given runs.
+// Each PAPX holds the style index and sprms of a run, preceded by cb, the
+// size in words, as structures.ParseFKP expects: odd sizes store 2*cb-1
+// bytes after cb, even ones store a zero cb followed by the word count.
+func buildPAPXFKP(runs []fkpRun, start, end uint32) ([]byte, error) {
+	fcs, grpprls := fkpEntries(runs, start, end)
+
+	cpara := len(grpprls)
+	page := make([]byte, fkpPageSize)
+	for i, fc := range fcs {
+		binary.LittleEndian.PutUint32(page[i*4:], fc)
+	}
+	bxStart := len(fcs) * 4
+	free := fkpPageSize - 1 // The last byte is cpara
+	for i, grpprl := range grpprls {
+		if grpprl == nil {
+			continue // Offset zero means the Normal style
+		}
+		header := []byte{byte((len(grpprl) + 1) / 2)}
+		if len(grpprl)%2 == 0 {
+			header = []byte{0, byte(len(grpprl) / 2)}
+		}
+		free = (free - len(header) - len(grpprl)) &^ 1 // PAPXs start on a word boundary
+		if free < bxStart+cpara*bxPapSize {
+			return nil, fmt.Errorf("paragraph properties do not fit in an FKP")
+		}
+		copy(page[free:], header)
+		copy(page[free+len(header):], grpprl)
+		page[bxStart+i*bxPapSize] = byte(free / 2)
+	}
+	page[fkpPageSize-1] = byte(cpara)
+	return page, nil
+}
+
+// Format IDs of the property sets written by the writer.
+var (
+	fmtidSummaryInformation = [16]byte{
+		0xE0, 0x85, 0x9F, 0xF2, 0xF9, 0x4F, 0x68, 0x10,
+		0xAB, 0x91, 0x08, 0x00, 0x2B, 0x27, 0xB3, 0xD9,
+	}
+	fmtidDocSummaryInformation = [16]byte{
+		0x02, 0xD5, 0xCD, 0xD5, 0x9C, 0x2E, 0x1B, 0x10,
+		0x93, 0x97, 0x08, 0x00, 0x2B, 0x2C, 0xF9, 0xAE,
+	}
+	fmtidUserDefinedProperties = [16]byte{
+		0x05, 0xD5, 0xCD, 0xD5, 0x9C, 0x2E, 0x1B, 0x10,
+		0x93, 0x97, 0x08, 0x00, 0x2B, 0x2C, 0xF9, 0xAE,
+	}
+)
+
+// stringProperty is a string property written to a property set.
+type stringProperty struct {
+	id    uint32
+	value string
+}
+
+// propertySection is a section of a property set stream.
+type propertySection struct {
+	fmtid [16]byte
+	data  []byte
 }
 
 // buildSummaryInformationStream constructs the SummaryInformation property set.
 func (dw *DocumentWriter) buildSummaryInformationStream() ([]byte, error) {
-	// This would build the complete property set stream
-	// For now, return minimal data
-	return dw.buildMinimalPropertySet(dw.metadata), nil
+	info := dw.metadata
+	return buildPropertySet(propertySection{fmtidSummaryInformation, buildStringSection([]stringProperty{
+		{metadata.PIDTitle, info.Title},
+		{metadata.PIDSubject, info.Subject},
+		{metadata.PIDAuthor, info.Author},
+		{metadata.PIDKeywords, info.Keywords},
+		{metadata.PIDComments, info.Comments},
+		{metadata.PIDTemplate, info.Template},
+		{metadata.PIDAppName, info.Application},
+	})}), nil
 }
 
 // buildDocumentSummaryInformationStream constructs DocumentSummaryInformation.
+// Custom properties go in a second, user-defined section.
 func (dw *DocumentWriter) buildDocumentSummaryInformationStream() ([]byte, error) {
-	// This would build the complete document summary property set
-	return dw.buildMinimalPropertySet(dw.metadata), nil
+	info := dw.metadata
+	sections := []propertySection{{fmtidDocSummaryInformation, buildStringSection([]stringProperty{
+		{metadata.PIDCategory, info.Category},
+		{metadata.PIDManager, info.Manager},
+		{metadata.PIDCompany, info.Company},
+		{metadata.PIDContentStatus, info.ContentStatus},
+	})}}
+	if len(dw.customProperties) > 0 {
+		custom, err := buildCustomSection(dw.customProperties)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, propertySection{fmtidUserDefinedProperties, custom})
+	}
+	return buildPropertySet(sections...), nil
 }
 
-// buildMinimalPropertySet creates a minimal property set with basic metadata.
-func (dw *DocumentWriter) buildMinimalPropertySet(info *DocumentInfo) []byte {
-	var buffer bytes.Buffer
+// propertySetCodePage is the code page of the strings written to property
+// sets. UTF-8 keeps non-ASCII values intact.
+const propertySetCodePage = 65001
 
-	// Property set header
-	binary.Write(&buffer, binary.LittleEndian, uint16(0xFFFE)) // Byte order
-	binary.Write(&buffer, binary.LittleEndian, uint16(0x0000)) // Version
-	binary.Write(&buffer, binary.LittleEndian, uint32(0x000))  // System ID
+// firstCustomPropertyID is the ID of the first custom property; lower IDs
+// are the dictionary and the code page.
+const firstCustomPropertyID = 2
 
-	// CLSID (16 bytes of zeros)
-	buffer.Write(make([]byte, 16))
+// fileTimeEpoch is the Unix time in FILETIME units, 100-nanosecond intervals
+// since January 1, 1601.
+const fileTimeEpoch = 116444736000000000
 
-	binary.Write(&buffer, binary.LittleEndian, uint32(1)) // Number of property sets
+// codePageValue returns the code page property value.
+func codePageValue() []byte {
+	codePage := binary.LittleEndian.AppendUint16([]byte{0x02, 0x00, 0x00, 0x00}, propertySetCodePage) // VT_I2
+	return append(codePage, 0, 0)
+}
+
+// stringValue returns a VT_LPSTR property value padded to a multiple of 4
+// bytes.
+func stringValue(s string) []byte {
+	value := binary.LittleEndian.AppendUint16(nil, uint16(metadata.PropertyTypeStringA))
+	value = append(value, 0, 0) // Padding
+	value = binary.LittleEndian.AppendUint32(value, uint32(len(s)+1))
+	value = append(append(value, s...), 0)
+	for len(value)%4 != 0 {
+		value = append(value, 0)
+	}
+	return value
+}
 
-	// Property set format ID and offset
-	buffer.Write(make([]byte, 16))                         // Format ID (SummaryInformation GUID)
-	binary.Write(&buffer, binary.LittleEndian, uint32(48)) // Offset
+// buildStringSection creates a property section holding the code page and
+// the non-empty string properties.
+func buildStringSection(props []stringProperty) []byte {
+	values := [][]byte{codePageValue()}
+	ids := []uint32{metadata.PIDCodePage}
+	for _, p := range props {
+		if p.value == "" {
+			continue
+		}
+		values = append(values, stringValue(p.value))
+		ids = append(ids, p.id)
+	}
+	return buildPropertySection(ids, values)
+}
 
-	// Property set data
-	binary.Write(&buffer, binary.LittleEndian, uint32(buffer.Len()+100)) // Size
-	binary.Write(&buffer, binary.LittleEndian, uint32(1))                // Property count
+// buildCustomSection creates the user-defined property section: the code
+// page, the dictionary naming the properties and their values.
+func buildCustomSection(props []customProperty) ([]byte, error) {
+	dictionary := binary.LittleEndian.AppendUint32(nil, uint32(len(props)))
+	values := [][]byte{nil, codePageValue()}
+	ids := []uint32{metadata.PIDDictionary, metadata.PIDCodePage}
+	for i, p := range props {
+		id := uint32(firstCustomPropertyID + i)
+		dictionary = binary.LittleEndian.AppendUint32(dictionary, id)
+		dictionary = binary.LittleEndian.AppendUint32(dictionary, uint32(len(p.name)+1))
+		dictionary = append(append(dictionary, p.name...), 0)
+
+		value, err := customPropertyValue(p.value)
+		if err != nil {
+			return nil, fmt.Errorf("custom property %q: %w", p.name, err)
+		}
+		values = append(values, value)
+		ids = append(ids, id)
+	}
+	for len(dictionary)%4 != 0 {
+		dictionary = append(dictionary, 0)
+	}
+	values[0] = dictionary
+	return buildPropertySection(ids, values), nil
+}
 
-	// Property ID and offset for title
-	binary.Write(&buffer, binary.LittleEndian, uint32(0x02))  // PID_TITLE
-	binary.Write(&buffer, binary.LittleEndian, uint32(48+16)) // Offset
+// customPropertyValue encodes a custom property value as a typed property
+// value padded to a multiple of 4 bytes. Integers that fit in 32 bits are
+// written as VT_I4 and others as VT_I8.
+func customPropertyValue(v interface{}) ([]byte, error) {
+	typed := func(t metadata.PropertyType) []byte {
+		return binary.LittleEndian.AppendUint16(binary.LittleEndian.AppendUint16(nil, uint16(t)), 0)
+	}
+	integer := func(n int64) []byte {
+		if n >= math.MinInt32 && n <= math.MaxInt32 {
+			return binary.LittleEndian.AppendUint32(typed(metadata.PropertyTypeInt32), uint32(n))
+		}
+		return binary.LittleEndian.AppendUint64(typed(metadata.PropertyTypeInt64), uint64(n))
+	}
 
-	// Title property value
-	binary.Write(&buffer, binary.LittleEndian, uint16(0x001F)) // VT_LPWSTR
-	binary.Write(&buffer, binary.LittleEndian, uint16(0x0000)) // Padding
+	switch v := v.(type) {
+	case string:
+		return stringValue(v), nil
+	case int:
+		return integer(int64(v)), nil
+	case int8:
+		return integer(int64(v)), nil
+	case int16:
+		return integer(int64(v)), nil
+	case int32:
+		return integer(int64(v)), nil
+	case int64:
+		return integer(v), nil
+	case uint8:
+		return integer(int64(v)), nil
+	case uint16:
+		return integer(int64(v)), nil
+	case uint32:
+		return integer(int64(v)), nil
+	case float32:
+		return binary.LittleEndian.AppendUint64(typed(metadata.PropertyTypeDouble), math.Float64bits(float64(v))), nil
+	case float64:
+		return binary.LittleEndian.AppendUint64(typed(metadata.PropertyTypeDouble), math.Float64bits(v)), nil
+	case bool:
+		var b uint16
+		if v {
+			b = 0xFFFF // VARIANT_TRUE
+		}
+		return append(binary.LittleEndian.AppendUint16(typed(metadata.PropertyTypeBoolean), b), 0, 0), nil
+	case time.Time:
+		return binary.LittleEndian.AppendUint64(typed(metadata.PropertyTypeFileTime), uint64(v.UnixNano()/100+fileTimeEpoch)), nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+}
 
-	titleBytes := []byte(info.Title)
-	binary.Write(&buffer, binary.LittleEndian, uint32(len(titleBytes)+1)) // Length
-	buffer.Write(titleBytes)
-	buffer.WriteByte(0) // Null terminator
+// buildPropertySection creates a property section from property IDs and
+// their values, each a multiple of 4 bytes long.
+func buildPropertySection(ids []uint32, values [][]byte) []byte {
+	// Section: size, count, (ID, offset) pairs, then the values
+	var section bytes.Buffer
+	offset := uint32(8 + len(values)*8)
+	sectionSize := offset
+	for _, value := range values {
+		sectionSize += uint32(len(value))
+	}
+	binary.Write(&section, binary.LittleEndian, sectionSize)
+	binary.Write(&section, binary.LittleEndian, uint32(len(values)))
+	for i, value := range values {
+		binary.Write(&section, binary.LittleEndian, ids[i])
+		binary.Write(&section, binary.LittleEndian, offset)
+		offset += uint32(len(value))
+	}
+	for _, value := range values {
+		section.Write(value)
+	}
+	return section.Bytes()
+}
 
+// buildPropertySet creates a property set stream holding the given sections.
+func buildPropertySet(sections ...propertySection) []byte {
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, uint16(0xFFFE))        // Byte order
+	binary.Write(&buffer, binary.LittleEndian, uint16(0x0000))        // Version
+	binary.Write(&buffer, binary.LittleEndian, uint32(0x0002))        // System ID: Win32
+	buffer.Write(make([]byte, 16))                                    // CLSID
+	binary.Write(&buffer, binary.LittleEndian, uint32(len(sections))) // Number of sections
+
+	// Each section follows the list of FMTID and offset pairs
+	offset := uint32(buffer.Len() + len(sections)*20)
+	for _, section := range sections {
+		buffer.Write(section.fmtid[:])
+		binary.Write(&buffer, binary.LittleEndian, offset)
+		offset += uint32(len(section.data))
+	}
+	for _, section := range sections {
+		buffer.Write(section.data)
+	}
 	return buffer.Bytes()
 }
 
+// Sizes of the FIB sections written by FIBBuilder.Build. The layout matches
+// what fib.ParseFIB reads.
+const (
+	fibRgLwSize     = 88
+	fibRgFcLcbCount = 93 // Number of fc/lcb pairs in FibRgFcLcb97
+	fibSize         = 32 + 2 + 28 + 2 + fibRgLwSize + 2 + fibRgFcLcbCount*8 + 2
+)
+
 // NewFIBBuilder creates a new FIB builder.
 func NewFIBBuilder() *FIBBuilder {
 	return &FIBBuilder{
@@ -513,25 +1184,102 @@ func (fb *FIBBuilder) SetModified(modified time.Time) {
 	// Convert to FILETIME format if needed
 }
 
+// SetReadOnlyRecommended sets or clears the fReadOnlyRecommended flag.
+func (fb *FIBBuilder) SetReadOnlyRecommended(recommended bool) {
+	if recommended {
+		fb.fib.Base.Flags1 |= 0x0400
+	} else {
+		fb.fib.Base.Flags1 &^= 0x0400
+	}
+}
+
+// SetClx sets the location of the piece table in the table stream.
+func (fb *FIBBuilder) SetClx(fc, lcb uint32) {
+	fb.fib.RgFcLcb.FcClx = fc
+	fb.fib.RgFcLcb.LcbClx = lcb
+}
+
+// SetPlcfSed sets the location of the section table in the table stream.
+func (fb *FIBBuilder) SetPlcfSed(fc, lcb uint32) {
+	fb.fib.RgFcLcb.FcPlcfsed = fc
+	fb.fib.RgFcLcb.LcbPlcfsed = lcb
+}
+
+// SetPlcfbteChpx sets the location of the character formatting bin table in
+// the table stream.
+func (fb *FIBBuilder) SetPlcfbteChpx(fc, lcb uint32) {
+	fb.fib.RgFcLcb.FcPlcfbteChpx = fc
+	fb.fib.RgFcLcb.LcbPlcfbteChpx = lcb
+}
+
+// SetPlcfbtePapx sets the location of the paragraph formatting bin table in
+// the table stream.
+func (fb *FIBBuilder) SetPlcfbtePapx(fc, lcb uint32) {
+	fb.fib.RgFcLcb.FcPlcfbtePapx = fc
+	fb.fib.RgFcLcb.LcbPlcfbtePapx = lcb
+}
+
+// SetPlcffldMom sets the location of the main document field table in the
+// table stream.
+func (fb *FIBBuilder) SetPlcffldMom(fc, lcb uint32) {
+	fb.fib.RgFcLcb.FcPlcffldMom = fc
+	fb.fib.RgFcLcb.LcbPlcffldMom = lcb
+}
+
+// SetDop sets the location of the document properties in the table stream.
+func (fb *FIBBuilder) SetDop(fc, lcb uint32) {
+	fb.fib.RgFcLcb.FcDop = fc
+	fb.fib.RgFcLcb.LcbDop = lcb
+}
+
 // Build constructs the FIB data.
 func (fb *FIBBuilder) Build() ([]byte, error) {
 	var buffer bytes.Buffer
 
 	// Set required FIB fields
-	fb.fib.Base.WIdent = 0xA5EC // Word identifier
-	fb.fib.Base.NFib = 0x0112   // Word 2003 FIB version
-	fb.fib.Base.LKey = 0        // No encryption key
-	fb.fib.Base.Envr = 0        // Not created by Word
-	fb.fib.Base.Flags1 = 0x0000 // No special flags
+	fb.fib.Base.WIdent = 0xA5EC  // Word identifier
+	fb.fib.Base.NFib = 0x00C1    // Word 97 FIB version, matching FibRgFcLcb97
+	fb.fib.Base.LKey = 0         // No encryption key
+	fb.fib.Base.Envr = 0         // Not created by Word
+	fb.fib.Base.Flags1 |= 0x0200 // fWhichTblStm: the table stream is 1Table
+	fb.fib.Csw = 14
+	fb.fib.Cslw = 22
+	fb.fib.CbRgFcLcb = fibRgFcLcbCount
 
 	// Write FIB base
 	if err := binary.Write(&buffer, binary.LittleEndian, &fb.fib.Base); err != nil {
 		return nil, fmt.Errorf("failed to write FIB base: %w", err)
 	}
 
-	// Write other FIB sections
-	// This would write Csw, FibRgW, Cslw, FibRgLw, etc.
-	// For now, write minimal required sections
+	// Write Csw and FibRgW
+	binary.Write(&buffer, binary.LittleEndian, fb.fib.Csw)
+	binary.Write(&buffer, binary.LittleEndian, &fb.fib.FibRgW)
+
+	// Write Cslw and FibRgLw
+	var rgLw bytes.Buffer
+	binary.Write(&rgLw, binary.LittleEndian, &fb.fib.FibRgLw)
+	binary.Write(&buffer, binary.LittleEndian, fb.fib.Cslw)
+	buffer.Write(rgLw.Bytes()[:fibRgLwSize])
+
+	// Write CbRgFcLcb and the fc/lcb pairs
+	fields := make([]uint32, fibRgFcLcbCount*2)
+	fields[12] = fb.fib.RgFcLcb.FcPlcfsed
+	fields[13] = fb.fib.RgFcLcb.LcbPlcfsed
+	fields[24] = fb.fib.RgFcLcb.FcPlcfbteChpx
+	fields[25] = fb.fib.RgFcLcb.LcbPlcfbteChpx
+	fields[26] = fb.fib.RgFcLcb.FcPlcfbtePapx
+	fields[27] = fb.fib.RgFcLcb.LcbPlcfbtePapx
+	fields[32] = fb.fib.RgFcLcb.FcPlcffldMom
+	fields[33] = fb.fib.RgFcLcb.LcbPlcffldMom
+	fields[62] = fb.fib.RgFcLcb.FcDop
+	fields[63] = fb.fib.RgFcLcb.LcbDop
+	fields[66] = fb.fib.RgFcLcb.FcClx
+	fields[67] = fb.fib.RgFcLcb.LcbClx
+	binary.Write(&buffer, binary.LittleEndian, fb.fib.CbRgFcLcb)
+	binary.Write(&buffer, binary.LittleEndian, fields)
+
+	// cswNew: a Word 97 FIB has no FibRgCswNew
+	binary.Write(&buffer, binary.LittleEndian, uint16(0))
 
 	return buffer.Bytes(), nil
 }